@@ -13,17 +13,21 @@ import (
 	"fmt"
 	"gopkg.in/yaml.v3"
 	"io"
-	"io/ioutil"
-	"log"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"syscall"
 
 	sdtCli "main/src/cli"
 	sdtType "main/src/cliType"
+	sdtConfig "main/src/config"
+	sdtContext "main/src/context"
 	sdtCreate "main/src/create"
 	sdtDelete "main/src/delete"
 	sdtDeploy "main/src/deploy"
+	sdtDiagnose "main/src/diagnose"
+	sdtExport "main/src/export"
 	sdtGet "main/src/get"
 	sdtGitea "main/src/gitea"
 	sdtHelp "main/src/help"
@@ -31,8 +35,10 @@ import (
 	sdtLogin "main/src/login"
 	sdtLogs "main/src/logs"
 	sdtMessage "main/src/message"
+	sdtRollback "main/src/rollback"
 	sdtUpdate "main/src/update"
 	sdtUtil "main/src/util"
+	sdtLog "pkg/log"
 )
 
 // These are the global variables used in the BWC-CLI package.
@@ -47,10 +53,13 @@ var (
 // The way to log the text "Hello World" as an Info log type is shown below.
 // procLog.Info.Printf("Hello World\n")
 // Output: [INFO] Hello World
-func initError(logFile io.Writer) {
-	procLog.Info = log.New(logFile, "[INFO] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Warn = log.New(logFile, "[WARNING] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Error = log.New(logFile, "[ERROR] ", log.Ldate|log.Ltime|log.Lshortfile)
+//
+// Logs below logLevel (loglevel in config.json) are discarded.
+// If logFormat is "json", each log is written as a JSON line. (See pkg/log NewJSONLogger)
+func initError(logFile io.Writer, logLevel string, logFormat string) {
+	procLog.Info = sdtLog.NewLogger(logFile, "info", logLevel, logFormat)
+	procLog.Warn = sdtLog.NewLogger(logFile, "warn", logLevel, logFormat)
+	procLog.Error = sdtLog.NewLogger(logFile, "error", logLevel, logFormat)
 }
 
 func isExistFile(fname string) bool {
@@ -66,7 +75,7 @@ func isExistFile(fname string) bool {
 //   - ConfigInfo: This is the config struct of BWC.
 func GetConfigJson() sdtType.ConfigInfo {
 	targetFile := "/etc/sdt/device.config/config.json"
-	jsonFile, err := ioutil.ReadFile(targetFile)
+	jsonFile, err := os.ReadFile(targetFile)
 	if err != nil {
 		fmt.Printf("Not found file Error: %v\n", err)
 	}
@@ -79,6 +88,33 @@ func GetConfigJson() sdtType.ConfigInfo {
 	return jsonData
 }
 
+// ValidateConfigFile is a function that checks the BWC config file of the device.
+// Unlike GetConfigJson, errors of reading and parsing the file are returned as issues.
+//
+// Input:
+//   - targetFile: Path of the config file.
+//
+// Output:
+//   - []string: Detected issues. It is empty if the config file is valid.
+func ValidateConfigFile(targetFile string) []string {
+	jsonFile, err := os.ReadFile(targetFile)
+	if err != nil {
+		return []string{fmt.Sprintf("cannot read file: %v", err)}
+	}
+	var jsonData sdtType.ConfigInfo
+	var errList []string
+	err = json.Unmarshal(jsonFile, &jsonData)
+	if err != nil {
+		// Wrong type of a field. (ex. number instead of string)
+		errList = append(errList, fmt.Sprintf("invalid json: %v", err))
+	}
+
+	return append(errList, sdtType.ValidateConfigInfo(jsonData)...)
+}
+
+// appNameRegexp is the pattern that spec.appName in the framework file must match.
+var appNameRegexp = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
 // GetFrameworks is a function that reads the framework file of the app.
 //
 // Input:
@@ -93,7 +129,7 @@ func GetFrameworks(dirName string) sdtType.Framework {
 	// Frist. JSON
 	if isExistFile(targetFile) {
 		//procLog.Info.Printf("Get bwcFramework from JSON file.\n")
-		jsonFile, err := ioutil.ReadFile(targetFile)
+		jsonFile, err := os.ReadFile(targetFile)
 		if err != nil {
 			fmt.Printf("Not found file Error: %v\n", err)
 		}
@@ -109,7 +145,7 @@ func GetFrameworks(dirName string) sdtType.Framework {
 	targetFile = fmt.Sprintf("%s/framework.yaml", dirName)
 	if isExistFile(targetFile) {
 		//procLog.Info.Printf("Get bwcFramework from YAML file.\n")
-		yamlFile, err := ioutil.ReadFile(targetFile)
+		yamlFile, err := os.ReadFile(targetFile)
 		if err != nil {
 			fmt.Printf("Not found file Error: %v\n", err)
 		}
@@ -123,6 +159,43 @@ func GetFrameworks(dirName string) sdtType.Framework {
 	return bwcFramework
 }
 
+// ValidateFramework is a function that checks the framework file of the app before it is used.
+// The constraints are as follows:
+//   - spec.appName: Must be non-empty and match [a-z0-9_-]{1,50}.
+//   - spec.env.runtime: Must be python, go, node or java. A version may follow. (ex. python3.9)
+//   - spec.runFile: Must be non-empty.
+//
+// Input:
+//   - bwcFramework: This is the framework struct of the app.
+//
+// Output:
+//   - []string: Error message for each violated constraint. (Empty if the framework is valid)
+func ValidateFramework(bwcFramework sdtType.Framework) []string {
+	errList := make([]string, 0)
+
+	if bwcFramework.Spec.AppName == "" {
+		errList = append(errList, "spec.appName is required.")
+	} else if !appNameRegexp.MatchString(bwcFramework.Spec.AppName) {
+		errList = append(errList, fmt.Sprintf("spec.appName(%s) must match [a-z0-9_-]{1,50}.", bwcFramework.Spec.AppName))
+	}
+
+	validRuntime := false
+	for _, runtime := range []string{"python", "go", "node", "java"} {
+		if strings.HasPrefix(bwcFramework.Spec.Env.RunTime, runtime) {
+			validRuntime = true
+			break
+		}
+	}
+	if !validRuntime {
+		errList = append(errList, fmt.Sprintf("spec.env.runtime(%s) must be one of python, go, node, java.", bwcFramework.Spec.Env.RunTime))
+	}
+
+	if bwcFramework.Spec.RunFile == "" {
+		errList = append(errList, "spec.runFile is required.")
+	}
+	return errList
+}
+
 // GetFrameworksYaml is a function that reads the framework.yaml file of the app.
 //
 // Input:
@@ -132,7 +205,7 @@ func GetFrameworks(dirName string) sdtType.Framework {
 //   - Framework: This is the framework struct of the app.
 //func GetFrameworksYaml(dirName string) sdtType.Framework {
 //	targetFile := fmt.Sprintf("%s/framework.yaml", dirName)
-//	yamlFile, err := ioutil.ReadFile(targetFile)
+//	yamlFile, err := os.ReadFile(targetFile)
 //	if err != nil {
 //		fmt.Printf("Not found file Error: %v\n", err)
 //	}
@@ -165,7 +238,7 @@ func GetUser() string {
 
 // This is the main function that parses the input command and dispatches it
 // to each corresponding feature. BWC-CLI handles the following features:
-// help, login, create, deploy, delete, update, get, status, init, logs, info.
+// help, login, create, deploy, delete, update, get, status, init, logs, info, config, app, diagnose, export, import.
 // These features have subtypes, and the processing varies depending on the combination of types.
 //
 // Input:
@@ -176,10 +249,29 @@ func GetUser() string {
 //   - '-l', '--line': This is the option to define how many lines to output when checking logs.
 //   - '-t', '--template': This is the name of the app template to download.
 //   - '-o', '--option': This is the option used to manage the app's state (e.g., restart) when managing the app's status.
+//   - '--output': This is the output format of the command. (e.g., json)
+//   - '--log-format': This is the format of the log file. (text or json)
+//
+// 'bwc context set|use|list' manages device contexts. If a context other than 'local' is used,
+// the command is forwarded to the device of the context over SSH.
 func main() {
 	// TODO
 	// 	- 실패 했을때 롤백 기능
 
+	// Device context
+	if len(os.Args) >= 2 {
+		if os.Args[1] == "context" {
+			sdtContext.RunContext(os.Args[2:])
+			os.Exit(0)
+		} else if os.Args[1] != "help" {
+			// Forward command to the device of the context.
+			bwcContext := sdtContext.GetCurrentContext()
+			if bwcContext.Name != sdtContext.LocalContext {
+				os.Exit(sdtContext.ForwardCommand(bwcContext, os.Args[1:]))
+			}
+		}
+	}
+
 	// Check root
 	euid := syscall.Geteuid()
 	if euid != 0 {
@@ -192,6 +284,7 @@ func main() {
 	var bwcFramework sdtType.Framework
 	var configData sdtType.ConfigInfo
 	var archType, rootPath, appPath, cmd string
+	var frameworkLoaded bool
 	cmdArgs := os.Args
 	configData = GetConfigJson()
 	archType = "linux"
@@ -220,9 +313,48 @@ func main() {
 				cliInfo.TemplateOption = cmdArgs[key+1]
 			} else if val == "-o" || val == "--option" {
 				cliInfo.AppOption = cmdArgs[key+1]
+			} else if val == "--clean-deploy-logs" {
+				cliInfo.CleanDeployLogs = true
+			} else if val == "--output" && key+1 < len(cmdArgs) {
+				cliInfo.OutputOption = cmdArgs[key+1]
+			} else if val == "--tag-version" && key+1 < len(cmdArgs) {
+				cliInfo.TagVersionOption = cmdArgs[key+1]
+			} else if val == "--describe" {
+				cliInfo.DescribeOption = true
+			} else if val == "--level" && key+1 < len(cmdArgs) {
+				cliInfo.LevelOption = cmdArgs[key+1]
+			} else if val == "--since" && key+1 < len(cmdArgs) {
+				cliInfo.SinceOption = cmdArgs[key+1]
+			} else if val == "--log-format" && key+1 < len(cmdArgs) {
+				cliInfo.LogFormatOption = cmdArgs[key+1]
+			} else if val == "-y" || val == "--yes" {
+				cliInfo.YesOption = true
+			} else if val == "--include-secrets" {
+				cliInfo.IncludeSecrets = true
+			} else if val == "-w" || val == "--watch" {
+				// The interval is optional. (bwc status -w, bwc status -w 10)
+				cliInfo.WatchOption = true
+				if key+1 < len(cmdArgs) {
+					if interval, err := strconv.Atoi(cmdArgs[key+1]); err == nil {
+						cliInfo.WatchInterval = interval
+					}
+				}
+			} else if val == "--detail" {
+				cliInfo.DetailOption = true
+			} else if val == "--history" {
+				cliInfo.HistoryOption = true
+			} else if val == "--no-systemd" {
+				cliInfo.NoSystemd = true
+			} else if val == "--inspect" && key+1 < len(cmdArgs) {
+				cliInfo.InspectOption = cmdArgs[key+1]
+			} else if (val == "-m" || val == "--mac") && key+1 < len(cmdArgs) {
+				cliInfo.MacOption = cmdArgs[key+1]
 			}
 		}
 	}
+	if cliInfo.WatchOption && cliInfo.WatchInterval <= 0 {
+		cliInfo.WatchInterval = 5
+	}
 
 	// Set URL
 	// Check parameter
@@ -239,6 +371,7 @@ func main() {
 			os.Exit(1)
 		} else {
 			bwcFramework = GetFrameworks(cliInfo.DirOption)
+			frameworkLoaded = true
 		}
 
 		if cliInfo.TargetCmd == "venv" {
@@ -286,6 +419,7 @@ func main() {
 			os.Exit(1)
 		} else {
 			bwcFramework = GetFrameworks(cliInfo.DirOption)
+			frameworkLoaded = true
 		}
 
 		if cliInfo.TargetCmd == "app" {
@@ -346,6 +480,7 @@ func main() {
 			os.Exit(1)
 		} else {
 			bwcFramework = GetFrameworks(cliInfo.DirOption)
+			frameworkLoaded = true
 		}
 
 		if cliInfo.TargetCmd == "venv" {
@@ -362,7 +497,25 @@ func main() {
 			}
 		} else if cliInfo.TargetCmd == "app" {
 			fmt.Printf("Update app in your device. \n")
-			// TODO App 업데이트가 정말 필요한 기능인가?
+			if cliInfo.NameOption != "" {
+				bwcFramework.Spec.AppName = cliInfo.NameOption
+			}
+			// 빈 값 찾기
+			if bwcFramework.Spec.AppName == "" {
+				fmt.Printf("Check spec's appName value in framework.yaml. \n")
+				os.Exit(1)
+			} else if cliInfo.UploadOption {
+				if bwcFramework.Stackbase.TagName == "" {
+					fmt.Printf("Check stackbase's tageName value in framework.yaml. \n")
+					os.Exit(1)
+				} else if bwcFramework.Stackbase.RepoName == "" {
+					fmt.Printf("Check stackbase's repoName value in framework.yaml. \n")
+					os.Exit(1)
+				}
+			}
+			if bwcFramework.Spec.RunFile == "" && strings.Contains(bwcFramework.Spec.Env.RunTime, "python") {
+				bwcFramework.Spec.RunFile = "main.py"
+			}
 		} else {
 			fmt.Printf("Please enter the variable value.\n")
 			fmt.Printf(" - Your Cmd: update <target resource> -d <target directory>\n")
@@ -384,21 +537,66 @@ func main() {
 			os.Exit(1)
 		}
 		cmd = fmt.Sprintf("%s-%s", cliInfo.FirstCmd, cliInfo.TargetCmd)
+	case "rollback":
+		if cliInfo.TargetCmd != "app" || cliInfo.NameOption == "" {
+			fmt.Printf("Please enter the variable value.\n")
+			fmt.Printf(" - Your Cmd: rollback app -n <app name>\n")
+			fmt.Printf(" - target resource: app\n")
+			os.Exit(1)
+		}
+		fmt.Printf("Rollback app in your device \n")
+		cmd = fmt.Sprintf("%s-%s", cliInfo.FirstCmd, cliInfo.TargetCmd)
 	case "get":
+		// -o/--output is parsed here for every get target. (-o is also --option)
+		for key, val := range cmdArgs {
+			if val == "-o" && key+1 < len(cmdArgs) {
+				cliInfo.OutputOption = cmdArgs[key+1]
+			}
+		}
+		if cliInfo.OutputOption == "" {
+			cliInfo.OutputOption = "table"
+		}
+		if cliInfo.OutputOption != "table" && cliInfo.OutputOption != "json" {
+			fmt.Printf("Invalid output format: %s\n", cliInfo.OutputOption)
+			fmt.Printf(" - output format: table or json\n")
+			os.Exit(1)
+		}
+
+		var getMessage string
 		if cliInfo.TargetCmd == "app" {
-			fmt.Printf("Get app in your device \n")
+			getMessage = "Get app in your device"
 		} else if cliInfo.TargetCmd == "venv" {
-			fmt.Printf("Get virtual environment in your device \n")
+			getMessage = "Get virtual environment in your device"
 		} else if cliInfo.TargetCmd == "bwc" {
-			fmt.Printf("Get bwc process in your device \n")
+			getMessage = "Get bwc process in your device"
 		} else if cliInfo.TargetCmd == "template" {
-			fmt.Printf("Get templates in your repos \n")
+			getMessage = "Get templates in your repos"
 		} else {
 			fmt.Printf("Please enter the variable value.\n")
 			fmt.Printf(" - Your Cmd: get <target resource>\n")
 			fmt.Printf(" - target resource: app or venv\n")
 			os.Exit(1)
 		}
+		// Only the JSON document is written to stdout in json format.
+		if cliInfo.OutputOption == "table" {
+			fmt.Printf("%s \n", getMessage)
+		}
+		cmd = fmt.Sprintf("%s-%s", cliInfo.FirstCmd, cliInfo.TargetCmd)
+	case "restart":
+		if cliInfo.TargetCmd != "agent" {
+			fmt.Printf("Please enter the variable value.\n")
+			fmt.Printf(" - Your Cmd: restart <target resource> <agent name>\n")
+			fmt.Printf(" - target resource: agent\n")
+			os.Exit(1)
+		}
+		if cliInfo.NameOption == "" && len(cmdArgs) >= 4 && !strings.HasPrefix(cmdArgs[3], "-") {
+			cliInfo.NameOption = cmdArgs[3]
+		}
+		if !sdtUtil.Contains(sdtGet.BwcList, cliInfo.NameOption) {
+			fmt.Printf("Please enter the agent name.\n")
+			fmt.Printf(" - agent name: %s\n", strings.Join(sdtGet.BwcList, ", "))
+			os.Exit(1)
+		}
 		cmd = fmt.Sprintf("%s-%s", cliInfo.FirstCmd, cliInfo.TargetCmd)
 	case "status":
 		// fmt.Printf("Show status of device.\n")
@@ -406,21 +604,55 @@ func main() {
 	case "info":
 		// fmt.Printf("Show information of device.\n")
 		cmd = "info"
+	case "diagnose":
+		cmd = "diagnose"
+	case "export", "import":
+		// bwc export config -o <file.tar.gz>, bwc import config -f <file.tar.gz>
+		// (-o and -f are parsed here since they are also --option and --follow)
+		fileFlag := "-o"
+		if cliInfo.FirstCmd == "import" {
+			fileFlag = "-f"
+		}
+		for key, val := range cmdArgs {
+			if val == fileFlag && key+1 < len(cmdArgs) {
+				cliInfo.FileOption = cmdArgs[key+1]
+			}
+		}
+		if cliInfo.TargetCmd != "config" || cliInfo.FileOption == "" {
+			fmt.Printf("Please enter the variable value.\n")
+			fmt.Printf(" - Your Cmd: export config -o <file.tar.gz> [--include-secrets]\n")
+			fmt.Printf(" - Your Cmd: import config -f <file.tar.gz>\n")
+			os.Exit(1)
+		}
+		cmd = fmt.Sprintf("%s-%s", cliInfo.FirstCmd, cliInfo.TargetCmd)
 	case "logs":
 		if cliInfo.TargetCmd == "bwc" {
 			fmt.Printf("Get bwc process's logs in your device \n")
 		} else if cliInfo.TargetCmd == "app" {
 			fmt.Printf("Get bwc process's logs in your device \n")
+		} else if cliInfo.TargetCmd == "audit" {
+			fmt.Printf("Get audit logs of control commands in your device \n")
 		} else {
 			fmt.Printf("Please enter the variable value.\n")
 			fmt.Printf(" - Your Cmd: logs <target resource>\n")
-			fmt.Printf(" - target resource: bwc\n")
+			fmt.Printf(" - target resource: bwc, app, audit\n")
 			os.Exit(1)
 		}
-		if cliInfo.NameOption == "" {
+		if cliInfo.NameOption == "" && cliInfo.TargetCmd != "audit" {
 			fmt.Printf("Please enter name variable. (-n)")
 			os.Exit(1)
 		}
+		if cliInfo.LevelOption != "" && !sdtUtil.Contains([]string{"info", "warn", "error"}, cliInfo.LevelOption) {
+			fmt.Printf("Invalid log level: %s (info, warn, error)\n", cliInfo.LevelOption)
+			os.Exit(1)
+		}
+
+		if cliInfo.SinceOption != "" {
+			if _, err := sdtLogs.ParseSince(cliInfo.SinceOption); err != nil {
+				fmt.Printf("Invalid --since: %v\n", err)
+				os.Exit(1)
+			}
+		}
 
 		cmd = fmt.Sprintf("%s-%s", cliInfo.FirstCmd, cliInfo.TargetCmd)
 	case "init":
@@ -436,10 +668,69 @@ func main() {
 			os.Exit(1)
 		} else {
 			bwcFramework = GetFrameworks(cliInfo.DirOption)
+			frameworkLoaded = true
 		}
 		cmd = "upload"
+	case "push":
+		if cliInfo.DirOption == "" {
+			fmt.Printf("Please input directory option(-d) \n")
+			os.Exit(1)
+		} else {
+			bwcFramework = GetFrameworks(cliInfo.DirOption)
+			frameworkLoaded = true
+		}
+		cmd = "push"
+	case "app":
+		// bwc app config set <app name> <key> <value>
+		if cliInfo.TargetCmd != "config" || len(cmdArgs) < 7 || cmdArgs[3] != "set" {
+			fmt.Printf("Please enter the variable value.\n")
+			fmt.Printf(" - Your Cmd: app config set <app name> <key> <value>\n")
+			fmt.Printf(" - key: Key of config.json. (Nested key is separated by dots. ex. inference.threshold)\n")
+			os.Exit(1)
+		}
+		cliInfo.NameOption = cmdArgs[4]
+		cliInfo.ConfigKey = cmdArgs[5]
+		cliInfo.ConfigValue = cmdArgs[6]
+		cmd = fmt.Sprintf("%s-%s", cliInfo.FirstCmd, cliInfo.TargetCmd)
+	case "config":
+		if cliInfo.TargetCmd != "validate" {
+			fmt.Printf("Please enter the variable value.\n")
+			fmt.Printf(" - Your Cmd: config <action>\n")
+			fmt.Printf(" - action: validate\n")
+			os.Exit(1)
+		}
+		// Validate before the service type is checked, so that invalid config can be reported.
+		targetFile := "/etc/sdt/device.config/config.json"
+		errList := ValidateConfigFile(targetFile)
+		if len(errList) > 0 {
+			fmt.Printf("Found %d issue(s) in %s:\n", len(errList), targetFile)
+			for _, errMsg := range errList {
+				fmt.Printf(" - %s\n", errMsg)
+			}
+			os.Exit(1)
+		}
+		fmt.Printf("%s is valid.\n", targetFile)
+		os.Exit(0)
 	case "wol":
-		fmt.Printf("TEST - WOL \n")
+		if cliInfo.TargetCmd == "add" {
+			// bwc wol add -n <name> -m <mac>
+			if cliInfo.NameOption == "" || cliInfo.MacOption == "" {
+				fmt.Printf("Please enter the variable value.\n")
+				fmt.Printf(" - Your Cmd: wol add -n <device name> -m <mac address>\n")
+				os.Exit(1)
+			}
+			cmd = fmt.Sprintf("%s-%s", cliInfo.FirstCmd, cliInfo.TargetCmd)
+			break
+		}
+		// bwc wol <mac address|device name> or bwc wol -n <mac address|device name>
+		if cliInfo.NameOption == "" && cliInfo.TargetCmd != "" && !strings.HasPrefix(cliInfo.TargetCmd, "-") {
+			cliInfo.NameOption = cliInfo.TargetCmd
+		}
+		if cliInfo.NameOption == "" {
+			fmt.Printf("Please enter the variable value.\n")
+			fmt.Printf(" - Your Cmd: wol <mac address|device name>\n")
+			os.Exit(1)
+		}
 		cmd = "wol"
 	default:
 		fmt.Printf("Not found: %s\n", cmd)
@@ -450,6 +741,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Validate framework
+	if frameworkLoaded {
+		if errList := ValidateFramework(bwcFramework); len(errList) > 0 {
+			fmt.Printf("Invalid framework file in %s:\n", cliInfo.DirOption)
+			for _, errMsg := range errList {
+				fmt.Printf(" - %s\n", errMsg)
+			}
+			os.Exit(1)
+		}
+	}
+
 	// Set Config PATH
 	if archType == "win" {
 		rootPath = "C:/sdt"
@@ -489,23 +791,27 @@ func main() {
 
 	// Set logger
 	logFilePath := fmt.Sprintf("%s/device.logs/bwc-cli.log", rootPath)
-	logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY|os.O_SYNC, 0644)
+	logFile, err := sdtLog.NewRotateWriter(logFilePath, sdtLog.GetRotateConfig(fmt.Sprintf("%s/device.config/config.json", rootPath)))
 	if err != nil {
 		panic(err)
 	}
 	defer logFile.Close()
 
-	initError(logFile)
+	initError(logFile, configData.LogLevel, cliInfo.LogFormatOption)
 	sdtCli.Getlog(procLog)
+	sdtConfig.Getlog(procLog)
 	sdtCreate.Getlog(procLog)
 	sdtDelete.Getlog(procLog)
 	sdtDeploy.Getlog(procLog)
+	sdtDiagnose.Getlog(procLog)
+	sdtExport.Getlog(procLog)
 	sdtGet.Getlog(procLog)
 	sdtGitea.Getlog(procLog)
 	sdtInit.Getlog(procLog)
 	sdtLogin.Getlog(procLog)
 	sdtLogs.Getlog(procLog)
 	sdtMessage.Getlog(procLog)
+	sdtRollback.Getlog(procLog)
 	sdtUpdate.Getlog(procLog)
 	sdtUtil.Getlog(procLog)
 
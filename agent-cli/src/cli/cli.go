@@ -12,30 +12,43 @@
 //   - info: Get device information
 //   - logs-bwc: Check agent logs
 //   - logs-app: Check app logs
+//   - logs-audit: Check audit logs of control commands
+//   - restart-agent: Restart a BWC agent
 //   - login: Login function
+//   - push: Upload changed files of app and create release
 //   - status: Check device status
 //   - update-venv: Update virtual environment
+//   - update-app: Update deployed app
 package cli
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/ssh/terminal"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	sdtType "main/src/cliType"
+	sdtConfig "main/src/config"
 	sdtCreate "main/src/create"
 	sdtDelete "main/src/delete"
 	sdtDeploy "main/src/deploy"
+	sdtDiagnose "main/src/diagnose"
+	sdtExport "main/src/export"
 	sdtGet "main/src/get"
+	sdtGitea "main/src/gitea"
 	sdtInit "main/src/init"
 	sdtLogin "main/src/login"
 	sdtLogs "main/src/logs"
 	sdtMessage "main/src/message"
+	sdtRollback "main/src/rollback"
 	sdtUpdate "main/src/update"
 	sdtUtil "main/src/util"
 )
@@ -90,7 +103,7 @@ func RunBody(cmd string,
 
 	// Get Config's Info
 	jsonFilePath := fmt.Sprintf("%s/device.config/config.json", rootPath)
-	jsonFile, err := ioutil.ReadFile(jsonFilePath)
+	jsonFile, err := os.ReadFile(jsonFilePath)
 	if err != nil {
 		fmt.Printf("Device's config file not found file Error: %v\n", err)
 		os.Exit(1)
@@ -110,49 +123,183 @@ func RunBody(cmd string,
 	case "login":
 		sdtLogin.SaveLoginInfo(svcInfo.BwURL)
 	case "init-app":
-		// Get Ownername about target-template.
-		ownerName, _ := sdtGet.GetTemplateOwner(svcInfo.BwURL, cliInfo.TemplateOption, configData)
-		if ownerName == "" {
-			fmt.Printf("%s's template not found \n", cliInfo.TemplateOption)
-			os.Exit(1)
+		// Get Ownername about target-template. (<owner>/<repo>:<tag> uses owner in option.)
+		ownerName, templateName, tagName := sdtInit.ParseTemplateOption(cliInfo.TemplateOption)
+		if ownerName == "" && templateName != "" {
+			ownerName, _ = sdtGet.GetTemplateOwner(svcInfo.BwURL, templateName, configData)
+			if ownerName == "" {
+				fmt.Printf("%s's template not found \n", cliInfo.TemplateOption)
+				os.Exit(1)
+			}
 		}
 
 		// Create app.
-		sdtInit.CreateApp(cliInfo.NameOption, cliInfo.TemplateOption, svcInfo.GiteaURL, ownerName, bwcFramework.Spec.Env.HomeName)
+		accessToken := sdtInit.CreateApp(cliInfo.NameOption, templateName, tagName, svcInfo.GiteaURL, ownerName, bwcFramework.Spec.Env.HomeName, configData.AccessToken)
+		if accessToken != configData.AccessToken {
+			configData.AccessToken = accessToken
+			if err := sdtLogin.SaveAccessToken(jsonFilePath, accessToken); err != nil {
+				fmt.Printf("Failed save token in device config: %v\n", err)
+			}
+		}
 		fmt.Printf("Create %s app in device.\n", cliInfo.NameOption)
 	case "get-app":
-		appList := sdtGet.GetAppList()
-		fmt.Printf(" %-15s %-30s %-15s %-30s\n", "Status", "Name", "Venv", "AppID")
-		// fmt.Printf("-----------------------------------------------\n")
-		for _, val := range appList {
-			fmt.Printf(" %-15s %-30s %-15s %-30s\n", val.Status, val.AppName, val.AppVenv, val.AppId)
+		if cliInfo.HistoryOption {
+			historyList, err := sdtGet.GetDeployHistory()
+			if err != nil {
+				fmt.Printf("Failed get deploy history: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf(" %-20s %-30s %-30s %-10s %-15s %-8s %s\n", "DeployedAt", "Name", "AppID", "Size", "Venv", "PID", "AppRepoPath")
+			for i := len(historyList) - 1; i >= 0; i-- {
+				val := historyList[i]
+				deployedAt := time.UnixMilli(val.DeployedAt).Format("2006-01-02 15:04:05")
+				fmt.Printf(" %-20s %-30s %-30s %-10d %-15s %-8d %s\n", deployedAt, val.AppName, val.AppId, val.FileSize, val.Venv, val.Pid, val.AppRepoPath)
+			}
+			break
+		}
+		if cliInfo.InspectOption != "" {
+			appDetail, err := sdtGet.GetAppDetail(cliInfo.InspectOption)
+			if err != nil {
+				fmt.Printf("Failed get detail of app: %v\n", err)
+				os.Exit(1)
+			}
+			if cliInfo.OutputOption == "json" {
+				detailJson, _ := json.MarshalIndent(appDetail, "", "  ")
+				fmt.Printf("%s\n", detailJson)
+				break
+			}
+
+			deployedAt := "N/A"
+			if appDetail.DeployedAt > 0 {
+				deployedAt = time.UnixMilli(appDetail.DeployedAt).Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf(" %-15s %s\n", "Name", appDetail.AppName)
+			fmt.Printf(" %-15s %s\n", "AppID", appDetail.AppId)
+			fmt.Printf(" %-15s %s\n", "Status", appDetail.Status)
+			fmt.Printf(" %-15s %s\n", "Venv", appDetail.AppVenv)
+			fmt.Printf(" %-15s %s\n", "Managed", appDetail.Managed)
+			fmt.Printf(" %-15s %s\n", "Deployed At", deployedAt)
+			fmt.Printf(" %-15s %s\n", "Repo Path", strings.TrimSpace(appDetail.AppRepoPath))
+			fmt.Printf(" %-15s %d\n", "Deploy Count", len(appDetail.History))
+			configJson, _ := json.MarshalIndent(appDetail.Config, " ", "  ")
+			fmt.Printf("\n Config:\n %s\n", configJson)
+			fmt.Printf("\n Service File:\n%s\n", appDetail.ServiceFile)
+			break
+		}
+		printAppList := func() {
+			appList := sdtGet.GetAppList()
+			if cliInfo.OutputOption == "json" {
+				printJson(appList)
+				return
+			}
+			fmt.Printf(" %-15s %-30s %-15s %-30s\n", "Status", "Name", "Venv", "AppID")
+			// fmt.Printf("-----------------------------------------------\n")
+			for _, val := range appList {
+				fmt.Printf(" %-15s %-30s %-15s %-30s\n", val.Status, val.AppName, val.AppVenv, val.AppId)
+			}
 		}
+		if cliInfo.WatchOption {
+			watch(cliInfo.WatchInterval, "bwc get app", printAppList)
+			break
+		}
+		printAppList()
+	case "app-config":
+		appId := sdtGet.GetAppId(cliInfo.NameOption)
+		if appId == "" {
+			fmt.Printf("%s's app not found.\n", cliInfo.NameOption)
+			os.Exit(1)
+		}
+
+		configCmd := sdtType.CmdJson{
+			AppId:     appId,
+			AppName:   cliInfo.NameOption,
+			FileName:  "config.json",
+			Parameter: sdtConfig.ParseParameter(cliInfo.ConfigKey, cliInfo.ConfigValue),
+		}
+		stdout, configErr, configStatus := sdtConfig.JsonChange(configCmd, appPath)
+		cliMessage = fmt.Sprintf("Successfully change %s's config. %s", cliInfo.NameOption, stdout)
+		if configErr != nil {
+			cliMessage = fmt.Sprintf("Failed change %s's config.", cliInfo.NameOption)
+		}
+
+		// Send current config to SDT Cloud.
+		jsonResult, _, _ := sdtDeploy.GetAppConfig(appId, cliInfo.NameOption, appPath, "configFix")
+		sdtMessage.SendResult("/etc/sdt", configData, cliInfo.NameOption, cliMessage, configErr,
+			configStatus, "configFix", "config", requestId,
+			-1, -1, jsonResult, "", appId, "", "", "",
+		)
+
+		if configErr != nil {
+			fmt.Printf("%s: %v\n", cliMessage, configErr)
+			os.Exit(1)
+		}
+		fmt.Printf("%s\n", cliMessage)
 	case "get-venv":
 		// Get env list
 		envList := sdtGet.GetVenvList()
-		fmt.Printf(" %-20s %-7s %-20s\n", "Name", "Used", "App")
+		if cliInfo.OutputOption == "json" {
+			venvList := []sdtType.VenvStatus{}
+			for _, val := range envList {
+				appName, used := sdtGet.CheckVenvUsed(val)
+				venvStatus := sdtType.VenvStatus{Name: val, Used: used, AppName: appName}
+				if cliInfo.DetailOption {
+					venvDetail := sdtGet.GetVenvDetail(val, "/etc/sdt/venv")
+					venvStatus.Detail = &venvDetail
+				}
+				venvList = append(venvList, venvStatus)
+			}
+			printJson(venvList)
+			break
+		}
+		if cliInfo.DetailOption {
+			fmt.Printf(" %-20s %-7s %-20s %-16s %-9s %-8s\n", "Name", "Used", "App", "Python", "Packages", "Size")
+		} else {
+			fmt.Printf(" %-20s %-7s %-20s\n", "Name", "Used", "App")
+		}
 		// fmt.Printf("-------------------------------\n")
 		for _, val := range envList {
 			appName, used := sdtGet.CheckVenvUsed(val)
+			usedStr := "no"
 			if used {
-				fmt.Printf(" %-20s %-7s %-20s\n", val, "used", appName)
+				usedStr = "used"
+			}
+			if cliInfo.DetailOption {
+				venvDetail := sdtGet.GetVenvDetail(val, "/etc/sdt/venv")
+				packageCount := "-"
+				if venvDetail.PackageCount >= 0 {
+					packageCount = fmt.Sprintf("%d", venvDetail.PackageCount)
+				}
+				fmt.Printf(" %-20s %-7s %-20s %-16s %-9s %-8s\n", val, usedStr, appName, venvDetail.PythonVersion, packageCount, venvDetail.DiskUsage)
 			} else {
-				fmt.Printf(" %-20s %-7s %-20s\n", val, "no", appName)
+				fmt.Printf(" %-20s %-7s %-20s\n", val, usedStr, appName)
 			}
 		}
 	case "get-bwc":
-		appList := sdtGet.GetBWCList()
-		fmt.Printf(" %-15s %-30s\n", "Status", "Name")
-		// fmt.Printf("-----------------------------------------------\n")
-		for _, val := range appList {
-			fmt.Printf(" %-15s %-30s\n", val.Status, val.AppName)
+		printBWCList := func() {
+			appList := sdtGet.GetBWCList()
+			if cliInfo.OutputOption == "json" {
+				printJson(appList)
+				return
+			}
+			fmt.Printf(" %-15s %-30s\n", "Status", "Name")
+			// fmt.Printf("-----------------------------------------------\n")
+			for _, val := range appList {
+				fmt.Printf(" %-15s %-30s\n", val.Status, val.AppName)
+			}
 		}
+		if cliInfo.WatchOption {
+			watch(cliInfo.WatchInterval, "bwc get bwc", printBWCList)
+			break
+		}
+		printBWCList()
 	case "get-template":
 		var templateType, ownerName string
 
 		templateList, _ := sdtGet.GetTemplate(svcInfo.BwURL, configData)
-		fmt.Printf(" %-30s %-20s %-30s\n", "Name", "Owner", "Type")
-		// fmt.Printf("-----------------------------------------------\n")
+		if cliInfo.DescribeOption {
+			sdtGet.DescribeTemplate(svcInfo.GiteaURL, &templateList, configData.AccessToken)
+		}
+		templateStatus := []sdtType.TemplateStatus{}
 		for _, val := range templateList.Content {
 			ownerList := strings.Split(val.Owner.Username, ".")
 
@@ -167,7 +314,29 @@ func RunBody(cmd string,
 			} else {
 				templateType = "app"
 			}
-			fmt.Printf(" %-30s %-20s %-30s\n", val.Name, ownerName, templateType)
+			template := sdtType.TemplateStatus{Name: val.Name, Owner: ownerName, Type: templateType}
+			if cliInfo.DescribeOption {
+				template.Description = val.Description
+			}
+			templateStatus = append(templateStatus, template)
+		}
+		if cliInfo.OutputOption == "json" {
+			printJson(templateStatus)
+			break
+		}
+
+		if cliInfo.DescribeOption {
+			fmt.Printf(" %-30s %-20s %-15s %s\n", "Name", "Owner", "Type", "Description")
+		} else {
+			fmt.Printf(" %-30s %-20s %-30s\n", "Name", "Owner", "Type")
+		}
+		// fmt.Printf("-----------------------------------------------\n")
+		for _, val := range templateStatus {
+			if cliInfo.DescribeOption {
+				fmt.Printf(" %-30s %-20s %-15s %s\n", val.Name, val.Owner, val.Type, val.Description)
+			} else {
+				fmt.Printf(" %-30s %-20s %-30s\n", val.Name, val.Owner, val.Type)
+			}
 		}
 	case "deploy-app":
 		// var username, password, giteaURL, localRepoPath, releaseTitle, repoUser string
@@ -229,7 +398,7 @@ func RunBody(cmd string,
 
 			if repoOwnerName == "" {
 				repoOwnerName = deviceOwnerName
-				procLog.Info.Printf("Frist push app in code repository: %s\n")
+				procLog.Info.Printf("Frist push app in code repository: %s\n", repoOwnerName)
 			} else if repoOwnerName != deviceOwnerName { // repo's ownerName differ device's ownerName.
 				// Update password about repoOwnerName.
 				fmt.Printf("Device's stackbase user differ app's repo user. Please input password.\n")
@@ -238,7 +407,7 @@ func RunBody(cmd string,
 				configData.SdtcloudId = repoOwnerName
 				configData.SdtcloudPw = string(pw)
 			}
-			sdtDeploy.UploadStackbase(bwcFramework, cliInfo.DirOption, svcInfo.GiteaURL, configData, repoOwnerName)
+			sdtDeploy.UploadStackbase(bwcFramework, cliInfo.DirOption, svcInfo.GiteaURL, configData, repoOwnerName, cliInfo.TagVersionOption)
 			fmt.Printf("Successfully upload.")
 		}
 
@@ -254,7 +423,11 @@ func RunBody(cmd string,
 		sdtUtil.CopyDir(cliInfo.DirOption, appDir)
 
 		// Save App's info in json
-		sdtDeploy.SaveAppInfo(bwcFramework.Spec.AppName, appId, bwcFramework.Spec.Env.VirtualEnv, "systemd")
+		appManaged := "systemd"
+		if cliInfo.NoSystemd {
+			appManaged = "direct"
+		}
+		sdtDeploy.SaveAppInfo(bwcFramework.Spec.AppName, appId, bwcFramework.Spec.Env.VirtualEnv, appManaged)
 
 		// Create deamon service file and Move svc file in systemd directory
 		// TODO env.bin과 bin.runtime 을 정리해야 함
@@ -268,10 +441,10 @@ func RunBody(cmd string,
 
 		// Start APP
 		var appRepoPath string
-		deployErr := sdtDeploy.DeployApp(bwcFramework, appDir, svcInfo.MinioURL)
+		deployErr := sdtDeploy.DeployApp(bwcFramework, appDir, svcInfo.MinioURL, cliInfo.NoSystemd)
 		if deployErr != nil {
 			sdtDelete.DeleteAppInfo(bwcFramework.Spec.AppName)
-			//sdtDelete.DeleteApp(bwcFramework.Spec.AppName, appId)
+			//sdtDelete.DeleteApp(bwcFramework.Spec.AppName, appId, false)
 			fmt.Printf("App deployment failed: %v\n", deployErr)
 			os.Exit(1)
 		}
@@ -284,8 +457,8 @@ func RunBody(cmd string,
 			bwcFramework.Stackbase.TagName,
 		)
 		if ownerErr != nil {
+			sdtDelete.DeleteApp(bwcFramework.Spec.AppName, appId, false)
 			sdtDelete.DeleteAppInfo(bwcFramework.Spec.AppName)
-			sdtDelete.DeleteApp(bwcFramework.Spec.AppName, appId)
 			fmt.Printf("App deployment failed: %v\n", ownerErr)
 			os.Exit(1)
 		}
@@ -293,9 +466,9 @@ func RunBody(cmd string,
 		// Get PID
 		pid, err := sdtUtil.GetPid(bwcFramework.Spec.AppName)
 		if err != nil {
-			sdtLogs.GetLogsApp(bwcFramework.Spec.AppName, appId)
+			sdtLogs.GetLogsApp(bwcFramework.Spec.AppName, appId, false, time.Time{}, 0, configData)
+			sdtDelete.DeleteApp(bwcFramework.Spec.AppName, appId, false)
 			sdtDelete.DeleteAppInfo(bwcFramework.Spec.AppName)
-			sdtDelete.DeleteApp(bwcFramework.Spec.AppName, appId)
 			fmt.Printf("App deployment failed: %v\n", err)
 			os.Exit(1)
 		}
@@ -312,15 +485,19 @@ func RunBody(cmd string,
 			cliResult["pid"].(int), cliResult["size"].(int64), nil, appRepoPath, appId, "", "", bwcFramework.Spec.Env.VirtualEnv,
 		)
 		fmt.Printf("App deployment completed: %s\n", bwcFramework.Spec.AppName)
+		sdtRollback.RemoveBackups(appPath, bwcFramework.Spec.AppName)
 
 		// Send Message about app's config.
 		// Get config
 		procLog.Info.Printf("Get app's confing.\n")
-		jsonResult := sdtDeploy.GetAppConfig(appId, bwcFramework.Spec.AppName, archType)
+		jsonResult, configErr, configStatus := sdtDeploy.GetAppConfig(appId, bwcFramework.Spec.AppName, appPath, "getConfig")
 		cliMessage = fmt.Sprintf("Successfully get %s's config.", bwcFramework.Spec.AppName)
+		if configErr != nil {
+			cliMessage = fmt.Sprintf("Failed get %s's config.", bwcFramework.Spec.AppName)
+		}
 
-		sdtMessage.SendResult("/etc/sdt", configData, bwcFramework.Spec.AppName, cliMessage, nil,
-			http.StatusOK, "get", "config", requestId,
+		sdtMessage.SendResult("/etc/sdt", configData, bwcFramework.Spec.AppName, cliMessage, configErr,
+			configStatus, "get", "config", requestId,
 			-1, -1, jsonResult, "", appId, "", "", "",
 		)
 
@@ -434,7 +611,7 @@ func RunBody(cmd string,
 		var requirementStr string
 		pkgFile := bwcFramework.Spec.Env.Package
 
-		content, err := ioutil.ReadFile(pkgFile)
+		content, err := os.ReadFile(pkgFile)
 		if err != nil {
 			fmt.Printf("Venv update failed: %v\n", err)
 			os.Exit(1)
@@ -454,6 +631,200 @@ func RunBody(cmd string,
 		)
 		fmt.Printf("Venv update completed: %s\n", bwcFramework.Spec.Env.VirtualEnv)
 
+	case "update-app":
+		// Check exist app.
+		if !sdtGet.CheckExistApp(bwcFramework.Spec.AppName) {
+			fmt.Printf("App not found: %s\n", bwcFramework.Spec.AppName)
+			os.Exit(1)
+		}
+		appId := sdtGet.GetAppId(bwcFramework.Spec.AppName)
+		if appId == "" {
+			fmt.Printf("%s app is not deployed app.\n", bwcFramework.Spec.AppName)
+			os.Exit(1)
+		}
+
+		// Check exist venv
+		if bwcFramework.Spec.Env.VirtualEnv != "" && !sdtGet.CheckExistVenv(bwcFramework.Spec.Env.VirtualEnv) {
+			fmt.Printf("Venv not found: %s\n", bwcFramework.Spec.Env.VirtualEnv)
+			os.Exit(1)
+		}
+
+		// Check upload in stackbase
+		if cliInfo.UploadOption {
+			fmt.Printf("Upload app in code repository.\n")
+			// Get Repo(=templateName)'s OwnerName.
+			repoOwnerName, _ := sdtGet.GetTemplateOwner(svcInfo.BwURL, bwcFramework.Stackbase.RepoName, configData)
+			// Get Device's ownerName by using your device's sdtcloudID in device's config.json).
+			deviceOwnerName := sdtGet.GetRepoOwner(svcInfo.BwURL, configData.AccessToken, configData.SdtcloudId).Username
+
+			if repoOwnerName == "" {
+				repoOwnerName = deviceOwnerName
+				procLog.Info.Printf("Frist push app in code repository: %s\n", repoOwnerName)
+			} else if repoOwnerName != deviceOwnerName { // repo's ownerName differ device's ownerName.
+				// Update password about repoOwnerName.
+				fmt.Printf("Device's stackbase user differ app's repo user. Please input password.\n")
+				fmt.Printf("Password for %s: ", strings.Split(repoOwnerName, ".")[1])
+				pw, _ := terminal.ReadPassword(int(os.Stdin.Fd()))
+				configData.SdtcloudId = repoOwnerName
+				configData.SdtcloudPw = string(pw)
+			}
+			sdtDeploy.UploadStackbase(bwcFramework, cliInfo.DirOption, svcInfo.GiteaURL, configData, repoOwnerName, cliInfo.TagVersionOption)
+			fmt.Printf("Successfully upload.\n")
+		}
+
+		// Download new version from the code repository in staging directory.
+		// ('auto' tag is not known here, so the default branch with the uploaded app is downloaded.)
+		appDir := fmt.Sprintf("%s/%s_%s", appPath, bwcFramework.Spec.AppName, appId)
+		unitFile := fmt.Sprintf("/etc/systemd/system/%s.service", bwcFramework.Spec.AppName)
+		stagingDir := fmt.Sprintf("%s_new", appDir)
+		os.RemoveAll(stagingDir)
+		tagName := bwcFramework.Stackbase.TagName
+		if cliInfo.UploadOption && cliInfo.TagVersionOption == "auto" {
+			tagName = ""
+		}
+		appOwner, err := sdtGet.GetTemplateOwner(svcInfo.BwURL, bwcFramework.Stackbase.RepoName, configData)
+		if err == nil {
+			err = sdtGitea.DownloadGiteaTemplate(svcInfo.GiteaURL, bwcFramework.Stackbase.RepoName, stagingDir, appOwner, tagName, configData.AccessToken)
+		}
+		if err != nil {
+			os.RemoveAll(stagingDir)
+			fmt.Printf("App update failed: %v\n", err)
+			os.Exit(1)
+		}
+		appSize, _ := sdtUtil.GetDirectorySize(stagingDir)
+		cliMessage = fmt.Sprintf("%s's new version staged.", bwcFramework.Spec.AppName)
+		sdtMessage.SendResult("/etc/sdt", configData, bwcFramework.Spec.AppName, cliMessage, nil,
+			http.StatusOK, "appUpdate", "deploy", requestId,
+			-1, appSize, nil, "", appId, "", "", bwcFramework.Spec.Env.VirtualEnv,
+		)
+
+		// Stop running app.
+		sdtDelete.DeleteApp(bwcFramework.Spec.AppName, appId, true)
+		cliMessage = fmt.Sprintf("%s's old version stopped.", bwcFramework.Spec.AppName)
+		sdtMessage.SendResult("/etc/sdt", configData, bwcFramework.Spec.AppName, cliMessage, nil,
+			http.StatusOK, "appUpdate", "deploy", requestId,
+			-1, appSize, nil, "", appId, "", "", bwcFramework.Spec.Env.VirtualEnv,
+		)
+
+		// Switch app's directory.
+		err = sdtUpdate.SwitchAppDir(appDir, unitFile)
+		if err != nil {
+			sdtCreate.CreateApp(bwcFramework, appDir, "")
+			os.RemoveAll(stagingDir)
+			sdtMessage.SendResult("/etc/sdt", configData, bwcFramework.Spec.AppName, "", err,
+				http.StatusBadRequest, "appUpdate", "deploy", requestId,
+				-1, -1, nil, "", appId, "", "", bwcFramework.Spec.Env.VirtualEnv,
+			)
+			fmt.Printf("App update failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Start new version.
+		deployErr := sdtDeploy.DeployApp(bwcFramework, appDir, svcInfo.MinioURL, false)
+		pid := -1
+		if deployErr == nil {
+			pid, deployErr = sdtUpdate.WaitAppPid(bwcFramework.Spec.AppName, 10)
+		}
+		if deployErr != nil {
+			// Restore old version.
+			sdtDelete.DeleteApp(bwcFramework.Spec.AppName, appId, true)
+			restoreErr := sdtUpdate.RestoreAppDir(appDir, unitFile)
+			if restoreErr != nil {
+				procLog.Error.Printf("Failed restore %s app: %v\n", bwcFramework.Spec.AppName, restoreErr)
+			}
+			sdtCreate.CreateApp(bwcFramework, appDir, "")
+			sdtMessage.SendResult("/etc/sdt", configData, bwcFramework.Spec.AppName, "", deployErr,
+				http.StatusBadRequest, "appUpdate", "deploy", requestId,
+				-1, -1, nil, "", appId, "", "", bwcFramework.Spec.Env.VirtualEnv,
+			)
+			fmt.Printf("App update failed, old version restored: %v\n", deployErr)
+			os.Exit(1)
+		}
+		// Keep old version for 'bwc rollback app'.
+		os.Remove(fmt.Sprintf("%s_old.service", appDir))
+		if err := sdtRollback.SaveBackup(fmt.Sprintf("%s_old", appDir), appDir); err != nil {
+			os.RemoveAll(fmt.Sprintf("%s_old", appDir))
+		}
+
+		// Update App's info in json
+		sdtDelete.DeleteAppInfo(bwcFramework.Spec.AppName)
+		sdtDeploy.SaveAppInfo(bwcFramework.Spec.AppName, appId, bwcFramework.Spec.Env.VirtualEnv, "systemd")
+
+		cliMessage = fmt.Sprintf("%s's %s successed.", bwcFramework.Spec.AppName, cmd)
+		sdtMessage.SendResult("/etc/sdt", configData, bwcFramework.Spec.AppName, cliMessage, nil,
+			http.StatusOK, "appUpdate", "deploy", requestId,
+			pid, appSize, nil, "", appId, "", "", bwcFramework.Spec.Env.VirtualEnv,
+		)
+		fmt.Printf("App update completed: %s\n", bwcFramework.Spec.AppName)
+
+	case "rollback-app":
+		// Check exist app.
+		if !sdtGet.CheckExistApp(cliInfo.NameOption) {
+			fmt.Printf("App not found: %s\n", cliInfo.NameOption)
+			os.Exit(1)
+		}
+		appId := sdtGet.GetAppId(cliInfo.NameOption)
+		if appId == "" {
+			fmt.Printf("%s app is not deployed app.\n", cliInfo.NameOption)
+			os.Exit(1)
+		}
+		appDir := fmt.Sprintf("%s/%s_%s", appPath, cliInfo.NameOption, appId)
+		if !sdtRollback.HasBackup(appDir) {
+			fmt.Printf("Backup not found: %s\n", sdtRollback.BackupDir(appDir))
+			fmt.Printf("A backup is kept when the app is updated with 'bwc update app'.\n")
+			os.Exit(1)
+		}
+		noSystemd := false
+		if appDetail, err := sdtGet.GetAppDetail(cliInfo.NameOption); err == nil && appDetail.Managed == "direct" {
+			noSystemd = true
+		}
+
+		// Stop running app and restore backup.
+		sdtDelete.DeleteApp(cliInfo.NameOption, appId, true)
+		startApp := func() (sdtType.Framework, int, error) {
+			appFramework, err := sdtRollback.GetFramework(appDir)
+			if err != nil {
+				return appFramework, -1, err
+			}
+			appFramework.Spec.Env.HomeName = bwcFramework.Spec.Env.HomeName
+			if err = sdtDeploy.DeployApp(appFramework, appDir, svcInfo.MinioURL, noSystemd); err != nil {
+				return appFramework, -1, err
+			}
+			pid, err := sdtUpdate.WaitAppPid(cliInfo.NameOption, 10)
+			return appFramework, pid, err
+		}
+
+		rollbackErr := sdtRollback.SwapBackup(appDir)
+		appFramework, pid, startErr := startApp()
+		if rollbackErr == nil && startErr != nil {
+			// Restored version not started. Start current version again.
+			rollbackErr = startErr
+			sdtDelete.DeleteApp(cliInfo.NameOption, appId, true)
+			if swapErr := sdtRollback.SwapBackup(appDir); swapErr != nil {
+				procLog.Error.Printf("Failed restore current version of %s app: %v\n", cliInfo.NameOption, swapErr)
+			}
+			appFramework, _, startErr = startApp()
+		}
+		if startErr != nil {
+			procLog.Error.Printf("Failed start %s app: %v\n", cliInfo.NameOption, startErr)
+		}
+		if rollbackErr != nil {
+			sdtMessage.SendResult("/etc/sdt", configData, cliInfo.NameOption, "", rollbackErr,
+				http.StatusBadRequest, "appUpdate", "deploy", requestId,
+				-1, -1, nil, "", appId, "", "", appFramework.Spec.Env.VirtualEnv,
+			)
+			fmt.Printf("App rollback failed: %v\n", rollbackErr)
+			os.Exit(1)
+		}
+
+		appSize, _ := sdtUtil.GetDirectorySize(appDir)
+		cliMessage = fmt.Sprintf("%s's %s successed.", cliInfo.NameOption, cmd)
+		sdtMessage.SendResult("/etc/sdt", configData, cliInfo.NameOption, cliMessage, nil,
+			http.StatusOK, "appUpdate", "deploy", requestId,
+			pid, appSize, nil, "", appId, "", "", appFramework.Spec.Env.VirtualEnv,
+		)
+		fmt.Printf("App rollback completed: %s\n", cliInfo.NameOption)
+
 	case "delete-venv":
 		// Check exist venv
 		if !sdtGet.CheckExistVenv(cliInfo.NameOption) {
@@ -461,15 +832,23 @@ func RunBody(cmd string,
 			os.Exit(1)
 		}
 
-		// Check venv used
-		appName, used := sdtGet.CheckVenvUsed(cliInfo.NameOption)
-		if used {
+		// Ask before deleting the venv. (Skipped with --yes or when stdin is not a terminal.)
+		if _, used := sdtGet.CheckVenvUsed(cliInfo.NameOption); !used && !cliInfo.YesOption && terminal.IsTerminal(int(os.Stdin.Fd())) {
+			if !confirm(fmt.Sprintf("Are you sure you want to delete venv '%s'? [y/N] ", cliInfo.NameOption)) {
+				fmt.Printf("Venv deletion canceled: %s\n", cliInfo.NameOption)
+				os.Exit(1)
+			}
+		}
+
+		// Delete venv (venv used by app isn't deleted.)
+		appName, err := sdtDelete.DeleteVenv(cliInfo.NameOption)
+		if appName != "" {
 			fmt.Printf("%s venv used by %s app.\n", cliInfo.NameOption, appName)
 			fmt.Printf("If you want to delete venv, delete %s app.\n", appName)
 			os.Exit(1)
+		} else if err != nil {
+			os.Exit(1)
 		}
-
-		sdtDelete.DeleteVenv(cliInfo.NameOption)
 		statusCode := 200
 		cliMessage = fmt.Sprintf("%s's %s successed.", cliInfo.NameOption, cmd)
 
@@ -485,12 +864,20 @@ func RunBody(cmd string,
 			os.Exit(1)
 		}
 
-		// Delete app's info and get appId
-		appId := sdtDelete.DeleteAppInfo(cliInfo.NameOption)
+		// Get appId. The app's info is deleted after the app is stopped, since DeleteApp reads it.
+		var appId string
+		if appInfo, err := sdtUtil.GetAppInfo(cliInfo.NameOption); err == nil {
+			appId = appInfo.AppId
+		}
 		//fmt.Println("APPID: [", appId, "]")
 
 		// Stop App
-		sdtDelete.DeleteApp(cliInfo.NameOption, appId)
+		sdtDelete.DeleteApp(cliInfo.NameOption, appId, false)
+		sdtDelete.DeleteAppInfo(cliInfo.NameOption)
+		sdtRollback.RemoveBackups(appPath, cliInfo.NameOption)
+		if cliInfo.CleanDeployLogs {
+			sdtDelete.CleanDeployLogs(cliInfo.NameOption, appId)
+		}
 
 		// If app executed, not send message
 		if appId == "" {
@@ -511,35 +898,69 @@ func RunBody(cmd string,
 			cliResult["pid"].(int), cliResult["size"].(int64), nil, "", appId, "", "", "",
 		)
 		fmt.Printf("App deletion completed: %s\n", cliInfo.NameOption)
+	case "restart-agent":
+		fmt.Printf("Restart %s agent.\n", cliInfo.NameOption)
+		state, err := sdtUtil.RestartAgent(cliInfo.NameOption)
+		sdtMessage.SendAgentRestart("/etc/sdt", configData, cliInfo.NameOption, state, err)
+		if err != nil {
+			fmt.Printf("Failed restart %s: %v\n", cliInfo.NameOption, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Successfully restart %s. (state: %s)\n", cliInfo.NameOption, state)
 	case "status":
-		sdtGet.GetStatus(configData.AssetCode, configData.Organzation, svcInfo.BwURL)
+		if cliInfo.WatchOption {
+			// API errors are shown and polling continues, since the device may be coming online.
+			watch(cliInfo.WatchInterval, "bwc status", func() {
+				deviceStatus, err := sdtGet.FetchStatus(configData.AssetCode, configData.Organzation, svcInfo.BwURL)
+				if err != nil {
+					fmt.Printf("%v\n", err)
+					return
+				}
+				sdtGet.PrintStatus(deviceStatus, cliInfo.OutputOption)
+			})
+			break
+		}
+		sdtGet.GetStatus(configData.AssetCode, configData.Organzation, svcInfo.BwURL, cliInfo.OutputOption)
 	case "info":
 		sdtGet.GetInfoDevice(configData)
+	case "diagnose":
+		if !sdtDiagnose.RunDiagnose(rootPath, appPath, bwcFramework.Spec.Env.HomeName, configData, svcInfo) {
+			os.Exit(1)
+		}
+	case "export-config":
+		if err := sdtExport.ExportConfig(rootPath, cliInfo.FileOption, cliInfo.IncludeSecrets); err != nil {
+			procLog.Error.Printf("Export config Error: %v\n", err)
+			fmt.Printf("Export config Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Successfully export config: %s\n", cliInfo.FileOption)
+	case "import-config":
+		if err := sdtExport.ImportConfig(rootPath, cliInfo.FileOption); err != nil {
+			procLog.Error.Printf("Import config Error: %v\n", err)
+			fmt.Printf("Import config Error: %v\n", err)
+			os.Exit(1)
+		}
 	case "upload":
 		fmt.Printf("Upload app in code repository.\n")
-		// Get Repo(=templateName)'s OwnerName.
-		repoOwnerName, _ := sdtGet.GetTemplateOwner(svcInfo.BwURL, bwcFramework.Stackbase.RepoName, configData)
-		// Get Device's ownerName by using your device's sdtcloudID in device's config.json).
-		deviceOwnerName := sdtGet.GetRepoOwner(svcInfo.BwURL, configData.AccessToken, configData.SdtcloudId).Username
-
-		if repoOwnerName == "" {
-			repoOwnerName = deviceOwnerName
-			procLog.Info.Printf("Frist push app in code repository: %s\n")
-		} else if repoOwnerName != deviceOwnerName { // repo's ownerName differ device's ownerName.
-			// Update password about repoOwnerName.
-			fmt.Printf("Device's stackbase user differ app's repo user. Please input password.\n")
-			fmt.Printf("Password for %s: ", strings.Split(repoOwnerName, ".")[1])
-			pw, _ := terminal.ReadPassword(int(os.Stdin.Fd()))
-			configData.SdtcloudId = repoOwnerName
-			configData.SdtcloudPw = string(pw)
-		}
-		sdtDeploy.UploadStackbase(bwcFramework, cliInfo.DirOption, svcInfo.GiteaURL, configData, repoOwnerName)
+		repoOwnerName := resolveRepoOwner(svcInfo.BwURL, bwcFramework.Stackbase.RepoName, &configData)
+		sdtDeploy.UploadStackbase(bwcFramework, cliInfo.DirOption, svcInfo.GiteaURL, configData, repoOwnerName, cliInfo.TagVersionOption)
 		fmt.Printf("Successfully upload.")
+	case "push":
+		fmt.Printf("Push changed files of app in code repository.\n")
+		repoOwnerName := resolveRepoOwner(svcInfo.BwURL, bwcFramework.Stackbase.RepoName, &configData)
+		sdtDeploy.PushStackbase(bwcFramework, cliInfo.DirOption, svcInfo.GiteaURL, configData, repoOwnerName, cliInfo.TagVersionOption)
+		fmt.Printf("Successfully push.\n")
 	case "logs-bwc":
 		if cliInfo.TailOption {
-			sdtLogs.GetLogsTail(cliInfo.NameOption)
+			sdtLogs.GetLogsTail(cliInfo.NameOption, cliInfo.LevelOption)
+		} else {
+			sdtLogs.GetLogs(cliInfo.NameOption, cliInfo.LineOption, cliInfo.LevelOption)
+		}
+	case "logs-audit":
+		if cliInfo.TailOption {
+			sdtLogs.GetLogsTail("audit", "")
 		} else {
-			sdtLogs.GetLogs(cliInfo.NameOption, cliInfo.LineOption)
+			sdtLogs.GetLogs("audit", cliInfo.LineOption, "")
 		}
 	case "logs-app":
 		if !sdtGet.CheckExistApp(cliInfo.NameOption) {
@@ -547,11 +968,119 @@ func RunBody(cmd string,
 			os.Exit(1)
 		}
 		appId := sdtGet.GetAppId(cliInfo.NameOption)
-		sdtLogs.GetLogsApp(cliInfo.NameOption, appId)
+		var since time.Time
+		if cliInfo.SinceOption != "" {
+			since, _ = sdtLogs.ParseSince(cliInfo.SinceOption)
+		}
+		sdtLogs.GetLogsApp(cliInfo.NameOption, appId, cliInfo.TailOption, since, cliInfo.LineOption, configData)
 	case "wol":
-		sdtDeploy.WolTest(cliInfo.NameOption)
+		err := sdtDeploy.WolTest(cliInfo.NameOption)
+		if err != nil {
+			fmt.Printf("[WOL] ERROR: %v\n", err)
+			os.Exit(1)
+		}
+	case "wol-add":
+		err := sdtDeploy.AddWolTarget(cliInfo.NameOption, cliInfo.MacOption)
+		if err != nil {
+			fmt.Printf("[WOL] ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved WOL target: %s (%s)\n", cliInfo.NameOption, cliInfo.MacOption)
 	}
 
 	// fmt.Println(cliResult)
 
 }
+
+// confirm function prints the question and reads one line from stdin.
+//
+// Input:
+//   - question: Question to print.
+//
+// Output:
+//   - bool: True if the answer is 'y' or 'yes'. (Case-insensitive)
+func confirm(question string) bool {
+	fmt.Printf("%s", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// resolveRepoOwner function returns the owner of the app's repository for upload and push.
+// If the repository is owned by another user than the device's stackbase user, the password
+// of the owner is read from the terminal and the credentials of configData are replaced.
+func resolveRepoOwner(bwURL string, repoName string, configData *sdtType.ConfigInfo) string {
+	// Get Repo(=templateName)'s OwnerName.
+	repoOwnerName, _ := sdtGet.GetTemplateOwner(bwURL, repoName, *configData)
+	// Get Device's ownerName by using your device's sdtcloudID in device's config.json).
+	deviceOwnerName := sdtGet.GetRepoOwner(bwURL, configData.AccessToken, configData.SdtcloudId).Username
+
+	if repoOwnerName == "" {
+		repoOwnerName = deviceOwnerName
+		procLog.Info.Printf("Frist push app in code repository: %s\n", repoOwnerName)
+	} else if repoOwnerName != deviceOwnerName { // repo's ownerName differ device's ownerName.
+		// Update password about repoOwnerName.
+		fmt.Printf("Device's stackbase user differ app's repo user. Please input password.\n")
+		fmt.Printf("Password for %s: ", strings.Split(repoOwnerName, ".")[1])
+		pw, _ := terminal.ReadPassword(int(os.Stdin.Fd()))
+		configData.SdtcloudId = repoOwnerName
+		configData.SdtcloudPw = string(pw)
+	}
+	return repoOwnerName
+}
+
+// printJson function prints the value as indented JSON to stdout. (--output json)
+//
+// Input:
+//   - value: Value to print.
+func printJson(value interface{}) {
+	jsonData, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		fmt.Printf("Failed marshal output: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s\n", jsonData)
+}
+
+// watch function calls render every interval seconds until Ctrl-C is pressed, like 'watch -n'.
+// Each time, the screen is cleared with ANSI escape codes so that the output is overwritten in place.
+//
+// Input:
+//   - interval: Refresh interval in seconds.
+//   - title: Command shown in the header. (ex. bwc status)
+//   - render: Function that prints the output.
+func watch(interval int, title string, render func()) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+	watchLoop(os.Stdout, interval, title, render, ticker.C, sigCh)
+}
+
+// watchLoop function is the loop of watch. The header is written to out and render is called
+// on every tick until a signal is received from stop.
+//
+// Input:
+//   - out: Writer of the header. (os.Stdout)
+//   - interval: Refresh interval in seconds. (Shown in the header)
+//   - title: Command shown in the header.
+//   - render: Function that prints the output.
+//   - tick: Channel of the refresh ticks.
+//   - stop: Channel of the stop signals. (Ctrl-C)
+func watchLoop(out io.Writer, interval int, title string, render func(), tick <-chan time.Time, stop <-chan os.Signal) {
+	for {
+		// Move the cursor home and clear the screen.
+		fmt.Fprintf(out, "\033[H\033[2J")
+		fmt.Fprintf(out, "Every %ds: %s    %s\n\n", interval, title, time.Now().Format("2006-01-02 15:04:05"))
+		render()
+
+		select {
+		case <-tick:
+		case <-stop:
+			fmt.Fprintf(out, "\n")
+			return
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchLoop(t *testing.T) {
+	tick := make(chan time.Time)
+	stop := make(chan os.Signal, 1)
+	renders := 0
+	render := func() {
+		renders++
+		switch renders {
+		case 1, 2:
+			go func() { tick <- time.Now() }()
+		case 3:
+			stop <- os.Interrupt
+		}
+	}
+
+	var out bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		watchLoop(&out, 5, "bwc status", render, tick, stop)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchLoop did not return after the stop signal")
+	}
+
+	if renders != 3 {
+		t.Errorf("render called %d times, want 3", renders)
+	}
+	if got := strings.Count(out.String(), "\033[H\033[2J"); got != 3 {
+		t.Errorf("screen cleared %d times, want 3", got)
+	}
+	if got := strings.Count(out.String(), "Every 5s: bwc status"); got != 3 {
+		t.Errorf("header written %d times, want 3:\n%q", got, out.String())
+	}
+	if !strings.HasSuffix(out.String(), "\n\n\n") {
+		t.Errorf("output should end with a newline after the stop signal: %q", out.String())
+	}
+}
@@ -2,7 +2,10 @@
 package cliType
 
 import (
+	"fmt"
 	"log"
+	"regexp"
+	"strings"
 )
 
 // ControlService defines the structure for the environment information of the control agent.
@@ -27,18 +30,56 @@ type ControlService struct {
 //   - UploadOption: Option to upload an app. (Uploads to the code repository.)
 //   - TailOption: Option to use the 'tail' function for logs.
 //   - LineOption: Number of lines to display for logs.
-//   - TemplateOption: App template name.
+//   - TemplateOption: App template name. (<templateName> or <owner>/<repo>:<tag>)
 //   - AppOption: App status processing value. (For example, there is 'Restart'.)
+//   - CleanDeployLogs: Option to remove the app's deploy.log when deleting the app.
+//   - OutputOption: Output format of the command. (For example, there is 'json'.)
+//   - TagVersionOption: Release tag of the uploaded app. ('auto' increments the patch version of the latest tag.)
+//   - DescribeOption: Option to show the description of app templates.
+//   - HistoryOption: Option to show the deploy history of apps.
+//   - NoSystemd: Option to run the deployed app directly as a background process without systemd.
+//   - InspectOption: Name of the app to show the detailed information.
+//   - ConfigKey: Key of the app's config to set. (Dots are nested JSON path.)
+//   - ConfigValue: Value of the app's config to set.
+//   - MacOption: MAC address of the device to wake up with Wake-on-LAN.
+//   - DetailOption: Option to show the Python version, package count and disk size of venvs.
+//   - YesOption: Option to skip the confirmation prompt. (ex. delete venv)
+//   - LevelOption: Log level to print in the bwc logs. (info, warn, error)
+//   - SinceOption: Print the app logs newer than this. (Duration like 1h or RFC3339 time)
+//   - LogFormatOption: Format of the BWC-CLI log file. (text, json)
+//   - FileOption: Archive file of the exported device config. (bwc export|import config)
+//   - IncludeSecrets: Option to export the secrets of config.json without redaction.
+//   - WatchOption: Option to refresh the output periodically. (bwc status, get app, get bwc)
+//   - WatchInterval: Refresh interval of WatchOption in seconds.
 type CliCmd struct {
-	FirstCmd       string
-	TargetCmd      string
-	NameOption     string
-	DirOption      string
-	UploadOption   bool
-	TailOption     bool
-	LineOption     int
-	TemplateOption string
-	AppOption      string
+	FirstCmd         string
+	TargetCmd        string
+	NameOption       string
+	DirOption        string
+	UploadOption     bool
+	TailOption       bool
+	LineOption       int
+	TemplateOption   string
+	AppOption        string
+	CleanDeployLogs  bool
+	OutputOption     string
+	TagVersionOption string
+	DescribeOption   bool
+	HistoryOption    bool
+	NoSystemd        bool
+	InspectOption    string
+	ConfigKey        string
+	ConfigValue      string
+	MacOption        string
+	DetailOption     bool
+	YesOption        bool
+	LevelOption      string
+	SinceOption      string
+	LogFormatOption  string
+	FileOption       string
+	IncludeSecrets   bool
+	WatchOption      bool
+	WatchInterval    int
 }
 
 // Definition of ConfigInfo Struct. ConfigInfo is the SDT Cloud config file used on devices.
@@ -57,6 +98,8 @@ type CliCmd struct {
 //   - SdtcloudId: SDT Cloud user ID.
 //   - SdtcloudPw: SDT Cloud user password.
 //   - AccessToken: Access token of SDT Cloud user.
+//   - LogLevel: Log level of BWC. (debug, info, warn, error)
+//   - TopicPrefix: Namespace prepended to the MQTT topics. (Empty by default)
 type ConfigInfo struct {
 	ModelName   string `json:"modelname"`
 	Admin       string `json:"admin"`
@@ -74,6 +117,69 @@ type ConfigInfo struct {
 	SdtcloudId  string `json:"sdtcloudid"`
 	SdtcloudPw  string `json:"sdtcloudpw"`
 	AccessToken string `json:"accesstoken"`
+	LogLevel    string `json:"loglevel,omitempty"`
+	TopicPrefix string `json:"topicprefix,omitempty"`
+}
+
+// Topic function returns the MQTT topic of the device. If TopicPrefix is set, it is prepended
+// to the topic so that multiple BWC deployments can share one MQTT broker.
+//
+//	<topicPrefix>/<serviceCode>/<projectCode>/<assetCode>/<subTopic>
+//
+// Input:
+//   - subTopic: Topic after the asset code. (ex. bwc/health)
+//
+// Output:
+//   - string: MQTT topic.
+func (c ConfigInfo) Topic(subTopic string) string {
+	topic := fmt.Sprintf("%s/%s/%s/%s", c.ServiceCode, c.ProjectCode, c.AssetCode, subTopic)
+	if c.TopicPrefix != "" {
+		topic = fmt.Sprintf("%s/%s", c.TopicPrefix, topic)
+	}
+	return topic
+}
+
+// assetCodeRegexp is the pattern that assetcode in config.json must match.
+var assetCodeRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ValidateConfigInfo function checks the fields of config.json against the expected values.
+//
+// Input:
+//   - c: Config data of the device.
+//
+// Output:
+//   - []string: Detected issues. It is empty if the config is valid.
+func ValidateConfigInfo(c ConfigInfo) []string {
+	var errList []string
+
+	if !assetCodeRegexp.MatchString(c.AssetCode) {
+		errList = append(errList, fmt.Sprintf("assetcode '%s' must match [a-zA-Z0-9_-]{1,64}", c.AssetCode))
+	}
+	if !strings.HasPrefix(c.MqttUrl, "ssl://") && !strings.HasPrefix(c.MqttUrl, "tcp://") {
+		errList = append(errList, fmt.Sprintf("mqtturl '%s' must start with ssl:// or tcp://", c.MqttUrl))
+	}
+	switch c.ServiceType {
+	case "eks", "aws-dev", "dev":
+	case "onprem":
+		if c.ServerIp == "" {
+			errList = append(errList, "serverip is required when servicetype is onprem")
+		}
+	default:
+		errList = append(errList, fmt.Sprintf("servicetype '%s' must be one of eks, aws-dev, dev, onprem", c.ServiceType))
+	}
+	if c.ServiceCode == "" {
+		errList = append(errList, "servicecode is required")
+	}
+	if c.ProjectCode == "" {
+		errList = append(errList, "projectcode is required")
+	}
+	switch strings.ToLower(c.LogLevel) {
+	case "", "debug", "info", "warn", "warning", "error":
+	default:
+		errList = append(errList, fmt.Sprintf("loglevel '%s' must be one of debug, info, warn, error", c.LogLevel))
+	}
+
+	return errList
 }
 
 // Struct defining the format of logs.
@@ -109,22 +215,110 @@ type AppInfo struct {
 	Managed string `json:"Managed"`
 }
 
+// CmdJson defines the structure for modifying the config file of an app.
+//   - AppId: ID of the app.
+//   - AppName: Name of the app.
+//   - FileName: Name of the config file to modify.
+//   - Parameter: JSON content to modify.
+type CmdJson struct {
+	AppId     string                 `json:"appId"`
+	AppName   string                 `json:"appName"`
+	FileName  string                 `json:"fileName"`
+	Parameter map[string]interface{} `json:"parameter"`
+}
+
 // Struct defining configuration information for managing app metadata on the device.
 //   - AppInfoList: List variable of AppInfo Struct.
 type AppConfig struct {
 	AppInfoList []AppInfo `json:"AppInfoList"`
 }
 
+// Struct defining an entry of the deploy history. (deploy-history.json)
+//   - AppName: Name of the app.
+//   - AppId: ID of the app.
+//   - AppRepoPath: Repository path of the deployed app file.
+//   - DeployedAt: Time the app was deployed. (Unix time in ms)
+//   - FileSize: Size of the deployed app file.
+//   - Venv: Virtual environment used by the app.
+//   - Pid: PID of the app after the deployment.
+type DeployHistory struct {
+	AppName     string `json:"appName"`
+	AppId       string `json:"appId"`
+	AppRepoPath string `json:"appRepoPath"`
+	DeployedAt  int64  `json:"deployedAt"`
+	FileSize    int64  `json:"fileSize"`
+	Venv        string `json:"venv"`
+	Pid         int    `json:"pid"`
+}
+
+// Struct defining the detailed information of a deployed app. (bwc get app --inspect)
+//   - AppName: Name of the app.
+//   - AppId: ID of the app.
+//   - AppVenv: Virtual environment used by the app.
+//   - Managed: Manager of the app. (systemd, dockerd, direct)
+//   - Status: Status of the app.
+//   - DeployedAt: Time the app was last deployed. (Unix time in ms, 0 if not in the deploy history)
+//   - AppRepoPath: Repository path of the deployed app file.
+//   - History: Deploy history of the app. (Oldest first)
+//   - Config: Config values of the app. (config.json)
+//   - ServiceFile: Content of the systemd unit file of the app.
+type AppDetail struct {
+	AppName     string                 `json:"appName"`
+	AppId       string                 `json:"appId"`
+	AppVenv     string                 `json:"appVenv"`
+	Managed     string                 `json:"managed"`
+	Status      string                 `json:"status"`
+	DeployedAt  int64                  `json:"deployedAt"`
+	AppRepoPath string                 `json:"appRepoPath"`
+	History     []DeployHistory        `json:"history"`
+	Config      map[string]interface{} `json:"config"`
+	ServiceFile string                 `json:"serviceFile"`
+}
+
+// Struct defining the detailed information of a virtual environment. (bwc get venv --detail)
+//   - PythonVersion: Python version of the venv. (ex. Python 3.10.12)
+//   - PackageCount: Number of packages installed in the venv.
+//   - DiskUsage: Disk size of the venv. (ex. 120M)
+type VenvDetail struct {
+	PythonVersion string `json:"pythonVersion"`
+	PackageCount  int    `json:"packageCount"`
+	DiskUsage     string `json:"diskUsage"`
+}
+
 // Struct used for querying the list of apps.
 //   - AppName: Name of the app.
 //   - Status: Status of the app.
 //   - AppId: ID of the app.
 //   - AppVenv: Virtual environment used by the app.
 type AppStatus struct {
-	AppName string
-	Status  string
-	AppId   string
-	AppVenv string
+	AppName string `json:"appName"`
+	Status  string `json:"status"`
+	AppId   string `json:"appId"`
+	AppVenv string `json:"appVenv"`
+}
+
+// Struct used for querying the list of venvs. (bwc get venv --output json)
+//   - Name: Name of the venv.
+//   - Used: True if an app uses the venv.
+//   - AppName: Name of the app using the venv.
+//   - Detail: Detailed information of the venv. (Only with --detail)
+type VenvStatus struct {
+	Name    string      `json:"name"`
+	Used    bool        `json:"used"`
+	AppName string      `json:"appName"`
+	Detail  *VenvDetail `json:"detail,omitempty"`
+}
+
+// Struct used for querying the list of templates. (bwc get template --output json)
+//   - Name: Name of the template.
+//   - Owner: Owner of the template.
+//   - Type: Type of the template. (base-template, app)
+//   - Description: Description of the template. (Only with --describe)
+type TemplateStatus struct {
+	Name        string `json:"name"`
+	Owner       string `json:"owner"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
 }
 
 // Struct defining information about the spec.env type variable in the framework file of the app.
@@ -194,11 +388,13 @@ type AccessInfo struct {
 //   - FullName: Name including the repository path.
 //   - Name: Repository name.
 //   - Owner: User name of the repository owner.
+//   - Description: Description of the repository. (Filled by 'bwc get template --describe')
 type Repos struct {
-	ID       int       `json:"id"`
-	FullName string    `json:"full_name"`
-	Name     string    `json:"name"`
-	Owner    OwnerInfo `json:"owner"`
+	ID          int       `json:"id"`
+	FullName    string    `json:"full_name"`
+	Name        string    `json:"name"`
+	Owner       OwnerInfo `json:"owner"`
+	Description string    `json:"description"`
 	// 여기에 다른 필요한 필드 추가
 }
 
@@ -221,3 +417,42 @@ type GiteaUser struct {
 	AccessToken string `json:"accessToken"`
 	Username    string `json:"userName"`
 }
+
+// Struct defining the SDT Cloud status of the device.
+//   - AssetCode: Serial number of the device.
+//   - State: Connection state of the device.
+//   - Status: Status of the device.
+//   - ProjectCode: Project ID to which the device belongs.
+//   - DeviceType: Type of the device.
+//   - AgentVersion: Version of the BWC agent.
+//   - LastSeen: Time when the device last sent a heartbeat.
+type DeviceStatus struct {
+	AssetCode    string `json:"assetCode"`
+	State        string `json:"state"`
+	Status       string `json:"status"`
+	ProjectCode  string `json:"projectCode"`
+	DeviceType   string `json:"deviceType"`
+	AgentVersion string `json:"agentVersion"`
+	LastSeen     string `json:"lastSeen"`
+}
+
+// Struct defining a device context of BWC-CLI. A device context is the device
+// that BWC-CLI commands are run on.
+//   - Name: Name of the context.
+//   - Host: Host (IP) of the device.
+//   - Port: SSH port of the device.
+//   - AssetCode: Serial number of the device.
+type BwcContext struct {
+	Name      string `yaml:"name"`
+	Host      string `yaml:"host"`
+	Port      int    `yaml:"port"`
+	AssetCode string `yaml:"asset"`
+}
+
+// Struct defining the context file(~/.bwc/contexts.yaml) of BWC-CLI.
+//   - CurrentContext: Name of the context in use.
+//   - Contexts: List of saved contexts.
+type ContextConfig struct {
+	CurrentContext string       `yaml:"currentContext"`
+	Contexts       []BwcContext `yaml:"contexts"`
+}
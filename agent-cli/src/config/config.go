@@ -0,0 +1,263 @@
+// The config package modifies the config values of apps deployed on the device
+// from the terminal. It changes the config file in the same way as the 'configFix'
+// control command of Device-Control, without going through SDT Cloud.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	sdtType "main/src/cliType"
+)
+
+// These are the global variables used in the config package.
+// - procLog: This is the struct that defines the format of the log.
+var procLog sdtType.Logger
+
+// Getlog is a function that loads the log format. Log formats are defined as Info,
+// Warn, Error, and are output using Printf.
+// Here's how you would record the text "Hello World" as an Info log type:
+//
+//	procLog.Info.Printf("Hello World\n")   ->   [INFO] Hello World
+func Getlog(logConfig sdtType.Logger) {
+	procLog = logConfig
+}
+
+// ParseParameter function converts a key and a value entered in the terminal to the parameter
+// of JsonChange. If the key contains dots, it is a nested JSON path. The value is converted
+// to an integer or a float if possible, otherwise it is a string.
+//
+//	inference.threshold 0.5   ->   {"inference": {"threshold": 0.5}}
+//
+// Input:
+//   - key: Key of the config. (ex. inference.threshold)
+//   - value: Value of the config.
+//
+// Output:
+//   - map[string]interface{}: Parameter to modify.
+func ParseParameter(key string, value string) map[string]interface{} {
+	var val interface{} = value
+	if intVal, err := strconv.Atoi(value); err == nil {
+		val = intVal
+	} else if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+		val = floatVal
+	}
+
+	keyList := strings.Split(key, ".")
+	for i := len(keyList) - 1; i > 0; i-- {
+		val = map[string]interface{}{keyList[i]: val}
+	}
+	return map[string]interface{}{keyList[0]: val}
+}
+
+// ToNumber function converts an Integer or Float type variable to a Json Number type.
+//
+// Input:
+//   - f: An interface variable of type Integer or Float.
+//
+// Output:
+//   - json.Number: A Json Number type variable.
+func ToNumber(f interface{}) json.Number {
+	var s string
+	if reflect.TypeOf(f).Kind() == reflect.Float64 {
+		s = fmt.Sprintf("%.1f", f) // 1 decimal if integer
+		return json.Number(s)
+	} else if reflect.TypeOf(f).Kind() == reflect.String {
+		s = f.(string)
+	} else {
+		s = fmt.Sprintf("%.1f", float64(f.(int)))
+	}
+	return json.Number(s)
+}
+
+// checkKey function checks that all keys of the parameter exist in the config.
+func checkKey(data map[string]interface{}, target map[string]interface{}, path string) error {
+	for key, val := range data {
+		keyPath := strings.TrimPrefix(fmt.Sprintf("%s.%s", path, key), ".")
+		targetVal, exist := target[key]
+		if !exist {
+			return fmt.Errorf("not found key [%s] in config", keyPath)
+		}
+		if valMap, ok := val.(map[string]interface{}); ok {
+			targetMap, ok := targetVal.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("[%s] is not object in config", keyPath)
+			}
+			if err := checkKey(valMap, targetMap, keyPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// changeInterface function changes the values of the config to the values of the parameter.
+// It is the same as the changeInterface function of Device-Control.
+func changeInterface(data map[string]interface{}, target map[string]interface{}) (map[string]interface{}, error) {
+	var err error = nil
+
+	for key, val := range data {
+		if _, exist := target[key]; exist {
+			if err != nil {
+				return nil, err
+			}
+
+			paramType := reflect.TypeOf(target[key])
+
+			if paramType.Kind() == reflect.Map {
+				if reflect.TypeOf(val).Kind() == paramType.Kind() {
+					target[key], err = changeInterface(val.(map[string]interface{}), target[key].(map[string]interface{}))
+					continue
+				} else {
+					procLog.Error.Printf("Please check key's type.[key=%s]\n", key)
+					err = errors.New(fmt.Sprintf("Check [%s] parameter", key))
+					return nil, err
+				}
+			}
+
+			// 수정하려는 값이 Object 타입인 경우
+			if reflect.TypeOf(val).Kind() == reflect.Map {
+				if paramType.Kind() != reflect.Map {
+					procLog.Error.Printf("Please check key's type.[key=%s]\n", key)
+					err = errors.New(fmt.Sprintf("Check %s parameter", key))
+					return nil, err
+				}
+			}
+
+			// 수정하려는 값이 문자열인 경우
+			if reflect.TypeOf(val).Kind() == reflect.String {
+				target[key] = val
+				continue
+			}
+
+			// 숫자인 경우(int,float 등)
+			n, ok := target[key].(json.Number)
+			if !ok {
+				target[key] = val
+			} else if _, err = n.Int64(); err == nil {
+				target[key] = val
+			} else if _, err = n.Float64(); err == nil {
+				target[key] = ToNumber(val)
+			}
+		}
+	}
+
+	return target, err
+}
+
+// schemaFileName is the JSON Schema (draft-07) of the app's config. If the app directory has this
+// file, JsonChange validates the config against it before writing. (Same as Device-Control)
+const schemaFileName = "config.schema.json"
+
+// ErrSchemaValidation is returned by JsonChange when the config doesn't match config.schema.json
+// of the app. The config file is not modified.
+//   - SchemaFile: Path of the schema file.
+//   - Err: Validation error of the schema.
+type ErrSchemaValidation struct {
+	SchemaFile string
+	Err        error
+}
+
+// Error function returns the message of ErrSchemaValidation.
+func (e *ErrSchemaValidation) Error() string {
+	return fmt.Sprintf("Config doesn't match %s: %v", e.SchemaFile, e.Err)
+}
+
+// Unwrap function returns the validation error of the schema.
+func (e *ErrSchemaValidation) Unwrap() error {
+	return e.Err
+}
+
+// validateSchema function validates the config against config.schema.json in the app directory.
+// If the app doesn't have the schema file, the config is not validated.
+//
+// Input:
+//   - appDir: Directory of the app.
+//   - jsonData: Config to validate. (The config file with the requested parameters applied)
+//
+// Output:
+//   - error: *ErrSchemaValidation if the config is invalid, or the error of reading the schema.
+func validateSchema(appDir string, jsonData map[string]interface{}) error {
+	schemaFile := filepath.Join(appDir, schemaFileName)
+	if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+		return nil
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft7
+	schema, err := compiler.Compile(schemaFile)
+	if err != nil {
+		return fmt.Errorf("Invalid %s: %v", schemaFile, err)
+	}
+	if err := schema.Validate(jsonData); err != nil {
+		return &ErrSchemaValidation{SchemaFile: schemaFile, Err: err}
+	}
+	return nil
+}
+
+// JsonChange function modifies the config information of an app deployed on the device.
+// The app keeps running. It reads the changed config by itself.
+// If the app has config.schema.json, the modified config is validated against it before
+// writing, and *ErrSchemaValidation is returned for an invalid config.
+//
+// Input:
+//   - configCmd: Struct containing config modification command information.
+//   - appPath: Path where apps are installed.
+//
+// Output:
+//   - string: Modified config value (returned as a string after conversion).
+//   - error: Error message for the JsonChange command.
+//   - int: Status code of the command.
+func JsonChange(configCmd sdtType.CmdJson, appPath string) (string, error, int) {
+	procLog.Info.Printf("Change %s app's config: %v\n", configCmd.AppName, configCmd.Parameter)
+	targetFile := fmt.Sprintf("%s/%s_%s/%s", appPath, configCmd.AppName, configCmd.AppId, configCmd.FileName)
+	jsonFile, err := os.ReadFile(targetFile)
+	if err != nil {
+		procLog.Error.Printf("Not found file Error: %v\n", err)
+		return "", err, http.StatusBadRequest
+	}
+	var jsonData map[string]interface{}
+	jsonRecode := json.NewDecoder(strings.NewReader(string(jsonFile)))
+	jsonRecode.UseNumber()
+	err = jsonRecode.Decode(&jsonData)
+	if err != nil {
+		procLog.Error.Printf("Unmarshal Error: %v\n", err)
+		return "", err, http.StatusBadRequest
+	}
+
+	err = checkKey(configCmd.Parameter, jsonData, "")
+	if err != nil {
+		procLog.Error.Printf("Failed change config: %v\n", err)
+		return "", err, http.StatusBadRequest
+	}
+	jsonData, err = changeInterface(configCmd.Parameter, jsonData)
+	if err != nil {
+		procLog.Error.Printf("Failed change config: %v\n", err)
+		return "", err, http.StatusBadRequest
+	}
+
+	// Validate the config before the file is modified.
+	if err := validateSchema(filepath.Dir(targetFile), jsonData); err != nil {
+		procLog.Error.Printf("Schema validation Error: %v\n", err)
+		return "", err, http.StatusBadRequest
+	}
+
+	saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
+	err = os.WriteFile(targetFile, saveJson, 0644)
+	if err != nil {
+		procLog.Error.Printf("Failed save config: %v\n", err)
+		return "", err, http.StatusBadRequest
+	}
+
+	jsonBytes, _ := json.Marshal(configCmd.Parameter)
+	return string(jsonBytes), nil, http.StatusOK
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdtType "main/src/cliType"
+	sdtLog "pkg/log"
+)
+
+func TestJsonChangeSchema(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	schema := `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type": "object",
+	"properties": {"interval": {"type": "integer", "minimum": 1}}
+}`
+	config := `{"interval": 5}`
+
+	tests := []struct {
+		name       string
+		schema     bool
+		parameter  map[string]interface{}
+		wantStatus int
+		wantConfig string
+	}{
+		{"valid config", true, map[string]interface{}{"interval": 10}, http.StatusOK, "{\n\t\"interval\": 10\n}"},
+		{"invalid config is not written", true, map[string]interface{}{"interval": 0}, http.StatusBadRequest, config},
+		{"no schema", false, map[string]interface{}{"interval": 0}, http.StatusOK, "{\n\t\"interval\": 0\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appPath := t.TempDir()
+			appDir := filepath.Join(appPath, "web_app-1")
+			if err := os.MkdirAll(appDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			configFile := filepath.Join(appDir, "config.json")
+			if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.schema {
+				if err := os.WriteFile(filepath.Join(appDir, schemaFileName), []byte(schema), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			configCmd := sdtType.CmdJson{AppId: "app-1", AppName: "web", FileName: "config.json", Parameter: tt.parameter}
+			_, err, status := JsonChange(configCmd, appPath)
+			if status != tt.wantStatus {
+				t.Fatalf("JsonChange() = %v, %d, want status %d", err, status, tt.wantStatus)
+			}
+			var schemaErr *ErrSchemaValidation
+			if tt.wantStatus == http.StatusBadRequest && !errors.As(err, &schemaErr) {
+				t.Errorf("JsonChange() error = %v, want ErrSchemaValidation", err)
+			}
+			got, _ := os.ReadFile(configFile)
+			if string(got) != tt.wantConfig {
+				t.Errorf("config.json = %q, want %q", got, tt.wantConfig)
+			}
+		})
+	}
+}
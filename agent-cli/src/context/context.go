@@ -0,0 +1,263 @@
+// Context package handles device contexts of BWC-CLI. A device context is the device
+// that BWC-CLI commands are run on. Contexts are saved in ~/.bwc/contexts.yaml.
+// The "local" context (this device) is always available and is the default.
+// When another context is in use, commands are forwarded to the device over SSH.
+package context
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	sdtType "main/src/cliType"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LocalContext is the name of the context for this device.
+const LocalContext = "local"
+
+// GetContextFile function returns the path of the context file. If BWC-CLI is run
+// with sudo, the home directory of the sudo user is used.
+//
+// Output:
+//   - string: Path of the context file.
+func GetContextFile() string {
+	homeDir, _ := os.UserHomeDir()
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		if u, err := user.Lookup(sudoUser); err == nil {
+			homeDir = u.HomeDir
+		}
+	}
+	return filepath.Join(homeDir, ".bwc", "contexts.yaml")
+}
+
+// LoadContexts function reads the context file. If the file doesn't exist,
+// only the local context is used.
+//
+// Output:
+//   - sdtType.ContextConfig: Struct of the context file.
+func LoadContexts() sdtType.ContextConfig {
+	var contextConfig sdtType.ContextConfig
+	yamlFile, err := os.ReadFile(GetContextFile())
+	if err == nil {
+		err = yaml.Unmarshal(yamlFile, &contextConfig)
+		if err != nil {
+			fmt.Printf("Unmarshal Error: %v\n", err)
+		}
+	}
+	if contextConfig.CurrentContext == "" {
+		contextConfig.CurrentContext = LocalContext
+	}
+	return contextConfig
+}
+
+// SaveContexts function writes the context file.
+//
+// Input:
+//   - contextConfig: Struct of the context file.
+//
+// Output:
+//   - error: Error message of the SaveContexts command.
+func SaveContexts(contextConfig sdtType.ContextConfig) error {
+	contextFile := GetContextFile()
+	err := os.MkdirAll(filepath.Dir(contextFile), 0755)
+	if err != nil {
+		return err
+	}
+	saveYaml, err := yaml.Marshal(&contextConfig)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(contextFile, saveYaml, 0644)
+	if err != nil {
+		return err
+	}
+
+	// The file is owned by the sudo user.
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		if u, err := user.Lookup(sudoUser); err == nil {
+			uid, _ := strconv.Atoi(u.Uid)
+			gid, _ := strconv.Atoi(u.Gid)
+			os.Chown(filepath.Dir(contextFile), uid, gid)
+			os.Chown(contextFile, uid, gid)
+		}
+	}
+	return nil
+}
+
+// GetCurrentContext function returns the context in use.
+//
+// Output:
+//   - sdtType.BwcContext: Context in use. (Name is "local" for this device.)
+func GetCurrentContext() sdtType.BwcContext {
+	contextConfig := LoadContexts()
+	for _, val := range contextConfig.Contexts {
+		if val.Name == contextConfig.CurrentContext {
+			return val
+		}
+	}
+	return sdtType.BwcContext{Name: LocalContext}
+}
+
+// SetContext function saves a context. If the context already exists, it is updated.
+//
+// Input:
+//   - newContext: Context to save.
+//
+// Output:
+//   - error: Error message of the SetContext command.
+func SetContext(newContext sdtType.BwcContext) error {
+	if newContext.Name == "" || newContext.Name == LocalContext {
+		return fmt.Errorf("Can't use context name: '%s'", newContext.Name)
+	} else if newContext.Host == "" {
+		return errors.New("Please input host option(--host).")
+	}
+	if newContext.Port == 0 {
+		newContext.Port = 22
+	}
+
+	contextConfig := LoadContexts()
+	for key, val := range contextConfig.Contexts {
+		if val.Name == newContext.Name {
+			contextConfig.Contexts[key] = newContext
+			return SaveContexts(contextConfig)
+		}
+	}
+	contextConfig.Contexts = append(contextConfig.Contexts, newContext)
+	return SaveContexts(contextConfig)
+}
+
+// UseContext function sets the context in use.
+//
+// Input:
+//   - name: Name of the context.
+//
+// Output:
+//   - error: Error message of the UseContext command.
+func UseContext(name string) error {
+	contextConfig := LoadContexts()
+	if name != LocalContext {
+		found := false
+		for _, val := range contextConfig.Contexts {
+			if val.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("Context not found: %s", name)
+		}
+	}
+	contextConfig.CurrentContext = name
+	return SaveContexts(contextConfig)
+}
+
+// ListContexts function prints the list of contexts. The context in use is marked with '*'.
+func ListContexts() {
+	contextConfig := LoadContexts()
+	fmt.Printf(" %-3s %-20s %-20s %-7s %-30s\n", "", "Name", "Host", "Port", "Asset")
+	current := ""
+	if contextConfig.CurrentContext == LocalContext {
+		current = "*"
+	}
+	fmt.Printf(" %-3s %-20s %-20s %-7s %-30s\n", current, LocalContext, "-", "-", "-")
+	for _, val := range contextConfig.Contexts {
+		current = ""
+		if val.Name == contextConfig.CurrentContext {
+			current = "*"
+		}
+		fmt.Printf(" %-3s %-20s %-20s %-7d %-30s\n", current, val.Name, val.Host, val.Port, val.AssetCode)
+	}
+}
+
+// RunContext function runs the context command.
+//   - bwc context set <name> --host <ip> --port <port> --asset <code>
+//   - bwc context use <name>
+//   - bwc context list
+//
+// Input:
+//   - cmdArgs: Arguments after 'context'.
+func RunContext(cmdArgs []string) {
+	if len(cmdArgs) == 0 {
+		fmt.Printf("Please input context command(set, use, list).\n")
+		os.Exit(1)
+	}
+
+	var err error
+	switch cmdArgs[0] {
+	case "set":
+		if len(cmdArgs) < 2 {
+			fmt.Printf("Please input context name.\n")
+			os.Exit(1)
+		}
+		newContext := sdtType.BwcContext{Name: cmdArgs[1]}
+		for key := 2; key < len(cmdArgs)-1; key++ {
+			if cmdArgs[key] == "--host" {
+				newContext.Host = cmdArgs[key+1]
+			} else if cmdArgs[key] == "--port" {
+				newContext.Port, _ = strconv.Atoi(cmdArgs[key+1])
+			} else if cmdArgs[key] == "--asset" {
+				newContext.AssetCode = cmdArgs[key+1]
+			}
+		}
+		err = SetContext(newContext)
+		if err == nil {
+			fmt.Printf("Context saved: %s\n", newContext.Name)
+		}
+	case "use":
+		if len(cmdArgs) < 2 {
+			fmt.Printf("Please input context name.\n")
+			os.Exit(1)
+		}
+		err = UseContext(cmdArgs[1])
+		if err == nil {
+			fmt.Printf("Switched to context: %s\n", cmdArgs[1])
+		}
+	case "list":
+		ListContexts()
+	default:
+		fmt.Printf("Not found: context %s\n", cmdArgs[0])
+		fmt.Printf("Please input context command(set, use, list).\n")
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Printf("Context failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// ForwardCommand function runs the BWC-CLI command on the device of the context over SSH.
+//
+// Input:
+//   - bwcContext: Context of the device.
+//   - cmdArgs: Arguments of the BWC-CLI command.
+//
+// Output:
+//   - int: Exit code of the command.
+func ForwardCommand(bwcContext sdtType.BwcContext, cmdArgs []string) int {
+	var quoteArgs []string
+	for _, val := range cmdArgs {
+		quoteArgs = append(quoteArgs, fmt.Sprintf("'%s'", strings.ReplaceAll(val, "'", `'\''`)))
+	}
+	remoteCmd := fmt.Sprintf("sudo bwc %s", strings.Join(quoteArgs, " "))
+
+	cmd_run := exec.Command("ssh", "-t", "-p", strconv.Itoa(bwcContext.Port), bwcContext.Host, remoteCmd)
+	cmd_run.Stdin = os.Stdin
+	cmd_run.Stdout = os.Stdout
+	cmd_run.Stderr = os.Stderr
+	err := cmd_run.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	} else if err != nil {
+		fmt.Printf("Failed forward command to %s(%s): %v\n", bwcContext.Name, bwcContext.Host, err)
+		return 1
+	}
+	return 0
+}
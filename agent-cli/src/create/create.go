@@ -9,7 +9,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"strings"
@@ -215,13 +214,13 @@ func SaveAppInfo(appName string, appVenv string) error {
 			return err
 		}
 
-		err = ioutil.WriteFile(appInfoFile, saveJson, 0644)
+		err = os.WriteFile(appInfoFile, saveJson, 0644)
 		if err != nil {
 			procLog.Error.Printf("Failed save app's info: %v\n", err)
 			return err
 		}
 	} else {
-		jsonFile, err := ioutil.ReadFile(appInfoFile)
+		jsonFile, err := os.ReadFile(appInfoFile)
 		if err != nil {
 			procLog.Error.Printf("Failed load app's file: %v\n", err)
 			return err
@@ -240,7 +239,7 @@ func SaveAppInfo(appName string, appVenv string) error {
 
 		jsonData.AppInfoList = append(jsonData.AppInfoList, newApp)
 		saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
-		err = ioutil.WriteFile(appInfoFile, saveJson, 0644)
+		err = os.WriteFile(appInfoFile, saveJson, 0644)
 		if err != nil {
 			procLog.Error.Printf("Failed save app's file: %v\n", err)
 			return err
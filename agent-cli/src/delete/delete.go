@@ -4,11 +4,12 @@ package delete
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
+	"time"
 
 	sdtType "main/src/cliType"
+	sdtUtil "main/src/util"
 )
 
 // These are the global variables used in the Delete package.
@@ -24,28 +25,81 @@ func Getlog(logConfig sdtType.Logger) {
 	procLog = logConfig
 }
 
+// directStopTimeout is the waiting time before an app run without systemd is killed.
+const directStopTimeout = 10 * time.Second
+
 // DeleteVenv function deletes the virtual environment installed on the device.
-// All packages installed in the virtual environment are also deleted.
+// All packages installed in the virtual environment are also deleted. If an app
+// deployed on the device uses the virtual environment, it isn't deleted.
 //
 // Input:
 //   - venvName: Name of the virtual environment.
-func DeleteVenv(venvName string) {
+//
+// Output:
+//   - string: Name of the app that is using the virtual environment.
+//   - error: Error message of the DeleteVenv command.
+func DeleteVenv(venvName string) (string, error) {
+	// Check venv used
+	appInfoFile := "/etc/sdt/device.config/app.json"
+	jsonFile, err := os.ReadFile(appInfoFile)
+	if err == nil {
+		var jsonData sdtType.AppConfig
+		err = json.Unmarshal(jsonFile, &jsonData)
+		if err != nil {
+			procLog.Error.Printf("Failed load app's Unmarshal: %v\n", err)
+			return "", err
+		}
+		for _, val := range jsonData.AppInfoList {
+			if venvName == val.AppVenv {
+				procLog.Error.Printf("%s venv used by %s app.\n", venvName, val.AppName)
+				return val.AppName, fmt.Errorf("%s venv used by %s app.", venvName, val.AppName)
+			}
+		}
+	}
+
 	procLog.Warn.Printf("Delete %s venv.\n", venvName)
 	targetEnv := fmt.Sprintf("/etc/sdt/venv/%s", venvName)
 	removeErr := os.RemoveAll(targetEnv)
 	if removeErr != nil {
 		procLog.Error.Printf("%s venv deletion failed: %v\n", venvName, removeErr)
 		fmt.Printf("%s venv deletion failed: %v\n", venvName, removeErr)
+		return "", removeErr
 	}
+	return "", nil
 }
 
 // DeleteApp function deletes the app installed on the device.
-// All data associated with the app will be deleted.
+// All data associated with the app will be deleted except deploy.log. If stopOnly is true,
+// the app's service is only stopped and its files are kept. An app run without systemd
+// (Managed is "direct" in app.json) is stopped by its process group instead of systemctl.
 //
 // Input:
 //   - appName: Name of the app.
 //   - appId: ID of the app.
-func DeleteApp(appName string, appId string) {
+//   - stopOnly: Option to stop the app's service without deleting it.
+func DeleteApp(appName string, appId string, stopOnly bool) {
+	if appInfo, err := sdtUtil.GetAppInfo(appName); err == nil && appInfo.Managed == "direct" {
+		procLog.Info.Printf("Stop %s app process.\n", appName)
+		if err := stopDirectApp(appName, appId); err != nil {
+			procLog.Error.Printf("%s app's stop failed: %v\n", appName, err)
+		}
+		if !stopOnly {
+			removeAppFiles(appName, appId)
+		}
+		return
+	}
+
+	if stopOnly {
+		procLog.Info.Printf("Stop %s app.\n", appName)
+		stopCmd := fmt.Sprintf("systemctl stop %s", appName)
+		cmd_run := exec.Command("sh", "-c", stopCmd)
+		stdout, cmd_err := cmd_run.CombinedOutput()
+		if cmd_err != nil {
+			procLog.Error.Printf("%s app's stop failed: %s\n", appName, stdout)
+		}
+		return
+	}
+
 	procLog.Warn.Printf("Delete %s app.\n", appName)
 	// disable service
 	disableCmd := fmt.Sprintf("systemctl disable %s", appName)
@@ -72,20 +126,73 @@ func DeleteApp(appName string, appId string) {
 		procLog.Error.Printf("%s app's svc file remove failed: %s\n", appName, stdout)
 	}
 
-	// remove appID in app.json
+	// remove app's file (deploy.log is kept.)
+	removeAppFiles(appName, appId)
+}
+
+// removeAppFiles function deletes the files of the app except deploy.log.
+//
+// Input:
+//   - appName: Name of the app.
+//   - appId: ID of the app.
+func removeAppFiles(appName string, appId string) {
 	var appRemoveCmd string
 	if appId == "" {
 		appRemoveCmd = fmt.Sprintf("rm -rf /etc/sdt/execute/%s", appName)
 	} else {
-		appRemoveCmd = fmt.Sprintf("rm -rf /usr/local/sdt/app/%s_%s", appName, appId)
+		appRemoveCmd = fmt.Sprintf("find /usr/local/sdt/app/%s_%s -mindepth 1 -maxdepth 1 ! -name deploy.log -exec rm -rf {} +", appName, appId)
 	}
-	cmd_run = exec.Command("sh", "-c", appRemoveCmd)
-	stdout, cmd_err = cmd_run.CombinedOutput()
+	cmd_run := exec.Command("sh", "-c", appRemoveCmd)
+	stdout, cmd_err := cmd_run.CombinedOutput()
 	if cmd_err != nil {
 		fmt.Printf("%s app's file remove failed: %s\n", appName, stdout)
 	}
 }
 
+// stopDirectApp function stops an app run without systemd. (bwc deploy app --no-systemd)
+// The app is run in its own process group, so the processes started by the app are stopped too.
+// The '.pid' file is removed after the app is stopped.
+//
+// Input:
+//   - appName: Name of the app.
+//   - appId: ID of the app.
+//
+// Output:
+//   - error: Error message if the app can't be stopped.
+func stopDirectApp(appName string, appId string) error {
+	pidFile := sdtUtil.DirectPidFile(appName, appId)
+	pid, err := sdtUtil.ReadDirectPid(pidFile)
+	if err != nil {
+		procLog.Warn.Printf("%s app is not running.\n", appName)
+		os.Remove(pidFile)
+		return nil
+	}
+	if err = stopProcessGroup(pid, directStopTimeout); err != nil {
+		return err
+	}
+	os.Remove(pidFile)
+	return nil
+}
+
+// CleanDeployLogs function deletes the app's directory including deploy.log.
+// deploy.log is kept by DeleteApp, so it is removed only when the user requests it.
+//
+// Input:
+//   - appName: Name of the app.
+//   - appId: ID of the app.
+func CleanDeployLogs(appName string, appId string) {
+	if appId == "" {
+		return
+	}
+	procLog.Warn.Printf("Delete %s app's deploy.log.\n", appName)
+	appDir := fmt.Sprintf("/usr/local/sdt/app/%s_%s", appName, appId)
+	removeErr := os.RemoveAll(appDir)
+	if removeErr != nil {
+		procLog.Error.Printf("%s app's deploy.log remove failed: %v\n", appName, removeErr)
+		fmt.Printf("%s app's deploy.log remove failed: %v\n", appName, removeErr)
+	}
+}
+
 // DeleteAppInfo function deletes information about the app to be deleted from the app config.
 //
 // Input:
@@ -98,7 +205,7 @@ func DeleteAppInfo(appName string) string {
 	var appId string = ""
 	appInfoFile := "/etc/sdt/device.config/app.json"
 
-	jsonFile, err := ioutil.ReadFile(appInfoFile)
+	jsonFile, err := os.ReadFile(appInfoFile)
 	if err != nil {
 		procLog.Error.Printf("Load app's file failed: %v\n", err)
 		return appId
@@ -110,7 +217,7 @@ func DeleteAppInfo(appName string) string {
 		return appId
 	}
 
-	for _, val := range jsonData.AppInfoList {
+	for _, val := range deduplicateAppInfo(jsonData.AppInfoList) {
 		if val.AppName == appName {
 			appId = val.AppId
 			continue
@@ -119,10 +226,36 @@ func DeleteAppInfo(appName string) string {
 	}
 
 	saveJson, _ := json.MarshalIndent(&saveData, "", "\t")
-	err = ioutil.WriteFile(appInfoFile, saveJson, 0644)
+	err = os.WriteFile(appInfoFile, saveJson, 0644)
 	if err != nil {
 		procLog.Error.Printf("Delete app's file failed: %v\n", err)
 		return appId
 	}
 	return appId
 }
+
+// deduplicateAppInfo function removes the entries of app.json that have the same app name.
+// The most recently deployed entry (the last one in the list) is kept. Duplicates can be
+// left by a failed rollback.
+//
+// Input:
+//   - appInfoList: List of app's info in app.json.
+//
+// Output:
+//   - []sdtType.AppInfo: List of app's info without duplicates.
+func deduplicateAppInfo(appInfoList []sdtType.AppInfo) []sdtType.AppInfo {
+	lastIndex := make(map[string]int)
+	for i, val := range appInfoList {
+		lastIndex[val.AppName] = i
+	}
+
+	var dedupList []sdtType.AppInfo
+	for i, val := range appInfoList {
+		if lastIndex[val.AppName] != i {
+			procLog.Warn.Printf("Duplicate app %s: remove stale appId %s.\n", val.AppName, val.AppId)
+			continue
+		}
+		dedupList = append(dedupList, val)
+	}
+	return dedupList
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+// +build !windows
+
+package delete
+
+import (
+	"syscall"
+	"time"
+)
+
+// stopProcessGroup function stops the process group of an app run without systemd.
+// SIGTERM is sent to the group, and SIGKILL is sent if the group doesn't exit in timeout.
+// (Same as "systemctl stop" of a service with KillMode=control-group)
+//
+// Input:
+//   - pgid: Process group ID. (PID of the app, since it is run with setpgid(0, 0))
+//   - timeout: Waiting time before SIGKILL is sent.
+//
+// Output:
+//   - error: Error message if the signal can't be sent.
+func stopProcessGroup(pgid int, timeout time.Duration) error {
+	if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
+		if err == syscall.ESRCH {
+			return nil
+		}
+		return err
+	}
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); {
+		if syscall.Kill(-pgid, 0) == syscall.ESRCH {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := syscall.Kill(-pgid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+//go:build !windows
+// +build !windows
+
+package delete
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// startGroup starts the shell script in a new process group, as StartDirectApp does.
+// The returned channel is closed when all processes of the script have exited.
+// (They share the write end of a pipe, so the read end gets EOF.)
+func startGroup(t *testing.T, script string) (int, chan struct{}) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Stdout = w
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: 0}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	done := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, r)
+		r.Close()
+		cmd.Wait()
+		close(done)
+	}()
+	t.Cleanup(func() { syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) })
+	return cmd.Process.Pid, done
+}
+
+func TestStopProcessGroup(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		// The child process of the app is stopped with the app.
+		{"app with child", "sleep 60 & wait"},
+		// SIGKILL is sent to the app that ignores SIGTERM.
+		{"ignores SIGTERM", "trap '' TERM; sleep 60 & wait; sleep 60"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pgid, done := startGroup(t, tt.script)
+			time.Sleep(200 * time.Millisecond)
+
+			if err := stopProcessGroup(pgid, time.Second); err != nil {
+				t.Fatalf("stopProcessGroup() = %v", err)
+			}
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("app is still running")
+			}
+		})
+	}
+
+	if err := stopProcessGroup(999999, time.Second); err != nil {
+		t.Errorf("stopProcessGroup() of no process = %v, want nil", err)
+	}
+}
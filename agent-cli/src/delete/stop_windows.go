@@ -0,0 +1,27 @@
+//go:build windows
+// +build windows
+
+package delete
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// stopProcessGroup function stops the process tree of an app run without systemd.
+//
+// Input:
+//   - pgid: PID of the app. (Root of the process group)
+//   - timeout: Not used. The process tree is killed at once.
+//
+// Output:
+//   - error: Error message if the process tree can't be killed.
+func stopProcessGroup(pgid int, timeout time.Duration) error {
+	stdout, err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pgid)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, stdout)
+	}
+	return nil
+}
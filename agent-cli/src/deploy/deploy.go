@@ -10,9 +10,11 @@ import (
 	"errors"
 	"fmt"
 	"github.com/minio/minio-go/v7"
-	"io/ioutil"
+	"net"
+	"net/http"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
@@ -47,11 +49,13 @@ func Getlog(logConfig sdtType.Logger) {
 //   - giteaURL: URL of the code repository.
 //   - configData: Struct containing configuration information for BWC.
 //   - ownerName: User ID of the code repository.
+//   - tagVersion: If 'auto', the tag is the latest tag of the repository with the patch version incremented.
 func UploadStackbase(
 	bwcFramework sdtType.Framework,
 	targetDir string, giteaURL string,
 	configData sdtType.ConfigInfo,
 	ownerName string,
+	tagVersion string,
 ) {
 	procLog.Info.Printf("Upload app in code repository.\n")
 	var username, password, localRepoPath, releaseTitle string
@@ -60,14 +64,24 @@ func UploadStackbase(
 	password = configData.SdtcloudPw
 
 	localRepoPath = fmt.Sprintf("/etc/sdt/gitea-repo/%s", bwcFramework.Stackbase.RepoName)
-	releaseTitle = bwcFramework.Stackbase.TagName
-
-	procLog.Info.Printf("Code repository spec: repoName=%s, tag=%s, username=%s \n", bwcFramework.Stackbase.RepoName, bwcFramework.Stackbase.TagName, username)
 
 	// Upload app in gitea
 	sdtGitea.CreateGiteaRepo(giteaURL, username, password, bwcFramework.Stackbase.RepoName)
 	gitClient := sdtGitea.CloneGiteaRepo(giteaURL, username, password, bwcFramework.Stackbase.RepoName, localRepoPath, ownerName)
 
+	if tagVersion == "auto" {
+		tagName, err := NextTagVersion(localRepoPath)
+		if err != nil {
+			fmt.Printf("Error tag version: %v\n", err)
+			os.RemoveAll(localRepoPath)
+			os.Exit(1)
+		}
+		fmt.Printf("Release tag: %s\n", tagName)
+		bwcFramework.Stackbase.TagName = tagName
+	}
+	releaseTitle = bwcFramework.Stackbase.TagName
+	procLog.Info.Printf("Code repository spec: repoName=%s, tag=%s, username=%s \n", bwcFramework.Stackbase.RepoName, bwcFramework.Stackbase.TagName, username)
+
 	// Copy app dir in clone dir
 	sdtUtil.CopyDir(targetDir, localRepoPath)
 	uploadError := sdtGitea.PushGiteaRepo(gitClient, username, password)
@@ -91,6 +105,12 @@ func UploadStackbase(
 	} else {
 		fmt.Printf("Successfully released app in code repository.\n")
 	}
+	verifyError := sdtGitea.VerifyGiteaTag(giteaURL, username, password, ownerName, bwcFramework.Stackbase.RepoName, bwcFramework.Stackbase.TagName)
+	if verifyError != nil {
+		fmt.Printf("Error verify release tag: %v\n", verifyError)
+		os.RemoveAll(localRepoPath)
+		os.Exit(1)
+	}
 	err := os.RemoveAll(localRepoPath)
 	if err != nil {
 		fmt.Println(err)
@@ -99,6 +119,129 @@ func UploadStackbase(
 	procLog.Info.Printf("Successfully upload app in code repository.\n")
 }
 
+// PushStackbase function uploads only the changed files of the app to the code repository.
+// The SHA-256 of the files is compared with .bwc-manifest.json in the repository, so unchanged
+// files are not uploaded again. After the push, a new release is generated like UploadStackbase.
+//
+// Input:
+//   - bwcFramework: Struct containing information about the app's framework.
+//   - targetDir: Path of the app to push.
+//   - giteaURL: URL of the code repository.
+//   - configData: Struct containing configuration information for BWC.
+//   - ownerName: User ID of the code repository.
+//   - tagVersion: If 'auto', the tag is the latest tag of the repository with the patch version incremented.
+func PushStackbase(
+	bwcFramework sdtType.Framework,
+	targetDir string, giteaURL string,
+	configData sdtType.ConfigInfo,
+	ownerName string,
+	tagVersion string,
+) {
+	procLog.Info.Printf("Push app in code repository.\n")
+	username := configData.SdtcloudId
+	password := configData.SdtcloudPw
+	repoName := bwcFramework.Stackbase.RepoName
+
+	sdtGitea.CreateGiteaRepo(giteaURL, username, password, repoName)
+
+	if tagVersion == "auto" {
+		latestTag, err := sdtGitea.LatestGiteaTag(giteaURL, username, password, ownerName, repoName)
+		if err != nil {
+			fmt.Printf("Error tag version: %v\n", err)
+			os.Exit(1)
+		}
+		tagName, err := IncrementTagVersion(latestTag)
+		if err != nil {
+			fmt.Printf("Error tag version: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Release tag: %s\n", tagName)
+		bwcFramework.Stackbase.TagName = tagName
+	}
+	procLog.Info.Printf("Code repository spec: repoName=%s, tag=%s, username=%s \n", repoName, bwcFramework.Stackbase.TagName, username)
+
+	message := fmt.Sprintf("bwc push %s", bwcFramework.Stackbase.TagName)
+	uploaded, deleted, pushError := sdtGitea.PushGiteaDelta(giteaURL, username, password, ownerName, repoName, targetDir, message)
+	if pushError != nil {
+		fmt.Printf("Error push: %v\n", pushError)
+		os.Exit(1)
+	}
+	if uploaded == 0 && deleted == 0 {
+		fmt.Printf("No changed files. Only the release is created.\n")
+	} else {
+		fmt.Printf("Successfully push app in code repository. (Uploaded: %d, Deleted: %d)\n", uploaded, deleted)
+	}
+
+	time.Sleep(2 * time.Second)
+	releaseError := sdtGitea.ReleaseGiteaRepo(giteaURL, username, password, ownerName, repoName, bwcFramework.Stackbase.TagName, bwcFramework.Stackbase.TagName)
+	if releaseError != nil {
+		fmt.Printf("Error release: %v\n", releaseError)
+		fmt.Printf("There is already a release version of the app registered in the code repository.\n" +
+			"If you want to push a new release version, please modify the stackbase.tagName value in the framework.yaml file.\n")
+		os.Exit(1)
+	} else {
+		fmt.Printf("Successfully released app in code repository.\n")
+	}
+	verifyError := sdtGitea.VerifyGiteaTag(giteaURL, username, password, ownerName, repoName, bwcFramework.Stackbase.TagName)
+	if verifyError != nil {
+		fmt.Printf("Error verify release tag: %v\n", verifyError)
+		os.Exit(1)
+	}
+	procLog.Info.Printf("Successfully push app in code repository.\n")
+}
+
+// NextTagVersion function reads the latest tag of the repository (git describe --tags --abbrev=0)
+// and returns the tag with the patch version incremented. (For example, v1.2.3 -> v1.2.4)
+// If the repository has no tag, "v0.0.1" is returned.
+//
+// Input:
+//   - repoPath: Path of the cloned repository.
+//
+// Output:
+//   - string: Next tag version.
+//   - error: Error message if the latest tag is not a semantic version.
+func NextTagVersion(repoPath string) (string, error) {
+	cmd_run := exec.Command("git", "describe", "--tags", "--abbrev=0")
+	cmd_run.Dir = repoPath
+	stdout, err := cmd_run.Output()
+	if err != nil {
+		procLog.Warn.Printf("Tag not found in repository: %v\n", err)
+		return "v0.0.1", nil
+	}
+
+	return IncrementTagVersion(strings.TrimSpace(string(stdout)))
+}
+
+// IncrementTagVersion function returns the tag with the patch version incremented.
+// (For example, v1.2.3 -> v1.2.4) If the tag is empty, "v0.0.1" is returned.
+//
+// Input:
+//   - latestTag: Latest tag of the repository.
+//
+// Output:
+//   - string: Next tag version.
+//   - error: Error message if the latest tag is not a semantic version.
+func IncrementTagVersion(latestTag string) (string, error) {
+	if latestTag == "" {
+		return "v0.0.1", nil
+	}
+	prefix := ""
+	version := latestTag
+	if strings.HasPrefix(version, "v") {
+		prefix = "v"
+		version = version[1:]
+	}
+	semver := strings.Split(version, ".")
+	if len(semver) != 3 {
+		return "", fmt.Errorf("%s is not semantic version (major.minor.patch)", latestTag)
+	}
+	patch, err := strconv.Atoi(semver[2])
+	if err != nil {
+		return "", fmt.Errorf("%s is not semantic version (major.minor.patch)", latestTag)
+	}
+	return fmt.Sprintf("%s%s.%s.%d", prefix, semver[0], semver[1], patch+1), nil
+}
+
 // CreateGoService function creates a systemd service file (.service) for a Golang app.
 //
 // Input:
@@ -221,16 +364,83 @@ WantedBy=multi-user.target
 	return nil
 }
 
+// StartDirectApp function runs an app directly as a background process without systemd.
+// This is used on devices without systemd. (busybox-based firmware, containers)
+// The PID of the app is written to '<appDir>/.pid'.
+//
+// Input:
+//   - appDir: Path of the app to be installed on the device.
+//   - bwcFramework: Struct containing information about the app's framework.
+//
+// Output:
+//   - error: Error message for the StartDirectApp command.
+func StartDirectApp(appDir string, bwcFramework sdtType.Framework) error {
+	procLog.Info.Printf("[direct] Start app process.\n")
+	runCmd := bwcFramework.Spec.RunFile
+	var cmd_run *exec.Cmd
+	if strings.Contains(bwcFramework.Spec.Env.RunTime, "python") {
+		appVenv := bwcFramework.Spec.Env.VirtualEnv
+		cmd_run = exec.Command(fmt.Sprintf("/etc/sdt/venv/%s/bin/python", appVenv), runCmd)
+		cmd_run.Env = append(os.Environ(), fmt.Sprintf("PATH=/etc/sdt/venv/%s/bin:%s", appVenv, os.Getenv("PATH")))
+	} else if strings.Contains(bwcFramework.Spec.Env.RunTime, "go") {
+		err := os.Chmod(fmt.Sprintf("%s/%s", appDir, runCmd), 0755)
+		if err != nil {
+			procLog.Error.Printf("Failed chmod app's run file: %v\n", err)
+			return err
+		}
+		cmd_run = exec.Command(fmt.Sprintf("%s/%s", appDir, runCmd))
+	} else {
+		procLog.Error.Printf("Failed starting %s's app.\n", bwcFramework.Spec.Env.RunTime)
+		return errors.New(fmt.Sprintf("Not found runtime[%s]\n", bwcFramework.Spec.Env.RunTime))
+	}
+	cmd_run.Dir = appDir
+
+	// Same log files as systemd service.
+	outFile, err := os.OpenFile(fmt.Sprintf("%s/app.log", appDir), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		procLog.Error.Printf("Failed open app's log file: %v\n", err)
+		return err
+	}
+	defer outFile.Close()
+	errFile, err := os.OpenFile(fmt.Sprintf("%s/app-error.log", appDir), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		procLog.Error.Printf("Failed open app's error log file: %v\n", err)
+		return err
+	}
+	defer errFile.Close()
+	cmd_run.Stdout = outFile
+	cmd_run.Stderr = errFile
+	detachProcess(cmd_run)
+
+	err = cmd_run.Start()
+	if err != nil {
+		procLog.Error.Printf("App deployment failed: %v\n", err)
+		return err
+	}
+	pid := cmd_run.Process.Pid
+	cmd_run.Process.Release()
+
+	err = os.WriteFile(fmt.Sprintf("%s/.pid", appDir), []byte(strconv.Itoa(pid)), 0644)
+	if err != nil {
+		procLog.Error.Printf("Failed save app's pid: %v\n", err)
+		return err
+	}
+	procLog.Info.Printf("[direct] Successfully start app process. (pid=%d)\n", pid)
+	return nil
+}
+
 // DeployApp function deploys an app on the device. When deploying the app, the app
-// directory and systemd (.service) file are created.
+// directory and systemd (.service) file are created. If noSystemd is true, the app is
+// run directly as a background process instead of the systemd service.
 //
 // Input:
 //   - appDir: Path of the app to be installed on the device.
 //   - bwcFramework: Struct containing information about the app's framework.
+//   - noSystemd: Run the app without systemd.
 //
 // Output:
 //   - error: Error message for the DeployApp command.
-func DeployApp(bwcFramework sdtType.Framework, appDir string, minioURL string) error {
+func DeployApp(bwcFramework sdtType.Framework, appDir string, minioURL string, noSystemd bool) error {
 	// TODO Env.Bin 삭제
 	appType := bwcFramework.Spec.AppType
 	procLog.Info.Printf("[Deploy] Check appType.(=%s)\n", appType)
@@ -249,6 +459,10 @@ func DeployApp(bwcFramework sdtType.Framework, appDir string, minioURL string) e
 
 	}
 
+	if noSystemd {
+		return StartDirectApp(appDir, bwcFramework)
+	}
+
 	procLog.Info.Printf("[Deploy] Check runtime.\n")
 	if strings.Contains(bwcFramework.Spec.Env.RunTime, "python") {
 		CreatePythonService(appDir, bwcFramework)
@@ -261,7 +475,7 @@ func DeployApp(bwcFramework sdtType.Framework, appDir string, minioURL string) e
 	procLog.Info.Printf("App's lang is %s.\n", bwcFramework.Spec.Env.RunTime)
 
 	procLog.Info.Printf("[systemd] Start app servie.\n")
-	startCmd := fmt.Sprintf("systemctl start %s", bwcFramework.Spec.AppName)
+	startCmd := fmt.Sprintf("systemctl daemon-reload && systemctl start %s", bwcFramework.Spec.AppName)
 	cmd_run := exec.Command("sh", "-c", startCmd)
 	stdout, cmd_err := cmd_run.CombinedOutput()
 	if cmd_err != nil {
@@ -280,6 +494,7 @@ func DeployApp(bwcFramework sdtType.Framework, appDir string, minioURL string) e
 //   - appName: Name of the app.
 //   - appId: ID of the app.
 //   - appVenv: Virtual environment used by the app.
+//   - appManaged: Manager of the app. (systemd, dockerd, direct)
 //
 // Output:
 //   - error: Error message for the SaveAppInfo command.
@@ -305,13 +520,13 @@ func SaveAppInfo(appName string, appId string, appVenv string, appManaged string
 			return err
 		}
 
-		err = ioutil.WriteFile(appInfoFile, saveJson, 0644)
+		err = os.WriteFile(appInfoFile, saveJson, 0644)
 		if err != nil {
 			procLog.Error.Printf("Failed save app's info: %v\n", err)
 			return err
 		}
 	} else {
-		jsonFile, err := ioutil.ReadFile(appInfoFile)
+		jsonFile, err := os.ReadFile(appInfoFile)
 		if err != nil {
 			procLog.Error.Printf("Failed load app's file: %v\n", err)
 			return err
@@ -332,7 +547,7 @@ func SaveAppInfo(appName string, appId string, appVenv string, appManaged string
 
 		jsonData.AppInfoList = append(jsonData.AppInfoList, newApp)
 		saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
-		err = ioutil.WriteFile(appInfoFile, saveJson, 0644)
+		err = os.WriteFile(appInfoFile, saveJson, 0644)
 		if err != nil {
 			procLog.Error.Printf("Failed save app's file: %v\n", err)
 			return err
@@ -342,50 +557,49 @@ func SaveAppInfo(appName string, appId string, appVenv string, appManaged string
 	return nil
 }
 
-// GetConfig function collects the config information of the deployed app on the device.
+// GetAppConfig function reads the config file (config.json) of the deployed app on the device.
+// The app's directory is resolved the same way as the control agent's GetConfig function.
+// The result is keyed by the file name, so it can be sent to SDT Cloud as the app's config.
 //
 // Input:
 //   - appId: ID of the app.
 //   - appName: Name of the app.
-//   - archType: Architecture of the device.
+//   - appPath: Path where apps are installed.
+//   - subCmdType: Sub-command type of the request.
 //
 // Output:
-//   - map[string]interface{}: Config values of the app.
-func GetAppConfig(appId string, appName string, archType string) map[string]interface{} {
+//   - map[string]interface{}: Config values of the app. (nil if config.json not found.)
+//   - error: Error message if config.json can't be read or unmarshaled.
+//   - int: Status code of the command.
+func GetAppConfig(appId string, appName string, appPath string, subCmdType string) (map[string]interface{}, error, int) {
 	procLog.Info.Printf("Get app's config.\n")
 	var targetDir string
-	if archType == "win" {
-		targetDir = fmt.Sprintf("C:/sdt/app/%s_%s", appName, appId)
+	if subCmdType == "controlAquarack" || subCmdType == "getConfigAquarack" {
+		targetDir = fmt.Sprintf("%s/%s", appPath, appName)
 	} else {
-		targetDir = fmt.Sprintf("/usr/local/sdt/app/%s_%s", appName, appId)
+		targetDir = fmt.Sprintf("%s/%s_%s", appPath, appName, appId)
 	}
-	procLog.Info.Printf("Read config file: %s\n", targetDir)
-	fileList, err := ioutil.ReadDir(targetDir)
-	if err != nil {
-		procLog.Error.Printf("App not found: %v\n", err)
-		return nil
-	}
-
-	procLog.Info.Printf("Convert files to json.\n")
-	var allConfig = make(map[string]interface{})
-	for _, file := range fileList {
-		fileName := fmt.Sprintf("%s", file.Name())
-		fileType := strings.Split(fileName, ".")
-
-		if fileType[len(fileType)-1] == "json" {
-			result := GetJson(fileName, targetDir)
-			allConfig[fileName] = result
-		}
+	targetFile := fmt.Sprintf("%s/config.json", targetDir)
+	procLog.Info.Printf("Read config file: %s\n", targetFile)
 
-	}
-	// Not found config file.
-	if len(allConfig) == 0 {
+	jsonFile, err := os.ReadFile(targetFile)
+	if os.IsNotExist(err) {
 		procLog.Warn.Printf("App's config not found.\n")
-		return allConfig
+		return nil, nil, http.StatusOK
+	} else if err != nil {
+		procLog.Error.Printf("Failed read app's config: %v\n", err)
+		return nil, err, http.StatusBadRequest
+	}
+
+	var jsonData map[string]interface{}
+	err = json.Unmarshal(jsonFile, &jsonData)
+	if err != nil {
+		procLog.Error.Printf("Unmarshal Error: %v\n", err)
+		return nil, err, http.StatusBadRequest
 	}
 
 	procLog.Info.Printf("Successfully get app's config.\n")
-	return allConfig
+	return map[string]interface{}{"config.json": jsonData}, nil, http.StatusOK
 }
 
 // GetJson function reads a JSON file.
@@ -401,7 +615,7 @@ func GetAppConfig(appId string, appName string, archType string) map[string]inte
 func GetJson(fileName string, targetDir string) map[string]interface{} {
 	procLog.Info.Printf("Read app's config. Unmarshal config file.\n")
 	targetFile := fmt.Sprintf("%s/%s", targetDir, fileName)
-	jsonFile, err := ioutil.ReadFile(targetFile)
+	jsonFile, err := os.ReadFile(targetFile)
 	if err != nil {
 		procLog.Error.Printf("File not found: %v\n", err)
 		return nil
@@ -460,13 +674,116 @@ func DownloadWeight(bwcFramework sdtType.Framework, appDir string, minioURL stri
 	return nil
 }
 
-func WolTest(macAddress string) {
-	startCmd := fmt.Sprintf("wakeonlan -p 8 %s", macAddress)
-	fmt.Printf("[WOL] Info: %s\n", startCmd)
-	cmd_run := exec.Command("sh", "-c", startCmd)
-	stdout, cmd_err := cmd_run.CombinedOutput()
-	if cmd_err != nil {
-		fmt.Println("[WOL] ERROR: ", cmd_err, "\n", string(stdout))
-		os.Exit(1)
+// WolTargetFile is the file that maps device names to MAC addresses for Wake-on-LAN.
+const WolTargetFile = "/etc/sdt/device.config/wol-targets.json"
+
+// The ParseMac function checks the MAC address format. (ex. aa:bb:cc:dd:ee:ff, aa-bb-cc-dd-ee-ff)
+//
+// Input:
+//   - macAddress: MAC address to check.
+//
+// Output:
+//   - net.HardwareAddr: Parsed MAC address.
+//   - error: An error message if the format is invalid.
+func ParseMac(macAddress string) (net.HardwareAddr, error) {
+	mac, err := net.ParseMAC(macAddress)
+	if err != nil || len(mac) != 6 {
+		return nil, fmt.Errorf("invalid MAC address: %s", macAddress)
 	}
+	return mac, nil
+}
+
+// The GetWolTargets function reads the Wake-on-LAN targets. (device name -> MAC address)
+// If the file doesn't exist, an empty map is returned.
+//
+// Output:
+//   - map[string]string: MAC address by device name.
+//   - error: An error message if the file can't be read.
+func GetWolTargets() (map[string]string, error) {
+	wolTargets := map[string]string{}
+	jsonFile, err := os.ReadFile(WolTargetFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return wolTargets, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(jsonFile, &wolTargets); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", WolTargetFile, err)
+	}
+	return wolTargets, nil
+}
+
+// The AddWolTarget function saves the MAC address of the device in wol-targets.json.
+// If the device name already exists, the MAC address is replaced.
+//
+// Input:
+//   - name: Device name.
+//   - macAddress: MAC address of the device.
+//
+// Output:
+//   - error: An error message if the MAC address is invalid or the file can't be written.
+func AddWolTarget(name string, macAddress string) error {
+	mac, err := ParseMac(macAddress)
+	if err != nil {
+		return err
+	}
+	wolTargets, err := GetWolTargets()
+	if err != nil {
+		return err
+	}
+	wolTargets[name] = mac.String()
+
+	jsonData, err := json.MarshalIndent(wolTargets, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(WolTargetFile, jsonData, 0644)
+}
+
+// The WolTest function wakes up the device with the Wake-on-LAN magic packet.
+// The magic packet is 6 bytes of 0xFF followed by the MAC address repeated 16 times,
+// and it is broadcast via UDP to 255.255.255.255:9.
+//
+// Input:
+//   - target: MAC address or device name in wol-targets.json.
+//
+// Output:
+//   - error: An error message if the MAC address is invalid or the packet can't be sent.
+func WolTest(target string) error {
+	macAddress := target
+	if _, err := ParseMac(target); err != nil {
+		wolTargets, err := GetWolTargets()
+		if err != nil {
+			return err
+		}
+		savedMac, ok := wolTargets[target]
+		if !ok {
+			return fmt.Errorf("invalid MAC address or unknown device name: %s", target)
+		}
+		macAddress = savedMac
+	}
+	mac, err := ParseMac(macAddress)
+	if err != nil {
+		return err
+	}
+
+	packet := make([]byte, 0, 102)
+	for i := 0; i < 6; i++ {
+		packet = append(packet, 0xFF)
+	}
+	for i := 0; i < 16; i++ {
+		packet = append(packet, mac...)
+	}
+
+	conn, err := net.Dial("udp", "255.255.255.255:9")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err = conn.Write(packet); err != nil {
+		return err
+	}
+	fmt.Printf("[WOL] Sent magic packet to %s\n", mac.String())
+	return nil
 }
@@ -0,0 +1,59 @@
+package deploy
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdtType "main/src/cliType"
+	sdtLog "pkg/log"
+)
+
+func TestGetAppConfig(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+
+	tests := []struct {
+		name       string
+		subCmdType string
+		appDir     string
+		config     string
+		wantConfig bool
+		wantErr    bool
+		wantStatus int
+	}{
+		{"config not found", "deploy", "", "", false, false, http.StatusOK},
+		{"malformed config", "deploy", "web_app-1", `{"port": `, false, true, http.StatusBadRequest},
+		{"valid config", "deploy", "web_app-1", `{"port": 8080, "mode": "prod"}`, true, false, http.StatusOK},
+		{"aquarack config", "getConfigAquarack", "web", `{"port": 8080, "mode": "prod"}`, true, false, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appPath := t.TempDir()
+			if tt.appDir != "" {
+				dir := filepath.Join(appPath, tt.appDir)
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(tt.config), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			config, err, status := GetAppConfig("app-1", "web", appPath, tt.subCmdType)
+			if (err != nil) != tt.wantErr || status != tt.wantStatus {
+				t.Fatalf("GetAppConfig() = %v, %d, want error %v, status %d", err, status, tt.wantErr, tt.wantStatus)
+			}
+			if !tt.wantConfig {
+				if config != nil {
+					t.Errorf("config = %v, want nil", config)
+				}
+				return
+			}
+			values, ok := config["config.json"].(map[string]interface{})
+			if !ok || values["port"] != float64(8080) || values["mode"] != "prod" {
+				t.Errorf("config = %v", config)
+			}
+		})
+	}
+}
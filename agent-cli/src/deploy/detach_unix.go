@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package deploy
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess function runs the command in a new process group, so that the app
+// keeps running after BWC-CLI exits. (Same as setpgid(0, 0))
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: 0}
+}
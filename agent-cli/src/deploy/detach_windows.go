@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package deploy
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess function runs the command in a new process group, so that the app
+// keeps running after BWC-CLI exits.
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
@@ -0,0 +1,272 @@
+// The diagnose package runs pre-flight checks of the device before an app is deployed.
+// 'bwc diagnose' prints the result of each check as follows:
+//
+//	✓ MQTT broker: tcp://192.168.1.162:1883 is reachable.
+//	✗ Disk space: 312 MB free in /usr/local/sdt/app. (At least 500 MB is required)
+//
+// Each check is a function returning (bool, string) so that it can be run on its own.
+package diagnose
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	sdtType "main/src/cliType"
+)
+
+// These are the global variables used in the Diagnose package.
+// - procLog: This is the struct that defines the format of the log.
+// - minFreeDisk: Free disk space required to deploy an app. (Bytes)
+// - certWarnDays: A certificate expiring within these days fails the check.
+// - dialTimeout: Timeout of the network checks.
+// - diskFree: Function that returns the free disk space of the path. It is a variable so that it can be replaced in tests.
+var (
+	procLog      sdtType.Logger
+	minFreeDisk  uint64 = 500 * 1024 * 1024
+	certWarnDays        = 30
+	dialTimeout         = 5 * time.Second
+	diskFree            = freeDiskSpace
+)
+
+// Getlog is a function that loads the log format. Log formats are defined as Info,
+// Warn, Error, and are output using Printf.
+// Here's how you would record the text "Hello World" as an Info log type:
+//
+//	procLog.Info.Printf("Hello World\n")   ->   [INFO] Hello World
+func Getlog(logConfig sdtType.Logger) {
+	procLog = logConfig
+}
+
+// CheckMqtt function checks that the MQTT broker accepts TCP connections.
+//
+// Input:
+//   - mqttUrl: MQTT URL of the config file. (ex. tcp://192.168.1.162:1883, ssl://xxx.iot.amazonaws.com:8883)
+//
+// Output:
+//   - bool: True if the broker is reachable.
+//   - string: Result message.
+func CheckMqtt(mqttUrl string) (bool, string) {
+	if mqttUrl == "" {
+		return false, "mqtturl is not set in config.json."
+	}
+	host := mqttUrl
+	if u, err := url.Parse(mqttUrl); err == nil && u.Host != "" {
+		host = u.Host
+		if u.Port() == "" {
+			if u.Scheme == "ssl" || u.Scheme == "tls" || u.Scheme == "mqtts" {
+				host = net.JoinHostPort(u.Hostname(), "8883")
+			} else {
+				host = net.JoinHostPort(u.Hostname(), "1883")
+			}
+		}
+	}
+
+	conn, err := net.DialTimeout("tcp", host, dialTimeout)
+	if err != nil {
+		return false, fmt.Sprintf("%s is not reachable: %v", mqttUrl, err)
+	}
+	conn.Close()
+	return true, fmt.Sprintf("%s is reachable.", mqttUrl)
+}
+
+// CertFiles function returns the certificate files used for the MQTT connection.
+// The private key is included to check that it exists.
+//
+// Input:
+//   - rootPath: The root path of BWC.
+//   - configData: BWC Config information struct.
+//
+// Output:
+//   - []string: Paths of the certificate files.
+func CertFiles(rootPath string, configData sdtType.ConfigInfo) []string {
+	var rootCa string
+	if configData.ServiceType == "onprem" {
+		rootCa = fmt.Sprintf("%s/cert/rootCa.pem", rootPath)
+	} else {
+		rootCa = fmt.Sprintf("%s/cert/AmazonRootCA1.pem", rootPath)
+	}
+	return []string{
+		rootCa,
+		fmt.Sprintf("%s/cert/%s-certificate.pem", rootPath, configData.ProjectCode),
+		fmt.Sprintf("%s/cert/%s-private.pem", rootPath, configData.ProjectCode),
+	}
+}
+
+// CheckCerts function checks that the certificate files exist and that the certificates
+// in them are not expired or expiring within certWarnDays days.
+// Files without a certificate (ex. private key) are only checked for existence.
+//
+// Input:
+//   - certFiles: Paths of the certificate files.
+//
+// Output:
+//   - bool: True if all files exist and no certificate is expiring.
+//   - string: Result message.
+func CheckCerts(certFiles []string) (bool, string) {
+	var issues []string
+	var earliest time.Time
+	for _, certFile := range certFiles {
+		data, err := os.ReadFile(certFile)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("%s not found", certFile))
+			continue
+		}
+
+		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				issues = append(issues, fmt.Sprintf("%s cannot be parsed: %v", certFile, err))
+				break
+			}
+			left := time.Until(cert.NotAfter)
+			if left <= 0 {
+				issues = append(issues, fmt.Sprintf("%s expired at %s", certFile, cert.NotAfter.Format("2006-01-02")))
+			} else if left < time.Duration(certWarnDays)*24*time.Hour {
+				issues = append(issues, fmt.Sprintf("%s expires in %d days", certFile, int(left.Hours()/24)))
+			}
+			if earliest.IsZero() || cert.NotAfter.Before(earliest) {
+				earliest = cert.NotAfter
+			}
+		}
+	}
+
+	if len(issues) > 0 {
+		return false, strings.Join(issues, ", ") + "."
+	}
+	if earliest.IsZero() {
+		return true, fmt.Sprintf("%d file(s) found.", len(certFiles))
+	}
+	return true, fmt.Sprintf("%d file(s) found. Valid until %s.", len(certFiles), earliest.Format("2006-01-02"))
+}
+
+// CheckDiskSpace function checks that the file system of the path has more than 500 MB free.
+//
+// Input:
+//   - path: Path where apps are deployed.
+//
+// Output:
+//   - bool: True if there is enough free space.
+//   - string: Result message.
+func CheckDiskSpace(path string) (bool, string) {
+	free, err := diskFree(path)
+	if err != nil {
+		return false, fmt.Sprintf("cannot get free space of %s: %v", path, err)
+	}
+	freeMB := free / 1024 / 1024
+	if free < minFreeDisk {
+		return false, fmt.Sprintf("%d MB free in %s. (At least %d MB is required)", freeMB, path, minFreeDisk/1024/1024)
+	}
+	return true, fmt.Sprintf("%d MB free in %s.", freeMB, path)
+}
+
+// CheckSystemd function checks that the device is running systemd, which is used to run apps.
+//
+// Output:
+//   - bool: True if systemctl exists and systemd is the init system.
+//   - string: Result message.
+func CheckSystemd() (bool, string) {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return false, "systemctl not found. Apps must be deployed with --no-systemd."
+	}
+	// Same check as sd_booted(3)
+	if _, err := os.Stat("/run/systemd/system"); err != nil {
+		return false, "systemd is not running. Apps must be deployed with --no-systemd."
+	}
+	return true, "systemd is available."
+}
+
+// CheckPython function checks that miniconda3 or python3 exists. They are used to create
+// the virtual environment of python apps. (See spec.env.bin in the framework file)
+//
+// Input:
+//   - homeName: User name whose home has miniconda3.
+//
+// Output:
+//   - bool: True if miniconda3 or python3 is found.
+//   - string: Result message.
+func CheckPython(homeName string) (bool, string) {
+	var found []string
+	condaPath := fmt.Sprintf("/home/%s/miniconda3/bin/python", homeName)
+	if _, err := os.Stat(condaPath); err == nil {
+		found = append(found, fmt.Sprintf("miniconda3(%s)", condaPath))
+	}
+	if pythonPath, err := exec.LookPath("python3"); err == nil {
+		found = append(found, fmt.Sprintf("python3(%s)", pythonPath))
+	}
+
+	if len(found) == 0 {
+		return false, fmt.Sprintf("Neither %s nor python3 is found.", condaPath)
+	}
+	return true, strings.Join(found, ", ") + " found."
+}
+
+// CheckRepo function checks that the code repository answers HTTP requests.
+//
+// Input:
+//   - giteaURL: URL of the code repository.
+//
+// Output:
+//   - bool: True if the code repository responds without a server error.
+//   - string: Result message.
+func CheckRepo(giteaURL string) (bool, string) {
+	client := &http.Client{Timeout: dialTimeout}
+	resp, err := client.Get(giteaURL)
+	if err != nil {
+		return false, fmt.Sprintf("%s is not reachable: %v", giteaURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return false, fmt.Sprintf("%s responded %s.", giteaURL, resp.Status)
+	}
+	return true, fmt.Sprintf("%s is reachable.", giteaURL)
+}
+
+// RunDiagnose function runs all checks and prints ✓ or ✗ with the message of each check.
+//
+// Input:
+//   - rootPath: The root path of BWC.
+//   - appPath: Path where apps are deployed.
+//   - homeName: User name whose home has miniconda3.
+//   - configData: BWC Config information struct.
+//   - svcInfo: URLs of SDT Cloud services.
+//
+// Output:
+//   - bool: True if all checks passed.
+func RunDiagnose(rootPath, appPath, homeName string, configData sdtType.ConfigInfo, svcInfo sdtType.ControlService) bool {
+	checks := []struct {
+		name string
+		run  func() (bool, string)
+	}{
+		{"MQTT broker", func() (bool, string) { return CheckMqtt(configData.MqttUrl) }},
+		{"Certificates", func() (bool, string) { return CheckCerts(CertFiles(rootPath, configData)) }},
+		{"Disk space", func() (bool, string) { return CheckDiskSpace(appPath) }},
+		{"Systemd", CheckSystemd},
+		{"Python", func() (bool, string) { return CheckPython(homeName) }},
+		{"Code repository", func() (bool, string) { return CheckRepo(svcInfo.GiteaURL) }},
+	}
+
+	allPassed := true
+	for _, check := range checks {
+		ok, msg := check.run()
+		mark := "✓"
+		if !ok {
+			mark = "✗"
+			allPassed = false
+			procLog.Warn.Printf("[DIAGNOSE] %s: %s\n", check.name, msg)
+		}
+		fmt.Printf("%s %s: %s\n", mark, check.name, msg)
+	}
+	return allPassed
+}
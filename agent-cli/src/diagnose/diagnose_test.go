@@ -0,0 +1,172 @@
+package diagnose
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCert writes a self-signed certificate that expires at notAfter.
+func writeCert(t *testing.T, path string, notAfter time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "device"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckMqtt(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		mqttUrl string
+		want    bool
+	}{
+		{"reachable", "tcp://" + ln.Addr().String(), true},
+		{"host and port only", ln.Addr().String(), true},
+		{"not reachable", "tcp://" + closedAddr, false},
+		{"not set", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok, msg := CheckMqtt(tt.mqttUrl); ok != tt.want {
+				t.Errorf("CheckMqtt(%q) = %v, %q", tt.mqttUrl, ok, msg)
+			}
+		})
+	}
+}
+
+func TestCheckCerts(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.pem")
+	expiring := filepath.Join(dir, "expiring.pem")
+	expired := filepath.Join(dir, "expired.pem")
+	privateKey := filepath.Join(dir, "private.pem")
+	writeCert(t, valid, time.Now().Add(365*24*time.Hour))
+	writeCert(t, expiring, time.Now().Add(10*24*time.Hour))
+	writeCert(t, expired, time.Now().Add(-24*time.Hour))
+	os.WriteFile(privateKey, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")}), 0600)
+
+	tests := []struct {
+		name      string
+		files     []string
+		want      bool
+		wantInMsg string
+	}{
+		{"valid", []string{valid, privateKey}, true, "Valid until"},
+		{"key only", []string{privateKey}, true, "1 file(s) found."},
+		{"expiring", []string{valid, expiring}, false, "expires in"},
+		{"expired", []string{expired}, false, "expired at"},
+		{"missing", []string{valid, filepath.Join(dir, "missing.pem")}, false, "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := CheckCerts(tt.files)
+			if ok != tt.want || !strings.Contains(msg, tt.wantInMsg) {
+				t.Errorf("CheckCerts() = %v, %q, want %v with %q", ok, msg, tt.want, tt.wantInMsg)
+			}
+		})
+	}
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	defer func() { diskFree = freeDiskSpace }()
+
+	tests := []struct {
+		name string
+		free uint64
+		err  error
+		want bool
+	}{
+		{"enough", 2 * 1024 * 1024 * 1024, nil, true},
+		{"exactly 500 MB", 500 * 1024 * 1024, nil, true},
+		{"not enough", 312 * 1024 * 1024, nil, false},
+		{"statfs error", 0, errors.New("no such file or directory"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diskFree = func(path string) (uint64, error) { return tt.free, tt.err }
+			if ok, msg := CheckDiskSpace("/usr/local/sdt/app"); ok != tt.want {
+				t.Errorf("CheckDiskSpace() = %v, %q, want %v", ok, msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPython(t *testing.T) {
+	binDir := t.TempDir()
+	t.Setenv("PATH", binDir)
+	if ok, msg := CheckPython("nobody-sdt-test"); ok {
+		t.Errorf("CheckPython() without python3 = %v, %q", ok, msg)
+	}
+
+	if err := os.WriteFile(filepath.Join(binDir, "python3"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if ok, msg := CheckPython("nobody-sdt-test"); !ok || !strings.Contains(msg, "python3(") {
+		t.Errorf("CheckPython() = %v, %q", ok, msg)
+	}
+}
+
+func TestCheckRepo(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   bool
+	}{
+		{"ok", http.StatusOK, true},
+		{"login required", http.StatusUnauthorized, true},
+		{"server error", http.StatusBadGateway, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+			if ok, msg := CheckRepo(srv.URL); ok != tt.want {
+				t.Errorf("CheckRepo() = %v, %q, want %v", ok, msg, tt.want)
+			}
+		})
+	}
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	if ok, msg := CheckRepo(srv.URL); ok {
+		t.Errorf("CheckRepo() of a closed server = %v, %q", ok, msg)
+	}
+}
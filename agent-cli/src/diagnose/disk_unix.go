@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package diagnose
+
+import (
+	"syscall"
+)
+
+// freeDiskSpace function returns the free space of the file system that the path is on,
+// available to unprivileged users. (Bytes)
+func freeDiskSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,353 @@
+// The export package saves and restores the configuration of the device for migration and
+// disaster recovery. 'bwc export config' bundles the following files into a tar.gz archive,
+// and 'bwc import config' validates and writes them back to the device:
+//   - config.json: BWC config file. (<rootPath>/device.config/config.json)
+//   - app.json: Deployed apps. (<rootPath>/device.config/app.json)
+//   - systemd/<appName>.service: Systemd service files of the deployed apps.
+//   - venvs.json: Names of the venvs in <rootPath>/venv. (Only for reference. Venvs must be created again)
+//
+// Secrets in config.json are redacted unless --include-secrets is used.
+package export
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	sdtType "main/src/cliType"
+	sdtConfigLock "pkg/configlock"
+)
+
+// These are the global variables used in the Export package.
+// - procLog: This is the struct that defines the format of the log.
+// - redactedValue: Value written instead of a secret in the exported config.json.
+// - secretKeys: Keys of config.json redacted from exports. (Lower case)
+// - systemdPath: Directory of the systemd service files.
+var (
+	procLog       sdtType.Logger
+	redactedValue = "REDACTED"
+	secretKeys    = []string{"accesskeyid", "secretaccesskey", "accesstoken", "sdtcloudpw", "password"}
+	systemdPath   = "/etc/systemd/system"
+)
+
+// Getlog is a function that loads the log format. Log formats are defined as Info,
+// Warn, Error, and are output using Printf.
+// Here's how you would record the text "Hello World" as an Info log type:
+//
+//	procLog.Info.Printf("Hello World\n")   ->   [INFO] Hello World
+func Getlog(logConfig sdtType.Logger) {
+	procLog = logConfig
+}
+
+// isSecretKey function checks whether the key of config.json is a secret.
+func isSecretKey(key string) bool {
+	for _, secretKey := range secretKeys {
+		if strings.ToLower(key) == secretKey {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSecrets function replaces the secret values of the JSON object with redactedValue.
+// Nested objects (ex. exmq) are also redacted.
+func redactSecrets(data map[string]interface{}) {
+	for key, val := range data {
+		if nested, ok := val.(map[string]interface{}); ok {
+			redactSecrets(nested)
+		} else if isSecretKey(key) && val != "" {
+			data[key] = redactedValue
+		}
+	}
+}
+
+// restoreSecrets function replaces the redacted values of the imported JSON object with the
+// values of the current config. If the current config doesn't have the value, the key is removed.
+func restoreSecrets(data map[string]interface{}, current map[string]interface{}) {
+	for key, val := range data {
+		if nested, ok := val.(map[string]interface{}); ok {
+			currentNested, _ := current[key].(map[string]interface{})
+			restoreSecrets(nested, currentNested)
+		} else if val == redactedValue {
+			if currentVal, ok := current[key]; ok {
+				data[key] = currentVal
+			} else {
+				delete(data, key)
+			}
+		}
+	}
+}
+
+// addTarFile function writes a file to the tar archive.
+func addTarFile(tw *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// ExportConfig function bundles config.json, app.json, the systemd service files of the apps
+// and the venv list into a tar.gz archive.
+//
+// Input:
+//   - rootPath: The root path of BWC.
+//   - outFile: Path of the archive to create. (ex. device.tar.gz)
+//   - includeSecrets: If true, the secrets in config.json are not redacted.
+//
+// Output:
+//   - error: Error message of the export.
+func ExportConfig(rootPath string, outFile string, includeSecrets bool) error {
+	configFile := fmt.Sprintf("%s/device.config/config.json", rootPath)
+	configJson, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	var configMap map[string]interface{}
+	if err := json.Unmarshal(configJson, &configMap); err != nil {
+		return fmt.Errorf("invalid %s: %v", configFile, err)
+	}
+	if !includeSecrets {
+		redactSecrets(configMap)
+	}
+	configJson, _ = json.MarshalIndent(configMap, "", "\t")
+
+	out, err := os.OpenFile(outFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	gw := gzip.NewWriter(out)
+	tw := tar.NewWriter(gw)
+
+	if err := addTarFile(tw, "config.json", configJson); err != nil {
+		return err
+	}
+	fmt.Printf("Exported config.json\n")
+
+	// app.json doesn't exist if no app has been deployed.
+	var appConfig sdtType.AppConfig
+	appFile := fmt.Sprintf("%s/device.config/app.json", rootPath)
+	if appJson, err := os.ReadFile(appFile); err == nil {
+		if err := json.Unmarshal(appJson, &appConfig); err != nil {
+			return fmt.Errorf("invalid %s: %v", appFile, err)
+		}
+		if err := addTarFile(tw, "app.json", appJson); err != nil {
+			return err
+		}
+		fmt.Printf("Exported app.json (%d apps)\n", len(appConfig.AppInfoList))
+	}
+
+	for _, appInfo := range appConfig.AppInfoList {
+		if appInfo.Managed != "systemd" {
+			continue
+		}
+		svcFile := fmt.Sprintf("%s/%s.service", systemdPath, appInfo.AppName)
+		svcData, err := os.ReadFile(svcFile)
+		if err != nil {
+			procLog.Warn.Printf("Skip %s's service file: %v\n", appInfo.AppName, err)
+			continue
+		}
+		if err := addTarFile(tw, fmt.Sprintf("systemd/%s.service", appInfo.AppName), svcData); err != nil {
+			return err
+		}
+		fmt.Printf("Exported %s\n", svcFile)
+	}
+
+	venvList := []string{}
+	if entries, err := os.ReadDir(fmt.Sprintf("%s/venv", rootPath)); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				venvList = append(venvList, entry.Name())
+			}
+		}
+	}
+	venvJson, _ := json.MarshalIndent(venvList, "", "\t")
+	if err := addTarFile(tw, "venvs.json", venvJson); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	if !includeSecrets {
+		fmt.Printf("Secrets in config.json are redacted. (Use --include-secrets to export them)\n")
+	}
+	procLog.Info.Printf("Exported device config to %s\n", outFile)
+	return nil
+}
+
+// readArchive function reads the files of the tar.gz archive created by ExportConfig.
+// Files that are not exported by ExportConfig are rejected.
+//
+// Input:
+//   - inFile: Path of the archive.
+//
+// Output:
+//   - map[string][]byte: Contents of the files. (Name in the archive -> Content)
+//   - error: Error message of reading the archive.
+func readArchive(inFile string) (map[string][]byte, error) {
+	in, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	gr, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		switch {
+		case name == "config.json", name == "app.json", name == "venvs.json":
+		case path.Dir(name) == "systemd" && strings.HasSuffix(name, ".service"):
+		default:
+			return nil, fmt.Errorf("unexpected file in the archive: %s", header.Name)
+		}
+
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, tr); err != nil {
+			return nil, err
+		}
+		files[name] = buf.Bytes()
+	}
+	return files, nil
+}
+
+// ImportConfig function validates the files of the archive created by ExportConfig and writes
+// them to the device. Redacted secrets keep the values of the current config.json.
+// The current config.json is saved as config.json.bak before it is replaced.
+//
+// Input:
+//   - rootPath: The root path of BWC.
+//   - inFile: Path of the archive to import.
+//
+// Output:
+//   - error: Error message of the import.
+func ImportConfig(rootPath string, inFile string) error {
+	files, err := readArchive(inFile)
+	if err != nil {
+		return err
+	}
+
+	// Validate
+	configJson, ok := files["config.json"]
+	if !ok {
+		return errors.New("config.json is not in the archive")
+	}
+	var configMap map[string]interface{}
+	if err := json.Unmarshal(configJson, &configMap); err != nil {
+		return fmt.Errorf("invalid config.json: %v", err)
+	}
+	var configData sdtType.ConfigInfo
+	if err := json.Unmarshal(configJson, &configData); err != nil {
+		return fmt.Errorf("invalid config.json: %v", err)
+	}
+	if errList := sdtType.ValidateConfigInfo(configData); len(errList) > 0 {
+		return fmt.Errorf("invalid config.json: %s", strings.Join(errList, ", "))
+	}
+	if appJson, ok := files["app.json"]; ok {
+		var appConfig sdtType.AppConfig
+		if err := json.Unmarshal(appJson, &appConfig); err != nil {
+			return fmt.Errorf("invalid app.json: %v", err)
+		}
+	}
+
+	// config.json
+	configFile := fmt.Sprintf("%s/device.config/config.json", rootPath)
+	release, err := sdtConfigLock.AcquireConfigLock(configFile)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	currentJson, err := os.ReadFile(configFile)
+	if err == nil {
+		var currentMap map[string]interface{}
+		json.Unmarshal(currentJson, &currentMap)
+		restoreSecrets(configMap, currentMap)
+		if err := os.WriteFile(configFile+".bak", currentJson, 0644); err != nil {
+			return err
+		}
+	} else {
+		restoreSecrets(configMap, nil)
+	}
+	configJson, _ = json.MarshalIndent(configMap, "", "\t")
+	if err := os.WriteFile(configFile, configJson, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %s (Previous file: %s.bak)\n", configFile, configFile)
+
+	// app.json
+	if appJson, ok := files["app.json"]; ok {
+		appFile := fmt.Sprintf("%s/device.config/app.json", rootPath)
+		if err := os.WriteFile(appFile, appJson, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Imported %s\n", appFile)
+	}
+
+	// systemd service files
+	svcImported := false
+	for name, svcData := range files {
+		if path.Dir(name) != "systemd" {
+			continue
+		}
+		svcFile := fmt.Sprintf("%s/%s", systemdPath, path.Base(name))
+		if err := os.WriteFile(svcFile, svcData, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Imported %s\n", svcFile)
+		svcImported = true
+	}
+	if svcImported {
+		if _, err := exec.LookPath("systemctl"); err == nil {
+			if out, err := exec.Command("systemctl", "daemon-reload").CombinedOutput(); err != nil {
+				procLog.Warn.Printf("systemctl daemon-reload Error: %v, %s\n", err, out)
+			}
+		}
+	}
+
+	if venvJson, ok := files["venvs.json"]; ok {
+		var venvList []string
+		if err := json.Unmarshal(venvJson, &venvList); err == nil && len(venvList) > 0 {
+			fmt.Printf("Venvs of the exported device: %s (Create them with 'bwc create venv')\n", strings.Join(venvList, ", "))
+		}
+	}
+	fmt.Printf("Restart the BWC agents to apply the imported config.\n")
+	procLog.Info.Printf("Imported device config from %s\n", inFile)
+	return nil
+}
@@ -7,12 +7,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	sdtType "main/src/cliType"
@@ -21,7 +23,11 @@ import (
 
 // These are the global variables used in the get package.
 // - procLog: This is the Struct that defines the format of the log.
-var procLog sdtType.Logger
+// - BwcList: List of BWC agents (systemd service names) on the device.
+var (
+	procLog sdtType.Logger
+	BwcList = []string{"device-control", "device-health", "device-heartbeat", "process-checker", "bwc-management"}
+)
 
 // Getlog is a function that loads the log format. Log formats are defined as Info,
 // Warn, Error, and are output using Printf.
@@ -42,7 +48,7 @@ func Getlog(logConfig sdtType.Logger) {
 //   - string: Contents of the requirement file (returned as a string).
 func GetRequirement(pkgFile string) string {
 	procLog.Info.Printf("Get list of package.\n")
-	content, err := ioutil.ReadFile(pkgFile)
+	content, err := os.ReadFile(pkgFile)
 	if err != nil {
 		procLog.Error.Printf("Read failed: %v\n", err)
 		return ""
@@ -113,7 +119,7 @@ func GetRepoOwner(bwUrl string, accessToken string, sdtcloudId string) sdtType.G
 	defer resp.Body.Close()
 
 	//request에 대한 응답
-	respBody, _ := ioutil.ReadAll(resp.Body)
+	respBody, _ := io.ReadAll(resp.Body)
 	statusArr := strings.Split(resp.Status, " ")
 	statusValue, _ := strconv.Atoi(statusArr[0])
 
@@ -170,7 +176,7 @@ func GetTemplate(bwUrl string, configData sdtType.ConfigInfo) (sdtType.TemplateI
 	defer resp.Body.Close()
 
 	//request에 대한 응답
-	respBody, err := ioutil.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	statusArr := strings.Split(resp.Status, " ")
 	statusValue, _ := strconv.Atoi(statusArr[0])
 
@@ -196,58 +202,181 @@ func GetTemplate(bwUrl string, configData sdtType.ConfigInfo) (sdtType.TemplateI
 	return templateInfo, nil
 }
 
-// GetStatus function prints the SDT Cloud connection status of the device.
+// DescribeTemplate function fills the description of each app template from the code repository API.
+// The descriptions are fetched concurrently, up to 5 at a time, to limit the load of the API.
+// If the description of a template can't be fetched, it is left empty.
+//
+// Input:
+//   - giteaURL: URL of the code repository.
+//   - templateInfo: App template list from GetTemplate.
+//   - accessToken: Access token of the code repository.
+func DescribeTemplate(giteaURL string, templateInfo *sdtType.TemplateInfo, accessToken string) {
+	procLog.Info.Printf("Get description of templates.\n")
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, 5)
+
+	for i := range templateInfo.Content {
+		wg.Add(1)
+		go func(template *sdtType.Repos) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			description, err := getRepoDescription(giteaURL, template.Owner.Username, template.Name, accessToken)
+			if err != nil {
+				procLog.Warn.Printf("Failed get description of %s: %v\n", template.Name, err)
+				return
+			}
+			template.Description = description
+		}(&templateInfo.Content[i])
+	}
+	wg.Wait()
+}
+
+// getRepoDescription function gets the description of a repository from the code repository API.
+// (GET /api/v1/repos/<owner>/<repo>)
+func getRepoDescription(giteaURL string, owner string, repoName string, accessToken string) (string, error) {
+	apiUrl := fmt.Sprintf("%s/api/v1/repos/%s/%s", giteaURL, owner, repoName)
+	req, err := http.NewRequest("GET", apiUrl, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("accept", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Status code: %s", resp.Status)
+	}
+	var repoInfo sdtType.Repos
+	if err := json.NewDecoder(resp.Body).Decode(&repoInfo); err != nil {
+		return "", err
+	}
+	return repoInfo.Description, nil
+}
+
+// FetchStatus function requests the SDT Cloud connection status of the device.
+// Fields not found in the API response are N/A.
 //
 // Input:
 //   - assetCode: Serial number of the device.
 //   - organizationId: ID of the organization to which the device belongs.
 //   - bwUrl: URL of SDT Cloud.
-func GetStatus(assetCode string, organizationId string, bwURL string) {
-	procLog.Info.Printf("Get status of device.\n")
+//
+// Output:
+//   - sdtType.DeviceStatus: Connection status of the device.
+//   - error: Error of the API call.
+func FetchStatus(assetCode string, organizationId string, bwURL string) (sdtType.DeviceStatus, error) {
 	apiUrl := fmt.Sprintf("%s/assets/%s/status", bwURL, assetCode)
 
 	req, err := http.NewRequest("GET", apiUrl, nil)
 	if err != nil {
-		procLog.Error.Printf("Http not connected. : %v\n", err)
-		fmt.Printf("Http not connected. : %v\n", err)
-		os.Exit(1)
+		return sdtType.DeviceStatus{}, fmt.Errorf("Http not connected. : %v", err)
 	}
 
 	// req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("X-OrganizationId", organizationId)
 
-	client := &http.Client{}
+	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		procLog.Error.Printf("Failed call api: %v\n", err)
-		fmt.Printf("Failed call api: %v\n", err)
-		os.Exit(1)
+		return sdtType.DeviceStatus{}, fmt.Errorf("Failed call api: %v", err)
 	}
 	defer resp.Body.Close()
 
 	//request에 대한 응답
-	respBody, err := ioutil.ReadAll(resp.Body)
-	statusArr := strings.Split(resp.Status, " ")
-	statusValue, _ := strconv.Atoi(statusArr[0])
-	if statusValue >= 400 {
-		procLog.Error.Printf("API call Error %d: %v\n", statusValue, err)
-		fmt.Printf("API call Error %d: %v\n", statusValue, err)
-		os.Exit(1)
-
+	respBody, err := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		return sdtType.DeviceStatus{}, fmt.Errorf("API call Error %d: %v", resp.StatusCode, err)
 	}
 	result := map[string]interface{}{}
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		procLog.Error.Printf("unmarshal Error: %v\n", err)
-		fmt.Printf("unmarshal Error: %v\n", err)
-		os.Exit(1)
+		return sdtType.DeviceStatus{}, fmt.Errorf("unmarshal Error: %v", err)
+	}
+
+	deviceStatus := sdtType.DeviceStatus{
+		AssetCode:    assetCode,
+		State:        getStatusField(result, "state"),
+		Status:       getStatusField(result, "status"),
+		ProjectCode:  getStatusField(result, "projectCode"),
+		DeviceType:   getStatusField(result, "deviceType"),
+		AgentVersion: getStatusField(result, "agentVersion"),
+		LastSeen:     "N/A",
+	}
+	if updatedAt, ok := result["stateUpdatedAt"].(float64); ok {
+		t := time.Unix(int64(updatedAt/1000), 0)
+		deviceStatus.LastSeen = t.Format("2006-01-02 15:04:05")
+	}
+	return deviceStatus, nil
+}
+
+// PrintStatus function prints the connection status of the device.
+//
+// Input:
+//   - deviceStatus: Connection status of the device.
+//   - outputType: Output format. ("json" or table)
+func PrintStatus(deviceStatus sdtType.DeviceStatus, outputType string) {
+	printStatus(os.Stdout, deviceStatus, outputType)
+}
+
+// printStatus function writes the connection status of the device to out. (See PrintStatus)
+func printStatus(out io.Writer, deviceStatus sdtType.DeviceStatus, outputType string) {
+	if outputType == "json" {
+		statusJson, _ := json.MarshalIndent(deviceStatus, "", "  ")
+		fmt.Fprintf(out, "%s\n", statusJson)
+	} else {
+		fmt.Fprintf(out, " %-15s %s\n", "Asset Code", deviceStatus.AssetCode)
+		fmt.Fprintf(out, " %-15s %s\n", "State", deviceStatus.State)
+		fmt.Fprintf(out, " %-15s %s\n", "Status", deviceStatus.Status)
+		fmt.Fprintf(out, " %-15s %s\n", "Project Code", deviceStatus.ProjectCode)
+		fmt.Fprintf(out, " %-15s %s\n", "Device Type", deviceStatus.DeviceType)
+		fmt.Fprintf(out, " %-15s %s\n", "Agent Version", deviceStatus.AgentVersion)
+		fmt.Fprintf(out, " %-15s %s\n", "Last Seen", deviceStatus.LastSeen)
 	}
-	t := time.Unix(int64(result["stateUpdatedAt"].(float64)/1000), 0)
-	resultTime := t.Format("2006-01-02 15:04:05")
+}
 
-	fmt.Printf("[%s] State:%s, Status:%s \n", resultTime, result["state"], result["status"])
+// GetStatus function prints the SDT Cloud connection status of the device.
+// Fields not found in the API response are printed as N/A.
+//
+// Input:
+//   - assetCode: Serial number of the device.
+//   - organizationId: ID of the organization to which the device belongs.
+//   - bwUrl: URL of SDT Cloud.
+//   - outputType: Output format. ("json" or table)
+func GetStatus(assetCode string, organizationId string, bwURL string, outputType string) {
+	procLog.Info.Printf("Get status of device.\n")
+	deviceStatus, err := FetchStatus(assetCode, organizationId, bwURL)
+	if err != nil {
+		procLog.Error.Printf("%v\n", err)
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	PrintStatus(deviceStatus, outputType)
 	procLog.Info.Printf("Successfully get status of device.\n")
 }
 
+// getStatusField function returns the string value of the status field. If the field
+// isn't found, it returns "N/A".
+func getStatusField(result map[string]interface{}, key string) string {
+	value, ok := result[key]
+	if !ok || value == nil {
+		return "N/A"
+	}
+	if strValue, ok := value.(string); ok {
+		if strValue == "" {
+			return "N/A"
+		}
+		return strValue
+	}
+	return fmt.Sprintf("%v", value)
+}
+
 // GetInfoDevice function prints the information of the device.
 //
 // Input:
@@ -272,7 +401,7 @@ func GetAppList() []sdtType.AppStatus {
 	var appStatus []sdtType.AppStatus
 	appInfoFile := "/etc/sdt/device.config/app.json"
 
-	jsonFile, err := ioutil.ReadFile(appInfoFile)
+	jsonFile, err := os.ReadFile(appInfoFile)
 	if err != nil {
 		procLog.Error.Printf("Failed load app's file: %v\n", err)
 		return appStatus
@@ -286,9 +415,13 @@ func GetAppList() []sdtType.AppStatus {
 
 	procLog.Info.Printf("Convert file to json.\n")
 	for _, val := range jsonData.AppInfoList {
-		status := "Running"
-		if appPid, _ := sdtUtil.GetPid(val.AppName); appPid == -1 {
-			status = "Not running"
+		var status string
+		if runtime.GOOS == "windows" {
+			state, _ := sdtUtil.GetWinServiceState(fmt.Sprintf("%s_%s", val.AppName, val.AppId))
+			status = ConvertAppState(state)
+		} else {
+			state, _ := sdtUtil.GetSystemdState(val.AppName)
+			status = ConvertAppState(state)
 		}
 		app := sdtType.AppStatus{
 			AppName: val.AppName,
@@ -302,6 +435,167 @@ func GetAppList() []sdtType.AppStatus {
 	return appStatus
 }
 
+// GetDeployHistory function reads the deploy history of apps on the device.
+// The deploy history is saved by device-control every time an app is deployed.
+//
+// Output:
+//   - []sdtType.DeployHistory: Deploy history of apps. (Oldest first)
+//   - error: Error message if the deploy history can't be read.
+func GetDeployHistory() ([]sdtType.DeployHistory, error) {
+	procLog.Info.Printf("Get deploy history of app.\n")
+	var historyList []sdtType.DeployHistory
+	historyFile := "/etc/sdt/device.config/deploy-history.json"
+
+	jsonFile, err := os.ReadFile(historyFile)
+	if err != nil {
+		procLog.Error.Printf("Failed load deploy history: %v\n", err)
+		return historyList, err
+	}
+	err = json.Unmarshal(jsonFile, &historyList)
+	if err != nil {
+		procLog.Error.Printf("Failed deploy history's Unmarshal: %v\n", err)
+		return historyList, err
+	}
+	return historyList, nil
+}
+
+// GetPreviousDeploy function finds the deployment of the app before the latest one in the
+// deploy history. It is used to re-download the previous version of the app for rollback.
+//
+// Input:
+//   - appName: Name of the app.
+//
+// Output:
+//   - sdtType.DeployHistory: Previous deployment of the app.
+//   - error: Error message if there is no previous deployment.
+func GetPreviousDeploy(appName string) (sdtType.DeployHistory, error) {
+	historyList, err := GetDeployHistory()
+	if err != nil {
+		return sdtType.DeployHistory{}, err
+	}
+
+	found := 0
+	for i := len(historyList) - 1; i >= 0; i-- {
+		if historyList[i].AppName != appName {
+			continue
+		}
+		found++
+		if found == 2 {
+			return historyList[i], nil
+		}
+	}
+	return sdtType.DeployHistory{}, fmt.Errorf("previous deployment of %s not found", appName)
+}
+
+// GetAppDetail function collects the detailed information of a deployed app from app.json,
+// deploy-history.json, the app's config.json and the systemd unit file.
+// Missing config.json, unit file or deploy history is not an error.
+//
+// Input:
+//   - appName: Name of the app.
+//
+// Output:
+//   - sdtType.AppDetail: Detailed information of the app.
+//   - error: Error message if the app is not found in app.json.
+func GetAppDetail(appName string) (sdtType.AppDetail, error) {
+	procLog.Info.Printf("Get detail of %s app.\n", appName)
+	var appDetail sdtType.AppDetail
+	appInfoFile := "/etc/sdt/device.config/app.json"
+
+	jsonFile, err := os.ReadFile(appInfoFile)
+	if err != nil {
+		procLog.Error.Printf("Failed load app's file: %v\n", err)
+		return appDetail, err
+	}
+	var jsonData sdtType.AppConfig
+	err = json.Unmarshal(jsonFile, &jsonData)
+	if err != nil {
+		procLog.Error.Printf("Failed app's Unmarshal: %v\n", err)
+		return appDetail, err
+	}
+
+	found := false
+	for _, val := range jsonData.AppInfoList {
+		if val.AppName == appName {
+			appDetail.AppName = val.AppName
+			appDetail.AppId = val.AppId
+			appDetail.AppVenv = val.AppVenv
+			appDetail.Managed = val.Managed
+			found = true
+		}
+	}
+	if !found {
+		return appDetail, fmt.Errorf("%s's app not found", appName)
+	}
+
+	// Status
+	if runtime.GOOS == "windows" {
+		state, _ := sdtUtil.GetWinServiceState(fmt.Sprintf("%s_%s", appDetail.AppName, appDetail.AppId))
+		appDetail.Status = ConvertAppState(state)
+	} else {
+		state, _ := sdtUtil.GetSystemdState(appDetail.AppName)
+		appDetail.Status = ConvertAppState(state)
+	}
+
+	// Deploy history
+	historyList, _ := GetDeployHistory()
+	for _, val := range historyList {
+		if val.AppName != appName {
+			continue
+		}
+		appDetail.History = append(appDetail.History, val)
+		if val.AppId == appDetail.AppId {
+			appDetail.DeployedAt = val.DeployedAt
+			appDetail.AppRepoPath = val.AppRepoPath
+		}
+	}
+
+	// Config
+	configFile := fmt.Sprintf("/usr/local/sdt/app/%s_%s/config.json", appDetail.AppName, appDetail.AppId)
+	if configData, err := os.ReadFile(configFile); err == nil {
+		if err := json.Unmarshal(configData, &appDetail.Config); err != nil {
+			procLog.Warn.Printf("Failed app's config Unmarshal: %v\n", err)
+		}
+	}
+
+	// systemd unit file
+	svcFile := fmt.Sprintf("/etc/systemd/system/%s.service", appDetail.AppName)
+	if svcData, err := os.ReadFile(svcFile); err == nil {
+		appDetail.ServiceFile = string(svcData)
+	}
+
+	procLog.Info.Printf("Successfully get detail of %s app.\n", appName)
+	return appDetail, nil
+}
+
+// ConvertAppState function converts the systemd state or Windows Service state of an app
+// to the status shown to users.
+//
+// Input:
+//   - state: systemd state ("<ActiveState>/<SubState>") or Windows Service state.
+//
+// Output:
+//   - string: Status of the app.
+func ConvertAppState(state string) string {
+	activeState := strings.Split(state, "/")[0]
+	switch activeState {
+	case "active", "running":
+		return "Running"
+	case "activating", "reloading", "start_pending", "continue_pending":
+		return "Starting"
+	case "deactivating", "stop_pending", "pause_pending":
+		return "Stopping"
+	case "failed":
+		return "Failed"
+	case "paused":
+		return "Paused"
+	case "", "inactive", "stopped":
+		return "Not running"
+	default:
+		return state
+	}
+}
+
 // GetAppId function retrieves the ID of an app.
 //
 // Input:
@@ -313,7 +607,7 @@ func GetAppId(appName string) string {
 	procLog.Info.Printf("Get appID.\n")
 	appInfoFile := "/etc/sdt/device.config/app.json"
 
-	jsonFile, err := ioutil.ReadFile(appInfoFile)
+	jsonFile, err := os.ReadFile(appInfoFile)
 	if err != nil {
 		procLog.Error.Printf("Failed load app's file: %v\n", err)
 		return ""
@@ -342,9 +636,8 @@ func GetAppId(appName string) string {
 func GetBWCList() []sdtType.AppStatus {
 	procLog.Info.Printf("Get BWC's process.\n")
 	var bwcStatus []sdtType.AppStatus
-	bwcList := []string{"device-control", "device-health", "device-heartbeat", "process-checker", "bwc-management"}
 
-	for _, val := range bwcList {
+	for _, val := range BwcList {
 		status := "Running"
 		if appPid, _ := sdtUtil.GetPid(val); appPid == -1 {
 			status = "Not running"
@@ -368,9 +661,13 @@ func GetVenvList() []string {
 	//   - 어떤 Bin 파일로 생성 됐는지 출력
 	procLog.Info.Printf("Get list of venv.\n")
 	var envList []string
-	envDir, _ := ioutil.ReadDir("/etc/sdt/venv")
+	envDir, _ := os.ReadDir("/etc/sdt/venv")
 
 	for _, f := range envDir {
+		// Skip venv in creation by Device-Control. (/etc/sdt/venv/.<envName>.incomplete)
+		if _, err := os.Stat(fmt.Sprintf("/etc/sdt/venv/.%s.incomplete", f.Name())); err == nil {
+			continue
+		}
 		if f.IsDir() {
 			envList = append(envList, f.Name())
 		}
@@ -379,6 +676,54 @@ func GetVenvList() []string {
 	return envList
 }
 
+// GetVenvDetail function collects the Python version, the number of installed packages
+// and the disk size of a virtual environment. If a value can't be collected, it is left
+// as "-" (or -1 for the package count).
+//
+// Input:
+//   - venvName: Name of the virtual environment.
+//   - venvPath: Directory of the virtual environments. (ex. /etc/sdt/venv)
+//
+// Output:
+//   - sdtType.VenvDetail: Detailed information of the virtual environment.
+func GetVenvDetail(venvName string, venvPath string) sdtType.VenvDetail {
+	venvDir := fmt.Sprintf("%s/%s", venvPath, venvName)
+	venvDetail := sdtType.VenvDetail{
+		PythonVersion: "-",
+		PackageCount:  -1,
+		DiskUsage:     "-",
+	}
+
+	// Python 2 prints the version to stderr.
+	stdout, err := exec.Command(fmt.Sprintf("%s/bin/python", venvDir), "--version").CombinedOutput()
+	if err != nil {
+		procLog.Warn.Printf("Cannot get python version of %s: %v\n", venvName, err)
+	} else {
+		venvDetail.PythonVersion = strings.TrimSpace(string(stdout))
+	}
+
+	stdout, err = exec.Command(fmt.Sprintf("%s/bin/pip", venvDir), "list", "--format=json").Output()
+	if err != nil {
+		procLog.Warn.Printf("Cannot get package list of %s: %v\n", venvName, err)
+	} else {
+		var packageList []map[string]interface{}
+		if err := json.Unmarshal(stdout, &packageList); err != nil {
+			procLog.Warn.Printf("Cannot parse package list of %s: %v\n", venvName, err)
+		} else {
+			venvDetail.PackageCount = len(packageList)
+		}
+	}
+
+	stdout, err = exec.Command("du", "-sh", venvDir).Output()
+	if err != nil {
+		procLog.Warn.Printf("Cannot get disk usage of %s: %v\n", venvName, err)
+	} else if fields := strings.Fields(string(stdout)); len(fields) > 0 {
+		venvDetail.DiskUsage = fields[0]
+	}
+
+	return venvDetail
+}
+
 // CheckExistVenv function checks whether a specific virtual environment exists.
 //
 // Input:
@@ -388,7 +733,7 @@ func GetVenvList() []string {
 //   - bool: True if the virtual environment exists, False otherwise.
 func CheckExistVenv(targetVenv string) bool {
 	procLog.Info.Printf("Check venv.\n")
-	envDir, _ := ioutil.ReadDir("/etc/sdt/venv")
+	envDir, _ := os.ReadDir("/etc/sdt/venv")
 	for _, f := range envDir {
 		if f.IsDir() {
 			if f.Name() == targetVenv {
@@ -417,7 +762,7 @@ func CheckVenvUsed(targetVenv string) (string, bool) {
 		return "", false
 	}
 
-	jsonFile, err := ioutil.ReadFile(appInfoFile)
+	jsonFile, err := os.ReadFile(appInfoFile)
 	if err != nil {
 		procLog.Error.Printf("Failed load app's file: %v\n", err)
 		fmt.Printf("Failed load app's file.\n")
@@ -457,7 +802,7 @@ func CheckExistApp(targetApp string) bool {
 		return false
 	}
 
-	jsonFile, err := ioutil.ReadFile(appInfoFile)
+	jsonFile, err := os.ReadFile(appInfoFile)
 	if err != nil {
 		procLog.Error.Printf("Failed load app's file: %v\n", err)
 		fmt.Printf("Failed load app's file.\n")
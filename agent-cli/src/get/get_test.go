@@ -0,0 +1,120 @@
+package get
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	sdtType "main/src/cliType"
+)
+
+func TestFetchStatus(t *testing.T) {
+	updatedAt := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		code    int
+		body    string
+		want    sdtType.DeviceStatus
+		wantErr bool
+	}{
+		{
+			name: "all fields",
+			code: http.StatusOK,
+			body: `{"state":"ONLINE","status":"ACTIVE","projectCode":"PRJ-1","deviceType":"NODE","agentVersion":"1.2.3","stateUpdatedAt":` +
+				strconv.FormatInt(updatedAt.UnixMilli(), 10) + `}`,
+			want: sdtType.DeviceStatus{
+				AssetCode: "A001", State: "ONLINE", Status: "ACTIVE", ProjectCode: "PRJ-1",
+				DeviceType: "NODE", AgentVersion: "1.2.3",
+				LastSeen: time.Unix(updatedAt.Unix(), 0).Format("2006-01-02 15:04:05"),
+			},
+		},
+		{
+			name: "missing fields are N/A",
+			code: http.StatusOK,
+			body: `{"state":"OFFLINE","status":"","projectCode":null}`,
+			want: sdtType.DeviceStatus{
+				AssetCode: "A001", State: "OFFLINE", Status: "N/A", ProjectCode: "N/A",
+				DeviceType: "N/A", AgentVersion: "N/A", LastSeen: "N/A",
+			},
+		},
+		{name: "not found", code: http.StatusNotFound, body: `{"message":"not found"}`, wantErr: true},
+		{name: "server error", code: http.StatusInternalServerError, body: ``, wantErr: true},
+		{name: "invalid json", code: http.StatusOK, body: `not json`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/assets/A001/status" {
+					t.Errorf("path = %s, want /assets/A001/status", r.URL.Path)
+				}
+				if got := r.Header.Get("X-OrganizationId"); got != "org-1" {
+					t.Errorf("X-OrganizationId = %q, want org-1", got)
+				}
+				w.WriteHeader(tt.code)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			got, err := FetchStatus("A001", "org-1", srv.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("FetchStatus() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchStatusUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	if _, err := FetchStatus("A001", "org-1", url); err == nil {
+		t.Fatal("FetchStatus() error = nil, want error for closed server")
+	}
+}
+
+func TestPrintStatus(t *testing.T) {
+	status := sdtType.DeviceStatus{
+		AssetCode: "A001", State: "ONLINE", Status: "ACTIVE", ProjectCode: "N/A",
+		DeviceType: "NODE", AgentVersion: "1.2.3", LastSeen: "2024-05-01 09:30:00",
+	}
+
+	var buf bytes.Buffer
+	printStatus(&buf, status, "")
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	wantLines := []string{
+		" Asset Code      A001",
+		" State           ONLINE",
+		" Status          ACTIVE",
+		" Project Code    N/A",
+		" Device Type     NODE",
+		" Agent Version   1.2.3",
+		" Last Seen       2024-05-01 09:30:00",
+	}
+	if len(lines) != len(wantLines) {
+		t.Fatalf("table output has %d lines, want %d:\n%s", len(lines), len(wantLines), buf.String())
+	}
+	for i, want := range wantLines {
+		if lines[i] != want {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want)
+		}
+	}
+
+	buf.Reset()
+	printStatus(&buf, status, "json")
+	var got sdtType.DeviceStatus
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json output is not valid: %v\n%s", err, buf.String())
+	}
+	if got != status {
+		t.Errorf("json output = %+v, want %+v", got, status)
+	}
+}
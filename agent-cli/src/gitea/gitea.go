@@ -5,22 +5,33 @@
 package gitea
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	sdtType "main/src/cliType"
 	bhttp "net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
 // These are the global variables used in the gitea package.
 // - procLog: This is the struct that defines the format of the log.
-var procLog sdtType.Logger
+// - ErrUnauthorized: Error returned when the code repository responds with HTTP 401.
+var (
+	procLog         sdtType.Logger
+	ErrUnauthorized = errors.New("Unauthorized in code repository.")
+)
 
 // Getlog is a function that loads the log format. Log formats are defined as Info,
 // Warn, Error, and are output using Printf.
@@ -231,6 +242,196 @@ func CloneGiteaTemplate(
 	procLog.Info.Printf("Successfully clone app template in device.\n")
 }
 
+// DownloadGiteaTemplate function downloads an app template from the code repository as an
+// archive and extracts it to the device. The access token is sent as a Bearer token, so
+// templates in private repositories can be downloaded. If tagName is empty, the default
+// branch of the repository is downloaded.
+//
+// Input:
+//   - giteaURL: URL of the code repository.
+//   - repoName: Name of the code repository.
+//   - localRepoPath: Path on the device where the template will be extracted.
+//   - templateOwner: Username of the owner of the app template.
+//   - tagName: Release tag name in the code repository.
+//   - accessToken: Access token of the code repository.
+//
+// Output:
+//   - error: Error message for the DownloadGiteaTemplate command. (ErrUnauthorized if HTTP 401)
+func DownloadGiteaTemplate(
+	giteaURL string,
+	repoName string,
+	localRepoPath string,
+	templateOwner string,
+	tagName string,
+	accessToken string,
+) error {
+	procLog.Info.Printf("Download app template in device.\n")
+	refName := tagName
+	if refName == "" {
+		refName = "main"
+		repoInfo, err := giteaRequest(fmt.Sprintf("%s/api/v1/repos/%s/%s", giteaURL, templateOwner, repoName), accessToken)
+		if err != nil {
+			return err
+		}
+		var repoData map[string]interface{}
+		if err := json.Unmarshal(repoInfo, &repoData); err == nil {
+			if branch, ok := repoData["default_branch"].(string); ok && branch != "" {
+				refName = branch
+			}
+		}
+	}
+
+	procLog.Info.Printf("Downloading repository %s/%s (%s)...\n", templateOwner, repoName, refName)
+	archiveData, err := giteaRequest(fmt.Sprintf("%s/api/v1/repos/%s/%s/archive/%s.zip", giteaURL, templateOwner, repoName, refName), accessToken)
+	if err != nil {
+		return err
+	}
+
+	err = unzipTemplate(archiveData, localRepoPath)
+	if err != nil {
+		procLog.Error.Printf("Unzip error: %v\n", err)
+		return err
+	}
+	procLog.Info.Printf("Successfully download app template in device.\n")
+	return nil
+}
+
+// CreateGiteaToken function creates an access token of the code repository by using
+// the username and password.
+//
+// Input:
+//   - giteaURL: URL of the code repository.
+//   - username: Username of the code repository.
+//   - password: Password for the username.
+//
+// Output:
+//   - string: Access token of the code repository.
+//   - error: Error message for the CreateGiteaToken command.
+func CreateGiteaToken(giteaURL string, username string, password string) (string, error) {
+	procLog.Info.Printf("Create access token in code repository.\n")
+	createTokenURL := fmt.Sprintf("%s/api/v1/users/%s/tokens", giteaURL, username)
+
+	payload := map[string]interface{}{
+		"name":   fmt.Sprintf("bwc-%d", time.Now().Unix()),
+		"scopes": []string{"read:repository"},
+	}
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		procLog.Error.Printf("Error marshaling JSON: %v\n", err)
+		return "", err
+	}
+
+	req, err := bhttp.NewRequest("POST", createTokenURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		procLog.Error.Printf("Error creating HTTP request: %v\n", err)
+		return "", err
+	}
+
+	req.SetBasicAuth(username, password)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := bhttp.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		procLog.Error.Printf("Error making HTTP request: %v\n", err)
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != bhttp.StatusCreated {
+		procLog.Error.Printf("Failed to create token. Status code: %d\n", resp.StatusCode)
+		return "", fmt.Errorf("Status code: %s", resp.Status)
+	}
+
+	var tokenData map[string]interface{}
+	if err := json.Unmarshal(respBody, &tokenData); err != nil {
+		procLog.Error.Printf("unmarshal Error: %v\n", err)
+		return "", err
+	}
+	token, _ := tokenData["sha1"].(string)
+	if token == "" {
+		return "", errors.New("Token not found in response.")
+	}
+
+	procLog.Info.Printf("Successfully create access token in code repository.\n")
+	return token, nil
+}
+
+// giteaRequest function calls the code repository API with the access token.
+func giteaRequest(apiUrl string, accessToken string) ([]byte, error) {
+	req, err := bhttp.NewRequest("GET", apiUrl, nil)
+	if err != nil {
+		procLog.Error.Printf("Error creating HTTP request: %v\n", err)
+		return nil, err
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	client := bhttp.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		procLog.Error.Printf("Error making HTTP request: %v\n", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == bhttp.StatusUnauthorized {
+		procLog.Warn.Printf("Unauthorized in code repository: %s\n", apiUrl)
+		return nil, ErrUnauthorized
+	} else if resp.StatusCode != bhttp.StatusOK {
+		procLog.Error.Printf("Failed request. Status code: %d (%s)\n", resp.StatusCode, apiUrl)
+		return nil, fmt.Errorf("Status code: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// unzipTemplate function extracts the archive of the app template. The top directory
+// of the archive (<repoName>/) is removed.
+func unzipTemplate(archiveData []byte, destDir string) error {
+	zipReader, err := zip.NewReader(bytes.NewReader(archiveData), int64(len(archiveData)))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zipReader.File {
+		parts := strings.SplitN(f.Name, "/", 2)
+		if len(parts) < 2 || parts[1] == "" {
+			continue
+		}
+		targetPath := filepath.Join(destDir, parts[1])
+		if !strings.HasPrefix(targetPath, filepath.Clean(destDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("Invalid file path: %s", f.Name)
+		}
+
+		if f.FileInfo().IsDir() {
+			os.MkdirAll(targetPath, os.ModePerm)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(targetPath), os.ModePerm); err != nil {
+			return err
+		}
+
+		srcFile, err := f.Open()
+		if err != nil {
+			return err
+		}
+		dstFile, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+		if err != nil {
+			srcFile.Close()
+			return err
+		}
+		_, err = io.Copy(dstFile, srcFile)
+		srcFile.Close()
+		dstFile.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // PushGiteaRepo function creates a release in the code repository. When deploying an app through BWC-CLI,
 // the app is stored in the code repository and a release is created.
 //
@@ -275,7 +476,13 @@ func PushGiteaRepo(gitClient *git.Repository, username string, password string)
 		procLog.Error.Printf("Error commit: push: %v\n", err)
 	}
 
-	// push
+	// push (print upload progress)
+	progressClient := http.NewClient(&bhttp.Client{Transport: &progressTransport{}})
+	client.InstallProtocol("http", progressClient)
+	client.InstallProtocol("https", progressClient)
+	defer client.InstallProtocol("http", http.DefaultClient)
+	defer client.InstallProtocol("https", http.DefaultClient)
+
 	err = gitClient.Push(&git.PushOptions{
 		RemoteName: "origin",
 		//RefSpecs:   []config.RefSpec{config.RefSpec("refs/heads/main:refs/heads/main")},
@@ -289,3 +496,119 @@ func PushGiteaRepo(gitClient *git.Repository, username string, password string)
 	procLog.Info.Printf("Successfully clone app template in device.\n")
 	return nil
 }
+
+// VerifyGiteaTag function checks that the release tag was created in the code repository.
+//
+// Input:
+//   - giteaURL: URL of the code repository.
+//   - username: Username of the code repository.
+//   - password: Password for the username.
+//   - ownerName: Owner's username of the code repository.
+//   - repoName: Name of the code repository.
+//   - tagName: Release tag name in the code repository.
+//
+// Output:
+//   - error: Error message with the response body of the code repository if the tag is not found.
+func VerifyGiteaTag(giteaURL string, username string, password string, ownerName string, repoName string, tagName string) error {
+	procLog.Info.Printf("Verify release tag in code repository.\n")
+	tagURL := fmt.Sprintf("%s/api/v1/repos/%s/%s/tags/%s", giteaURL, ownerName, repoName, tagName)
+
+	req, err := bhttp.NewRequest("GET", tagURL, nil)
+	if err != nil {
+		procLog.Error.Printf("Error creating HTTP request: %v\n", err)
+		return err
+	}
+	req.SetBasicAuth(username, password)
+
+	httpClient := bhttp.Client{}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		procLog.Error.Printf("Error making HTTP request: %v\n", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != bhttp.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		procLog.Error.Printf("Release tag not found. Status code: %d\n", resp.StatusCode)
+		return fmt.Errorf("tag %s not found (%s): %s", tagName, resp.Status, string(body))
+	}
+
+	procLog.Info.Printf("Successfully verify release tag: %s\n", tagName)
+	return nil
+}
+
+// progressTransport is an http.RoundTripper that prints the upload progress of the request body.
+type progressTransport struct{}
+
+// progressBody is the request body wrapped by io.TeeReader to count the uploaded bytes.
+//   - Reader: TeeReader of the request body.
+//   - Closer: Original request body.
+type progressBody struct {
+	io.Reader
+	io.Closer
+}
+
+// countWriter counts the bytes written by io.TeeReader.
+type countWriter struct {
+	count int64
+}
+
+// Write function adds the length of p to the uploaded bytes.
+func (w *countWriter) Write(p []byte) (int, error) {
+	atomic.AddInt64(&w.count, int64(len(p)))
+	return len(p), nil
+}
+
+// RoundTrip function sends the request and prints the uploaded bytes and the estimated
+// completion time every 500 ms until the request is done.
+func (t *progressTransport) RoundTrip(req *bhttp.Request) (*bhttp.Response, error) {
+	if req.Body == nil || req.Method != "POST" {
+		return bhttp.DefaultTransport.RoundTrip(req)
+	}
+
+	counter := &countWriter{}
+	req.Body = &progressBody{Reader: io.TeeReader(req.Body, counter), Closer: req.Body}
+	total := req.ContentLength
+	start := time.Now()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				printProgress(atomic.LoadInt64(&counter.count), total, start)
+				fmt.Printf("\n")
+				return
+			case <-ticker.C:
+				printProgress(atomic.LoadInt64(&counter.count), total, start)
+			}
+		}
+	}()
+
+	resp, err := bhttp.DefaultTransport.RoundTrip(req)
+	close(done)
+	return resp, err
+}
+
+// printProgress function prints the uploaded bytes and the estimated completion time.
+//
+// Input:
+//   - uploaded: Uploaded bytes.
+//   - total: Total bytes of the request body. (-1 if unknown)
+//   - start: Time the upload started.
+func printProgress(uploaded int64, total int64, start time.Time) {
+	if total <= 0 {
+		fmt.Printf("\rUploading: %d bytes", uploaded)
+		return
+	}
+	eta := "-"
+	elapsed := time.Since(start).Seconds()
+	if uploaded > 0 && elapsed > 0 {
+		remain := float64(total-uploaded) / (float64(uploaded) / elapsed)
+		eta = (time.Duration(remain) * time.Second).String()
+	}
+	fmt.Printf("\rUploading: %d / %d bytes (%.1f%%), ETA %s   ", uploaded, total, float64(uploaded)*100/float64(total), eta)
+}
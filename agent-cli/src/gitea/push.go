@@ -0,0 +1,357 @@
+package gitea
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	bhttp "net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestFile is the file in the repository root that has the SHA-256 of the files pushed by 'bwc push'.
+const ManifestFile = ".bwc-manifest.json"
+
+// pushBranch is the branch updated by 'bwc push'. (Same as the target of ReleaseGiteaRepo)
+const pushBranch = "main"
+
+// Manifest is the SHA-256 of the files of the app. (Path relative to the app directory -> Hex SHA-256)
+type Manifest map[string]string
+
+// BuildManifest function computes the SHA-256 of the files in the app directory.
+// The .git directory and the manifest file are skipped.
+//
+// Input:
+//   - dir: Path of the app.
+//
+// Output:
+//   - Manifest: SHA-256 of the files. (Paths are separated by '/')
+//   - error: Error of reading the files.
+func BuildManifest(dir string) (Manifest, error) {
+	manifest := Manifest{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if relPath == ManifestFile {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		hash := sha256.New()
+		if _, err := io.Copy(hash, f); err != nil {
+			return err
+		}
+		manifest[relPath] = hex.EncodeToString(hash.Sum(nil))
+		return nil
+	})
+	return manifest, err
+}
+
+// DiffManifest function compares the local manifest with the remote manifest.
+//
+// Input:
+//   - local: Manifest of the app directory.
+//   - remote: Manifest of the repository. (Empty if the repository has no manifest)
+//
+// Output:
+//   - []string: Files added or changed in the app directory. (Sorted)
+//   - []string: Files of the remote manifest removed from the app directory. (Sorted)
+func DiffManifest(local Manifest, remote Manifest) ([]string, []string) {
+	var changed, deleted []string
+	for path, hash := range local {
+		if remote[path] != hash {
+			changed = append(changed, path)
+		}
+	}
+	for path := range remote {
+		if _, ok := local[path]; !ok {
+			deleted = append(deleted, path)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(deleted)
+	return changed, deleted
+}
+
+// contentsURL function returns the contents API URL of the file in the repository.
+func contentsURL(giteaURL string, ownerName string, repoName string, filePath string) string {
+	segments := strings.Split(filePath, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return fmt.Sprintf("%s/api/v1/repos/%s/%s/contents/%s", giteaURL, ownerName, repoName, strings.Join(segments, "/"))
+}
+
+// giteaAuthRequest function calls the code repository API with basic auth.
+// A 404 response is returned as (nil, nil), since the file or the branch doesn't exist yet.
+func giteaAuthRequest(method string, apiUrl string, username string, password string, body interface{}) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewBuffer(jsonData)
+	}
+	req, err := bhttp.NewRequest(method, apiUrl, reqBody)
+	if err != nil {
+		procLog.Error.Printf("Error creating HTTP request: %v\n", err)
+		return nil, err
+	}
+	req.SetBasicAuth(username, password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := bhttp.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		procLog.Error.Printf("Error making HTTP request: %v\n", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == bhttp.StatusNotFound {
+		return nil, nil
+	} else if resp.StatusCode == bhttp.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	} else if resp.StatusCode >= 300 {
+		procLog.Error.Printf("Failed request. Status code: %d (%s %s)\n", resp.StatusCode, method, apiUrl)
+		return nil, fmt.Errorf("Status code: %s: %s", resp.Status, string(respBody))
+	}
+	return respBody, nil
+}
+
+// GetRemoteTree function reads the files of the branch with the tree API of the code repository.
+//
+// Input:
+//   - giteaURL: URL of the code repository.
+//   - username: Username of the code repository.
+//   - password: Password for the username.
+//   - ownerName: Owner's username of the code repository.
+//   - repoName: Name of the code repository.
+//
+// Output:
+//   - map[string]string: Blob SHA of the files. (Empty if the branch doesn't exist)
+//   - error: Error of the API call.
+func GetRemoteTree(giteaURL string, username string, password string, ownerName string, repoName string) (map[string]string, error) {
+	tree := map[string]string{}
+	for page := 1; ; page++ {
+		treeURL := fmt.Sprintf("%s/api/v1/repos/%s/%s/git/trees/%s?recursive=true&page=%d", giteaURL, ownerName, repoName, pushBranch, page)
+		respBody, err := giteaAuthRequest("GET", treeURL, username, password, nil)
+		if err != nil || respBody == nil {
+			return tree, err
+		}
+
+		var treeInfo struct {
+			Tree []struct {
+				Path string `json:"path"`
+				Type string `json:"type"`
+				Sha  string `json:"sha"`
+			} `json:"tree"`
+			Truncated bool `json:"truncated"`
+		}
+		if err := json.Unmarshal(respBody, &treeInfo); err != nil {
+			return nil, err
+		}
+		for _, entry := range treeInfo.Tree {
+			if entry.Type == "blob" {
+				tree[entry.Path] = entry.Sha
+			}
+		}
+		if !treeInfo.Truncated || len(treeInfo.Tree) == 0 {
+			return tree, nil
+		}
+	}
+}
+
+// GetRemoteManifest function reads .bwc-manifest.json of the repository.
+//
+// Input:
+//   - giteaURL: URL of the code repository.
+//   - username: Username of the code repository.
+//   - password: Password for the username.
+//   - ownerName: Owner's username of the code repository.
+//   - repoName: Name of the code repository.
+//
+// Output:
+//   - Manifest: Manifest of the repository. (Empty if the repository has no manifest)
+//   - error: Error of the API call.
+func GetRemoteManifest(giteaURL string, username string, password string, ownerName string, repoName string) (Manifest, error) {
+	manifest := Manifest{}
+	apiUrl := contentsURL(giteaURL, ownerName, repoName, ManifestFile) + "?ref=" + pushBranch
+	respBody, err := giteaAuthRequest("GET", apiUrl, username, password, nil)
+	if err != nil || respBody == nil {
+		return manifest, err
+	}
+
+	var contents struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(respBody, &contents); err != nil {
+		return nil, err
+	}
+	manifestJson, err := base64.StdEncoding.DecodeString(contents.Content)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(manifestJson, &manifest); err != nil {
+		procLog.Warn.Printf("Invalid %s in code repository. All files are pushed: %v\n", ManifestFile, err)
+		return Manifest{}, nil
+	}
+	return manifest, nil
+}
+
+// putGiteaFile function creates the file in the repository, or updates it if blobSha is set.
+func putGiteaFile(giteaURL string, username string, password string, ownerName string, repoName string,
+	filePath string, content []byte, blobSha string, message string) error {
+	payload := map[string]interface{}{
+		"content": base64.StdEncoding.EncodeToString(content),
+		"message": message,
+		"branch":  pushBranch,
+	}
+	method := "POST"
+	if blobSha != "" {
+		method = "PUT"
+		payload["sha"] = blobSha
+	}
+	_, err := giteaAuthRequest(method, contentsURL(giteaURL, ownerName, repoName, filePath), username, password, payload)
+	return err
+}
+
+// deleteGiteaFile function deletes the file from the repository.
+func deleteGiteaFile(giteaURL string, username string, password string, ownerName string, repoName string,
+	filePath string, blobSha string, message string) error {
+	payload := map[string]interface{}{
+		"sha":     blobSha,
+		"message": message,
+		"branch":  pushBranch,
+	}
+	_, err := giteaAuthRequest("DELETE", contentsURL(giteaURL, ownerName, repoName, filePath), username, password, payload)
+	return err
+}
+
+// PushGiteaDelta function uploads only the files whose SHA-256 differs from .bwc-manifest.json of the
+// repository with the update-file API, and deletes the files removed from the app directory.
+// Files in the repository that are not in the manifest (ex. README.md created by auto_init) are kept.
+// Finally, the manifest of the app directory is written to the repository.
+//
+// Input:
+//   - giteaURL: URL of the code repository.
+//   - username: Username of the code repository.
+//   - password: Password for the username.
+//   - ownerName: Owner's username of the code repository.
+//   - repoName: Name of the code repository.
+//   - targetDir: Path of the app to push.
+//   - message: Commit message.
+//
+// Output:
+//   - int: Number of the uploaded files.
+//   - int: Number of the deleted files.
+//   - error: Error message of the push.
+func PushGiteaDelta(giteaURL string, username string, password string, ownerName string, repoName string,
+	targetDir string, message string) (int, int, error) {
+	procLog.Info.Printf("Push changed files in code repository: %s/%s\n", ownerName, repoName)
+	localManifest, err := BuildManifest(targetDir)
+	if err != nil {
+		return 0, 0, err
+	}
+	remoteManifest, err := GetRemoteManifest(giteaURL, username, password, ownerName, repoName)
+	if err != nil {
+		return 0, 0, err
+	}
+	remoteTree, err := GetRemoteTree(giteaURL, username, password, ownerName, repoName)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	changed, deleted := DiffManifest(localManifest, remoteManifest)
+	for _, filePath := range changed {
+		content, err := os.ReadFile(filepath.Join(targetDir, filepath.FromSlash(filePath)))
+		if err != nil {
+			return 0, 0, err
+		}
+		fmt.Printf("  upload %s (%d bytes)\n", filePath, len(content))
+		if err := putGiteaFile(giteaURL, username, password, ownerName, repoName, filePath, content, remoteTree[filePath], message); err != nil {
+			return 0, 0, fmt.Errorf("upload %s: %v", filePath, err)
+		}
+	}
+	deletedCount := 0
+	for _, filePath := range deleted {
+		blobSha, ok := remoteTree[filePath]
+		if !ok {
+			continue
+		}
+		fmt.Printf("  delete %s\n", filePath)
+		if err := deleteGiteaFile(giteaURL, username, password, ownerName, repoName, filePath, blobSha, message); err != nil {
+			return len(changed), deletedCount, fmt.Errorf("delete %s: %v", filePath, err)
+		}
+		deletedCount++
+	}
+
+	if len(changed) > 0 || len(deleted) > 0 {
+		manifestJson, _ := json.MarshalIndent(localManifest, "", "  ")
+		if err := putGiteaFile(giteaURL, username, password, ownerName, repoName, ManifestFile, manifestJson, remoteTree[ManifestFile], message); err != nil {
+			return len(changed), deletedCount, fmt.Errorf("upload %s: %v", ManifestFile, err)
+		}
+	}
+	procLog.Info.Printf("Successfully push %d changed and %d deleted files.\n", len(changed), deletedCount)
+	return len(changed), deletedCount, nil
+}
+
+// LatestGiteaTag function returns the latest tag of the repository.
+//
+// Input:
+//   - giteaURL: URL of the code repository.
+//   - username: Username of the code repository.
+//   - password: Password for the username.
+//   - ownerName: Owner's username of the code repository.
+//   - repoName: Name of the code repository.
+//
+// Output:
+//   - string: Latest tag. (Empty if the repository has no tag)
+//   - error: Error of the API call.
+func LatestGiteaTag(giteaURL string, username string, password string, ownerName string, repoName string) (string, error) {
+	tagsURL := fmt.Sprintf("%s/api/v1/repos/%s/%s/tags?limit=1", giteaURL, ownerName, repoName)
+	respBody, err := giteaAuthRequest("GET", tagsURL, username, password, nil)
+	if err != nil || respBody == nil {
+		return "", err
+	}
+	var tags []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(respBody, &tags); err != nil {
+		return "", err
+	}
+	if len(tags) == 0 {
+		return "", nil
+	}
+	return tags[0].Name, nil
+}
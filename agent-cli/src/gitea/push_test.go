@@ -0,0 +1,403 @@
+package gitea
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	sdtType "main/src/cliType"
+	sdtLog "pkg/log"
+)
+
+// mockGitea is an in-memory code repository that serves the tree and contents API of Gitea
+// for the branch main of owner/app.
+type mockGitea struct {
+	t        *testing.T
+	mu       sync.Mutex
+	files    map[string][]byte
+	shas     map[string]string
+	nextSha  int
+	pageSize int
+	calls    []string
+}
+
+func newMockGitea(t *testing.T, files map[string]string) *mockGitea {
+	m := &mockGitea{t: t, files: map[string][]byte{}, shas: map[string]string{}}
+	for path, content := range files {
+		m.store(path, []byte(content))
+	}
+	return m
+}
+
+func (m *mockGitea) store(path string, content []byte) {
+	m.nextSha++
+	m.files[path] = content
+	m.shas[path] = "blob-" + strconv.Itoa(m.nextSha)
+}
+
+// writes returns the POST/PUT/DELETE calls and clears the call log.
+func (m *mockGitea) writes() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var writes []string
+	for _, call := range m.calls {
+		if !strings.HasPrefix(call, "GET ") {
+			writes = append(writes, call)
+		}
+	}
+	m.calls = nil
+	return writes
+}
+
+func (m *mockGitea) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "pw" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	const prefix = "/api/v1/repos/owner/app/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	apiPath := strings.TrimPrefix(r.URL.Path, prefix)
+	m.calls = append(m.calls, r.Method+" "+apiPath)
+
+	switch {
+	case apiPath == "git/trees/main" && r.Method == http.MethodGet:
+		m.serveTree(w, r)
+	case strings.HasPrefix(apiPath, "contents/"):
+		m.serveContents(w, r, strings.TrimPrefix(apiPath, "contents/"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (m *mockGitea) serveTree(w http.ResponseWriter, r *http.Request) {
+	if len(m.files) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.URL.Query().Get("recursive") != "true" {
+		m.t.Errorf("tree request without recursive=true: %s", r.URL)
+	}
+	paths := make([]string, 0, len(m.files))
+	for path := range m.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	type entry struct {
+		Path string `json:"path"`
+		Type string `json:"type"`
+		Sha  string `json:"sha"`
+	}
+	var tree []entry
+	for _, path := range paths {
+		tree = append(tree, entry{Path: path, Type: "blob", Sha: m.shas[path]})
+		if dir := filepath.ToSlash(filepath.Dir(path)); dir != "." {
+			tree = append(tree, entry{Path: dir, Type: "tree", Sha: "tree-" + dir})
+		}
+	}
+	truncated := false
+	if m.pageSize > 0 {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		start := (page - 1) * m.pageSize
+		if start > len(tree) {
+			start = len(tree)
+		}
+		end := start + m.pageSize
+		if end >= len(tree) {
+			end = len(tree)
+		} else {
+			truncated = true
+		}
+		tree = tree[start:end]
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"tree": tree, "truncated": truncated})
+}
+
+func (m *mockGitea) serveContents(w http.ResponseWriter, r *http.Request, path string) {
+	switch r.Method {
+	case http.MethodGet:
+		content, ok := m.files[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"content": base64.StdEncoding.EncodeToString(content), "sha": m.shas[path]})
+		return
+	}
+
+	var payload struct {
+		Content string `json:"content"`
+		Message string `json:"message"`
+		Branch  string `json:"branch"`
+		Sha     string `json:"sha"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		m.t.Errorf("%s %s: invalid body: %v", r.Method, path, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if payload.Branch != "main" || payload.Message == "" {
+		m.t.Errorf("%s %s: branch = %q, message = %q", r.Method, path, payload.Branch, payload.Message)
+	}
+	_, exists := m.files[path]
+	switch r.Method {
+	case http.MethodPost:
+		if exists {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+	case http.MethodPut, http.MethodDelete:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if payload.Sha != m.shas[path] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		delete(m.files, path)
+		delete(m.shas, path)
+	} else {
+		content, err := base64.StdEncoding.DecodeString(payload.Content)
+		if err != nil {
+			m.t.Errorf("%s %s: invalid content: %v", r.Method, path, err)
+		}
+		m.store(path, content)
+	}
+	if r.Method == http.MethodPost {
+		w.WriteHeader(http.StatusCreated)
+	}
+	w.Write([]byte(`{}`))
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		fullPath := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestBuildManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.py":          "print('hello')\n",
+		"conf/config.json": "{}",
+		".git/HEAD":        "ref: refs/heads/main\n",
+		ManifestFile:       "{}",
+	})
+
+	got, err := BuildManifest(dir)
+	if err != nil {
+		t.Fatalf("BuildManifest() error = %v", err)
+	}
+	want := Manifest{
+		"main.py":          sha256Hex("print('hello')\n"),
+		"conf/config.json": sha256Hex("{}"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildManifest() = %v, want %v", got, want)
+	}
+}
+
+func TestDiffManifest(t *testing.T) {
+	tests := []struct {
+		name        string
+		local       Manifest
+		remote      Manifest
+		wantChanged []string
+		wantDeleted []string
+	}{
+		{
+			name:        "no remote manifest",
+			local:       Manifest{"b.py": "2", "a.py": "1"},
+			remote:      Manifest{},
+			wantChanged: []string{"a.py", "b.py"},
+		},
+		{
+			name:   "unchanged",
+			local:  Manifest{"a.py": "1"},
+			remote: Manifest{"a.py": "1"},
+		},
+		{
+			name:        "changed, added and deleted",
+			local:       Manifest{"a.py": "1", "b.py": "changed", "d.py": "4"},
+			remote:      Manifest{"a.py": "1", "b.py": "2", "c.py": "3"},
+			wantChanged: []string{"b.py", "d.py"},
+			wantDeleted: []string{"c.py"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, deleted := DiffManifest(tt.local, tt.remote)
+			if !reflect.DeepEqual(changed, tt.wantChanged) || !reflect.DeepEqual(deleted, tt.wantDeleted) {
+				t.Errorf("DiffManifest() = %v, %v, want %v, %v", changed, deleted, tt.wantChanged, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestPushGiteaDelta(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	// README.md is created by auto_init and is not in the manifest.
+	repo := newMockGitea(t, map[string]string{"README.md": "# app\n"})
+	srv := httptest.NewServer(repo)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.py":     "print('v1')\n",
+		"lib/util.py": "def f(): pass\n",
+	})
+	push := func() (int, int) {
+		t.Helper()
+		uploaded, deleted, err := PushGiteaDelta(srv.URL, "user", "pw", "owner", "app", dir, "bwc push")
+		if err != nil {
+			t.Fatalf("PushGiteaDelta() error = %v", err)
+		}
+		return uploaded, deleted
+	}
+
+	// First push: every file is created, and the manifest is written.
+	if uploaded, deleted := push(); uploaded != 2 || deleted != 0 {
+		t.Errorf("first push = %d uploaded, %d deleted, want 2, 0", uploaded, deleted)
+	}
+	wantWrites := []string{"POST contents/lib/util.py", "POST contents/main.py", "POST contents/" + ManifestFile}
+	if got := repo.writes(); !reflect.DeepEqual(got, wantWrites) {
+		t.Errorf("first push writes = %v, want %v", got, wantWrites)
+	}
+
+	// Nothing changed: nothing is written.
+	if uploaded, deleted := push(); uploaded != 0 || deleted != 0 {
+		t.Errorf("unchanged push = %d uploaded, %d deleted, want 0, 0", uploaded, deleted)
+	}
+	if got := repo.writes(); len(got) != 0 {
+		t.Errorf("unchanged push writes = %v, want none", got)
+	}
+
+	// One file changed, one added and one removed.
+	writeFiles(t, dir, map[string]string{"main.py": "print('v2')\n", "new.py": "x = 1\n"})
+	if err := os.Remove(filepath.Join(dir, "lib", "util.py")); err != nil {
+		t.Fatal(err)
+	}
+	if uploaded, deleted := push(); uploaded != 2 || deleted != 1 {
+		t.Errorf("delta push = %d uploaded, %d deleted, want 2, 1", uploaded, deleted)
+	}
+	wantWrites = []string{"PUT contents/main.py", "POST contents/new.py", "DELETE contents/lib/util.py", "PUT contents/" + ManifestFile}
+	if got := repo.writes(); !reflect.DeepEqual(got, wantWrites) {
+		t.Errorf("delta push writes = %v, want %v", got, wantWrites)
+	}
+
+	wantFiles := map[string]string{"README.md": "# app\n", "main.py": "print('v2')\n", "new.py": "x = 1\n"}
+	for path, content := range wantFiles {
+		if got := string(repo.files[path]); got != content {
+			t.Errorf("repo file %s = %q, want %q", path, got, content)
+		}
+	}
+	if _, ok := repo.files["lib/util.py"]; ok {
+		t.Error("lib/util.py should be deleted from the repo")
+	}
+	var remoteManifest Manifest
+	if err := json.Unmarshal(repo.files[ManifestFile], &remoteManifest); err != nil {
+		t.Fatalf("invalid remote manifest: %v", err)
+	}
+	localManifest, _ := BuildManifest(dir)
+	if !reflect.DeepEqual(remoteManifest, localManifest) {
+		t.Errorf("remote manifest = %v, want %v", remoteManifest, localManifest)
+	}
+}
+
+func TestPushGiteaDeltaUnauthorized(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	srv := httptest.NewServer(newMockGitea(t, nil))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.py": "print('v1')\n"})
+	_, _, err := PushGiteaDelta(srv.URL, "user", "wrong", "owner", "app", dir, "bwc push")
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("PushGiteaDelta() error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestGetRemoteTreePages(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	files := map[string]string{}
+	for i := 0; i < 5; i++ {
+		files[fmt.Sprintf("src/file%d.py", i)] = strconv.Itoa(i)
+	}
+	repo := newMockGitea(t, files)
+	repo.pageSize = 3
+	srv := httptest.NewServer(repo)
+	defer srv.Close()
+
+	tree, err := GetRemoteTree(srv.URL, "user", "pw", "owner", "app")
+	if err != nil {
+		t.Fatalf("GetRemoteTree() error = %v", err)
+	}
+	if !reflect.DeepEqual(tree, repo.shas) {
+		t.Errorf("GetRemoteTree() = %v, want %v", tree, repo.shas)
+	}
+}
+
+func TestGetRemoteManifest(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  Manifest
+	}{
+		{name: "no manifest", files: map[string]string{"README.md": "# app\n"}, want: Manifest{}},
+		{name: "invalid manifest", files: map[string]string{ManifestFile: "not json"}, want: Manifest{}},
+		{name: "manifest", files: map[string]string{ManifestFile: `{"main.py":"abc"}`}, want: Manifest{"main.py": "abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(newMockGitea(t, tt.files))
+			defer srv.Close()
+
+			got, err := GetRemoteManifest(srv.URL, "user", "pw", "owner", "app")
+			if err != nil {
+				t.Fatalf("GetRemoteManifest() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetRemoteManifest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
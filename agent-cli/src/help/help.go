@@ -9,19 +9,31 @@ import (
 func PrintHelp() {
 	fmt.Printf("Init Example  : bwc init app -n <app name> \n")
 	fmt.Printf("Create Example: bwc create app|venv -d <target directory> \n")
-	fmt.Printf("Deploy Example: bwc deploy app -d <target directory> \n")
+	fmt.Printf("Deploy Example: bwc deploy app -d <target directory> [--no-systemd]\n")
+	fmt.Printf("Update Example: bwc update app|venv -d <target directory> \n")
+	fmt.Printf("Upload Example: bwc upload -d <target directory> [--tag-version auto]\n")
+	fmt.Printf("Push Example  : bwc push -d <target directory> [--tag-version auto]\n")
 	fmt.Printf("Delete Example: bwc delete app|venv -n <target name>\n")
+	fmt.Printf("Rollback Example: bwc rollback app -n <app name>\n")
 	fmt.Printf("Get Example   : bwc get app|venv\n")
+	fmt.Printf("Restart Example: bwc restart agent <agent name>\n")
 	fmt.Printf("Status Example: bwc status\n")
 	fmt.Printf("Info Example  : bwc info\n")
-	fmt.Printf("Logs Example  : bwc logs bwc|app -n <service name|app name>\n")
+	fmt.Printf("Diagnose Example: bwc diagnose\n")
+	fmt.Printf("Logs Example  : bwc logs bwc|app|audit -n <service name|app name>\n")
+	fmt.Printf("Context Example: bwc context set|use|list <context name>\n")
+	fmt.Printf("Config Example: bwc config validate\n")
+	fmt.Printf("Export Example: bwc export config -o <file.tar.gz> [--include-secrets]\n")
+	fmt.Printf("Import Example: bwc import config -f <file.tar.gz>\n")
+	fmt.Printf("App Example   : bwc app config set <app name> <key> <value>\n")
+	fmt.Printf("WOL Example   : bwc wol <mac address|device name>\n")
 
 	fmt.Printf("\n")
 	fmt.Printf("[init] : It create app.\n")
 	fmt.Printf("  - If you use this command, you must enter the following command:\n")
 	fmt.Printf("  	- bwc init app [-n,-name] [-t,-template]\n")
 	fmt.Printf("  	- [-n,-name]: Created directroy's name.\n")
-	fmt.Printf("  	- [-t,-template]: Template name.\n")
+	fmt.Printf("  	- [-t,-template]: Template name. (<template name> or <owner>/<repo>:<tag>)\n")
 
 	fmt.Printf("\n")
 	fmt.Printf("[create] : It create app and venv in your device. In the case of app creation, this is to check whether the app operates well. To deploy an app, you must use the deploy command.\n")
@@ -34,44 +46,140 @@ func PrintHelp() {
 	fmt.Printf("\n")
 	fmt.Printf("[deploy] : It deploy app in your device.\n")
 	fmt.Printf("  - If you use this command, you must enter the following command:\n")
-	fmt.Printf("  	- bwc deploy app [-d,-directory] [-u,-upload]\n")
+	fmt.Printf("  	- bwc deploy app [-d,-directory] [-u,-upload] [--no-systemd]\n")
 	fmt.Printf("  	- app: Target resource.\n")
 	fmt.Printf("  	- [-d,-directory]: App's directory or directory path's framework.yaml\n")
 	fmt.Printf("  	- [-u,-upload]: This is an option to upload to gitea or not. Enter -u if you are uploading, and leave out -u if you are not uploading.\n")
+	fmt.Printf("  	- [--no-systemd]: Run the app directly as a background process. (For devices without systemd. PID is saved in <app directory>/.pid)\n")
 
 	fmt.Printf("\n")
-	fmt.Printf("[update] : It update venv's package in your device.\n")
+	fmt.Printf("[update] : It update venv's package or deployed app in your device.\n")
 	fmt.Printf("  - If you use this command, you must enter the following command:\n")
-	fmt.Printf("  	- bwc update venv [-d,-directory]\n")
-	fmt.Printf("  	- app: Target resource.\n")
+	fmt.Printf("  	- bwc update [app|venv] [-d,-directory] [-u,-upload]\n")
+	fmt.Printf("  	- [app|venv]: Target resource.\n")
 	fmt.Printf("  	- [-d,-directory]: App's directory or directory path's framework.yaml\n")
+	fmt.Printf("  	- [-u,-upload]: Upload new version of app to gitea or not. (Only app)\n")
+
+	fmt.Printf("\n")
+	fmt.Printf("[upload] : It upload app in code repository and create release.\n")
+	fmt.Printf("  - If you use this command, you must enter the following command:\n")
+	fmt.Printf("  	- bwc upload [-d,-directory] [--tag-version auto]\n")
+	fmt.Printf("  	- [-d,-directory]: App's directory or directory path's framework.yaml\n")
+	fmt.Printf("  	- [--tag-version auto]: Release tag is the latest tag with the patch version incremented. (v1.2.3 -> v1.2.4)\n")
+
+	fmt.Printf("\n")
+	fmt.Printf("[push] : It upload only changed files of app in code repository and create release.\n")
+	fmt.Printf("  - Files are compared with .bwc-manifest.json (SHA-256 of the files) in the code repository.\n")
+	fmt.Printf("  - If you use this command, you must enter the following command:\n")
+	fmt.Printf("  	- bwc push [-d,-directory] [--tag-version auto]\n")
+	fmt.Printf("  	- [-d,-directory]: App's directory or directory path's framework.yaml\n")
+	fmt.Printf("  	- [--tag-version auto]: Release tag is the latest tag with the patch version incremented. (v1.2.3 -> v1.2.4)\n")
 
 	fmt.Printf("\n")
 	fmt.Printf("[delete] : It delete app in your device.\n")
 	fmt.Printf("  - If you use this command, you must enter the following command:\n")
-	fmt.Printf("  	- bwc delete [app|venv] [-n,-name]\n")
+	fmt.Printf("  	- bwc delete [app|venv] [-n,-name] [--clean-deploy-logs] [-y,--yes]\n")
 	fmt.Printf("  	- [app|venv]: Target resource.\n")
 	fmt.Printf("  	- [-n,-name]: App or virtual environment name.\n")
+	fmt.Printf("  	- [--clean-deploy-logs]: Remove the app's deploy.log. (deploy.log is kept by default.)\n")
+	fmt.Printf("  	- [-y,--yes]: Delete venv without the confirmation prompt. (No prompt if stdin is not a terminal.)\n")
 
+	fmt.Printf("\n")
+	fmt.Printf("[rollback] : It restore the previous version of app in your device.\n")
+	fmt.Printf("  - The previous version is kept as <app directory>.bak when the app is updated with 'bwc update app'.\n")
+	fmt.Printf("  - If you use this command, you must enter the following command:\n")
+	fmt.Printf("  	- bwc rollback app [-n,-name]\n")
+	fmt.Printf("  	- app: Target resource.\n")
+	fmt.Printf("  	- [-n,-name]: App name.\n")
 	fmt.Printf("\n")
 	fmt.Printf("[get] : It show apps or virtual environments in your device.\n")
 	fmt.Printf("  - If you use this command, you must enter the following command:\n")
-	fmt.Printf("    - bwc get [app|venv|bwc]\n")
+	fmt.Printf("    - bwc get [app|venv|bwc|template] [-o|--output table|json]\n")
 	fmt.Printf("  	- [app|venv]: Target resource.\n")
+	fmt.Printf("  	- [-o|--output table|json]: Output format. (Default: table) json prints the list as a JSON array.\n")
+	fmt.Printf("    - bwc get [app|bwc] [-w|--watch [seconds]]\n")
+	fmt.Printf("  	- [-w|--watch [seconds]]: Refresh the list every interval until Ctrl-C. (Default: 5 seconds)\n")
+	fmt.Printf("    - bwc get app [--history]\n")
+	fmt.Printf("  	- [--history]: Show the deploy history of apps. (Latest first, up to 100)\n")
+	fmt.Printf("    - bwc get app [--inspect <app name>] [--output json]\n")
+	fmt.Printf("  	- [--inspect <app name>]: Show the detail of the app. (Deploy time, venv, config and service file)\n")
+	fmt.Printf("    - bwc get venv [--detail]\n")
+	fmt.Printf("  	- [--detail]: Show the Python version, package count and disk size of each venv.\n")
+	fmt.Printf("    - bwc get template [--describe]\n")
+	fmt.Printf("  	- [--describe]: Show the description of each template from the code repository.\n")
+
+	fmt.Printf("\n")
+	fmt.Printf("[restart] : It restart bwc agent in your device. The result is also sent to SDT Cloud.\n")
+	fmt.Printf("  - If you use this command, you must enter the following command:\n")
+	fmt.Printf("    - bwc restart agent <agent name>\n")
+	fmt.Printf("  	- <agent name>: device-control, device-health, device-heartbeat, process-checker, bwc-management\n")
 
 	fmt.Printf("\n")
 	fmt.Printf("[status] : It show device. This shows the device's registration and connection status to SDT Cloud. \n")
 	fmt.Printf("  - If you use this command, you must enter the following command:\n")
-	fmt.Printf("    - bwc status\n")
+	fmt.Printf("    - bwc status [--output json] [-w|--watch [seconds]]\n")
+	fmt.Printf("  	- [--output json]: Print status as JSON.\n")
+	fmt.Printf("  	- [-w|--watch [seconds]]: Refresh the status every interval until Ctrl-C. (Default: 5 seconds)\n")
 
 	fmt.Printf("\n")
 	fmt.Printf("[info] : It show information of device. This shows the device's projectcode, assetcode, type and etc.\n")
 	fmt.Printf("  - If you use this command, you must enter the following command:\n")
 	fmt.Printf("    - bwc info\n")
 
+	fmt.Printf("\n")
+	fmt.Printf("[diagnose] : It check the device before deploying an app. Exit code is 1 if any check fails.\n")
+	fmt.Printf("  - Checks: MQTT broker, certificates, disk space (500 MB), systemd, miniconda3/python3, code repository.\n")
+	fmt.Printf("  - If you use this command, you must enter the following command:\n")
+	fmt.Printf("    - bwc diagnose\n")
+
 	fmt.Printf("\n")
 	fmt.Printf("[logs] : It show log's bwc process or app in device. \n")
 	fmt.Printf("  - If you use this command, you must enter the following command:\n")
-	fmt.Printf("    - bwc logs [bwc|app] [-n,-name]\n")
+	fmt.Printf("    - bwc logs [bwc|app] [-n,-name] [-f,-follow]\n")
 	fmt.Printf("  	- [-n,-name]: process or app name.\n")
+	fmt.Printf("  	- [-f,-follow]: Keep printing new logs. App's logs are also sent to SDT Cloud.\n")
+	fmt.Printf("    - bwc logs bwc [-n,-name] [--level info|warn|error] [-l,-line] [-f,-follow]\n")
+	fmt.Printf("  	- [--level]: Print only [INFO], [WARNING] or [ERROR] logs. (Only bwc)\n")
+	fmt.Printf("    - bwc logs app [-n,-name] [--since 1h|<RFC3339 time>] [-l,-line] [-f,-follow]\n")
+	fmt.Printf("  	- [--since]: Print only the app's logs newer than the duration or time. (ex. --since 30m, --since 2024-05-01T09:00:00+09:00)\n")
+	fmt.Printf("  	- [-l,-line]: Print only the last lines of the logs.\n")
+	fmt.Printf("    - bwc logs audit [-l,-line] [-f,-follow]\n")
+	fmt.Printf("  	- audit: Control commands (bash, systemd, docker) executed on the device. (JSON)\n")
+
+	fmt.Printf("\n")
+	fmt.Printf("[app] : It change config.json of the deployed app without restarting it. The change is also sent to SDT Cloud.\n")
+	fmt.Printf("  - If you use this command, you must enter the following command:\n")
+	fmt.Printf("    - bwc app config set <app name> <key> <value>\n")
+	fmt.Printf("  	- <key>: Key of config.json. Nested key is separated by dots. (ex. inference.threshold)\n")
+	fmt.Printf("  	- <value>: Integer and float values are converted automatically.\n")
+
+	fmt.Printf("\n")
+	fmt.Printf("[config] : It check config.json of bwc in your device. Exit code is 1 if any issues are found.\n")
+	fmt.Printf("  - If you use this command, you must enter the following command:\n")
+	fmt.Printf("    - bwc config validate\n")
+
+	fmt.Printf("\n")
+	fmt.Printf("[export|import] : It save and restore the config of the device. (config.json, app.json, systemd service files of apps, venv list)\n")
+	fmt.Printf("  - If you use this command, you must enter the following command:\n")
+	fmt.Printf("    - bwc export config [-o] [--include-secrets]\n")
+	fmt.Printf("  	- [-o]: Archive file to create. (tar.gz)\n")
+	fmt.Printf("  	- [--include-secrets]: Don't redact secrets (accesstoken, sdtcloudpw, accessKeyId, secretAccessKey, password) of config.json.\n")
+	fmt.Printf("    - bwc import config [-f]\n")
+	fmt.Printf("  	- [-f]: Archive file created by 'bwc export config'. Redacted secrets keep the current values.\n")
+
+	fmt.Printf("\n")
+	fmt.Printf("[wol] : It wake up the device in the same network with Wake-on-LAN.\n")
+	fmt.Printf("  - If you use this command, you must enter the following command:\n")
+	fmt.Printf("    - bwc wol <mac address|device name>\n")
+	fmt.Printf("    - bwc wol add [-n,-name] [-m,-mac]\n")
+	fmt.Printf("  	- [-n,-name]: Device name saved in /etc/sdt/device.config/wol-targets.json.\n")
+	fmt.Printf("  	- [-m,-mac]: MAC address of the device. (ex. aa:bb:cc:dd:ee:ff)\n")
+
+	fmt.Printf("\n")
+	fmt.Printf("[context] : It manage devices that commands are run on. Contexts are saved in ~/.bwc/contexts.yaml.\n")
+	fmt.Printf("  - If another context than 'local' is used, commands are run on the device over SSH.\n")
+	fmt.Printf("  - If you use this command, you must enter the following command:\n")
+	fmt.Printf("    - bwc context set <context name> --host <ip> [--port <ssh port>] [--asset <asset code>]\n")
+	fmt.Printf("    - bwc context use <context name|local>\n")
+	fmt.Printf("    - bwc context list\n")
 }
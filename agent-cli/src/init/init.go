@@ -5,13 +5,16 @@ package init
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	sdtType "main/src/cliType"
 	sdtGitea "main/src/gitea"
 	sdtUtil "main/src/util"
 	"os"
+	"strings"
 
 	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 // These are the global variables used in the init package.
@@ -170,18 +173,47 @@ func CreateRequirement(appName string) {
 	procLog.Info.Printf("Successfully create requirement.txt file.\n")
 }
 
+// ParseTemplateOption function parses the template option. The template option is
+// <templateName>, <templateName>:<tag> or <owner>/<repo>:<tag>.
+//
+// Input:
+//   - templateOption: Template option of the init command.
+//
+// Output:
+//   - string: Owner of the template. (empty if not set)
+//   - string: Name of the template.
+//   - string: Tag of the template. (empty if not set)
+func ParseTemplateOption(templateOption string) (string, string, string) {
+	var ownerName, tagName string
+	templateName := templateOption
+	if index := strings.LastIndex(templateName, ":"); index != -1 {
+		tagName = templateName[index+1:]
+		templateName = templateName[:index]
+	}
+	if index := strings.Index(templateName, "/"); index != -1 {
+		ownerName = templateName[:index]
+		templateName = templateName[index+1:]
+	}
+	return ownerName, templateName, tagName
+}
+
 // CreateApp function downloads an app onto the device. If a templateName is specified,
 // it downloads the app template from the code repository. If no templateName is provided,
-// it generates necessary files for app execution.
+// it generates necessary files for app execution. If the code repository returns HTTP 401,
+// it asks username and password of the code repository and retries with a new token.
 //
 // Input:
 //   - appName: The name of the app.
 //   - templateName: The name of the app template.
+//   - tagName: The tag of the app template. (default branch if empty)
 //   - giteaURL: The URL of the code repository.
 //   - templateOwner: The username of the app template owner.
 //   - homename: The hostname of the device.
-
-func CreateApp(appName string, templateName string, giteaURL string, templateOwner string, homename string) {
+//   - accessToken: The access token for the code repository.
+//
+// Output:
+//   - string: The access token used to download the app template.
+func CreateApp(appName string, templateName string, tagName string, giteaURL string, templateOwner string, homename string, accessToken string) string {
 	procLog.Info.Printf("Init app template in device.\n")
 	if templateName == "" {
 		//Get base App
@@ -196,16 +228,34 @@ func CreateApp(appName string, templateName string, giteaURL string, templateOwn
 		CreateRequirement(appName)
 	} else {
 		//Get App Template
-		sdtGitea.CloneGiteaTemplate(giteaURL, templateName, appName, templateOwner)
+		err := sdtGitea.DownloadGiteaTemplate(giteaURL, templateName, appName, templateOwner, tagName, accessToken)
+		if errors.Is(err, sdtGitea.ErrUnauthorized) {
+			// Get token of code repository.
+			var username string
+			fmt.Printf("Code repository requires authentication.\n")
+			fmt.Printf("Username: ")
+			fmt.Scanln(&username)
+			fmt.Printf("Password for %s: ", username)
+			pw, _ := terminal.ReadPassword(int(os.Stdin.Fd()))
+			fmt.Printf("\n")
 
-		// Remove .git file
-		err := os.RemoveAll(fmt.Sprintf("%s/.git", appName))
+			accessToken, err = sdtGitea.CreateGiteaToken(giteaURL, username, string(pw))
+			if err != nil {
+				fmt.Printf("Failed create token: %v\n", err)
+				os.Exit(1)
+			}
+			err = sdtGitea.DownloadGiteaTemplate(giteaURL, templateName, appName, templateOwner, tagName, accessToken)
+		}
 		if err != nil {
-			procLog.Error.Printf("Can't remove .git file.(not found)\n")
+			procLog.Error.Printf("Download error: %v\n", err)
+			fmt.Printf("Download error: %v\n", err)
+			os.RemoveAll(appName)
+			os.Exit(1)
 		}
 	}
 
 	// Chown cmd
 	sdtUtil.ChownCmd(appName, homename)
 	procLog.Info.Printf("Successfully init app template in device.\n")
+	return accessToken
 }
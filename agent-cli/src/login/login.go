@@ -7,13 +7,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"golang.org/x/crypto/ssh/terminal"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
 
 	sdtType "main/src/cliType"
+	sdtConfigLock "pkg/configlock"
 )
 
 // These are the global variables used in the Login package.
@@ -63,7 +64,7 @@ func SaveLoginInfo(bwURL string) {
 	}
 	defer resp.Body.Close()
 
-	body, _ := ioutil.ReadAll(resp.Body)
+	body, _ := io.ReadAll(resp.Body)
 	statusArr := strings.Split(resp.Status, " ")
 	statusValue, _ := strconv.Atoi(statusArr[0])
 	//fmt.Printf("%s / %s \n", statusArr, statusValue)
@@ -85,8 +86,14 @@ func SaveLoginInfo(bwURL string) {
 
 	// Add Access info in device config file.
 	configFile := "/etc/sdt/device.config/config.json"
+	release, err := sdtConfigLock.AcquireConfigLock(configFile)
+	if err != nil {
+		procLog.Error.Printf("Failed lock device config: %v\n", err)
+		os.Exit(1)
+	}
+	defer release()
 
-	jsonFile, err := ioutil.ReadFile(configFile)
+	jsonFile, err := os.ReadFile(configFile)
 	if err != nil {
 		procLog.Error.Printf("Failed load app's file: %v\n", err)
 	}
@@ -101,9 +108,39 @@ func SaveLoginInfo(bwURL string) {
 	jsonData.AccessToken = tokenInfo.AccessToken
 
 	saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
-	err = ioutil.WriteFile(configFile, saveJson, 0644)
+	err = os.WriteFile(configFile, saveJson, 0644)
 	if err != nil {
 		procLog.Error.Printf("Failed save app's file: %v\n", err)
 	}
 	procLog.Info.Printf("Successfully save user'info(login) in device.\n")
 }
+
+// SaveAccessToken function stores the access token in the device config file. The config file
+// is locked while it is updated, so that other fields are not overwritten.
+//
+// Input:
+//   - configFile: Path of the device config file.
+//   - accessToken: Access token to store.
+//
+// Output:
+//   - error: Error message if saving fails.
+func SaveAccessToken(configFile string, accessToken string) error {
+	release, err := sdtConfigLock.AcquireConfigLock(configFile)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	jsonFile, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	var jsonData sdtType.ConfigInfo
+	if err := json.Unmarshal(jsonFile, &jsonData); err != nil {
+		return err
+	}
+	jsonData.AccessToken = accessToken
+
+	saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
+	return os.WriteFile(configFile, saveJson, 0644)
+}
@@ -7,10 +7,16 @@ import (
 	"bufio"
 	"fmt"
 	"github.com/hpcloud/tail"
-	"io/ioutil"
+	"io"
 	sdtType "main/src/cliType"
 	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
 
+	sdtMessage "main/src/message"
 	sdtUtil "main/src/util"
 )
 
@@ -27,12 +33,32 @@ func Getlog(logConfig sdtType.Logger) {
 	procLog = logConfig
 }
 
+// LevelPrefix function returns the prefix of the BWC log for the log level.
+//
+// Input:
+//   - level: Log level. (info, warn, error)
+//
+// Output:
+//   - string: Prefix of the log line. ([INFO], [WARNING], [ERROR]) Empty if the level is not set.
+func LevelPrefix(level string) string {
+	switch level {
+	case "info":
+		return "[INFO]"
+	case "warn":
+		return "[WARNING]"
+	case "error":
+		return "[ERROR]"
+	}
+	return ""
+}
+
 // GetLogsTail function prints the logs of the BWC agent in a tailing manner.
 // Tailing means continuously outputting the stored logs.
 //
 // Input:
 //   - targetName: The name of the object whose logs should be printed.
-func GetLogsTail(targetName string) {
+//   - level: Print only the logs of this level. (info, warn, error / Empty prints all logs)
+func GetLogsTail(targetName string, level string) {
 	procLog.Info.Printf("Get tail logs.\n")
 	filePath := fmt.Sprintf("/etc/sdt/device.logs/%s.log", targetName)
 	if _, err := os.Stat(filePath); err != nil {
@@ -56,7 +82,11 @@ func GetLogsTail(targetName string) {
 	}
 
 	// 실시간으로 파일의 변경을 감지하여 출력
+	prefix := LevelPrefix(level)
 	for line := range t.Lines {
+		if prefix != "" && !strings.Contains(line.Text, prefix) {
+			continue
+		}
 		fmt.Println(line.Text)
 	}
 	procLog.Info.Printf("Successfully get tail logs.\n")
@@ -67,15 +97,25 @@ func GetLogsTail(targetName string) {
 // Input:
 //   - targetName: The name of the object whose logs should be printed.
 //   - numLine: The number of lines of logs to print.
-func GetLogs(targetName string, numLine int) {
+//   - level: Print only the logs of this level. (info, warn, error / Empty prints all logs)
+func GetLogs(targetName string, numLine int, level string) {
 	procLog.Info.Printf("Get logs.\n")
 	filePath := fmt.Sprintf("/etc/sdt/device.logs/%s.log", targetName)
 
-	content, err := ioutil.ReadFile(filePath)
+	content, err := os.ReadFile(filePath)
 	if err != nil {
 		procLog.Error.Printf("%s not found.\n", targetName)
 		return
 	}
+	if prefix := LevelPrefix(level); prefix != "" {
+		var filtered []string
+		for _, line := range strings.Split(string(content), "\n") {
+			if strings.Contains(line, prefix) {
+				filtered = append(filtered, line)
+			}
+		}
+		content = []byte(strings.Join(filtered, "\n"))
+	}
 	if numLine == 0 {
 		fmt.Println(string(content))
 	} else {
@@ -92,11 +132,16 @@ func GetLogs(targetName string, numLine int) {
 // The application can be installed either through execution tests or deployment.
 // Execution test logs are located in '/etc/sdt/execute'.
 // Logs of deployed applications are located in '/usr/local/sdt/app'.
+// If follow is true, new logs are printed and sent to SDT Cloud until SIGINT is received.
 //
 // Input:
 //   - appName: The name of the application.
 //   - appId: The ID of the application.
-func GetLogsApp(appName string, appId string) {
+//   - follow: Option to continue printing new logs.
+//   - since: Print only the logs newer than this. (Zero time prints all logs.)
+//   - lines: Print only the last lines. (0 prints all logs.)
+//   - configData: Struct containing configuration information for BWC.
+func GetLogsApp(appName string, appId string, follow bool, since time.Time, lines int, configData sdtType.ConfigInfo) {
 	procLog.Info.Printf("Get app logs.\n")
 	var fileName string
 	// Get appId.
@@ -108,6 +153,15 @@ func GetLogsApp(appName string, appId string) {
 		fileName = fmt.Sprintf("/usr/local/sdt/app/%s_%s/app-error.log", appName, appId)
 	}
 
+	if follow {
+		if runtime.GOOS == "linux" {
+			FollowLogsJournal(appName, configData)
+		} else {
+			FollowLogsFile(appName, fileName, configData)
+		}
+		return
+	}
+
 	// open logfile.
 	file, err := os.Open(fileName)
 	if err != nil {
@@ -116,6 +170,30 @@ func GetLogsApp(appName string, appId string) {
 	}
 	defer file.Close()
 
+	// Read from the end of logfile, so that only the requested logs are read.
+	if !since.IsZero() || lines > 0 {
+		var logLines []string
+		err = readLinesBackward(file, func(line string) bool {
+			if lines > 0 && len(logLines) >= lines {
+				return false
+			}
+			// Lines without timestamp (ex. traceback) are kept with the logs around them.
+			if lineTime, ok := parseLineTime(line); ok && !since.IsZero() && lineTime.Before(since) {
+				return false
+			}
+			logLines = append(logLines, line)
+			return true
+		})
+		if err != nil {
+			procLog.Error.Printf("Failed read logs: %s\n", err)
+			os.Exit(1)
+		}
+		for i := len(logLines) - 1; i >= 0; i-- {
+			fmt.Println(logLines[i])
+		}
+		return
+	}
+
 	// Print logfile.
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
@@ -128,3 +206,214 @@ func GetLogsApp(appName string, appId string) {
 		os.Exit(1)
 	}
 }
+
+// ParseSince function converts the value of --since to the time. The value is a duration
+// before now (ex. 30m, 1h) or an RFC3339 time (ex. 2024-05-01T09:00:00+09:00).
+//
+// Input:
+//   - since: Value of --since.
+//
+// Output:
+//   - time.Time: Time of the bound.
+//   - error: Error message if the value is not a duration or an RFC3339 time.
+func ParseSince(since string) (time.Time, error) {
+	if duration, err := time.ParseDuration(since); err == nil {
+		return time.Now().Add(-duration), nil
+	}
+	sinceTime, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s is not a duration (ex. 1h) or an RFC3339 time", since)
+	}
+	return sinceTime, nil
+}
+
+// logTimeLayouts are the timestamp layouts at the start of the app's log lines.
+// (RFC3339, Python logging, Go log) Layouts without zone are parsed in the local time.
+var logTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006/01/02 15:04:05",
+}
+
+// parseLineTime function parses the timestamp at the start of a log line.
+//
+// Input:
+//   - line: Log line.
+//
+// Output:
+//   - time.Time: Time of the log line.
+//   - bool: false if the line doesn't start with a timestamp.
+func parseLineTime(line string) (time.Time, bool) {
+	line = strings.TrimLeft(line, "[")
+	if field := strings.Fields(line); len(field) > 0 {
+		if lineTime, err := time.Parse(time.RFC3339Nano, strings.TrimRight(field[0], "]")); err == nil {
+			return lineTime, true
+		}
+	}
+	for _, layout := range logTimeLayouts {
+		if len(line) < len(layout) {
+			continue
+		}
+		if lineTime, err := time.ParseInLocation(layout, line[:len(layout)], time.Local); err == nil {
+			return lineTime, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// readLinesBackward function reads the file from the end and calls fn with each line,
+// the last line first. Reading stops when fn returns false.
+//
+// Input:
+//   - file: Opened file.
+//   - fn: Function called with each line.
+//
+// Output:
+//   - error: Error message if the file can't be read.
+func readLinesBackward(file *os.File, fn func(line string) bool) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	offset := info.Size()
+	buf := make([]byte, 4096)
+	var rest []byte
+	trailing := true
+	for offset > 0 {
+		size := int64(len(buf))
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+		if _, err := file.ReadAt(buf[:size], offset); err != nil && err != io.EOF {
+			return err
+		}
+
+		// The first line of the chunk can continue in the previous chunk.
+		chunk := append(append([]byte{}, buf[:size]...), rest...)
+		chunkLines := strings.Split(string(chunk), "\n")
+		rest = []byte(chunkLines[0])
+		for i := len(chunkLines) - 1; i >= 1; i-- {
+			// Skip the empty line after the last newline of the file.
+			if trailing && chunkLines[i] == "" {
+				trailing = false
+				continue
+			}
+			trailing = false
+			if !fn(strings.TrimRight(chunkLines[i], "\r")) {
+				return nil
+			}
+		}
+	}
+	if len(rest) > 0 {
+		fn(strings.TrimRight(string(rest), "\r"))
+	}
+	return nil
+}
+
+// FollowLogsFile function prints new lines of the app's log file and sends them to SDT Cloud.
+// The log file is read from its end, so only logs written after the call are printed.
+// The function returns when SIGINT is received.
+//
+// Input:
+//   - appName: The name of the application.
+//   - fileName: Path of the app's log file.
+//   - configData: Struct containing configuration information for BWC.
+func FollowLogsFile(appName string, fileName string, configData sdtType.ConfigInfo) {
+	procLog.Info.Printf("Follow %s app's log file: %s\n", appName, fileName)
+	file, err := os.Open(fileName)
+	if err != nil {
+		procLog.Error.Printf("Failed get logs: %s\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	_, err = file.Seek(0, io.SeekEnd)
+	if err != nil {
+		procLog.Error.Printf("Failed seek logs: %s\n", err)
+		os.Exit(1)
+	}
+
+	sdtMessage.SetMqttClient("/etc/sdt", configData)
+	defer sdtMessage.DisconnectMqtt()
+
+	stopchan := make(chan os.Signal, 1)
+	signal.Notify(stopchan, os.Interrupt)
+	defer signal.Stop(stopchan)
+
+	reader := bufio.NewReader(file)
+	var partial string
+	for {
+		select {
+		case <-stopchan:
+			procLog.Info.Printf("Stop following %s app's logs.\n", appName)
+			return
+		default:
+		}
+
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			// Keep the incomplete line until the rest is written.
+			partial += line
+			time.Sleep(500 * time.Millisecond)
+			continue
+		} else if err != nil {
+			procLog.Error.Printf("Failed read logs: %s\n", err)
+			return
+		}
+
+		line = strings.TrimRight(partial+line, "\r\n")
+		partial = ""
+		fmt.Println(line)
+		sdtMessage.SendLogs(configData, appName, line)
+	}
+}
+
+// FollowLogsJournal function prints new logs of the app's systemd service using journalctl
+// and sends them to SDT Cloud. The function returns when SIGINT is received.
+//
+// Input:
+//   - appName: The name of the application.
+//   - configData: Struct containing configuration information for BWC.
+func FollowLogsJournal(appName string, configData sdtType.ConfigInfo) {
+	procLog.Info.Printf("Follow %s app's journal.\n", appName)
+	cmd_run := exec.Command("journalctl", "-u", appName, "-f", "--output=short-unix")
+	stdout, err := cmd_run.StdoutPipe()
+	if err != nil {
+		procLog.Error.Printf("Failed get journal: %s\n", err)
+		os.Exit(1)
+	}
+	err = cmd_run.Start()
+	if err != nil {
+		procLog.Error.Printf("Failed start journalctl: %s\n", err)
+		os.Exit(1)
+	}
+
+	sdtMessage.SetMqttClient("/etc/sdt", configData)
+	defer sdtMessage.DisconnectMqtt()
+
+	stopchan := make(chan os.Signal, 1)
+	signal.Notify(stopchan, os.Interrupt)
+	defer signal.Stop(stopchan)
+
+	donechan := make(chan struct{})
+	go func() {
+		defer close(donechan)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			line := scanner.Text()
+			fmt.Println(line)
+			sdtMessage.SendLogs(configData, appName, line)
+		}
+	}()
+
+	select {
+	case <-stopchan:
+		procLog.Info.Printf("Stop following %s app's logs.\n", appName)
+		cmd_run.Process.Kill()
+		<-donechan
+	case <-donechan:
+	}
+	cmd_run.Wait()
+}
@@ -7,7 +7,6 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"time"
 
@@ -51,7 +50,7 @@ func Getlog(logConfig sdtType.Logger) {
 //   - *mqttCli.ClientOptions: Variable of type MQTT ClientOptions.
 func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey string) *mqttCli.ClientOptions {
 	// Load CA certificate
-	caCert, err := ioutil.ReadFile(rootCa)
+	caCert, err := os.ReadFile(rootCa)
 	if err != nil {
 		procLog.Error.Printf("Error reading CA certificate file: %v\n", err)
 	}
@@ -131,7 +130,7 @@ func sendDataEdgeMqtt(
 	payload map[string]interface{}, // Result of command
 	configData sdtType.ConfigInfo,
 ) {
-	topic := fmt.Sprintf("%s/%s/%s/bwc/control/self-deploy", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
+	topic := configData.Topic("bwc/control/self-deploy")
 
 	resultBody, err := json.Marshal(payload)
 	if err != nil {
@@ -144,6 +143,115 @@ func sendDataEdgeMqtt(
 	}
 }
 
+// SetMqttClient function connects to the MQTT broker based on the SDT Cloud service type of the device.
+//
+// Input:
+//   - rootPath: The root path of BWC.
+//   - configData: BWC Config information struct.
+func SetMqttClient(rootPath string, configData sdtType.ConfigInfo) {
+	// mqtt Setting key
+	var rootCa string
+	if configData.ServiceType == "onprem" {
+		rootCa = fmt.Sprintf("%s/cert/rootCa.pem", rootPath)
+	} else {
+		rootCa = fmt.Sprintf("%s/cert/AmazonRootCA1.pem", rootPath)
+	}
+	private := fmt.Sprintf("%s/cert/%s-private.pem", rootPath, configData.ProjectCode)
+	fullCertChain := fmt.Sprintf("%s/cert/%s-certificate.pem", rootPath, configData.ProjectCode)
+
+	if configData.ServiceType == "onprem" {
+		cli = connectToMqtt(configData)
+	} else if configData.ServiceType == "aws-dev" || configData.ServiceType == "eks" || configData.ServiceType == "dev" {
+		opts := createAwsClientOptions(configData.MqttUrl, rootCa, fullCertChain, private)
+		cli = mqttCli.NewClient(opts)
+	} else {
+		fmt.Printf("Please check 'servicetype' variable in BWC config file. \n")
+		os.Exit(1)
+	}
+
+	if token := cli.Connect(); token.Wait() && token.Error() != nil {
+		procLog.Error.Printf("Failed to connect to MQTT broker: %v\n", token.Error())
+	}
+}
+
+// DisconnectMqtt function disconnects the MQTT client connected by SetMqttClient.
+func DisconnectMqtt() {
+	if cli != nil && cli.IsConnected() {
+		cli.Disconnect(250)
+	}
+}
+
+// SendLogs function publishes a log line of the app to SDT Cloud. The MQTT client must be
+// connected by SetMqttClient before calling this function. The topic is as follows:
+//
+//	<serviceCode>/<projectCode>/<assetCode>/bwc/logs/<appName>
+//
+// Input:
+//   - configData: BWC Config information struct.
+//   - appName: Name of the app.
+//   - line: Log line of the app.
+func SendLogs(configData sdtType.ConfigInfo, appName string, line string) {
+	topic := configData.Topic(fmt.Sprintf("bwc/logs/%s", appName))
+
+	payload := map[string]interface{}{
+		"assetCode": configData.AssetCode,
+		"appName":   appName,
+		"log":       line,
+		"createdAt": int64(time.Now().UTC().Unix() * 1000),
+	}
+	resultBody, err := json.Marshal(payload)
+	if err != nil {
+		procLog.Error.Printf("Marshal error: %v\n", err)
+		return
+	}
+	pub_token := cli.Publish(topic, 0, false, resultBody)
+
+	if pub_token.Wait() && pub_token.Error() != nil {
+		procLog.Error.Printf("Send to mqtt error: %v\n", pub_token.Error())
+	}
+}
+
+// SendAgentRestart function sends the result of restarting a BWC agent to SDT Cloud.
+// The topic is as follows:
+//
+//	<serviceCode>/<projectCode>/<assetCode>/bwc/agent/restart
+//
+// Input:
+//   - rootPath: The root path of BWC.
+//   - configData: BWC Config information struct.
+//   - agentName: Name of the restarted BWC agent.
+//   - state: State of the agent after the restart.
+//   - errData: Error message of the restart.
+func SendAgentRestart(rootPath string, configData sdtType.ConfigInfo, agentName string, state string, errData error) {
+	SetMqttClient(rootPath, configData)
+	defer cli.Disconnect(250)
+
+	succeed := 1
+	errMessage := ""
+	if errData != nil {
+		succeed = 0
+		errMessage = fmt.Sprintf("%v", errData)
+	}
+	payload := map[string]interface{}{
+		"assetCode":   configData.AssetCode,
+		"agentName":   agentName,
+		"state":       state,
+		"succeed":     succeed,
+		"errMsg":      errMessage,
+		"restartedAt": int64(time.Now().UTC().Unix() * 1000),
+	}
+	resultBody, err := json.Marshal(payload)
+	if err != nil {
+		procLog.Error.Printf("Marshal error: %v\n", err)
+		return
+	}
+	pub_token := cli.Publish(configData.Topic("bwc/agent/restart"), 0, false, resultBody)
+
+	if pub_token.Wait() && pub_token.Error() != nil {
+		procLog.Error.Printf("Send to mqtt error: %v\n", pub_token.Error())
+	}
+}
+
 // CheckResult function generates a result message after executing a control command to be sent to the cloud.
 // Below is the format of the message:
 //
@@ -216,30 +324,7 @@ func SendResult(
 	var errMessage string
 
 	// Set mqtt
-	// mqtt Setting key
-	var rootCa string
-	if configData.ServiceType == "onprem" {
-		rootCa = fmt.Sprintf("%s/cert/rootCa.pem", rootPath)
-	} else {
-		rootCa = fmt.Sprintf("%s/cert/AmazonRootCA1.pem", rootPath)
-	}
-	private := fmt.Sprintf("%s/cert/%s-private.pem", rootPath, configData.ProjectCode)
-	fullCertChain := fmt.Sprintf("%s/cert/%s-certificate.pem", rootPath, configData.ProjectCode)
-
-	if configData.ServiceType == "onprem" {
-		cli = connectToMqtt(configData)
-	} else if configData.ServiceType == "aws-dev" || configData.ServiceType == "eks" || configData.ServiceType == "dev" {
-		opts := createAwsClientOptions(configData.MqttUrl, rootCa, fullCertChain, private)
-		cli = mqttCli.NewClient(opts)
-	} else {
-		fmt.Printf("Please check 'servicetype' variable in BWC config file. \n")
-		os.Exit(1)
-	}
-
-	if token := cli.Connect(); token.Wait() && token.Error() != nil {
-		procLog.Error.Printf("Failed to connect to MQTT broker: %v\n", token.Error())
-	}
-
+	SetMqttClient(rootPath, configData)
 	defer cli.Disconnect(250)
 
 	if errData == nil {
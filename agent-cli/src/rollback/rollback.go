@@ -0,0 +1,156 @@
+// The rollback package restores the previous version of a deployed app. When an app is
+// updated, the replaced app directory is kept as '<appPath>/<appName>_<appId>.bak'.
+// 'bwc rollback app' stops the app, swaps the app directory with the backup and
+// starts the app again. Only one backup is kept per app; it is replaced by the next
+// successful update and removed when the app is deleted or deployed again.
+package rollback
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	sdtType "main/src/cliType"
+)
+
+// These are the global variables used in the Rollback package.
+// - procLog: This is the struct that defines the format of the log.
+var procLog sdtType.Logger
+
+// Getlog is a function that loads the log format. Log formats are defined as Info,
+// Warn, Error, and are output using Printf.
+// Here's how you would record the text "Hello World" as an Info log type:
+//
+//	procLog.Info.Printf("Hello World\n")   ->   [INFO] Hello World
+func Getlog(logConfig sdtType.Logger) {
+	procLog = logConfig
+}
+
+// BackupDir function returns the path of the app's backup directory.
+//
+// Input:
+//   - appDir: Path of the deployed app.
+//
+// Output:
+//   - string: Path of the backup directory. ('<appDir>.bak')
+func BackupDir(appDir string) string {
+	return fmt.Sprintf("%s.bak", appDir)
+}
+
+// HasBackup function checks that the app has a backup directory.
+//
+// Input:
+//   - appDir: Path of the deployed app.
+//
+// Output:
+//   - bool: true (backup exists) or false (no backup)
+func HasBackup(appDir string) bool {
+	info, err := os.Stat(BackupDir(appDir))
+	return err == nil && info.IsDir()
+}
+
+// SaveBackup function keeps the directory replaced by an update as the app's backup.
+// The previous backup is removed.
+//
+// Input:
+//   - oldDir: Directory of the replaced version. (ex. '<appDir>_old' of SwitchAppDir)
+//   - appDir: Path of the deployed app.
+//
+// Output:
+//   - error: Error message if SaveBackup command encounters an issue.
+func SaveBackup(oldDir string, appDir string) error {
+	backupDir := BackupDir(appDir)
+	procLog.Info.Printf("Save backup of app: %s\n", backupDir)
+	if err := os.RemoveAll(backupDir); err != nil {
+		procLog.Error.Printf("Failed remove old backup: %v\n", err)
+		return err
+	}
+	if err := os.Rename(oldDir, backupDir); err != nil {
+		procLog.Error.Printf("Failed save backup: %v\n", err)
+		return err
+	}
+	return nil
+}
+
+// RemoveBackups function removes all backups of the app. It is used when the app is
+// deleted or deployed again with a new app ID.
+//
+// Input:
+//   - appPath: Path where apps are installed.
+//   - appName: Name of the app.
+func RemoveBackups(appPath string, appName string) {
+	// The app ID is a UUID, so the backups of other apps with the same prefix are not matched.
+	backupDirs, _ := filepath.Glob(fmt.Sprintf("%s/%s_????????-????-????-????-????????????.bak", appPath, appName))
+	for _, backupDir := range backupDirs {
+		procLog.Info.Printf("Remove backup of app: %s\n", backupDir)
+		if err := os.RemoveAll(backupDir); err != nil {
+			procLog.Error.Printf("Failed remove backup: %v\n", err)
+		}
+	}
+}
+
+// SwapBackup function swaps the app directory with the backup directory. The current
+// version becomes the backup, so calling SwapBackup again undoes the rollback.
+// If the swap fails, the app directory is left as it was.
+//
+// Input:
+//   - appDir: Path of the deployed app.
+//
+// Output:
+//   - error: Error message if SwapBackup command encounters an issue.
+func SwapBackup(appDir string) error {
+	backupDir := BackupDir(appDir)
+	swapDir := fmt.Sprintf("%s_rollback", appDir)
+	procLog.Info.Printf("Swap app directory with backup: %s\n", appDir)
+
+	if !HasBackup(appDir) {
+		return fmt.Errorf("backup not found: %s", backupDir)
+	}
+	os.RemoveAll(swapDir)
+
+	if err := os.Rename(appDir, swapDir); err != nil {
+		procLog.Error.Printf("Failed move current version: %v\n", err)
+		return err
+	}
+	if err := os.Rename(backupDir, appDir); err != nil {
+		procLog.Error.Printf("Failed restore backup: %v\n", err)
+		os.Rename(swapDir, appDir)
+		return err
+	}
+	if err := os.Rename(swapDir, backupDir); err != nil {
+		procLog.Error.Printf("Failed keep current version as backup: %v\n", err)
+		return err
+	}
+
+	procLog.Info.Printf("Successfully swap app directory.\n")
+	return nil
+}
+
+// GetFramework function reads the framework file (framework.json or framework.yaml)
+// of the deployed app.
+//
+// Input:
+//   - appDir: Path of the deployed app.
+//
+// Output:
+//   - sdtType.Framework: Framework struct of the app.
+//   - error: Error message if the framework file can't be read.
+func GetFramework(appDir string) (sdtType.Framework, error) {
+	var bwcFramework sdtType.Framework
+
+	jsonFile, err := os.ReadFile(fmt.Sprintf("%s/framework.json", appDir))
+	if err == nil {
+		err = json.Unmarshal(jsonFile, &bwcFramework)
+		return bwcFramework, err
+	}
+
+	yamlFile, err := os.ReadFile(fmt.Sprintf("%s/framework.yaml", appDir))
+	if err != nil {
+		return bwcFramework, fmt.Errorf("framework file not found in %s", appDir)
+	}
+	err = yaml.Unmarshal(yamlFile, &bwcFramework)
+	return bwcFramework, err
+}
@@ -1,14 +1,17 @@
-// The update package handles updating Python virtual environments. If additional
-// Python packages need to be installed in the virtual environment, update manages
-// the installation process.
+// The update package handles updating Python virtual environments and deployed apps.
+// If additional Python packages need to be installed in the virtual environment, update
+// manages the installation process. Apps are updated in place by switching the app's
+// directory to a staged new version.
 package update
 
 import (
+	"errors"
 	"fmt"
 	sdtType "main/src/cliType"
 	sdtUtil "main/src/util"
 	"os"
 	"os/exec"
+	"time"
 )
 
 // These are the global variables used in the Update package.
@@ -51,3 +54,116 @@ func UpdateVenv(bwcFramework sdtType.Framework, envHome string, dirPath string)
 	sdtUtil.CopyFile(pkgFileName, destPath)
 	procLog.Info.Printf("Successfully update venv.\n")
 }
+
+// SwitchAppDir function switches the app's directory to the staged new version.
+// The live directory is kept as '<appDir>_old' and the systemd unit file as '<appDir>_old.service'
+// so that they can be restored, and the staging directory '<appDir>_new' is renamed to the
+// live directory.
+//
+// Input:
+//   - appDir: Path of the deployed app.
+//   - unitFile: Path of the app's systemd unit file. (ex. /etc/systemd/system/<appName>.service)
+//
+// Output:
+//   - error: Error message if SwitchAppDir command encounters an issue.
+func SwitchAppDir(appDir string, unitFile string) error {
+	procLog.Info.Printf("Switch app directory to new version: %s\n", appDir)
+	stagingDir := fmt.Sprintf("%s_new", appDir)
+	backupDir := fmt.Sprintf("%s_old", appDir)
+	backupUnit := fmt.Sprintf("%s_old.service", appDir)
+
+	if _, err := os.Stat(stagingDir); err != nil {
+		procLog.Error.Printf("Staging directory not found: %v\n", err)
+		return err
+	}
+	os.RemoveAll(backupDir)
+	os.Remove(backupUnit)
+
+	// The app run without systemd has no unit file.
+	if _, err := os.Stat(unitFile); err == nil {
+		err = sdtUtil.CopyFile(unitFile, backupUnit)
+		if err != nil {
+			procLog.Error.Printf("Failed backup unit file: %v\n", err)
+			return err
+		}
+	}
+
+	err := os.Rename(appDir, backupDir)
+	if err != nil {
+		procLog.Error.Printf("Failed backup app directory: %v\n", err)
+		return err
+	}
+	err = os.Rename(stagingDir, appDir)
+	if err != nil {
+		procLog.Error.Printf("Failed switch app directory: %v\n", err)
+		os.Rename(backupDir, appDir)
+		return err
+	}
+
+	procLog.Info.Printf("Successfully switch app directory.\n")
+	return nil
+}
+
+// RestoreAppDir function restores the app's directory and systemd unit file kept by SwitchAppDir.
+// The failed new version is removed, and systemd reloads the restored unit file.
+//
+// Input:
+//   - appDir: Path of the deployed app.
+//   - unitFile: Path of the app's systemd unit file. (ex. /etc/systemd/system/<appName>.service)
+//
+// Output:
+//   - error: Error message if RestoreAppDir command encounters an issue.
+func RestoreAppDir(appDir string, unitFile string) error {
+	procLog.Warn.Printf("Restore app directory to old version: %s\n", appDir)
+	backupDir := fmt.Sprintf("%s_old", appDir)
+	backupUnit := fmt.Sprintf("%s_old.service", appDir)
+
+	err := os.RemoveAll(appDir)
+	if err != nil {
+		procLog.Error.Printf("Failed remove new version: %v\n", err)
+		return err
+	}
+	err = os.Rename(backupDir, appDir)
+	if err != nil {
+		procLog.Error.Printf("Failed restore app directory: %v\n", err)
+		return err
+	}
+
+	if _, err := os.Stat(backupUnit); err == nil {
+		// The app directory and /etc/systemd/system can be on different file systems.
+		err = sdtUtil.CopyFile(backupUnit, unitFile)
+		if err != nil {
+			procLog.Error.Printf("Failed restore unit file: %v\n", err)
+			return err
+		}
+		os.Remove(backupUnit)
+		stdout, err := exec.Command("systemctl", "daemon-reload").CombinedOutput()
+		if err != nil {
+			procLog.Error.Printf("Failed reload systemd: %s\n", stdout)
+		}
+	}
+
+	procLog.Info.Printf("Successfully restore app directory.\n")
+	return nil
+}
+
+// WaitAppPid function waits until the app's service has a PID.
+//
+// Input:
+//   - appName: Name of the app.
+//   - timeout: Maximum time to wait. (second)
+//
+// Output:
+//   - int: PID of the app.
+//   - error: Error message if the app is not running within the timeout.
+func WaitAppPid(appName string, timeout int) (int, error) {
+	procLog.Info.Printf("Wait %s app's pid.\n", appName)
+	for i := 0; i < timeout; i++ {
+		pid, err := sdtUtil.GetPid(appName)
+		if err == nil && pid > 0 {
+			return pid, nil
+		}
+		time.Sleep(time.Second)
+	}
+	return -1, errors.New(fmt.Sprintf("%s app not running in %d seconds", appName, timeout))
+}
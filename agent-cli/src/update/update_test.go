@@ -0,0 +1,89 @@
+package update
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdtType "main/src/cliType"
+	sdtUtil "main/src/util"
+	sdtLog "pkg/log"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSwitchAndRestoreAppDir(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	sdtUtil.Getlog(procLog)
+	dir := t.TempDir()
+	appDir := filepath.Join(dir, "app", "web_app-1")
+	unitFile := filepath.Join(dir, "systemd", "web.service")
+	writeFile(t, filepath.Join(appDir, "main.py"), "old")
+	writeFile(t, filepath.Join(appDir+"_new", "main.py"), "new")
+	writeFile(t, unitFile, "old unit")
+
+	if err := SwitchAppDir(appDir, unitFile); err != nil {
+		t.Fatalf("SwitchAppDir() = %v", err)
+	}
+	if got := readFile(t, filepath.Join(appDir, "main.py")); got != "new" {
+		t.Errorf("main.py after switch = %q, want new", got)
+	}
+
+	// The new version writes its own unit file, and fails to start.
+	writeFile(t, unitFile, "new unit")
+	if err := RestoreAppDir(appDir, unitFile); err != nil {
+		t.Fatalf("RestoreAppDir() = %v", err)
+	}
+	if got := readFile(t, filepath.Join(appDir, "main.py")); got != "old" {
+		t.Errorf("main.py after restore = %q, want old", got)
+	}
+	if got := readFile(t, unitFile); got != "old unit" {
+		t.Errorf("unit file after restore = %q, want old unit", got)
+	}
+	for _, path := range []string{appDir + "_old", appDir + "_old.service"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s is not removed: %v", path, err)
+		}
+	}
+}
+
+func TestSwitchAppDirWithoutUnit(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	sdtUtil.Getlog(procLog)
+	dir := t.TempDir()
+	appDir := filepath.Join(dir, "web_app-1")
+	unitFile := filepath.Join(dir, "web.service")
+	writeFile(t, filepath.Join(appDir, "main.py"), "old")
+	writeFile(t, filepath.Join(appDir+"_new", "main.py"), "new")
+
+	// The app run without systemd has no unit file.
+	if err := SwitchAppDir(appDir, unitFile); err != nil {
+		t.Fatalf("SwitchAppDir() = %v", err)
+	}
+	if err := RestoreAppDir(appDir, unitFile); err != nil {
+		t.Fatalf("RestoreAppDir() = %v", err)
+	}
+	if got := readFile(t, filepath.Join(appDir, "main.py")); got != "old" {
+		t.Errorf("main.py after restore = %q, want old", got)
+	}
+	if _, err := os.Stat(unitFile); !os.IsNotExist(err) {
+		t.Errorf("unit file is created: %v", err)
+	}
+}
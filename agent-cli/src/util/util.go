@@ -2,6 +2,7 @@
 package util
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -9,8 +10,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 )
 
 // These are the global variables used in the util package.
@@ -160,6 +164,10 @@ func GetDirectorySize(path string) (int64, error) {
 //   - error: Error message if GetPid command encounters an issue.
 func GetPid(appName string) (int, error) {
 	procLog.Info.Printf("Get %s app's pid.\n", appName)
+	// The app is run without systemd. (bwc deploy app --no-systemd)
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return GetDirectPid(appName)
+	}
 	getPid := fmt.Sprintf("systemctl show --property MainPID %s", appName)
 	cmd_run := exec.Command("sh", "-c", getPid)
 	stdout1, err := cmd_run.Output()
@@ -186,6 +194,217 @@ func GetPid(appName string) (int, error) {
 	return pid, err
 }
 
+// GetAppInfo function retrieves the metadata of the app saved in app.json.
+//
+// Input:
+//   - appName: The name of the application.
+//
+// Output:
+//   - sdtType.AppInfo: Metadata of the application. (AppId, AppVenv, Managed)
+//   - error: Error message if app.json can't be read or the app is not found.
+func GetAppInfo(appName string) (sdtType.AppInfo, error) {
+	jsonFile, err := os.ReadFile("/etc/sdt/device.config/app.json")
+	if err != nil {
+		procLog.Error.Printf("Failed load app's file: %v\n", err)
+		return sdtType.AppInfo{}, err
+	}
+	var jsonData sdtType.AppConfig
+	err = json.Unmarshal(jsonFile, &jsonData)
+	if err != nil {
+		procLog.Error.Printf("Failed app's Unmarshal: %v\n", err)
+		return sdtType.AppInfo{}, err
+	}
+	// The last entry is the most recently deployed one, if app.json has duplicates.
+	for i := len(jsonData.AppInfoList) - 1; i >= 0; i-- {
+		if jsonData.AppInfoList[i].AppName == appName {
+			return jsonData.AppInfoList[i], nil
+		}
+	}
+	return sdtType.AppInfo{}, fmt.Errorf("%s app not found", appName)
+}
+
+// DirectPidFile function returns the path of the '.pid' file of an application run
+// without systemd.
+//
+// Input:
+//   - appName: The name of the application.
+//   - appId: The ID of the application.
+//
+// Output:
+//   - string: Path of the '.pid' file in the app directory.
+func DirectPidFile(appName string, appId string) string {
+	return fmt.Sprintf("/usr/local/sdt/app/%s_%s/.pid", appName, appId)
+}
+
+// GetDirectPid function retrieves the PID of an application run without systemd.
+// The PID is read from the '.pid' file in the app directory of the appId in app.json.
+//
+// Input:
+//   - appName: The name of the application.
+//
+// Output:
+//   - int: The PID (Process ID) of the application.
+//   - error: Error message if the pid file not found or the process is not running.
+func GetDirectPid(appName string) (int, error) {
+	appInfo, err := GetAppInfo(appName)
+	if err != nil {
+		return -1, err
+	}
+	return ReadDirectPid(DirectPidFile(appName, appInfo.AppId))
+}
+
+// ReadDirectPid function reads the PID in the '.pid' file and checks that the process is running.
+//
+// Input:
+//   - pidFile: Path of the '.pid' file.
+//
+// Output:
+//   - int: The PID (Process ID) of the application.
+//   - error: Error message if the pid file not found or the process is not running.
+func ReadDirectPid(pidFile string) (int, error) {
+	pidStr, err := os.ReadFile(pidFile)
+	if err != nil {
+		procLog.Error.Printf("Failed read pid file: %v\n", err)
+		return -1, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(pidStr)))
+	if err != nil {
+		procLog.Error.Printf("Failed convert pid (string -> int) error:  %v\n", err)
+		return -1, err
+	}
+
+	// Check process is running.
+	process, err := os.FindProcess(pid)
+	if err == nil {
+		err = process.Signal(syscall.Signal(0))
+	}
+	if err != nil {
+		procLog.Error.Printf("Process %d not found.\n", pid)
+		return -1, errors.New("App not found")
+	}
+	return pid, nil
+}
+
+// GetSystemdState function retrieves the systemd state of an application.
+// The state is combined with ActiveState and SubState. (e.g. "active/running", "failed/failed", "activating/start")
+//
+// Input:
+//   - appName: The name of the application.
+//
+// Output:
+//   - string: The systemd state of the application.
+//   - error: Error message if systemctl command encounters an issue.
+func GetSystemdState(appName string) (string, error) {
+	procLog.Info.Printf("Get %s app's systemd state.\n", appName)
+	cmd_run := exec.Command("systemctl", "show", "--property", "ActiveState,SubState", appName)
+	stdout, err := cmd_run.Output()
+	if err != nil {
+		procLog.Error.Printf("Failed get systemd state: %v\n", err)
+		return "", err
+	}
+
+	var activeState, subState string
+	for _, line := range strings.Split(string(stdout), "\n") {
+		if val, ok := strings.CutPrefix(line, "ActiveState="); ok {
+			activeState = strings.TrimSpace(val)
+		} else if val, ok := strings.CutPrefix(line, "SubState="); ok {
+			subState = strings.TrimSpace(val)
+		}
+	}
+	if activeState == "" {
+		return "", errors.New("systemd state not found")
+	}
+	return fmt.Sprintf("%s/%s", activeState, subState), nil
+}
+
+// GetWinServiceState function retrieves the Windows Service state of an application
+// using 'sc query'. (e.g. "running", "stopped", "start_pending")
+//
+// Input:
+//   - svcName: The name of the Windows Service. ({appName}_{appId})
+//
+// Output:
+//   - string: The Windows Service state of the application.
+//   - error: Error message if sc command encounters an issue.
+func GetWinServiceState(svcName string) (string, error) {
+	procLog.Info.Printf("Get %s service state.\n", svcName)
+	cmd_run := exec.Command("sc", "query", svcName)
+	stdout, err := cmd_run.Output()
+	if err != nil {
+		procLog.Error.Printf("Failed get service state: %v\n", err)
+		return "", err
+	}
+
+	// STATE              : 4  RUNNING
+	for _, line := range strings.Split(string(stdout), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 4 && fields[0] == "STATE" {
+			return strings.ToLower(fields[3]), nil
+		}
+	}
+	return "", errors.New("service state not found")
+}
+
+// winAgentServices is the Windows Service names of the BWC agents.
+var winAgentServices = map[string]string{
+	"device-control":   "SDTCloud DeviceControl",
+	"device-health":    "SDTCloud DeviceHealth",
+	"device-heartbeat": "SDTCloud DeviceHeartbeat",
+	"process-checker":  "SDTCloud ProcessChecker",
+}
+
+// RestartAgent function restarts a BWC agent. On linux, 'systemctl restart' is used,
+// and on windows, the Windows Service is stopped and started with 'sc'.
+// After the restart, the state of the agent is checked for up to 10 seconds.
+//
+// Input:
+//   - agentName: Name of the BWC agent. (ex. device-health)
+//
+// Output:
+//   - string: State of the agent after the restart. ("active/running" or "running" if succeeded)
+//   - error: Error message if the restart fails or the agent does not reach the running state.
+func RestartAgent(agentName string) (string, error) {
+	procLog.Info.Printf("Restart %s agent.\n", agentName)
+	var state, runningState string
+	var err error
+
+	if runtime.GOOS == "windows" {
+		svcName, ok := winAgentServices[agentName]
+		if !ok {
+			return "", fmt.Errorf("%s is not supported on windows", agentName)
+		}
+		exec.Command("sc", "stop", svcName).Run()
+		stdout, err := exec.Command("sc", "start", svcName).CombinedOutput()
+		if err != nil {
+			procLog.Error.Printf("Failed restart %s: %s\n", agentName, stdout)
+			return "", errors.New(string(stdout))
+		}
+		runningState = "running"
+	} else {
+		stdout, err := exec.Command("systemctl", "restart", agentName).CombinedOutput()
+		if err != nil {
+			procLog.Error.Printf("Failed restart %s: %s\n", agentName, stdout)
+			return "", errors.New(string(stdout))
+		}
+		runningState = "active/running"
+	}
+
+	// Wait for the running state. (10 sec)
+	for i := 0; i < 10; i++ {
+		time.Sleep(1 * time.Second)
+		if runtime.GOOS == "windows" {
+			state, err = GetWinServiceState(winAgentServices[agentName])
+		} else {
+			state, err = GetSystemdState(agentName)
+		}
+		if err == nil && state == runningState {
+			procLog.Info.Printf("Successfully restart %s agent.\n", agentName)
+			return state, nil
+		}
+	}
+	return state, fmt.Errorf("%s did not reach %s state (current: %s)", agentName, runningState, state)
+}
+
 func GetJournalCtl(appName string) string {
 	cmd_log := exec.Command("journalctl", "-u", appName, "-n", "30")
 	stdout, _ := cmd_log.Output()
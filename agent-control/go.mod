@@ -9,6 +9,7 @@ require (
 	github.com/docker/go-connections v0.4.0
 	github.com/eclipse/paho.mqtt.golang v1.4.3
 	github.com/go-playground/validator/v10 v10.15.3
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
 	github.com/streadway/amqp v1.1.0
 	gopkg.in/yaml.v3 v3.0.1
 )
@@ -27,21 +28,17 @@ require (
 	github.com/goccy/go-json v0.10.3 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/golang/snappy v0.0.4 // indirect
-	github.com/google/uuid v1.6.0 // indirect
 	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/klauspost/compress v1.17.9 // indirect
 	github.com/klauspost/cpuid/v2 v2.2.8 // indirect
 	github.com/leodido/go-urn v1.2.4 // indirect
 	github.com/lestrrat-go/file-rotatelogs v2.4.0+incompatible // indirect
 	github.com/lestrrat-go/strftime v1.0.6 // indirect
-	github.com/mholt/archiver v3.1.1+incompatible // indirect
 	github.com/minio/md5-simd v1.1.2 // indirect
-	github.com/minio/minio-go/v7 v7.0.73 // indirect
 	github.com/moby/term v0.5.0 // indirect
 	github.com/morikuni/aec v1.0.0 // indirect
 	github.com/nwaples/rardecode v1.1.3 // indirect
 	github.com/opencontainers/go-digest v1.0.0 // indirect
-	github.com/opencontainers/image-spec v1.0.2 // indirect
 	github.com/pierrec/lz4 v2.6.1+incompatible // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/rs/xid v1.5.0 // indirect
@@ -54,9 +51,31 @@ require (
 	golang.org/x/mod v0.17.0 // indirect
 	golang.org/x/net v0.26.0 // indirect
 	golang.org/x/sync v0.7.0 // indirect
-	golang.org/x/sys v0.21.0 // indirect
-	golang.org/x/text v0.16.0 // indirect
 	golang.org/x/time v0.3.0 // indirect
 	golang.org/x/tools v0.21.1-0.20240508182429-e35e4ccd0d2d // indirect
 	gotest.tools/v3 v3.5.0 // indirect
 )
+
+require pkg/log v0.0.0
+
+replace pkg/log => ../pkg/log
+
+require pkg/mqttutil v0.0.0
+
+replace pkg/mqttutil => ../pkg/mqttutil
+
+require pkg/configlock v0.0.0
+
+replace pkg/configlock => ../pkg/configlock
+
+require (
+	github.com/google/uuid v1.6.0
+	github.com/mholt/archiver v3.1.1+incompatible
+	github.com/minio/minio-go/v7 v7.0.73
+	github.com/opencontainers/image-spec v1.0.2
+	golang.org/x/sys v0.21.0
+	golang.org/x/text v0.16.0
+	pkg/winservice v0.0.0
+)
+
+replace pkg/winservice => ../pkg/winservice
@@ -20,8 +20,6 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"log"
 	"net/http"
 	"os"
 	"os/exec"
@@ -32,13 +30,16 @@ import (
 
 	dockerCli "github.com/docker/docker/client"
 	mqttCli "github.com/eclipse/paho.mqtt.golang"
+	sdtAudit "main/src/audit"
 	sdtConfig "main/src/config"
 	sdtControl "main/src/control"
 	sdtType "main/src/controlType"
 	sdtDeploy "main/src/deploy"
 	sdtDocker "main/src/docker"
+	sdtHealthz "main/src/healthz"
 	sdtMessage "main/src/message"
 	sdtModel "main/src/model"
+	sdtLog "pkg/log"
 )
 
 // Global variables used in the Device-Control package.
@@ -90,13 +91,13 @@ func SubMessage(client mqttCli.Client, msg mqttCli.Message) {
 			"requestId": m.RequestId,
 			"message":   "ok",
 		}
-		sdtMessage.SendAckMessage(ackMsg, cli, configData.ProjectCode, configData.AssetCode, configData.ServiceCode)
+		sdtMessage.SendAckMessage(ackMsg, cli, configData)
 		procLog.Info.Printf("[MQTT] Send ACK message. %s\n", ackMsg)
 
 		result, configResult = sdtControl.Control(svcInfo, configData, m, dockerClient, systemArch, systemHome, cli)
 
 		// MQTT Pub
-		topic = fmt.Sprintf("%s/%s/%s/bwc/control/response", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
+		topic = configData.Topic("bwc/control/response")
 		// DEBUG
 		//topic = fmt.Sprintf("%s/%s/%s/debug", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
 
@@ -120,10 +121,13 @@ func SubMessage(client mqttCli.Client, msg mqttCli.Message) {
 // The way to log the text "Hello World" as an Info log type is shown below.
 // procLog.Info.Printf("Hello World\n")
 // Output: [INFO] Hello World
-func initError(logFile io.Writer) {
-	procLog.Info = log.New(logFile, "[INFO] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Warn = log.New(logFile, "[WARNING] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Error = log.New(logFile, "[ERROR] ", log.Ldate|log.Ltime|log.Lshortfile)
+//
+// Logs below logLevel (loglevel in config.json) are discarded.
+// If logFormat is "json", each log is written as a JSON line. (See pkg/log NewJSONLogger)
+func initError(logFile io.Writer, logLevel string, logFormat string) {
+	procLog.Info = sdtLog.NewLogger(logFile, "info", logLevel, logFormat)
+	procLog.Warn = sdtLog.NewLogger(logFile, "warn", logLevel, logFormat)
+	procLog.Error = sdtLog.NewLogger(logFile, "error", logLevel, logFormat)
 }
 
 // This function is the main operation function of device control. It selects the MQTT broker
@@ -140,9 +144,12 @@ func RunBody() {
 	commonPythonPath := svcInfo.CommonPythonPath
 	//appPath := svcInfo.AppPath
 
+	// MQTT credentials can be overridden by environment variables.
+	sdtMessage.SetMqttCredential(os.Getenv("MQTT_USER"), os.Getenv("MQTT_PASSWORD"))
+
 	// var configData sdtType.ConfigInfo
 	jsonFilePath := fmt.Sprintf("%s/device.config/config.json", rootPath)
-	jsonFile, err := ioutil.ReadFile(jsonFilePath)
+	jsonFile, err := os.ReadFile(jsonFilePath)
 	if err != nil {
 		procLog.Error.Printf("[MAIN] %d Not found file Error: %v\n", err, time.Now())
 		panic(err)
@@ -175,6 +182,8 @@ func RunBody() {
 	} else {
 		procLog.Error.Printf("%s not supported. Please check your service.\n", configData.ServiceType)
 	}
+	svcInfo.MinioAccessKey = configData.MinioAccessKey
+	svcInfo.MinioSecretKey = configData.MinioSecretKey
 
 	// Set username
 	if systemHome == "" {
@@ -229,7 +238,7 @@ func RunBody() {
 			RequestId: configData.RequestId,
 		}
 
-		rebootTopic := fmt.Sprintf("%s/%s/%s/bwc/control/response", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
+		rebootTopic := configData.Topic("bwc/control/response")
 		sdtMessage.SendDataEdgeMqtt(rebootMsg, rebootTopic, cli)
 
 		// 메시지 보낸 후, 상태값 변경
@@ -318,17 +327,22 @@ func RunBody() {
 	procLog.Info.Printf("[SUJUNE] Version Info: %s\n", runtimeResult)
 
 	procLog.Info.Printf("[MAIN] Send runtime info: %s\n", runtimeList)
-	runtimeTopic := fmt.Sprintf("%s/%s/%s/bwc/control/runtime", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
+	runtimeTopic := configData.Topic("bwc/control/runtime")
 	sdtMessage.SendDataEdgeMqttInterface(runtimeResult, runtimeTopic, cli)
 
 	// Set subscribe mqtt
-	topic := fmt.Sprintf("%s/%s/%s/bwc/control/request", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
+	topic := configData.Topic("bwc/control/request")
 	token := cli.Subscribe(topic, 0, SubMessage)
 
 	if token.Wait() && token.Error() != nil {
 		procLog.Error.Printf("[MAIN] %v \n", token.Error())
 		os.Exit(1)
 	}
+	sdtMessage.SetOnReconnect(func() {
+		if token := cli.Subscribe(topic, 0, SubMessage); token.Wait() && token.Error() != nil {
+			procLog.Error.Printf("[MAIN] Subscribe again Error: %v \n", token.Error())
+		}
+	})
 
 	select {
 	case <-stopchan:
@@ -347,13 +361,17 @@ func RunBody() {
 //   - - exmq: EXMQ
 //   - arch: Architecture of the device.
 //   - home: Hostname of the device.
+//   - healthz-port: Port of the health endpoints(/healthz, /readyz). (0 is disabled)
 func main() {
 	// Set parameter
-	var mqttType, archType, rootPath, minicondaPath, commonPythonPath, appPath, venvPath, home string
+	var mqttType, archType, rootPath, minicondaPath, commonPythonPath, appPath, venvPath, home, logFormat string
+	var healthzPort int
 	var baseCmd [2]string
 	flag.StringVar(&mqttType, "mqtt", "", "Please input mqtt type(mosq? or aws? or exmq?)")
 	flag.StringVar(&archType, "arch", "", "Please input architecture type(amd? or arm? or win?)")
 	flag.StringVar(&home, "home", "", "Please input home's name.")
+	flag.IntVar(&healthzPort, "healthz-port", 0, "Please input port of health endpoint.(0 is disabled)")
+	flag.StringVar(&logFormat, "log-format", "text", "Please input log format(text or json)")
 	flag.Parse()
 	systemArch = archType
 	systemHome = home
@@ -390,12 +408,13 @@ func main() {
 
 	// Set logger
 	logFilePath := fmt.Sprintf("%s/device.logs/device-control.log", rootPath)
-	logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY|os.O_SYNC, 0644)
+	logFile, err := sdtLog.NewRotateWriter(logFilePath, sdtLog.GetRotateConfig(fmt.Sprintf("%s/device.config/config.json", rootPath)))
 	if err != nil {
 		panic(err)
 	}
 	defer logFile.Close()
-	initError(logFile)
+	logLevel := sdtLog.GetLevel(fmt.Sprintf("%s/device.config/config.json", rootPath))
+	initError(logFile, logLevel, logFormat)
 
 	sdtDeploy.Getlog(procLog)
 	sdtConfig.Getlog(procLog)
@@ -403,6 +422,18 @@ func main() {
 	sdtMessage.Getlog(procLog)
 	sdtDocker.Getlog(procLog)
 	sdtModel.Getlog(procLog)
+	sdtHealthz.Getlog(procLog)
+	sdtAudit.Getlog(procLog)
+	if err := sdtAudit.Init(rootPath); err != nil {
+		procLog.Error.Printf("[MAIN] Audit log Error: %v\n", err)
+	}
+
+	// Run health endpoint
+	if healthzPort != 0 {
+		go sdtHealthz.RunServer(healthzPort, rootPath, func() bool {
+			return cli != nil && cli.IsConnected()
+		})
+	}
 
 	// Run main
 
@@ -0,0 +1,177 @@
+// The audit package records the control commands (bash, systemd, docker) executed on
+// the device. Entries are written in JSON format to '<rootPath>/device.logs/audit.log',
+// separately from the process log, so that they can be monitored or forwarded independently.
+// The audit log is rotated daily and rotated files are kept for 7 days.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	sdtType "main/src/controlType"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Global variables used in the audit package:
+//   - procLog: Struct defining the format of logs.
+//   - auditLog: Logger that writes audit entries to the audit log file.
+//   - retentionDays: Number of days the rotated audit logs are kept.
+var (
+	procLog       sdtType.Logger
+	auditLog      *log.Logger
+	retentionDays = 7
+)
+
+// Entry defines the structure of an audit log entry.
+//   - Timestamp: Time the command was executed. (ms)
+//   - RequestId: Request ID of the control command.
+//   - CmdType: Type of the control command. (bash, systemd, docker)
+//   - Cmd: Executed command.
+//   - AssetCode: Device serial number.
+//   - Succeed: 1 if the command succeeded, otherwise 0.
+type Entry struct {
+	Timestamp int64  `json:"timestamp"`
+	RequestId string `json:"requestId"`
+	CmdType   string `json:"cmdType"`
+	Cmd       string `json:"cmd"`
+	AssetCode string `json:"assetCode"`
+	Succeed   int    `json:"succeed"`
+}
+
+// rotateWriter is an io.Writer that rotates the audit log file daily.
+// The rotated file is renamed to 'audit-<YYYY-MM-DD>.log'.
+//   - dir: Directory of the audit log file.
+//   - day: Date of the current audit log file.
+//   - file: Current audit log file.
+type rotateWriter struct {
+	mu   sync.Mutex
+	dir  string
+	day  string
+	file *os.File
+}
+
+// Getlog is a function that loads the log format. Log formats are defined as Info,
+// Warn, Error, and are output using Printf.
+// Here's how you would record the text "Hello World" as an Info log type:
+//
+//	procLog.Info.Printf("Hello World\n")   ->   [INFO] Hello World
+func Getlog(logConfig sdtType.Logger) {
+	procLog = logConfig
+}
+
+// Init function opens the audit log file and creates the audit logger.
+//
+// Input:
+//   - rootPath: SDT Cloud root path. (/etc/sdt, C:/sdt)
+//
+// Output:
+//   - error: An error message if the audit log file cannot be opened.
+func Init(rootPath string) error {
+	w := &rotateWriter{dir: fmt.Sprintf("%s/device.logs", rootPath)}
+	if err := w.open(); err != nil {
+		return err
+	}
+	auditLog = log.New(w, "", 0)
+	return nil
+}
+
+// Write function writes an audit entry of the executed control command.
+//
+// Input:
+//   - requestId: Request ID of the control command.
+//   - cmdType: Type of the control command.
+//   - cmd: Executed command.
+//   - assetCode: Device serial number.
+//   - succeed: 1 if the command succeeded, otherwise 0.
+func Write(requestId string, cmdType string, cmd string, assetCode string, succeed int) {
+	if auditLog == nil {
+		return
+	}
+	entry := Entry{
+		Timestamp: time.Now().UnixMilli(),
+		RequestId: requestId,
+		CmdType:   cmdType,
+		Cmd:       Sanitize(cmd),
+		AssetCode: assetCode,
+		Succeed:   succeed,
+	}
+	body, err := json.Marshal(entry)
+	if err != nil {
+		procLog.Error.Printf("[AUDIT] Marshal Error: %v\n", err)
+		return
+	}
+	auditLog.Println(string(body))
+}
+
+// Sanitize function replaces control characters (newline, tab, etc.) in the command with
+// spaces, so that one command is always written as one line.
+func Sanitize(cmd string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, cmd))
+}
+
+// open function opens the audit log file. If the existing file was written on a previous day,
+// it is rotated first.
+func (w *rotateWriter) open() error {
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return err
+	}
+	filePath := filepath.Join(w.dir, "audit.log")
+	today := time.Now().Format("2006-01-02")
+	if info, err := os.Stat(filePath); err == nil {
+		if day := info.ModTime().Format("2006-01-02"); day != today {
+			w.rename(day)
+		}
+	}
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.day = today
+	return nil
+}
+
+// rename function renames the audit log file of the day and removes the rotated files
+// older than the retention days.
+func (w *rotateWriter) rename(day string) {
+	filePath := filepath.Join(w.dir, "audit.log")
+	err := os.Rename(filePath, filepath.Join(w.dir, fmt.Sprintf("audit-%s.log", day)))
+	if err != nil {
+		procLog.Warn.Printf("[AUDIT] Rotate Error: %v\n", err)
+	}
+
+	oldFiles, _ := filepath.Glob(filepath.Join(w.dir, "audit-*.log"))
+	limit := time.Now().AddDate(0, 0, -retentionDays)
+	for _, oldFile := range oldFiles {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(oldFile), "audit-"), ".log")
+		fileDay, err := time.ParseInLocation("2006-01-02", name, time.Local)
+		if err == nil && fileDay.Before(limit) {
+			os.Remove(oldFile)
+		}
+	}
+}
+
+// Write function writes p to the audit log file, rotating the file when the day changes.
+func (w *rotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if today := time.Now().Format("2006-01-02"); today != w.day {
+		w.file.Close()
+		w.rename(w.day)
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	return w.file.Write(p)
+}
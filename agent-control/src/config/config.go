@@ -8,13 +8,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
 	sdtType "main/src/controlType"
+	sdtConfigLock "pkg/configlock"
 )
 
 // Global variables used in the config package.
@@ -114,7 +117,7 @@ func changeInterface(data map[string]interface{}, target map[string]interface{})
 //func AppidUpdate(appId string, fileName string, appName string) {
 //	procLog.Info.Printf("[AppID-Update] exec... appid Update...\n")
 //	targetFile := fmt.Sprintf("/usr/local/sdt/app/%s_%s/%s", appName, appId, fileName)
-//	jsonFile, err := ioutil.ReadFile(targetFile)
+//	jsonFile, err := os.ReadFile(targetFile)
 //
 //	if err != nil {
 //		procLog.Error.Printf("[AppID-Update] Not found file Error: %v\n", err)
@@ -131,7 +134,7 @@ func changeInterface(data map[string]interface{}, target map[string]interface{})
 //	jsonData["appId"] = appId
 //
 //	saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
-//	err = ioutil.WriteFile(targetFile, saveJson, 0644)
+//	err = os.WriteFile(targetFile, saveJson, 0644)
 //	if err != nil {
 //		procLog.Error.Printf("[AppID-Update] Marshal Error: %v\n", err)
 //	}
@@ -151,7 +154,14 @@ func changeInterface(data map[string]interface{}, target map[string]interface{})
 func Rebooting(rebootVal string, requestid string) (error, int) {
 	procLog.Info.Printf("[REBOOT] exec... reboot\n")
 	targetFile := "/etc/sdt/device.config/config.json"
-	jsonFile, err := ioutil.ReadFile(targetFile)
+	release, err := sdtConfigLock.AcquireConfigLock(targetFile)
+	if err != nil {
+		procLog.Error.Printf("[REBOOT] Can't lock config Error: %v\n", err)
+		return err, http.StatusInternalServerError
+	}
+	defer release()
+
+	jsonFile, err := os.ReadFile(targetFile)
 
 	if err != nil {
 		procLog.Error.Printf("[REBOOT] Not found file Error: %v\n", err)
@@ -171,7 +181,7 @@ func Rebooting(rebootVal string, requestid string) (error, int) {
 	jsonData["requestid"] = requestid
 
 	saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
-	err = ioutil.WriteFile(targetFile, saveJson, 0644)
+	err = os.WriteFile(targetFile, saveJson, 0644)
 	if err != nil {
 		return err, http.StatusBadRequest
 	}
@@ -179,8 +189,60 @@ func Rebooting(rebootVal string, requestid string) (error, int) {
 	return nil, http.StatusOK
 }
 
+// schemaFileName is the JSON Schema (draft-07) of the app's config. If the app directory has this
+// file, JsonChange validates the config against it before writing.
+const schemaFileName = "config.schema.json"
+
+// ErrSchemaValidation is returned by JsonChange when the config doesn't match config.schema.json
+// of the app. The config file is not modified.
+//   - SchemaFile: Path of the schema file.
+//   - Err: Validation error of the schema.
+type ErrSchemaValidation struct {
+	SchemaFile string
+	Err        error
+}
+
+// Error function returns the message of ErrSchemaValidation.
+func (e *ErrSchemaValidation) Error() string {
+	return fmt.Sprintf("Config doesn't match %s: %v", e.SchemaFile, e.Err)
+}
+
+// Unwrap function returns the validation error of the schema.
+func (e *ErrSchemaValidation) Unwrap() error {
+	return e.Err
+}
+
+// The validateSchema function validates the config against config.schema.json in the app directory.
+// If the app doesn't have the schema file, the config is not validated.
+//
+// Input:
+//   - appDir: Directory of the app.
+//   - jsonData: Config to validate. (The config file with the requested parameters applied)
+//
+// Output:
+//   - error: *ErrSchemaValidation if the config is invalid, or the error of reading the schema.
+func validateSchema(appDir string, jsonData map[string]interface{}) error {
+	schemaFile := filepath.Join(appDir, schemaFileName)
+	if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+		return nil
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft7
+	schema, err := compiler.Compile(schemaFile)
+	if err != nil {
+		return fmt.Errorf("Invalid %s: %v", schemaFile, err)
+	}
+	if err := schema.Validate(jsonData); err != nil {
+		return &ErrSchemaValidation{SchemaFile: schemaFile, Err: err}
+	}
+	return nil
+}
+
 // The JsonChange function modifies the config information of an app deployed on the device.
 // Apps deployed from SDT Cloud are managed alongside Json-formatted config files.
+// If the app has config.schema.json, the modified config is validated against it before
+// writing, and *ErrSchemaValidation is returned for an invalid config.
 //
 // Input:
 //   - configCmd: Struct containing config modification command information.
@@ -222,7 +284,7 @@ func JsonChange(configCmd sdtType.CmdJson, appPath string, cmdType string) (stri
 	//	targetFile = fmt.Sprintf("/usr/local/sdt/app/%s_%s/%s", appName, appId, fileName)
 	//}
 
-	jsonFile, err := ioutil.ReadFile(targetFile)
+	jsonFile, err := os.ReadFile(targetFile)
 	if err != nil {
 		procLog.Error.Printf("[CONFIG] Not found file Error: %v\n", err)
 		return "", err, http.StatusBadRequest
@@ -244,6 +306,12 @@ func JsonChange(configCmd sdtType.CmdJson, appPath string, cmdType string) (stri
 		return "", err, http.StatusBadRequest
 	}
 
+	// Validate the config before the file is modified.
+	if err := validateSchema(filepath.Dir(targetFile), jsonData); err != nil {
+		procLog.Error.Printf("[CONFIG] Schema validation Error: %v\n", err)
+		return "", err, http.StatusBadRequest
+	}
+
 	//for key, val := range paramData {
 	//	if _, exist := jsonData[key]; exist {
 	//		n, ok := jsonData[key].(json.Number)
@@ -261,7 +329,7 @@ func JsonChange(configCmd sdtType.CmdJson, appPath string, cmdType string) (stri
 	//}
 
 	saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
-	err = ioutil.WriteFile(targetFile, saveJson, 0644)
+	err = os.WriteFile(targetFile, saveJson, 0644)
 	if err != nil {
 		return "", err, http.StatusBadRequest
 	}
@@ -293,7 +361,7 @@ func GetConfig(appId string, appName string, appPath string, cmdType string) (ma
 		targetDir = fmt.Sprintf("%s/%s_%s", appPath, appName, appId)
 	}
 
-	fileList, err := ioutil.ReadDir(targetDir)
+	fileList, err := os.ReadDir(targetDir)
 	if err != nil {
 		procLog.Warn.Printf("[CONFIG] Not found App Error: %v\n", err)
 		return nil, err, http.StatusOK
@@ -304,6 +372,10 @@ func GetConfig(appId string, appName string, appPath string, cmdType string) (ma
 		fileName := fmt.Sprintf("%s", file.Name())
 		fileType := strings.Split(fileName, ".")
 
+		if fileName == schemaFileName {
+			// The schema is not a config of the app.
+			continue
+		}
 		if fileType[len(fileType)-1] == "json" {
 			result := GetJson(fileName, targetDir)
 			allConfig[fileName] = result
@@ -330,7 +402,7 @@ func GetConfig(appId string, appName string, appPath string, cmdType string) (ma
 //   - map[string]interface{}: Config values of the app.
 func GetJson(fileName string, targetDir string) map[string]interface{} {
 	targetFile := fmt.Sprintf("%s/%s", targetDir, fileName)
-	jsonFile, err := ioutil.ReadFile(targetFile)
+	jsonFile, err := os.ReadFile(targetFile)
 	if err != nil {
 		procLog.Error.Printf("[CONFIG] Not found file Error: %v\n", err)
 	}
@@ -363,7 +435,7 @@ func JsonAdd(fileName string, appName string, paramData map[string]interface{})
 	}
 
 	saveJson, _ := json.MarshalIndent(&paramData, "", " ")
-	err := ioutil.WriteFile(targetFile, saveJson, 0644)
+	err := os.WriteFile(targetFile, saveJson, 0644)
 	if err != nil {
 		return "", err, http.StatusBadRequest
 	}
@@ -18,6 +18,7 @@ import (
 	"os/exec"
 	"strings"
 
+	sdtAudit "main/src/audit"
 	sdtConfig "main/src/config"
 	sdtType "main/src/controlType"
 	sdtDeploy "main/src/deploy"
@@ -84,8 +85,11 @@ func Control(svcInfo sdtType.ControlService,
 			break
 		}
 
+		auditCmd := sdtAudit.Sanitize(bashData.Cmd)
 		if bashData.Cmd == "reboot" {
 			// TODO: Windows용 재부팅 명령어 추가 필요
+			// The device is rebooted before the result is returned, so the entry is written first.
+			sdtAudit.Write(m.RequestId, m.CmdType, auditCmd, configData.AssetCode, 1)
 			_, _ = sdtConfig.Rebooting("rebooting", m.RequestId)
 		}
 
@@ -109,8 +113,10 @@ func Control(svcInfo sdtType.ControlService,
 			procLog.Error.Printf("[BASH] Error1: %s\n", bashResult)
 			procLog.Error.Printf("[BASH] Error2: %v\n", cmdErr)
 			statusCode = http.StatusBadRequest
+			sdtAudit.Write(m.RequestId, m.CmdType, auditCmd, configData.AssetCode, 0)
 		} else {
 			statusCode = http.StatusOK
+			sdtAudit.Write(m.RequestId, m.CmdType, auditCmd, configData.AssetCode, 1)
 		}
 
 		// 결과 메시지 생성
@@ -167,6 +173,13 @@ func Control(svcInfo sdtType.ControlService,
 			}
 		}
 
+		auditCmd := fmt.Sprintf("systemctl %s %s", systemdData.Cmd, systemdData.Service)
+		if cmdErr != nil {
+			sdtAudit.Write(m.RequestId, m.CmdType, auditCmd, configData.AssetCode, 0)
+		} else {
+			sdtAudit.Write(m.RequestId, m.CmdType, auditCmd, configData.AssetCode, 1)
+		}
+
 		// 결과 메시지 생성
 		cmdResult := sdtType.NewCmdResult(m.CmdType, systemdData.Cmd, systemdResult)
 		cmdStatus := sdtType.NewCmdStatus(statusCode)
@@ -204,7 +217,7 @@ func Control(svcInfo sdtType.ControlService,
 		}
 
 		if m.SubCmdType == "appDeploy" {
-			cmdErr, statusCode = sdtDocker.CreateContainer(dockerClient, dockerData.Image, dockerData.AppName, dockerData.AppId, svcInfo.RootPath, nil, nil)
+			cmdErr, statusCode = sdtDocker.CreateContainer(dockerClient, dockerData.Image, dockerData.AppName, dockerData.AppId, svcInfo.RootPath, dockerData.PortBindings, nil, dockerData.MemoryLimit, dockerData.CPUShares)
 
 		} else if m.SubCmdType == "appDelete" {
 			cmdErr, statusCode = sdtDocker.DeleteContainer(dockerClient, dockerData.AppName, dockerData.AppId, svcInfo.RootPath)
@@ -214,11 +227,14 @@ func Control(svcInfo sdtType.ControlService,
 			cmdErr, statusCode = sdtDocker.StopContainer(dockerClient, dockerData.AppName, dockerData.AppId)
 		}
 
+		auditCmd := fmt.Sprintf("%s %s_%s %s", m.SubCmdType, dockerData.AppName, dockerData.AppId, dockerData.Image)
 		if cmdErr != nil {
 			procLog.Error.Printf("[DOCKER] Error: %v\n", cmdErr)
 			deployMessage = fmt.Sprintf("%s's %s failed.", dockerData.AppName, m.SubCmdType)
+			sdtAudit.Write(m.RequestId, m.CmdType, auditCmd, configData.AssetCode, 0)
 		} else {
 			deployMessage = fmt.Sprintf("%s's %s successed.", dockerData.AppName, m.SubCmdType)
+			sdtAudit.Write(m.RequestId, m.CmdType, auditCmd, configData.AssetCode, 1)
 		}
 
 		// 결과 메시지 생성
@@ -266,11 +282,11 @@ func Control(svcInfo sdtType.ControlService,
 
 			// if status is fail, delete app's data.
 			if statusCode == http.StatusBadRequest {
-				sdtDeploy.DeleteVenv(venvData.VenvName, svcInfo)
+				sdtDeploy.DeleteVenv(venvData.VenvName, svcInfo.RootPath, svcInfo)
 				procLog.Warn.Printf("[Venv] Venv's creation failed: delete venv's data.\n")
 			}
 		} else if m.SubCmdType == "venvDelete" {
-			stdout, cmdErr, statusCode = sdtDeploy.DeleteVenv(venvData.VenvName, svcInfo)
+			stdout, cmdErr, statusCode = sdtDeploy.DeleteVenv(venvData.VenvName, svcInfo.RootPath, svcInfo)
 		} else if m.SubCmdType == "venvUpdate" {
 			stdout, cmdErr, statusCode = sdtDeploy.UpdateVenv(venvData, svcInfo)
 		}
@@ -278,7 +294,9 @@ func Control(svcInfo sdtType.ControlService,
 		if cmdErr != nil {
 			procLog.Error.Printf("[Venv] Error1: %s\n", stdout)
 			procLog.Error.Printf("[Venv] Error2: %v\n", cmdErr)
-			statusCode = http.StatusBadRequest
+			if statusCode != http.StatusConflict {
+				statusCode = http.StatusBadRequest
+			}
 		} else {
 			statusCode = http.StatusOK
 		}
@@ -331,18 +349,27 @@ func Control(svcInfo sdtType.ControlService,
 		if m.SubCmdType == "appDeploy" {
 			if len(deployData.Apps) > 0 {
 				procLog.Info.Printf("[DEPLOY-INF] <INFERENCE APP> It is inference APP!!! \n")
-				inferenceResult, cmdErr, statusCode, deployData.VenvName = sdtDeploy.InferenceDeploy(deployData, archType, svcInfo, configData, homeUser, cli)
+				inferenceResult, cmdErr, statusCode, deployData = sdtDeploy.InferenceDeploy(deployData, archType, svcInfo, configData, homeUser, cli)
 
 				// if status is fail, delete app's data.
-				if statusCode == http.StatusBadRequest {
+				// (Rolling update deletes only the failed apps in InferenceDeploy.)
+				if statusCode == http.StatusBadRequest && !deployData.RollingUpdate {
 					procLog.Error.Printf("[DEPLOY-INF] App's deploy failed[Deploy]: %v.\n", cmdErr)
 					appNames, appIds := sdtDeploy.GetAppsFromGroup(deployData.AppGroupId, svcInfo.RootPath)
 					sdtDeploy.InferenceDelete(appNames, appIds, archType, deployData, svcInfo)
-				} else {
+				} else if statusCode != http.StatusBadRequest {
 					// 앱이 배포되어 새 Config 파일이 생성됐으므로, 현재 Config 값 확인
 					for infIndex, _ := range deployData.Apps {
-						jsonResult, configErr, statusCode = sdtConfig.GetConfig(deployData.Apps[infIndex].AppId, deployData.Apps[infIndex].AppName, svcInfo.AppPath, "")
-						if statusCode == http.StatusBadRequest {
+						if _, failed := inferenceResult[infIndex]["errMsg"]; failed {
+							infJsonResults = append(infJsonResults, nil)
+							continue
+						}
+						var configStatus int
+						jsonResult, configErr, configStatus = sdtConfig.GetConfig(deployData.Apps[infIndex].AppId, deployData.Apps[infIndex].AppName, svcInfo.AppPath, "")
+						if !deployData.RollingUpdate {
+							statusCode = configStatus
+						}
+						if configStatus == http.StatusBadRequest {
 							sdtDeploy.Delete(deployData.AppName, deployData.AppId, archType, svcInfo)
 							procLog.Error.Printf("[DEPLOY-INF] App's deploy failed[GetConfig]: %v.\n", configErr)
 							//break
@@ -418,7 +445,26 @@ func Control(svcInfo sdtType.ControlService,
 				cmdResult.ModelId = deployData.Apps[resultIndex].ModelId
 
 				// 처리 후 결과 값
-				if cmdErr != nil {
+				if deployData.RollingUpdate && resultIndex < len(inferenceResult) {
+					// Rolling update: result of each app with its own status code.
+					cmdResult.StatusCode = inferenceResult[resultIndex]["statusCode"].(int)
+					if errMsg, failed := inferenceResult[resultIndex]["errMsg"]; failed {
+						cmdResult.ErrMsg = errMsg.(string)
+						cmdResult.VenvName = ""
+						cmdResult.Size = int64(-1)
+						cmdResult.Pid = int(-1)
+						cmdResult.AppRepoPath = ""
+						cmdResult.Parameter = nil
+					} else {
+						cmdResult.VenvName = inferenceResult[resultIndex]["venv"].(string)
+						cmdResult.Size = inferenceResult[resultIndex]["size"].(int64)
+						cmdResult.Pid = inferenceResult[resultIndex]["pid"].(int)
+						cmdResult.AppRepoPath = inferenceResult[resultIndex]["appRepoPath"].(string)
+						if resultIndex < len(infJsonResults) {
+							cmdResult.Parameter = infJsonResults[resultIndex]
+						}
+					}
+				} else if cmdErr != nil {
 					cmdResult.VenvName = ""
 					cmdResult.Size = int64(-1)
 					cmdResult.Pid = int(-1)
@@ -709,6 +755,7 @@ func CheckSystemd(checkData sdtType.CmdSystemd) bool {
 
 // CheckDocker validates the request parameters for docker type control commands.
 // The docker command must specify the actual command to be executed.
+// For appDeploy, memoryLimit, cpuShares and portBindings must be in range. (See sdtDocker.ValidateResources)
 //
 // Input:
 //   - checkData: Struct containing docker command information.
@@ -720,6 +767,10 @@ func CheckDocker(subCmd string, checkData sdtType.CmdDocker) bool {
 		if checkData.Image == "" {
 			return false
 		}
+		if err := sdtDocker.ValidateResources(checkData.MemoryLimit, checkData.CPUShares, checkData.PortBindings); err != nil {
+			procLog.Error.Printf("[DOCKER] %v\n", err)
+			return false
+		}
 	} else if checkData.AppName == "" {
 		return false
 	}
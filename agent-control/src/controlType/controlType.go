@@ -2,6 +2,7 @@
 package controlType
 
 import (
+	"fmt"
 	"log"
 	"time"
 )
@@ -35,16 +36,58 @@ type Logger struct {
 //   - RequestId: Request ID of the command.
 //   - ServiceCode: SDT Cloud service code.
 //   - ServiceType: SDT Cloud service type (EKS, DEV, OnPerm).
+//   - MinioAccessKey: Access key of the object storage.
+//   - MinioSecretKey: Secret key of the object storage.
+//   - TopicPrefix: Namespace prepended to the MQTT topics. (Empty by default)
+//   - Exmq: EXMQ broker information. (Only used if the mqtt type is exmq)
 type ConfigInfo struct {
-	AssetCode   string `json:"assetcode"`
-	DeviceType  string `json:"devicetype"`
-	MqttUrl     string `json:"mqtturl"`
-	ProjectCode string `json:"projectcode"`
-	Reboot      string `json:"reboot"`
-	RequestId   string `json:"requestid"`
-	ServiceCode string `json:"servicecode"`
-	ServiceType string `json:"servicetype"`
-	ServerIp    string `json:"serverip"`
+	AssetCode      string   `json:"assetcode"`
+	DeviceType     string   `json:"devicetype"`
+	MqttUrl        string   `json:"mqtturl"`
+	ProjectCode    string   `json:"projectcode"`
+	Reboot         string   `json:"reboot"`
+	RequestId      string   `json:"requestid"`
+	ServiceCode    string   `json:"servicecode"`
+	ServiceType    string   `json:"servicetype"`
+	ServerIp       string   `json:"serverip"`
+	MinioAccessKey string   `json:"minioaccesskey"`
+	MinioSecretKey string   `json:"miniosecretkey"`
+	TopicPrefix    string   `json:"topicprefix,omitempty"`
+	Exmq           ExmqInfo `json:"exmq"`
+}
+
+// ExmqInfo struct defines the EXMQ broker information under the 'exmq' section of the config file.
+//   - MqttUrl: MQTT URL of the EXMQ broker. If empty, mqtturl of the config is used.
+//   - RootCa: Path to the Root CA file of the EXMQ broker.
+//   - Cert: Path to the client certificate file.
+//   - PrivateKey: Path to the private key file of the client certificate.
+//   - Username: Username of the EXMQ broker. (Optional)
+//   - Password: Password of the EXMQ broker. (Optional)
+type ExmqInfo struct {
+	MqttUrl    string `json:"mqtturl,omitempty"`
+	RootCa     string `json:"rootca"`
+	Cert       string `json:"cert"`
+	PrivateKey string `json:"privatekey"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+}
+
+// Topic function returns the MQTT topic of the device. If TopicPrefix is set, it is prepended
+// to the topic so that multiple BWC deployments can share one MQTT broker.
+//
+//	<topicPrefix>/<serviceCode>/<projectCode>/<assetCode>/<subTopic>
+//
+// Input:
+//   - subTopic: Topic after the asset code. (ex. bwc/health)
+//
+// Output:
+//   - string: MQTT topic.
+func (c ConfigInfo) Topic(subTopic string) string {
+	topic := fmt.Sprintf("%s/%s/%s/%s", c.ServiceCode, c.ProjectCode, c.AssetCode, subTopic)
+	if c.TopicPrefix != "" {
+		topic = fmt.Sprintf("%s/%s", c.TopicPrefix, topic)
+	}
+	return topic
 }
 
 // ControlService defines the structure for the environment information of the control agent.
@@ -54,6 +97,9 @@ type ConfigInfo struct {
 //   - HomeUser: Hostname of the device.
 //   - SdtcloudIP: IP address of SDT Cloud.
 //   - GiteaPort: Port value of the code repository.
+//   - MinioURL: URL of the object storage.
+//   - MinioAccessKey: Access key of the object storage.
+//   - MinioSecretKey: Secret key of the object storage.
 type ControlService struct {
 	MqttType         string
 	ArchType         string
@@ -66,6 +112,8 @@ type ControlService struct {
 	SdtcloudIP       string
 	GiteaPort        int
 	MinioURL         string
+	MinioAccessKey   string
+	MinioSecretKey   string
 	BaseCmd          [2]string
 }
 
@@ -103,12 +151,18 @@ type CmdSystemd struct {
 //   - AppId: ID of the application.
 //   - AppName: Name of the application.
 //   - Options: Container options.
+//   - MemoryLimit: Memory limit of the container in bytes. (0 is unlimited)
+//   - CPUShares: Relative CPU weight of the container. (0 is the docker default, 1024)
+//   - PortBindings: Host port of each container port. (ex. {"80/tcp": "8080", "53/udp": "127.0.0.1:5353"})
 type CmdDocker struct {
-	Cmd     string                 `json: "cmd"`
-	Image   string                 `json: "image"`
-	AppId   string                 `json: "appId"`
-	AppName string                 `json: "appName"`
-	Options map[string]interface{} `json: "options"`
+	Cmd          string                 `json: "cmd"`
+	Image        string                 `json: "image"`
+	AppId        string                 `json: "appId"`
+	AppName      string                 `json: "appName"`
+	Options      map[string]interface{} `json: "options"`
+	MemoryLimit  int64                  `json:"memoryLimit,omitempty"`
+	CPUShares    int64                  `json:"cpuShares,omitempty"`
+	PortBindings map[string]string      `json:"portBindings,omitempty"`
 }
 
 // CmdVenv defines the structure for virtual environment control command information.
@@ -131,6 +185,9 @@ type CmdVenv struct {
 //   - FileUrl: Download path of the application.
 //   - VenvName: Virtual environment name of the application.
 //   - Env: The environment of app manager.
+//   - RollingUpdate: If true, each app of the inference app group is deployed independently.
+//     A failed app is deleted by itself instead of rolling back the whole group.
+//   - MaxRetries: Number of attempts of the download and the service start. (Default: 3)
 type CmdDeploy struct {
 	AppId    string `json:"appId"`
 	AppName  string `json:"appName"`
@@ -140,10 +197,14 @@ type CmdDeploy struct {
 	FileUrl  string `json:"fileUrl"`
 	VenvName string `json:"venvName"`
 	// for inference
-	Apps       []InferenceDeploy `json:"apps"`
-	AppGroupId string            `json:"appGroupId"`
+	Apps          []InferenceDeploy `json:"apps"`
+	AppGroupId    string            `json:"appGroupId"`
+	RollingUpdate bool              `json:"rollingUpdate"`
+	MaxRetries    int               `json:"maxRetries,omitempty"`
 }
 
+// InferenceDeploy defines the deployment information of an app in the inference app group.
+//   - DependsOn: Names of the apps in the same group that must be deployed and running before this app.
 type InferenceDeploy struct {
 	AppType        string                 `json:"appType"`
 	AppId          string                 `json:"appId"`
@@ -159,6 +220,7 @@ type InferenceDeploy struct {
 	ModelFileKey   string                 `json:"modelFileKey"`
 	ModelParameter map[string]interface{} `json:"modelParameter"`
 	GpuIndex       int                    `json:"gpuIndex"`
+	DependsOn      []string               `json:"dependsOn,omitempty"`
 }
 
 type CmdModel struct {
@@ -243,6 +305,25 @@ type AppInferenceInfo struct {
 	ModelVersion int    `json:"ModelVersion"`
 }
 
+// DeployHistory defines the structure of an entry in deploy-history.json. An entry is added
+// every time an app is successfully deployed.
+//   - AppName: Name of the application.
+//   - AppId: ID of the application.
+//   - AppRepoPath: Repository path of the deployed app file.
+//   - DeployedAt: Time the app was deployed. (Unix time in ms)
+//   - FileSize: Size of the deployed app file.
+//   - Venv: Virtual environment used by the application.
+//   - Pid: PID of the application after the deployment.
+type DeployHistory struct {
+	AppName     string `json:"appName"`
+	AppId       string `json:"appId"`
+	AppRepoPath string `json:"appRepoPath"`
+	DeployedAt  int64  `json:"deployedAt"`
+	FileSize    int64  `json:"fileSize"`
+	Venv        string `json:"venv"`
+	Pid         int    `json:"pid"`
+}
+
 // Inference defining information about the inference type variable in the framework file of the app.
 //   - WeightFile: Name of weight file
 //   - Bucket: Bucket name in objectstorage.
@@ -272,11 +353,19 @@ type Framework struct {
 //   - AppName: Name of the app.
 //   - RunFile: File for running the app.
 //   - Env: Struct containing app environment information.
+//   - PreInstall: Script run before the app starts. (Relative path in the app directory)
+//   - PostInstall: Script run after the app starts. (Relative path in the app directory)
+//   - WorkingDir: WorkingDirectory of the systemd service. (Default is the app directory)
+//   - BaseImage: URL of the base archive shared by the apps of an inference deploy. (Downloaded only once)
 type Spec struct {
-	AppName string `yaml:"appName" json:"appName"`
-	AppType string `yaml:"appType" json:"appType"`
-	RunFile string `yaml:"runFile" json:"runFile"`
-	Env     Env    `yaml:"env" json:"env"`
+	AppName     string `yaml:"appName" json:"appName"`
+	AppType     string `yaml:"appType" json:"appType"`
+	RunFile     string `yaml:"runFile" json:"runFile"`
+	Env         Env    `yaml:"env" json:"env"`
+	PreInstall  string `yaml:"preInstall,omitempty" json:"preInstall,omitempty"`
+	PostInstall string `yaml:"postInstall,omitempty" json:"postInstall,omitempty"`
+	WorkingDir  string `yaml:"workingDir,omitempty" json:"workingDir,omitempty"`
+	BaseImage   string `yaml:"baseImage,omitempty" json:"baseImage,omitempty"`
 }
 
 // Struct defining information about the stackbase type variable in the framework file of the app.
@@ -293,12 +382,17 @@ type Stackbase struct {
 //   - VirtualEnv: Virtual environment of the app.
 //   - HomeName: Hostname of the device.
 //   - Package: File listing the required packages for the app.
+//   - EnvVars: Environment variables of the app service. (KEY: VALUE)
+//   - EnvSecretsFile: File with additional environment variables (KEY=VALUE lines) such as secrets.
+//     (Relative path in the app directory or absolute path)
 type Env struct {
-	Bin        string `yaml:"bin" json:"bin"`
-	RunTime    string `yaml:"runtime" json:"runtime"`
-	VirtualEnv string `yaml:"virtualEnv" json:"virtualEnv"`
-	HomeName   string `yaml:"homeUser" json:"homeUser"`
-	Package    string `yaml:"package" json:"package"`
+	Bin            string            `yaml:"bin" json:"bin"`
+	RunTime        string            `yaml:"runtime" json:"runtime"`
+	VirtualEnv     string            `yaml:"virtualEnv" json:"virtualEnv"`
+	HomeName       string            `yaml:"homeUser" json:"homeUser"`
+	Package        string            `yaml:"package" json:"package"`
+	EnvVars        map[string]string `yaml:"envVars,omitempty" json:"envVars,omitempty"`
+	EnvSecretsFile string            `yaml:"envSecretsFile,omitempty" json:"envSecretsFile,omitempty"`
 }
 
 type ResultMsg struct {
@@ -327,6 +421,8 @@ type CmdResult struct {
 	ModelName       string                 `yaml:"modelName" json:"modelName"`
 	ModelVersion    int                    `yaml:"modelVersion" json:"modelVersion"`
 	ModelId         string                 `yaml:"modelId" json:"modelId"`
+	StatusCode      int                    `yaml:"statusCode" json:"statusCode,omitempty"`
+	ErrMsg          string                 `yaml:"errMsg" json:"errMsg,omitempty"`
 	//Parameters   *[]map[string]interface{} `yaml:"parameters" json:"parameters,omitempty"`
 }
 
@@ -0,0 +1,178 @@
+package deploy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	sdtType "main/src/controlType"
+	sdtLog "pkg/log"
+)
+
+// app returns an app of the group with its dependencies.
+func app(name string, dependsOn ...string) sdtType.InferenceDeploy {
+	return sdtType.InferenceDeploy{AppName: name, DependsOn: dependsOn}
+}
+
+func appNames(apps []sdtType.InferenceDeploy) []string {
+	names := make([]string, 0, len(apps))
+	for _, app := range apps {
+		names = append(names, app.AppName)
+	}
+	return names
+}
+
+func TestSortAppsByDependency(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	tests := []struct {
+		name string
+		apps []sdtType.InferenceDeploy
+		want []string
+	}{
+		{"no dependency", []sdtType.InferenceDeploy{app("a"), app("b"), app("c")}, []string{"a", "b", "c"}},
+		{"linear chain", []sdtType.InferenceDeploy{app("c", "b"), app("b", "a"), app("a")}, []string{"a", "b", "c"}},
+		{"diamond", []sdtType.InferenceDeploy{app("d", "b", "c"), app("c", "a"), app("b", "a"), app("a")}, []string{"a", "c", "b", "d"}},
+		{"independent app keeps order", []sdtType.InferenceDeploy{app("x"), app("b", "a"), app("a")}, []string{"x", "a", "b"}},
+		{"dependency not in group", []sdtType.InferenceDeploy{app("b", "running-app"), app("a")}, []string{"b", "a"}},
+		{"empty", nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, err := SortAppsByDependency(tt.apps)
+			if err != nil {
+				t.Fatalf("SortAppsByDependency() = %v", err)
+			}
+			if got := appNames(sorted); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortAppsByDependency() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortAppsByDependencyCycle(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	tests := []struct {
+		name      string
+		apps      []sdtType.InferenceDeploy
+		wantInErr []string
+	}{
+		{"self", []sdtType.InferenceDeploy{app("a", "a"), app("b")}, []string{"a"}},
+		{"two apps", []sdtType.InferenceDeploy{app("a", "b"), app("b", "a")}, []string{"a", "b"}},
+		{"cycle after chain", []sdtType.InferenceDeploy{app("root"), app("a", "root", "c"), app("b", "a"), app("c", "b")}, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, err := SortAppsByDependency(tt.apps)
+			if err == nil {
+				t.Fatalf("SortAppsByDependency() = %v, want circular dependency error", appNames(sorted))
+			}
+			for _, name := range tt.wantInErr {
+				if !strings.Contains(err.Error(), name) {
+					t.Errorf("error %q does not name %s", err, name)
+				}
+			}
+			if strings.Contains(err.Error(), "root") {
+				t.Errorf("error %q names an app outside the cycle", err)
+			}
+		})
+	}
+}
+
+func TestFindFailedDependency(t *testing.T) {
+	if dep := findFailedDependency(app("c", "a", "b"), []string{"x", "b"}); dep != "b" {
+		t.Errorf("findFailedDependency() = %q, want b", dep)
+	}
+	if dep := findFailedDependency(app("c", "a"), []string{"b"}); dep != "" {
+		t.Errorf("findFailedDependency() = %q, want none", dep)
+	}
+}
+
+func TestInferenceDeployReturnsSortedApps(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	var downloaded []string
+	downloadFile = func(fullURLFile, appId, app, appName, archType string, svcInfo sdtType.ControlService) (string, int64, error, string, string) {
+		downloaded = append(downloaded, appName)
+		return "", 0, &ErrDownloadStatus{StatusCode: http.StatusNotFound, Status: "404 Not Found"}, "", ""
+	}
+	defer func() { downloadFile = fileDownload }()
+
+	// web depends on db, so the order of the group changes after sorting.
+	deployData := sdtType.CmdDeploy{
+		AppGroupId: "group-1",
+		Apps: []sdtType.InferenceDeploy{
+			{AppName: "web", AppId: "web-1", DependsOn: []string{"db"}},
+			{AppName: "db", AppId: "db-1"},
+		},
+	}
+	svcInfo := sdtType.ControlService{AppPath: t.TempDir()}
+	results, err, statusCode, gotData := InferenceDeploy(deployData, "amd", svcInfo, sdtType.ConfigInfo{}, "", nil)
+	var statusErr *ErrDownloadStatus
+	if !errors.As(err, &statusErr) || statusCode != http.StatusBadRequest {
+		t.Fatalf("InferenceDeploy() = %v, %d, want download error", err, statusCode)
+	}
+	if got := appNames(gotData.Apps); !reflect.DeepEqual(got, []string{"db", "web"}) {
+		t.Errorf("InferenceDeploy() apps = %v, want [db web]", got)
+	}
+	if !reflect.DeepEqual(downloaded, []string{"db"}) {
+		t.Errorf("downloaded = %v, want [db]", downloaded)
+	}
+	if len(results) > len(gotData.Apps) {
+		t.Errorf("got %d results for %d apps", len(results), len(gotData.Apps))
+	}
+	if got := appNames(deployData.Apps); !reflect.DeepEqual(got, []string{"web", "db"}) {
+		t.Errorf("apps of the caller = %v, want them unchanged", got)
+	}
+}
+
+func TestInferenceDeployRollingKeepsExistingApp(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	downloadFile = func(fullURLFile, appId, app, appName, archType string, svcInfo sdtType.ControlService) (string, int64, error, string, string) {
+		return "", 0, &ErrDownloadStatus{StatusCode: http.StatusNotFound, Status: "404 Not Found"}, "", ""
+	}
+	defer func() { downloadFile = fileDownload }()
+
+	// db is already on the device. Its failed update must not delete it.
+	rootPath := t.TempDir()
+	appInfoFile := filepath.Join(rootPath, "device.config", "app.json")
+	appConfig := sdtType.AppConfig{AppInfoList: []sdtType.AppInfo{{AppName: "db", AppId: "db-1"}}}
+	data, _ := json.Marshal(appConfig)
+	if err := os.MkdirAll(filepath.Dir(appInfoFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(appInfoFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deployData := sdtType.CmdDeploy{
+		AppGroupId:    "group-1",
+		RollingUpdate: true,
+		Apps: []sdtType.InferenceDeploy{
+			{AppName: "web", AppId: "web-1", DependsOn: []string{"db"}},
+			{AppName: "db", AppId: "db-1"},
+		},
+	}
+	svcInfo := sdtType.ControlService{RootPath: rootPath, AppPath: t.TempDir()}
+	results, err, statusCode, gotData := InferenceDeploy(deployData, "amd", svcInfo, sdtType.ConfigInfo{}, "", nil)
+	if err == nil || statusCode != http.StatusBadRequest {
+		t.Fatalf("InferenceDeploy() = %v, %d, want all apps failed", err, statusCode)
+	}
+	if !CheckExistApp("db", rootPath) {
+		t.Errorf("existing app db was deleted")
+	}
+
+	// The results are in the order of the sorted apps.
+	if len(results) != len(gotData.Apps) {
+		t.Fatalf("got %d results for %d apps", len(results), len(gotData.Apps))
+	}
+	wantStatus := []int{http.StatusBadRequest, http.StatusFailedDependency}
+	for i, app := range gotData.Apps {
+		if results[i]["name"] != app.AppName || results[i]["statusCode"] != wantStatus[i] {
+			t.Errorf("result %d = %v, want %s with status %d", i, results[i], app.AppName, wantStatus[i])
+		}
+	}
+}
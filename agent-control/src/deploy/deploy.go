@@ -8,17 +8,21 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/minio/minio-go/v7"
 	"io"
-	"io/ioutil"
+	"log"
 	sdtConfig "main/src/config"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -37,6 +41,16 @@ import (
 // - procLog: This is the struct that defines the format of the Log.
 var procLog sdtType.Logger
 
+// maxDeployHistory is the maximum number of entries kept in deploy-history.json.
+const maxDeployHistory = 100
+
+// deployedApp holds the result of an app deployed in an app group until the deploy history is saved.
+type deployedApp struct {
+	result map[string]interface{}
+	appId  string
+	venv   string
+}
+
 // Getlog is a function that loads the log format. Log formats are defined as Info,
 // Warn, Error, and are output using Printf.
 // Here's how you would record the text "Hello World" as an Info log type:
@@ -218,17 +232,15 @@ func Delete(appName string, appId string, archType string, svcInfo sdtType.Contr
 		// TO DO
 		// remove app
 	} else {
-		appRemoveCmd := fmt.Sprintf("rm -rf /usr/local/sdt/app/%s_%s", appName, appId)
-		cmd_run := exec.Command("sh", "-c", appRemoveCmd)
 		procLog.Warn.Printf("[DELETE] Remove app : %s\n", appName)
-		stdout, cmd_err := cmd_run.CombinedOutput()
+		cmd_err := os.RemoveAll(fmt.Sprintf("/usr/local/sdt/app/%s_%s", appName, appId))
 		if cmd_err != nil {
-			procLog.Error.Printf("[DELETE] Remove app Error: %s\n", stdout)
+			procLog.Error.Printf("[DELETE] Remove app Error: %v\n", cmd_err)
 		}
 
 		disableCmd := fmt.Sprintf("systemctl disable %s", appName)
-		cmd_run = exec.Command("sh", "-c", disableCmd)
-		stdout, cmd_err = cmd_run.CombinedOutput()
+		cmd_run := exec.Command("sh", "-c", disableCmd)
+		stdout, cmd_err := cmd_run.CombinedOutput()
 		if cmd_err != nil {
 			procLog.Error.Printf("[DELETE] Disable Error: %s\n", stdout)
 			// return nil, cmd_err, http.StatusBadRequest
@@ -279,17 +291,15 @@ func InferenceDelete(appNames []string,
 	}
 
 	for index, _ := range appNames {
-		appRemoveCmd := fmt.Sprintf("rm -rf /usr/local/sdt/app/%s_%s", appNames[index], appIds[index])
-		cmd_run := exec.Command("sh", "-c", appRemoveCmd)
 		procLog.Warn.Printf("[DELETE-INF] Remove app [%d / %d] : %s\n", index+1, len(appNames), appNames[index])
-		stdout, cmd_err := cmd_run.CombinedOutput()
+		cmd_err := os.RemoveAll(fmt.Sprintf("/usr/local/sdt/app/%s_%s", appNames[index], appIds[index]))
 		if cmd_err != nil {
-			procLog.Error.Printf("[DELETE-INF] Remove app Error: %s\n", stdout)
+			procLog.Error.Printf("[DELETE-INF] Remove app Error: %v\n", cmd_err)
 		}
 
 		disableCmd := fmt.Sprintf("systemctl disable %s", appNames[index])
-		cmd_run = exec.Command("sh", "-c", disableCmd)
-		stdout, cmd_err = cmd_run.CombinedOutput()
+		cmd_run := exec.Command("sh", "-c", disableCmd)
+		stdout, cmd_err := cmd_run.CombinedOutput()
 		if cmd_err != nil {
 			procLog.Error.Printf("[DELETE-INF] Disable Error: %s\n", stdout)
 			// return nil, cmd_err, http.StatusBadRequest
@@ -375,11 +385,19 @@ func Deploy(deployData sdtType.CmdDeploy,
 		"appRepoPath": "",
 	}
 
-	filePath, fileSize, cmd_err, appRepoPath, fileZip := fileDownload(fileUrl, appId, app, appName, archType)
+	// Open deploy.log
+	deployFile, deployLog := openDeployLog(svcInfo.AppPath, appName, appId)
+	defer deployFile.Close()
+	deployLog.Printf("Start deploy %s app. (appId: %s, fileUrl: %s)\n", appName, appId, fileUrl)
+
+	maxRetries := GetMaxRetries(deployData.MaxRetries)
+	filePath, fileSize, cmd_err, appRepoPath, fileZip := downloadWithRetry(maxRetries, fileUrl, appId, app, appName, archType, svcInfo)
 	if cmd_err != nil {
 		procLog.Error.Printf("[DEPLOY] Download Error: %v\n", cmd_err)
+		deployLog.Printf("Download failed: %v\n", cmd_err)
 		return deployResult, cmd_err, http.StatusBadRequest, venv
 	} else {
+		deployLog.Printf("Download complete: %s (%d bytes)\n", filePath, fileSize)
 		// TODO: Linux에서 한거처럼 앱 배포 순서 추가해야함
 		if archType == "win" {
 
@@ -424,9 +442,11 @@ func Deploy(deployData sdtType.CmdDeploy,
 
 					if cmdErr != nil {
 						procLog.Error.Printf("[DEPLOY] Failed download python pkg.\n")
+						deployLog.Printf("Venv creation failed: %s\n", stdout)
 						cmd_err = errors.New(string(stdout))
 						return deployResult, cmd_err, statusCode, venv
 					}
+					deployLog.Printf("Venv created: %s\n", venv)
 
 					newUUID := uuid.New()
 					requestId := newUUID.String()
@@ -452,7 +472,7 @@ func Deploy(deployData sdtType.CmdDeploy,
 						RequestId: requestId,
 					}
 
-					topic := fmt.Sprintf("%s/%s/%s/bwc/control/self-deploy", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
+					topic := configData.Topic("bwc/control/self-deploy")
 					sdtMessage.SendDataEdgeMqtt(result, topic, cli)
 
 				}
@@ -469,9 +489,11 @@ func Deploy(deployData sdtType.CmdDeploy,
 			cmdResult, cmd_err := cmd_run.CombinedOutput()
 			if cmd_err != nil {
 				procLog.Error.Printf("[DEPLOY] Service creation Error: %s\n", cmdResult)
+				deployLog.Printf("Service creation failed: %s\n", cmdResult)
 				return deployResult, errors.New(string(cmdResult)), http.StatusBadRequest, venv
 			}
 			procLog.Info.Printf("[DEPLOY] Service creation Result: %s\n", cmdResult)
+			deployLog.Printf("Service created: %s_%s\n", appName, appId)
 
 			// Restart 설정
 			svcName := fmt.Sprintf("%s_%s", appName, appId)
@@ -489,9 +511,11 @@ func Deploy(deployData sdtType.CmdDeploy,
 			cmdResult, cmd_err = cmd_run.CombinedOutput()
 			if cmd_err != nil {
 				procLog.Error.Printf("[DEPLOY] Service start Error: %s\n", cmdResult)
+				deployLog.Printf("Service start failed: %s\n", cmdResult)
 				return deployResult, errors.New(string(cmdResult)), http.StatusBadRequest, venv
 			}
 			procLog.Info.Printf("[DEPLOY] Service start Result: %s\n", cmdResult)
+			deployLog.Printf("Service started: %s_%s\n", appName, appId)
 
 			deployResult["name"] = appName
 			deployResult["pid"] = -1 // TODO: Windows의 PID를 어떻게 가져오지?
@@ -523,9 +547,11 @@ func Deploy(deployData sdtType.CmdDeploy,
 
 				if cmd_err != nil {
 					procLog.Error.Println("[DEPLOY] Fail deploy: ", cmd_err, "\n", string(stdout))
+					deployLog.Printf("install.sh failed: %s\n", stdout)
 					cmd_err = errors.New(string(stdout))
 					return deployResult, errors.New(string(stdout)), http.StatusBadRequest, venv
 				}
+				deployLog.Printf("install.sh complete.\n")
 			} else if os.IsNotExist(err) { // -> New version
 				// new version
 				bwcFramework = GetVenvFromFramework(appName, appId, svcInfo.AppPath)
@@ -533,6 +559,7 @@ func Deploy(deployData sdtType.CmdDeploy,
 				// Check exist about app
 				if CheckExistApp(appName, svcInfo.RootPath) {
 					procLog.Error.Printf("[DEPLOY] %s's app already exist.\n", appName)
+					deployLog.Printf("%s's app already exist.\n", appName)
 					return deployResult, errors.New("App already exist."), http.StatusBadRequest, venv
 				}
 
@@ -579,9 +606,11 @@ func Deploy(deployData sdtType.CmdDeploy,
 
 						if cmdErr != nil {
 							procLog.Error.Printf("[DEPLOY] Failed download python pkg.\n")
+							deployLog.Printf("Venv creation failed: %v\n", cmdErr)
 							//cmd_err = errors.New(string(stdout))
 							return deployResult, cmdErr, statusCode, venv
 						}
+						deployLog.Printf("Venv created: %s\n", venv)
 
 						newUUID := uuid.New()
 						requestId := newUUID.String()
@@ -610,15 +639,47 @@ func Deploy(deployData sdtType.CmdDeploy,
 						//result := sdtMessage.CheckResult(configData.AssetCode, "", stdout,
 						//	cmd_err, statusCode, "venvCreate",
 						//	"virtualEnv", requestId, -1, -1, nil, "", "", venvData.VenvName)
-						topic := fmt.Sprintf("%s/%s/%s/bwc/control/self-deploy", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
+						topic := configData.Topic("bwc/control/self-deploy")
 						sdtMessage.SendDataEdgeMqtt(result, topic, cli)
 
 					}
 
-					CreatePythonService(filePath, appName, venv, "main.py", svcInfo.VenvPath)
-				} else if strings.Contains(runTime, "go") {
-					CreateGoService(filePath, appName, "main.py")
+					svcErr := CreatePythonService(filePath, appName, venv, "main.py", svcInfo.VenvPath, bwcFramework.Spec.Env, bwcFramework.Spec.WorkingDir)
+					if svcErr != nil {
+						procLog.Error.Printf("[DEPLOY] Failed creating service file: %v\n", svcErr)
+						deployLog.Printf("Service file failed: %v\n", svcErr)
+						return deployResult, svcErr, http.StatusBadRequest, venv
+					}
+					deployLog.Printf("Service file written: %s.service\n", appName)
+				} else if strings.Contains(runTime, "node") {
+					// Node.js doesn't use venv. Packages are installed in the app directory.
+					stdout, npmErr := NpmInstall(filePath)
+					if npmErr != nil {
+						procLog.Error.Printf("[DEPLOY] Failed npm install: %s\n", stdout)
+						deployLog.Printf("npm install failed: %s\n", stdout)
+						return deployResult, errors.New(stdout), http.StatusBadRequest, venv
+					}
+					deployLog.Printf("npm install complete.\n")
 
+					runFile := bwcFramework.Spec.RunFile
+					if runFile == "" {
+						runFile = "index.js"
+					}
+					svcErr := CreateNodeService(filePath, appName, runFile, bwcFramework.Spec.Env, bwcFramework.Spec.WorkingDir)
+					if svcErr != nil {
+						procLog.Error.Printf("[DEPLOY] Failed creating service file: %v\n", svcErr)
+						deployLog.Printf("Service file failed: %v\n", svcErr)
+						return deployResult, svcErr, http.StatusBadRequest, venv
+					}
+					deployLog.Printf("Service file written: %s.service\n", appName)
+				} else if strings.Contains(runTime, "go") {
+					svcErr := CreateGoService(filePath, appName, "main.py", bwcFramework.Spec.Env, bwcFramework.Spec.WorkingDir)
+					if svcErr != nil {
+						procLog.Error.Printf("[DEPLOY] Failed creating service file: %v\n", svcErr)
+						deployLog.Printf("Service file failed: %v\n", svcErr)
+						return deployResult, svcErr, http.StatusBadRequest, venv
+					}
+					deployLog.Printf("Service file written: %s.service\n", appName)
 				}
 
 				// APP Info 저장
@@ -637,29 +698,40 @@ func Deploy(deployData sdtType.CmdDeploy,
 				//		return deployResult, err, http.StatusBadRequest, venv
 				//	}
 				//	procLog.Info.Printf("[DEPLOY] App is inference. So, device download weight file.(=%s)\n", appType)
-				//	err = DownloadWeight(bwcFramework, filePath, svcInfo.MinioURL)
+				//	err = DownloadWeight(bwcFramework, filePath, svcInfo.MinioURL, "")
 				//	if err != nil {
 				//		procLog.Error.Printf("[DEPLOY] Failed download inference model.\n")
 				//		return deployResult, err, http.StatusBadRequest, venv
 				//	}
 				//}
 
+				// run preInstall hook
+				if bwcFramework.Spec.PreInstall != "" {
+					hookErr := RunInstallHook(filePath, bwcFramework.Spec.PreInstall, svcInfo)
+					if hookErr != nil {
+						deployLog.Printf("preInstall failed: %v\n", hookErr)
+						return deployResult, hookErr, http.StatusBadRequest, venv
+					}
+					deployLog.Printf("preInstall complete: %s\n", bwcFramework.Spec.PreInstall)
+				}
+
 				// start systemd
-				startCmd := fmt.Sprintf("systemctl start %s", appName)
-				cmd_run := exec.Command("sh", "-c", startCmd)
-				stdout, cmd_err := cmd_run.CombinedOutput()
+				stdout, cmd_err := startServiceWithRetry(appName, maxRetries)
 				if cmd_err != nil {
 					procLog.Error.Println("[DEPLOY] Fail deploy: ", cmd_err, "\n", string(stdout))
+					deployLog.Printf("systemd start failed: %s\n", stdout)
 					cmd_err = errors.New(string(stdout))
 					return deployResult, errors.New(string(stdout)), http.StatusBadRequest, venv
 				}
+				deployLog.Printf("systemd started: %s\n", appName)
 
 				// enable systemd
-				startCmd = fmt.Sprintf("systemctl enable %s", appName)
-				cmd_run = exec.Command("sh", "-c", startCmd)
+				startCmd := fmt.Sprintf("systemctl enable %s", appName)
+				cmd_run := exec.Command("sh", "-c", startCmd)
 				stdout, cmd_err = cmd_run.CombinedOutput()
 				if cmd_err != nil {
 					procLog.Error.Println("[DEPLOY] Fail deploy: ", cmd_err, "\n", string(stdout))
+					deployLog.Printf("systemd enable failed: %s\n", stdout)
 					cmd_err = errors.New(string(stdout))
 					return deployResult, errors.New(string(stdout)), http.StatusBadRequest, venv
 				}
@@ -689,8 +761,20 @@ func Deploy(deployData sdtType.CmdDeploy,
 				deployResult["size"] = fileSize
 				deployResult["appRepoPath"] = appRepoPath
 
+				deployLog.Printf("PID not found: %v\n", cmd_err)
 				return deployResult, errors.New(logResult), http.StatusBadRequest, venv
 			}
+			deployLog.Printf("PID obtained: %d\n", pid)
+
+			// run postInstall hook
+			if bwcFramework.Spec.PostInstall != "" && pid > 0 {
+				hookErr := RunInstallHook(filePath, bwcFramework.Spec.PostInstall, svcInfo)
+				if hookErr != nil {
+					deployLog.Printf("postInstall failed: %v\n", hookErr)
+					return deployResult, hookErr, http.StatusBadRequest, venv
+				}
+				deployLog.Printf("postInstall complete: %s\n", bwcFramework.Spec.PostInstall)
+			}
 			//deployResult = map[string]interface{}{
 			//	"name":        appName,
 			//	"pid":         pid,
@@ -713,26 +797,40 @@ func Deploy(deployData sdtType.CmdDeploy,
 		os.Remove(fileZip)
 	}
 
+	if cmd_err == nil {
+		SaveDeployHistory(deployResult, appId, venv, svcInfo.RootPath)
+	}
+	deployLog.Printf("Deploy complete: %s\n", appName)
 	return deployResult, cmd_err, http.StatusOK, venv
 }
 
 // The InferenceDeploy function deploys inference onto the device. Deploying an
 // application creates its directory and Systemd (.service) file.
+// The apps are deployed in the order of their dependencies (dependsOn). If the dependencies are
+// circular, an error is returned before any app is deployed.
+// By default, the deployment is atomic: it stops at the first failed app, and the caller
+// deletes the whole app group. If deployData.RollingUpdate is true, each app is deployed
+// and started independently, and a failed app is deleted by itself without affecting the others.
+// An app that already existed on the device before the deploy is not deleted.
+// An app whose dependency failed is not deployed and is reported as failed.
 //
 // Input:
 //   - deployData: Struct containing deployment command information.
 //   - archType: The architecture of the device.
 //
 // Output:
-//   - map[string]interface{}: Information about the application (app name, PID, app size).
+//   - []map[string]interface{}: Information about the applications (app name, PID, app size).
+//     In rolling update mode, "statusCode" and "errMsg" of each app are included.
 //   - error: Error message in case of issues with the deploy command.
 //   - int: Status code of the command execution.
+//   - sdtType.CmdDeploy: deployData with the apps in deploy order and the venv of the last deployed app.
+//     The results are in the same order as its apps.
 func InferenceDeploy(deployData sdtType.CmdDeploy,
 	archType string,
 	svcInfo sdtType.ControlService,
 	configData sdtType.ConfigInfo,
 	homeUser string,
-	cli mqttCli.Client) ([]map[string]interface{}, error, int, string) {
+	cli mqttCli.Client) ([]map[string]interface{}, error, int, sdtType.CmdDeploy) {
 
 	// TODO:
 	//  - 배포 시, Config값 함께 수정되서 배포가 되는 로직 추가 (완료)
@@ -740,217 +838,431 @@ func InferenceDeploy(deployData sdtType.CmdDeploy,
 	//  - Config 파일 명칭 고정할 건지와 내용 정리(Key 값) (완료)
 	var deployResult map[string]interface{}
 	var inferenceResult []map[string]interface{}
-	var bwcFramework sdtType.Framework
-	var runTime, venv, appId, appName, modelFileName string
+	var failedApps []string
+	var deployedApps []deployedApp
+	var venv string
 	var cmdErr error
-	var pid int
+	var statusCode int
+	// Base archives shared by the apps are downloaded only once. (baseImage URL -> local path)
+	sharedBaseCache := map[string]string{}
+
+	orderedApps, err := SortAppsByDependency(deployData.Apps)
+	if err != nil {
+		procLog.Error.Printf("[DEPLOY-INF] %v\n", err)
+		return inferenceResult, err, http.StatusBadRequest, deployData
+	}
+	deployData.Apps = orderedApps
 
 	// 다수의 앱을 배포한다.
 	for appIndex, appItem := range deployData.Apps {
-		venv = appItem.VenvName
-		appId = appItem.AppId
-		appName = appItem.AppName
+		if failedDep := findFailedDependency(appItem, failedApps); failedDep != "" {
+			// Rolling update: skip the app whose dependency failed.
+			procLog.Error.Printf("[DEPLOY-INF] %s is not deployed. Dependency %s failed.\n", appItem.AppName, failedDep)
+			failedApps = append(failedApps, appItem.AppName)
+			inferenceResult = append(inferenceResult, map[string]interface{}{
+				"name":       appItem.AppName,
+				"errMsg":     fmt.Sprintf("Dependency %s failed.", failedDep),
+				"statusCode": http.StatusFailedDependency,
+			})
+			continue
+		}
 
-		// 결과 변수 초기화
-		deployResult = map[string]interface{}{
-			"name":        "",
-			"pid":         -1,
-			"size":        -1,
-			"appRepoPath": "",
-			"venv":        "",
+		// Rolling update: an app that was on the device before this deploy is not deleted on failure.
+		existed := deployData.RollingUpdate && CheckExistApp(appItem.AppName, svcInfo.RootPath)
+		deployResult, cmdErr, statusCode, venv = inferenceDeployApp(appIndex, appItem, deployData, archType, svcInfo, configData, homeUser, cli, sharedBaseCache)
+		if cmdErr != nil {
+			if !deployData.RollingUpdate {
+				deployData.VenvName = venv
+				return inferenceResult, cmdErr, statusCode, deployData
+			}
+
+			// Rolling update: delete only the failed app, if this deploy created it.
+			if existed {
+				procLog.Error.Printf("[DEPLOY-INF] %s's deploy failed. Keep the existing app: %v\n", appItem.AppName, cmdErr)
+			} else {
+				procLog.Error.Printf("[DEPLOY-INF] %s's deploy failed. Delete only this app: %v\n", appItem.AppName, cmdErr)
+				Delete(appItem.AppName, appItem.AppId, archType, svcInfo)
+			}
+			failedApps = append(failedApps, appItem.AppName)
+			deployResult["name"] = appItem.AppName
+			deployResult["errMsg"] = fmt.Sprintf("%v", cmdErr)
+		} else {
+			deployedApps = append(deployedApps, deployedApp{result: deployResult, appId: appItem.AppId, venv: venv})
+		}
+		if deployData.RollingUpdate {
+			deployResult["statusCode"] = statusCode
 		}
 
-		procLog.Info.Printf("[DEPLOY-INF] [%d / %d] %s App deploy. \n", appIndex+1, len(deployData.Apps), appName)
+		inferenceResult = append(inferenceResult, deployResult)
+	}
 
-		// app download
-		filePath, fileSize, cmdErr, appRepoPath, fileZip := fileDownload(appItem.FileUrl, appId, appItem.App, appName, archType)
+	deployData.VenvName = venv
 
-		if cmdErr != nil {
-			procLog.Error.Printf("[DEPLOY-INF] Download Error: %v\n", cmdErr)
-			return inferenceResult, cmdErr, http.StatusBadRequest, venv
+	// Save deploy history of the deployed apps.
+	for _, app := range deployedApps {
+		SaveDeployHistory(app.result, app.appId, app.venv, svcInfo.RootPath)
+	}
+
+	if len(failedApps) > 0 {
+		procLog.Warn.Printf("[DEPLOY-INF] Rolling update: %d / %d apps failed.\n", len(failedApps), len(deployData.Apps))
+		if len(failedApps) == len(deployData.Apps) {
+			return inferenceResult, fmt.Errorf("All apps failed: %s", strings.Join(failedApps, ", ")), http.StatusBadRequest, deployData
 		}
+		return inferenceResult, fmt.Errorf("Some apps failed: %s", strings.Join(failedApps, ", ")), http.StatusMultiStatus, deployData
+	}
 
-		// change appname in framework.yaml
-		SaveFramework(appName, appId, svcInfo.AppPath)
+	return inferenceResult, nil, http.StatusOK, deployData
+}
 
-		// new version
-		bwcFramework = GetVenvFromFramework(appName, appId, svcInfo.AppPath)
+// SortAppsByDependency function sorts the apps of the app group so that each app comes after
+// the apps in its dependsOn (Kahn's algorithm). Apps without dependencies between them keep
+// their original order. A dependency that is not in the app group is ignored, since the app
+// may already be running on the device.
+//
+// Input:
+//   - apps: Apps of the inference app group.
+//
+// Output:
+//   - []sdtType.InferenceDeploy: Apps in deploy order.
+//   - error: Error if the dependencies are circular.
+func SortAppsByDependency(apps []sdtType.InferenceDeploy) ([]sdtType.InferenceDeploy, error) {
+	appIndex := make(map[string]int, len(apps))
+	for i, app := range apps {
+		appIndex[app.AppName] = i
+	}
+
+	// inDegree: Number of dependencies not deployed yet. dependents: Apps waiting for the app.
+	inDegree := make([]int, len(apps))
+	dependents := make([][]int, len(apps))
+	for i, app := range apps {
+		for _, dep := range app.DependsOn {
+			j, ok := appIndex[dep]
+			if !ok {
+				procLog.Warn.Printf("[DEPLOY-INF] %s depends on %s, which is not in the app group. Ignore it.\n", app.AppName, dep)
+				continue
+			}
+			inDegree[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
 
-		// Check exist about app
-		if CheckExistApp(appName, svcInfo.RootPath) {
-			procLog.Error.Printf("[DEPLOY-INF] %s's app already exist.\n", appName)
-			return inferenceResult, errors.New("App already exist."), http.StatusBadRequest, venv
+	queue := make([]int, 0, len(apps))
+	for i := range apps {
+		if inDegree[i] == 0 {
+			queue = append(queue, i)
 		}
+	}
 
-		// Get venv from framework
-		if venv == "" || venv == "app-store" { // appstore
-			procLog.Info.Printf("[DEPLOY-INF] Deploy app as app-store.")
-			venv = bwcFramework.Spec.Env.VirtualEnv
-			runTime = bwcFramework.Spec.Env.RunTime
-		} else {
-			procLog.Info.Printf("[DEPLOY-INF] Deploy app as console.")
-			runTime = bwcFramework.Spec.Env.RunTime
-		}
-		procLog.Info.Printf("[DEPLOY-INF] The runtime is %s.\n", runTime)
-
-		// Only Python
-		// Check exist python Virtual Env
-		procLog.Info.Printf("[DEPLOY-INF] [%d / %d] Checking if %s venv exists.\n", appIndex+1, len(deployData.Apps), venv)
-		envList := GetVenvList(svcInfo.VenvPath)
-		if !Contains(envList, venv) {
-			// Create Virutal Env
-			procLog.Warn.Printf("[DEPLOY-INF] %s's venv not found.\n", venv)
-			procLog.Warn.Printf("[DEPLOY-INF] %s's venv install in device.\n", venv)
-			venvData := sdtType.CmdVenv{
-				VenvName:    venv,
-				Requirement: bwcFramework.Spec.Env.Package,
-				BinFile:     bwcFramework.Spec.Env.Bin,
-				RunTime:     bwcFramework.Spec.Env.RunTime,
+	sortedApps := make([]sdtType.InferenceDeploy, 0, len(apps))
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		sortedApps = append(sortedApps, apps[i])
+		for _, j := range dependents[i] {
+			inDegree[j]--
+			if inDegree[j] == 0 {
+				queue = append(queue, j)
+			}
+		}
+	}
+
+	if len(sortedApps) != len(apps) {
+		var cycleApps []string
+		for i, app := range apps {
+			if inDegree[i] > 0 {
+				cycleApps = append(cycleApps, app.AppName)
 			}
-			stdout, cmdErr, statusCode := CreateVenv(homeUser, venvData, filePath, svcInfo)
-			InstallDefaultPkg(venvData.VenvName, configData.DeviceType, configData.ServiceType, svcInfo)
+		}
+		return nil, fmt.Errorf("Circular dependency between apps: %s", strings.Join(cycleApps, ", "))
+	}
+	return sortedApps, nil
+}
 
-			if cmdErr != nil {
-				procLog.Error.Printf("[DEPLOY-INF] Failed download python pkg.\n")
-				cmdErr = errors.New(string(stdout))
-				return inferenceResult, cmdErr, statusCode, venv
+// findFailedDependency function returns the first dependency of the app that failed to deploy.
+//
+// Input:
+//   - appItem: App to deploy.
+//   - failedApps: Names of the apps that failed to deploy.
+//
+// Output:
+//   - string: Name of the failed dependency. (Empty if there is none)
+func findFailedDependency(appItem sdtType.InferenceDeploy, failedApps []string) string {
+	for _, dep := range appItem.DependsOn {
+		for _, failedApp := range failedApps {
+			if dep == failedApp {
+				return dep
 			}
+		}
+	}
+	return ""
+}
 
-			newUUID := uuid.New()
-			requestId := newUUID.String()
+// The inferenceDeployApp function deploys an app of the inference app group. The app is
+// downloaded, its venv and Systemd (.service) file are created, and the app is started.
+//
+// Input:
+//   - appIndex: Index of the app in the app group.
+//   - appItem: Struct containing deployment information of the app.
+//   - deployData: Struct containing deployment command information.
+//   - archType: The architecture of the device.
+//   - sharedBaseCache: Base archives already downloaded in this deploy. (baseImage URL -> local path)
+//
+// Output:
+//   - map[string]interface{}: Information about the application (app name, PID, app size).
+//   - error: Error message in case of issues with the deploy command.
+//   - int: Status code of the command execution.
+//   - string: Virtual environment of the application.
+func inferenceDeployApp(appIndex int,
+	appItem sdtType.InferenceDeploy,
+	deployData sdtType.CmdDeploy,
+	archType string,
+	svcInfo sdtType.ControlService,
+	configData sdtType.ConfigInfo,
+	homeUser string,
+	cli mqttCli.Client,
+	sharedBaseCache map[string]string) (map[string]interface{}, error, int, string) {
+	var deployResult map[string]interface{}
+	var bwcFramework sdtType.Framework
+	var runTime, venv, appId, appName, modelFileName string
+	var cmdErr error
+	var pid int
 
-			// 결과 메시지 생성
-			cmdResult := sdtType.NewCmdResult("virtualEnv", "venvCreate", stdout)
-			cmdResult.VenvName = venvData.VenvName
+	venv = appItem.VenvName
+	appId = appItem.AppId
+	appName = appItem.AppName
 
-			cmdStatus := sdtType.NewCmdStatus(statusCode)
-			if cmdErr == nil {
-				cmdStatus.ErrMsg = ""
-				cmdStatus.Succeed = 1
-			} else {
-				cmdStatus.ErrMsg = fmt.Sprintf("%v", cmdErr)
-				cmdStatus.Succeed = 0
-			}
+	// 결과 변수 초기화
+	deployResult = map[string]interface{}{
+		"name":        "",
+		"pid":         -1,
+		"size":        -1,
+		"appRepoPath": "",
+		"venv":        "",
+	}
 
-			result := sdtType.ResultMsg{
-				AssetCode: configData.AssetCode,
-				Result:    &cmdResult,
-				Status:    cmdStatus,
-				RequestId: requestId,
-			}
+	procLog.Info.Printf("[DEPLOY-INF] [%d / %d] %s App deploy. \n", appIndex+1, len(deployData.Apps), appName)
 
-			topic := fmt.Sprintf("%s/%s/%s/bwc/control/self-deploy", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
-			sdtMessage.SendDataEdgeMqtt(result, topic, cli)
+	// Open deploy.log
+	deployFile, deployLog := openDeployLog(svcInfo.AppPath, appName, appId)
+	defer deployFile.Close()
+	deployLog.Printf("Start deploy %s app. (appId: %s, appGroupId: %s, fileUrl: %s)\n", appName, appId, deployData.AppGroupId, appItem.FileUrl)
 
-		}
+	// app download
+	maxRetries := GetMaxRetries(deployData.MaxRetries)
+	filePath, fileSize, cmdErr, appRepoPath, fileZip := downloadWithRetry(maxRetries, appItem.FileUrl, appId, appItem.App, appName, archType, svcInfo)
 
-		// APP info 저장
-		// save Inference deploy json
-		SaveAppInfo(appName, appId, venv, "systemd", deployData.Apps[appIndex], deployData.AppGroupId, svcInfo.RootPath)
+	if cmdErr != nil {
+		procLog.Error.Printf("[DEPLOY-INF] Download Error: %v\n", cmdErr)
+		deployLog.Printf("Download failed: %v\n", cmdErr)
+		return deployResult, cmdErr, http.StatusBadRequest, venv
+	}
+	deployLog.Printf("Download complete: %s (%d bytes)\n", filePath, fileSize)
 
-		CreatePythonService(filePath, appName, venv, "main.py", svcInfo.VenvPath)
+	// change appname in framework.yaml
+	SaveFramework(appName, appId, svcInfo.AppPath)
 
-		// Inference와 Request APP 구분
-		if appItem.AppType == "INFERENCE" {
-			cmdErr = CreateInferenceDir(filePath)
-			if cmdErr != nil {
-				procLog.Error.Printf("[DEPLOY-INF] Failed creating inference directory.\n")
-				return inferenceResult, cmdErr, http.StatusBadRequest, venv
-			}
+	// new version
+	bwcFramework = GetVenvFromFramework(appName, appId, svcInfo.AppPath)
 
-			procLog.Info.Printf("[DEPLOY-INF] [%d / %d] App is inference. So, device download weight file.\n", appIndex+1, len(deployData.Apps))
-			//modelFileName = appItem.Parameter[appItem.ModelFileKey].(string)
-			keys := strings.Split(appItem.ModelFileKey, ".")
-			fileDict := appItem.Parameter
-			for n := 0; n < len(keys)-1; n++ {
-				fileDict = fileDict[keys[n]].(map[string]interface{})
-			}
+	// Link the shared base archive into the app. Files of the app archive take precedence.
+	if baseImage := bwcFramework.Spec.BaseImage; baseImage != "" {
+		basePath, baseErr := GetSharedBase(baseImage, sharedBaseCache, svcInfo)
+		if baseErr == nil {
+			baseErr = LinkSharedBase(basePath, filePath)
+		}
+		if baseErr != nil {
+			procLog.Error.Printf("[DEPLOY-INF] Base image Error: %v\n", baseErr)
+			deployLog.Printf("Base image failed: %v\n", baseErr)
+			return deployResult, baseErr, http.StatusBadRequest, venv
+		}
+		deployLog.Printf("Base image linked: %s\n", basePath)
+	}
 
-			modelFileName = fileDict[keys[len(keys)-1]].(string)
-			cmdErr = DownloadWeight_new(appItem.ModelUrl, appName, appId, modelFileName, svcInfo.AppPath)
-			if cmdErr != nil {
-				procLog.Error.Printf("[DEPLOY-INF] Failed download inference model.\n")
-				return inferenceResult, cmdErr, http.StatusBadRequest, venv
-			}
+	// Check exist about app
+	if CheckExistApp(appName, svcInfo.RootPath) {
+		procLog.Error.Printf("[DEPLOY-INF] %s's app already exist.\n", appName)
+		deployLog.Printf("%s's app already exist.\n", appName)
+		return deployResult, errors.New("App already exist."), http.StatusBadRequest, venv
+	}
 
-			// Apply config
-			// Common Parameter
-			parameter := sdtType.CmdJson{
-				AppId:     appId,
-				AppName:   appName,
-				FileName:  "config.json", // BW와 약속한 config 파일 이름
-				Parameter: appItem.Parameter,
-			}
+	// Get venv from framework
+	if venv == "" || venv == "app-store" { // appstore
+		procLog.Info.Printf("[DEPLOY-INF] Deploy app as app-store.")
+		venv = bwcFramework.Spec.Env.VirtualEnv
+		runTime = bwcFramework.Spec.Env.RunTime
+	} else {
+		procLog.Info.Printf("[DEPLOY-INF] Deploy app as console.")
+		runTime = bwcFramework.Spec.Env.RunTime
+	}
+	procLog.Info.Printf("[DEPLOY-INF] The runtime is %s.\n", runTime)
+
+	// Only Python
+	// Check exist python Virtual Env
+	procLog.Info.Printf("[DEPLOY-INF] [%d / %d] Checking if %s venv exists.\n", appIndex+1, len(deployData.Apps), venv)
+	envList := GetVenvList(svcInfo.VenvPath)
+	if !Contains(envList, venv) {
+		// Create Virutal Env
+		procLog.Warn.Printf("[DEPLOY-INF] %s's venv not found.\n", venv)
+		procLog.Warn.Printf("[DEPLOY-INF] %s's venv install in device.\n", venv)
+		venvData := sdtType.CmdVenv{
+			VenvName:    venv,
+			Requirement: bwcFramework.Spec.Env.Package,
+			BinFile:     bwcFramework.Spec.Env.Bin,
+			RunTime:     bwcFramework.Spec.Env.RunTime,
+		}
+		stdout, cmdErr, statusCode := CreateVenv(homeUser, venvData, filePath, svcInfo)
+		InstallDefaultPkg(venvData.VenvName, configData.DeviceType, configData.ServiceType, svcInfo)
 
-			_, configErr, _ := sdtConfig.JsonChange(parameter, svcInfo.AppPath, "")
-			if configErr != nil {
-				procLog.Error.Printf("[DEPLOY-INF] Failed fixing parameter.\n")
-				return inferenceResult, configErr, http.StatusBadRequest, venv
-			}
-		} else if appItem.AppType == "REQUEST" {
-			procLog.Info.Printf("[DEPLOY-INF] [%d / %d] App is request.\n", appIndex+1, len(deployData.Apps))
+		if cmdErr != nil {
+			procLog.Error.Printf("[DEPLOY-INF] Failed download python pkg.\n")
+			deployLog.Printf("Venv creation failed: %s\n", stdout)
+			cmdErr = errors.New(string(stdout))
+			return deployResult, cmdErr, statusCode, venv
+		}
+		deployLog.Printf("Venv created: %s\n", venv)
+
+		newUUID := uuid.New()
+		requestId := newUUID.String()
+
+		// 결과 메시지 생성
+		cmdResult := sdtType.NewCmdResult("virtualEnv", "venvCreate", stdout)
+		cmdResult.VenvName = venvData.VenvName
+
+		cmdStatus := sdtType.NewCmdStatus(statusCode)
+		if cmdErr == nil {
+			cmdStatus.ErrMsg = ""
+			cmdStatus.Succeed = 1
 		} else {
-			procLog.Error.Printf("[DEPLOY-INF] %s: Invalid app type. \n", appItem.AppType)
-			return inferenceResult, errors.New(fmt.Sprintf("%s: Invalid app type. \n", appItem.AppType)), http.StatusBadRequest, venv
+			cmdStatus.ErrMsg = fmt.Sprintf("%v", cmdErr)
+			cmdStatus.Succeed = 0
 		}
 
-		// start systemd
-		startCmd := fmt.Sprintf("systemctl start %s", appName)
-		cmd_run := exec.Command("sh", "-c", startCmd)
-		stdout, cmd_err := cmd_run.CombinedOutput()
-		if cmd_err != nil {
-			procLog.Error.Println("[DEPLOY-INF] Fail deploy: ", cmd_err, "\n", string(stdout))
-			cmd_err = errors.New(string(stdout))
-			return inferenceResult, errors.New(string(stdout)), http.StatusBadRequest, venv
+		result := sdtType.ResultMsg{
+			AssetCode: configData.AssetCode,
+			Result:    &cmdResult,
+			Status:    cmdStatus,
+			RequestId: requestId,
 		}
 
-		// enable systemd
-		startCmd = fmt.Sprintf("systemctl enable %s", appName)
-		cmd_run = exec.Command("sh", "-c", startCmd)
-		stdout, cmd_err = cmd_run.CombinedOutput()
-		if cmd_err != nil {
-			procLog.Error.Println("[DEPLOY] Fail deploy: ", cmd_err, "\n", string(stdout))
-			cmd_err = errors.New(string(stdout))
-			return inferenceResult, errors.New(string(stdout)), http.StatusBadRequest, venv
+		topic := configData.Topic("bwc/control/self-deploy")
+		sdtMessage.SendDataEdgeMqtt(result, topic, cli)
+
+	}
+
+	// APP info 저장
+	// save Inference deploy json
+	SaveAppInfo(appName, appId, venv, "systemd", deployData.Apps[appIndex], deployData.AppGroupId, svcInfo.RootPath)
+
+	cmdErr = CreatePythonService(filePath, appName, venv, "main.py", svcInfo.VenvPath, bwcFramework.Spec.Env, bwcFramework.Spec.WorkingDir)
+	if cmdErr != nil {
+		procLog.Error.Printf("[DEPLOY-INF] Failed creating service file: %v\n", cmdErr)
+		deployLog.Printf("Service file failed: %v\n", cmdErr)
+		return deployResult, cmdErr, http.StatusBadRequest, venv
+	}
+	deployLog.Printf("Service file written: %s.service\n", appName)
+
+	// Inference와 Request APP 구분
+	if appItem.AppType == "INFERENCE" {
+		cmdErr = CreateInferenceDir(filePath)
+		if cmdErr != nil {
+			procLog.Error.Printf("[DEPLOY-INF] Failed creating inference directory.\n")
+			return deployResult, cmdErr, http.StatusBadRequest, venv
 		}
-		procLog.Info.Println("[DEPLOY-INF] End Deploy..")
 
-		// get pid
-		pid, cmd_err, _ = GetPid(appName)
-		if cmd_err != nil {
-			// get error log
-			logResult := GetLogsApp(svcInfo.AppPath, appName, appId)
-			//cmd_log := exec.Command("journalctl", "-u", appName, "-n", "30")
-			//stdout, _ = cmd_log.CombinedOutput()
-			return inferenceResult, errors.New(logResult), http.StatusBadRequest, venv
-		}
-
-		procLog.Info.Printf("[DEPLOY-INF] Remove ZIP File: %s\n", fileZip)
-		removeErr := os.Remove(fileZip)
-		if removeErr != nil {
-			time.Sleep(1)
-			os.Remove(fileZip)
-		}
-		procLog.Warn.Printf("[DEPLOY-INF] REMOVE: %s\n", removeErr)
-
-		//deployResult = map[string]interface{}{
-		//	"name":        appName,
-		//	"pid":         pid,
-		//	"size":        fileSize,
-		//	"appRepoPath": appRepoPath,
-		//	"venv":        venv,
-		//}
-		deployResult["name"] = appName
-		deployResult["pid"] = pid
-		deployResult["size"] = fileSize
-		deployResult["appRepoPath"] = appRepoPath
-		deployResult["venv"] = venv
+		procLog.Info.Printf("[DEPLOY-INF] [%d / %d] App is inference. So, device download weight file.\n", appIndex+1, len(deployData.Apps))
+		//modelFileName = appItem.Parameter[appItem.ModelFileKey].(string)
+		keys := strings.Split(appItem.ModelFileKey, ".")
+		fileDict := appItem.Parameter
+		for n := 0; n < len(keys)-1; n++ {
+			fileDict = fileDict[keys[n]].(map[string]interface{})
+		}
 
-		inferenceResult = append(inferenceResult, deployResult)
+		modelFileName = fileDict[keys[len(keys)-1]].(string)
+		cmdErr = DownloadWeight_new(appItem.ModelUrl, appName, appId, modelFileName, svcInfo.AppPath)
+		if cmdErr != nil {
+			procLog.Error.Printf("[DEPLOY-INF] Failed download inference model.\n")
+			deployLog.Printf("Weight download failed: %v\n", cmdErr)
+			return deployResult, cmdErr, http.StatusBadRequest, venv
+		}
+		deployLog.Printf("Weight download complete: %s\n", modelFileName)
+
+		// Apply config
+		// Common Parameter
+		parameter := sdtType.CmdJson{
+			AppId:     appId,
+			AppName:   appName,
+			FileName:  "config.json", // BW와 약속한 config 파일 이름
+			Parameter: appItem.Parameter,
+		}
 
+		_, configErr, _ := sdtConfig.JsonChange(parameter, svcInfo.AppPath, "")
+		if configErr != nil {
+			procLog.Error.Printf("[DEPLOY-INF] Failed fixing parameter.\n")
+			return deployResult, configErr, http.StatusBadRequest, venv
+		}
+	} else if appItem.AppType == "REQUEST" {
+		procLog.Info.Printf("[DEPLOY-INF] [%d / %d] App is request.\n", appIndex+1, len(deployData.Apps))
+	} else {
+		procLog.Error.Printf("[DEPLOY-INF] %s: Invalid app type. \n", appItem.AppType)
+		return deployResult, errors.New(fmt.Sprintf("%s: Invalid app type. \n", appItem.AppType)), http.StatusBadRequest, venv
+	}
+
+	// start systemd
+	stdout, cmd_err := startServiceWithRetry(appName, maxRetries)
+	if cmd_err != nil {
+		procLog.Error.Println("[DEPLOY-INF] Fail deploy: ", cmd_err, "\n", string(stdout))
+		deployLog.Printf("systemd start failed: %s\n", stdout)
+		cmd_err = errors.New(string(stdout))
+		return deployResult, errors.New(string(stdout)), http.StatusBadRequest, venv
+	}
+	deployLog.Printf("systemd started: %s\n", appName)
+
+	// enable systemd
+	startCmd := fmt.Sprintf("systemctl enable %s", appName)
+	cmd_run := exec.Command("sh", "-c", startCmd)
+	stdout, cmd_err = cmd_run.CombinedOutput()
+	if cmd_err != nil {
+		procLog.Error.Println("[DEPLOY] Fail deploy: ", cmd_err, "\n", string(stdout))
+		deployLog.Printf("systemd enable failed: %s\n", stdout)
+		cmd_err = errors.New(string(stdout))
+		return deployResult, errors.New(string(stdout)), http.StatusBadRequest, venv
+	}
+	procLog.Info.Println("[DEPLOY-INF] End Deploy..")
+
+	// get pid
+	pid, cmd_err, _ = GetPid(appName)
+	if cmd_err != nil {
+		// get error log
+		logResult := GetLogsApp(svcInfo.AppPath, appName, appId)
+		//cmd_log := exec.Command("journalctl", "-u", appName, "-n", "30")
+		//stdout, _ = cmd_log.CombinedOutput()
+		deployLog.Printf("PID not found: %v\n", cmd_err)
+		return deployResult, errors.New(logResult), http.StatusBadRequest, venv
 	}
+	deployLog.Printf("PID obtained: %d\n", pid)
 
-	return inferenceResult, cmdErr, http.StatusOK, venv
+	procLog.Info.Printf("[DEPLOY-INF] Remove ZIP File: %s\n", fileZip)
+	removeErr := os.Remove(fileZip)
+	if removeErr != nil {
+		time.Sleep(1)
+		os.Remove(fileZip)
+	}
+	procLog.Warn.Printf("[DEPLOY-INF] REMOVE: %s\n", removeErr)
+
+	//deployResult = map[string]interface{}{
+	//	"name":        appName,
+	//	"pid":         pid,
+	//	"size":        fileSize,
+	//	"appRepoPath": appRepoPath,
+	//	"venv":        venv,
+	//}
+	deployResult["name"] = appName
+	deployResult["pid"] = pid
+	deployResult["size"] = fileSize
+	deployResult["appRepoPath"] = appRepoPath
+	deployResult["venv"] = venv
+
+	return deployResult, nil, http.StatusOK, venv
 }
 
 // The GetPid function retrieves the PID of an application deployed on the device.
@@ -993,21 +1305,37 @@ func GetPid(appName string) (int, error, int) {
 
 // The DeleteVenv function deletes a Python virtual environment installed on the device.
 // Virtual environments are used for managing dependencies for python applications.
+// If an app deployed on the device uses the virtual environment, or app.json can't be read,
+// it isn't deleted.
 //
 // Input:
 //   - envName: Name of the virtual environment.
+//   - rootPath: Root path of the BWC.
 //
 // Output:
 //   - string: Result message of the operation.
 //   - error: Error message in case of issues with the deleteVenv command.
 //   - int: Status code of the command execution.
-func DeleteVenv(envName string, svcInfo sdtType.ControlService) (string, error, int) {
+func DeleteVenv(envName string, rootPath string, svcInfo sdtType.ControlService) (string, error, int) {
 	if envName == "" || envName == "*" {
 		cmd_err := errors.New("Not collect value.")
 		procLog.Error.Printf("[DELETE-ENV] Not collect value: %s\n", envName)
 		return "", cmd_err, http.StatusBadRequest
 	}
 
+	// Check venv used
+	appNames, err := GetAppsFromVenv(envName, rootPath)
+	if err != nil {
+		cmd_err := fmt.Errorf("Can't check apps using %s venv: %v", envName, err)
+		procLog.Error.Printf("[DELETE-ENV] %v\n", cmd_err)
+		return "", cmd_err, http.StatusBadRequest
+	}
+	if len(appNames) > 0 {
+		cmd_err := fmt.Errorf("%s venv used by %s app.", envName, strings.Join(appNames, ", "))
+		procLog.Error.Printf("[DELETE-ENV] %v\n", cmd_err)
+		return "", cmd_err, http.StatusConflict
+	}
+
 	targetEnv := fmt.Sprintf("%s/%s", svcInfo.VenvPath, envName)
 	cmd_err := os.RemoveAll(targetEnv)
 	if cmd_err != nil {
@@ -1029,12 +1357,13 @@ func CreateBaseVenv(homeUser string, svcInfo sdtType.ControlService) {
 	envName := "base"
 	envHome := svcInfo.VenvPath
 
-	if _, err := os.Stat(envHome); os.IsNotExist(err) {
-		os.Mkdir(envHome, os.ModePerm)
+	if err := os.MkdirAll(envHome, os.ModePerm); err != nil {
+		procLog.Error.Printf("[CREATE-ENV-BASE] Cannot create venv directory %s: %v\n", envHome, err)
+		return
 	}
 
 	// Check Base Venv
-	envDir, _ := ioutil.ReadDir(envHome)
+	envDir, _ := os.ReadDir(envHome)
 	for _, f := range envDir {
 		if f.IsDir() {
 			if f.Name() == "base" {
@@ -1088,7 +1417,10 @@ func CreateBaseVenv(homeUser string, svcInfo sdtType.ControlService) {
 
 // The CreateVenv function creates a python virtual environment on the device.
 // Virtual environments are isolated spaces used for python applications to manage package dependencies.
-// They are specific to python applications.
+// They are specific to python applications. The virtual environment is created at its final path,
+// since conda writes the path into its files (conda-meta, activate.d, scripts). While it is created,
+// the marker file of venvIncompleteFile exists, so GetVenvList skips it. A failed creation, or one
+// left by a stopped agent, is removed.
 //
 // Input:
 //   - homeUser: Hostname of the device.
@@ -1104,11 +1436,29 @@ func CreateVenv(homeUser string, venvData sdtType.CmdVenv, appDir string, svcInf
 	envHome := svcInfo.VenvPath
 	minicondaPath := svcInfo.MinicondaPath
 
-	if _, err := os.Stat(envHome); os.IsNotExist(err) {
-		os.Mkdir(envHome, os.ModePerm)
+	if err := os.MkdirAll(envHome, os.ModePerm); err != nil {
+		procLog.Error.Printf("[CREATE-ENV] Cannot create venv directory %s: %v\n", envHome, err)
+		return "", err, http.StatusBadRequest
 	}
 
 	envPath := fmt.Sprintf("%s/%s", envHome, envName)
+	markerFile := venvIncompleteFile(envPath)
+	if _, err := os.Stat(markerFile); err == nil {
+		procLog.Warn.Printf("[CREATE-ENV] Remove incomplete V-ENV: %s\n", envPath)
+		os.RemoveAll(envPath)
+	}
+	if err := os.WriteFile(markerFile, nil, 0644); err != nil {
+		procLog.Error.Printf("[CREATE-ENV] Create marker file Error: %v\n", err)
+		return "", err, http.StatusBadRequest
+	}
+	created := false
+	defer func() {
+		// Remove incomplete venv if creation failed.
+		if !created {
+			os.RemoveAll(envPath)
+		}
+		os.Remove(markerFile)
+	}()
 	var createEnvCmd string
 
 	runTimeVersion := strings.Replace(venvData.RunTime, "python", "", -1)
@@ -1135,10 +1485,9 @@ func CreateVenv(homeUser string, venvData sdtType.CmdVenv, appDir string, svcInf
 	} else {
 		pkgFile, err := os.Create(pkgFileName)
 		if err != nil {
-			procLog.Error.Printf("[CREATE-ENV] Create requirement file Error: %v\n", cmd_err)
-			return "", cmd_err, http.StatusBadRequest
+			procLog.Error.Printf("[CREATE-ENV] Create requirement file Error: %v\n", err)
+			return "", err, http.StatusBadRequest
 		}
-		defer pkgFile.Close()
 
 		// Split Package name...
 		//  - " " 스페이스바, # 주석 라인 전처리 작업(삭제)
@@ -1151,10 +1500,12 @@ func CreateVenv(homeUser string, venvData sdtType.CmdVenv, appDir string, svcInf
 			content := fmt.Sprintf("%s\n", vals)
 			_, err = pkgFile.WriteString(content)
 			if err != nil {
-				procLog.Error.Printf("[CREATE-ENV] Write requirement file Error: %v\n", cmd_err)
-				return "", cmd_err, http.StatusBadRequest
+				pkgFile.Close()
+				procLog.Error.Printf("[CREATE-ENV] Write requirement file Error: %v\n", err)
+				return "", err, http.StatusBadRequest
 			}
 		}
+		pkgFile.Close()
 	}
 
 	procLog.Info.Printf("[CREATE-ENV] Install app's pkg.\n")
@@ -1180,10 +1531,26 @@ func CreateVenv(homeUser string, venvData sdtType.CmdVenv, appDir string, svcInf
 		return "", errContent, http.StatusBadRequest
 	}
 	procLog.Info.Printf("[CREATE-ENV] Installed V-ENV's package.\n")
+
+	created = true
+
 	venvResult := "Created V-Env."
 	return venvResult, cmd_err, http.StatusOK
 }
 
+// venvIncompleteFile function returns the marker file of a virtual environment in creation.
+// It is next to the virtual environment (<venvPath>/.<envName>.incomplete), since conda creates
+// the virtual environment only in a directory that doesn't exist.
+//
+// Input:
+//   - envPath: Path of the virtual environment.
+//
+// Output:
+//   - string: Path of the marker file.
+func venvIncompleteFile(envPath string) string {
+	return filepath.Join(filepath.Dir(envPath), fmt.Sprintf(".%s.incomplete", filepath.Base(envPath)))
+}
+
 // New version(only miniconda)
 func CreateVenv_newVersion(homeUser string, venvData sdtType.CmdVenv, configData sdtType.ConfigInfo) (string, error, int) {
 	// Set Variable
@@ -1201,8 +1568,9 @@ func CreateVenv_newVersion(homeUser string, venvData sdtType.CmdVenv, configData
 	// Set Runtime Version
 	runTimeVersion = strings.Replace(venvData.BinFile, "python", "", -1)
 
-	if _, err := os.Stat(envHome); os.IsNotExist(err) {
-		os.Mkdir(envHome, os.ModePerm)
+	if err := os.MkdirAll(envHome, os.ModePerm); err != nil {
+		procLog.Error.Printf("[CREATE-ENV] Cannot create venv directory %s: %v\n", envHome, err)
+		return "", err, http.StatusBadRequest
 	}
 
 	if configData.DeviceType == "nodeq" {
@@ -1337,8 +1705,208 @@ func UpdateVenv(venvData sdtType.CmdVenv, svcInfo sdtType.ControlService) (strin
 	return updateResult, cmd_err, http.StatusOK
 }
 
+// diskHeadroom is the free disk space that must be left after the app file is downloaded. (Byte)
+const diskHeadroom = 200 * 1024 * 1024
+
+// diskFree returns the free disk space of the path. It is a variable so that it can be replaced in tests.
+var diskFree = freeDiskSpace
+
+// ErrInsufficientDisk is returned by fileDownload when the device doesn't have enough free disk
+// space for the app file and diskHeadroom.
+//   - Path: Directory where the app file is downloaded.
+//   - Required: Size of the app file + diskHeadroom. (Byte)
+//   - Free: Free disk space of Path. (Byte)
+type ErrInsufficientDisk struct {
+	Path     string
+	Required uint64
+	Free     uint64
+}
+
+// Error function returns the message of ErrInsufficientDisk.
+func (e *ErrInsufficientDisk) Error() string {
+	return fmt.Sprintf("Insufficient disk space in %s: %d MB required, %d MB free",
+		e.Path, e.Required/1024/1024, e.Free/1024/1024)
+}
+
+// getContentLength function reads the size of the file from the Content-Length of a HEAD request.
+//
+// Input:
+//   - fullURLFile: URL of the file.
+//
+// Output:
+//   - int64: Size of the file. (-1 if the server doesn't return it)
+func getContentLength(fullURLFile string) int64 {
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Head(fullURLFile)
+	if err != nil {
+		procLog.Warn.Printf("[DEPLOY] HEAD %s error: %v\n", fullURLFile, err)
+		return -1
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return -1
+	}
+	return resp.ContentLength
+}
+
+// checkDiskSpace function checks that the directory has free space for the file and diskHeadroom.
+// If the size of the file is unknown, only diskHeadroom is checked.
+//
+// Input:
+//   - dir: Directory where the file is downloaded.
+//   - fileSize: Size of the file to download. (Byte, -1 if unknown)
+//
+// Output:
+//   - error: *ErrInsufficientDisk if the free space is not enough.
+func checkDiskSpace(dir string, fileSize int64) error {
+	free, err := diskFree(dir)
+	if err != nil {
+		// Don't block the deploy if the free space cannot be read.
+		procLog.Warn.Printf("[DEPLOY] Cannot get free disk space of %s: %v\n", dir, err)
+		return nil
+	}
+	required := uint64(diskHeadroom)
+	if fileSize > 0 {
+		required += uint64(fileSize)
+	}
+	if free < required {
+		return &ErrInsufficientDisk{Path: dir, Required: required, Free: free}
+	}
+	return nil
+}
+
+// defaultMaxRetries is the number of attempts of the download and the service start when
+// maxRetries of the deploy command is not set.
+const defaultMaxRetries = 3
+
+// Waiting time between the attempts. They are variables so that they can be replaced in tests.
+//   - downloadRetryWait: Waiting time before the app file is downloaded again.
+//   - startRetryWait: Waiting time before systemctl start is run again.
+//   - downloadFile: Function that downloads the app file. (fileDownload)
+var (
+	downloadRetryWait = 5 * time.Second
+	startRetryWait    = 10 * time.Second
+	downloadFile      = fileDownload
+)
+
+// ErrDownloadStatus is returned by fileDownload when the server responds with an unexpected status.
+//   - StatusCode: HTTP status code of the response.
+//   - Status: HTTP status of the response. (ex. 503 Service Unavailable)
+type ErrDownloadStatus struct {
+	StatusCode int
+	Status     string
+}
+
+// Error function returns the message of ErrDownloadStatus.
+func (e *ErrDownloadStatus) Error() string {
+	return fmt.Sprintf("Download error: %s", e.Status)
+}
+
+// GetMaxRetries function returns the number of attempts of the deploy command.
+//
+// Input:
+//   - maxRetries: maxRetries of the deploy command.
+//
+// Output:
+//   - int: maxRetries, or defaultMaxRetries if it is not set.
+func GetMaxRetries(maxRetries int) int {
+	if maxRetries <= 0 {
+		return defaultMaxRetries
+	}
+	return maxRetries
+}
+
+// isRetryableDownloadError function checks whether the download can succeed if it is tried again.
+// Network errors, a connection closed during the download and server errors (5xx) are retried.
+//
+// Input:
+//   - err: Error of fileDownload.
+//
+// Output:
+//   - bool: True if the download should be retried.
+func isRetryableDownloadError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var statusErr *ErrDownloadStatus
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// downloadWithRetry function calls fileDownload up to maxRetries times while it fails with a network
+// error, waiting downloadRetryWait between the attempts. An interrupted download is resumed
+// from the partial file by fileDownload.
+//
+// Input:
+//   - maxRetries: Number of attempts.
+//   - The other inputs are the same as fileDownload.
+//
+// Output:
+//   - Same as fileDownload.
+func downloadWithRetry(
+	maxRetries int,
+	fullURLFile string,
+	appId string,
+	app string,
+	appName string,
+	archType string,
+	svcInfo sdtType.ControlService,
+) (string, int64, error, string, string) {
+	var filePath, appRepoPath, fileZip string
+	var fileSize int64
+	var err error
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		filePath, fileSize, err, appRepoPath, fileZip = downloadFile(fullURLFile, appId, app, appName, archType, svcInfo)
+		if err == nil || !isRetryableDownloadError(err) || attempt == maxRetries {
+			break
+		}
+		procLog.Warn.Printf("[DEPLOY] Download %s failed (attempt %d / %d): %v. Retry in %v\n",
+			appName, attempt, maxRetries, err, downloadRetryWait)
+		time.Sleep(downloadRetryWait)
+	}
+	return filePath, fileSize, err, appRepoPath, fileZip
+}
+
+// startServiceWithRetry function runs "systemctl start" up to maxRetries times, waiting
+// startRetryWait between the attempts. The service can fail to start right after it is created
+// (ex. the unit file is not loaded yet).
+//
+// Input:
+//   - appName: Name of the service.
+//   - maxRetries: Number of attempts.
+//
+// Output:
+//   - []byte: Output of the last systemctl start.
+//   - error: Error of the last systemctl start.
+func startServiceWithRetry(appName string, maxRetries int) ([]byte, error) {
+	var stdout []byte
+	var err error
+	startCmd := fmt.Sprintf("systemctl start %s", appName)
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		stdout, err = exec.Command("sh", "-c", startCmd).CombinedOutput()
+		if err == nil || attempt == maxRetries {
+			break
+		}
+		procLog.Warn.Printf("[DEPLOY] systemctl start %s failed (attempt %d / %d): %v, %s. Retry in %v\n",
+			appName, attempt, maxRetries, err, strings.TrimSpace(string(stdout)), startRetryWait)
+		time.Sleep(startRetryWait)
+	}
+	return stdout, err
+}
+
 // The fileDownload function downloads an application file from a code repository.
-// The application is installed in the "/usr/local/sdt/app" directory.
+// The application is installed in the "/usr/local/sdt/app" directory. If the URI uses
+// the "minio://" or "s3://" scheme, the file is downloaded from the object storage.
+// Before the download, the free disk space is checked against the size of the file (Content-Length
+// of a HEAD request) and 200MB of headroom. If it is not enough, *ErrInsufficientDisk is returned.
+// The file is downloaded to "<app dir>/.download/<hash of URL>/", and an interrupted download is
+// resumed from its partial file. (See downloadHTTP)
 //
 // Input:
 //   - fullURLFile: URI of the application file to download.
@@ -1346,6 +1914,7 @@ func UpdateVenv(venvData sdtType.CmdVenv, svcInfo sdtType.ControlService) (strin
 //   - app: Application name stored in the code repository.
 //   - appName: Name of the application to deploy.
 //   - archType: Device architecture.
+//   - svcInfo: Struct containing the environment information of the control agent.
 //
 // Output:
 //   - string: Path of the installed application on the device.
@@ -1359,20 +1928,25 @@ func fileDownload(
 	app string, //App's name
 	appName string, // local app's name
 	archType string, // arch -> linux or window
+	svcInfo sdtType.ControlService,
 ) (string, int64, error, string, string) {
 	// Build fileName from fullPath
 	fileURL, err := url.Parse(fullURLFile)
 	if err != nil {
 		procLog.Error.Println("[DEPLOY] fileDownload URL parse error: ", err)
+		return "", 0, err, "", ""
 	}
 	path := fileURL.Path
 	segments := strings.Split(path, "/")
 	fileName := segments[len(segments)-1]
-	appRepoPath := fmt.Sprintf("%s://%s/%s/%s:%s\n", fileURL.Scheme,
-		fileURL.Host,
-		segments[1],
-		segments[2],
-		strings.Split(fileName, ".zip")[0])
+	appRepoPath := fullURLFile
+	if len(segments) > 2 {
+		appRepoPath = fmt.Sprintf("%s://%s/%s/%s:%s\n", fileURL.Scheme,
+			fileURL.Host,
+			segments[1],
+			segments[2],
+			strings.Split(fileName, ".zip")[0])
+	}
 	// Create blank file
 	var appDir string
 	if archType == "win" {
@@ -1380,36 +1954,63 @@ func fileDownload(
 	} else {
 		appDir = "/usr/local/sdt/app"
 	}
-	if _, err := os.Stat(appDir); os.IsNotExist(err) {
-		os.Mkdir(appDir, os.ModePerm)
+	if err := os.MkdirAll(appDir, os.ModePerm); err != nil {
+		procLog.Error.Printf("[DEPLOY] Cannot create app directory %s: %v\n", appDir, err)
+		return "", 0, err, appRepoPath, ""
+	}
+
+	// Downloads are keyed by the full URL, so apps whose archives have the same name
+	// (ex. archive/main.zip) don't share a partial file.
+	urlHash := fmt.Sprintf("%x", sha256.Sum256([]byte(fullURLFile)))
+	downloadDir := fmt.Sprintf("%s/.download/%s", appDir, urlHash[:16])
+	if err := os.MkdirAll(downloadDir, os.ModePerm); err != nil {
+		procLog.Error.Printf("[DEPLOY] Cannot create download directory %s: %v\n", downloadDir, err)
+		return "", 0, err, appRepoPath, ""
+	}
+	fileZip := fmt.Sprintf("%s/%s", downloadDir, fileName)
+	partFile := fileZip + ".part"
+	os.Remove(fileZip)
+
+	// Check free disk space. (The object storage doesn't answer HEAD, so only the headroom is checked)
+	var expectedSize int64 = -1
+	if fileURL.Scheme != "minio" && fileURL.Scheme != "s3" {
+		expectedSize = getContentLength(fullURLFile)
+		if partInfo, err := os.Stat(partFile); err == nil && expectedSize > 0 {
+			// Only the rest of the partial file is downloaded.
+			expectedSize -= partInfo.Size()
+		}
+	}
+	if err := checkDiskSpace(appDir, expectedSize); err != nil {
+		procLog.Error.Printf("[DEPLOY] %v\n", err)
+		return fileZip, 0, err, appRepoPath, fileZip
 	}
 
-	fileZip := fmt.Sprintf("%s/%s", appDir, fileName)
-	file, err := os.Create(fileZip)
-	if err != nil {
-		procLog.Error.Println("[DEPLOY] fileDownload file creation error: ", err)
-	}
-	client := http.Client{
-		CheckRedirect: func(r *http.Request, via []*http.Request) error {
-			r.URL.Opaque = r.URL.Path
-			return nil
-		},
-	}
-	// Put content on file
-	resp, err := client.Get(fullURLFile)
-	if err != nil {
-		procLog.Error.Println("[DEPLOY] fileDownload URL get file error: ", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.Status[:3] != "200" {
-		return fileZip, 0, errors.New(fmt.Sprintf("Download error: %s", resp.Status)), appRepoPath, fileZip
+	if fileURL.Scheme == "minio" || fileURL.Scheme == "s3" {
+		// Put content on file from object storage
+		_, err = downloadFromMinio(fullURLFile, downloadDir, fileName, svcInfo)
+		if err != nil {
+			os.RemoveAll(downloadDir)
+			return fileZip, 0, err, appRepoPath, fileZip
+		}
+	} else {
+		client := http.Client{
+			CheckRedirect: func(r *http.Request, via []*http.Request) error {
+				r.URL.Opaque = r.URL.Path
+				return nil
+			},
+		}
+		err = downloadHTTP(&client, fullURLFile, partFile)
+		if err != nil {
+			procLog.Error.Println("[DEPLOY] fileDownload error: ", err)
+			return fileZip, 0, err, appRepoPath, fileZip
+		}
+		if err = os.Rename(partFile, fileZip); err != nil {
+			os.RemoveAll(downloadDir)
+			return fileZip, 0, err, appRepoPath, fileZip
+		}
+		os.Remove(partFile + ".json")
 	}
 
-	_, err = io.Copy(file, resp.Body)
-
-	defer file.Close()
-
 	// unzip!!
 	// appPath -> usr/local/sdt/app/{app's Name}
 	// app's Name -> {appName}_{appId}
@@ -1419,13 +2020,27 @@ func fileDownload(
 
 	err = archiver.Unarchive(fileZip, targetPath)
 	if err != nil {
-		procLog.Error.Println("[DEPLOY] Unzip error: ", err)
+		procLog.Warn.Println("[DEPLOY] Unzip error, retry by file header: ", err)
+		err = unarchiveByHeader(fileZip, targetPath)
+		if err != nil {
+			procLog.Error.Println("[DEPLOY] Unzip error: ", err)
+			os.RemoveAll(downloadDir)
+			return appPath, 0, err, appRepoPath, fileZip
+		}
 	}
 
 	// file rename
 	procLog.Info.Println("[DEPLOY] ZIP File Path: ", zipPath)
 	procLog.Info.Println("[DEPLOY] APP File Path: ", appPath)
-	os.Rename(zipPath, appPath)
+	if _, err := os.Stat(appPath); err == nil {
+		// appPath already has deploy.log.
+		err = moveDirContents(zipPath, appPath)
+		if err != nil {
+			procLog.Error.Println("[DEPLOY] Move app file error: ", err)
+		}
+	} else {
+		os.Rename(zipPath, appPath)
+	}
 
 	// get file size
 	fileInfo, _ := os.Stat(fileZip)
@@ -1441,30 +2056,629 @@ func fileDownload(
 
 	// remove zip file
 	procLog.Info.Println("[DEPLOY] Remove ZIP File: ", fileZip)
-	removeErr := os.Remove(fileZip)
+	removeErr := os.RemoveAll(downloadDir)
 	if removeErr != nil {
 		time.Sleep(1)
-		os.Remove(fileZip)
+		os.RemoveAll(downloadDir)
 	}
 
 	return appPath, fileSize, nil, appRepoPath, fileZip
 }
 
+// partMeta is saved next to a partial download as "<partial file>.json". The partial file is
+// resumed only if the URL is the same and the server returned a validator for If-Range.
+//   - URL: Full URL of the file.
+//   - ETag: ETag of the file. (Weak ETags can't be used for If-Range)
+//   - LastModified: Last-Modified of the file. (Used if there is no strong ETag)
+//   - Size: Total size of the file. (-1 if unknown)
+type partMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Size         int64  `json:"size"`
+}
+
+// validator function returns the value of the If-Range header. (Empty if the download can't be resumed)
+func (m partMeta) validator() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+	return m.LastModified
+}
+
+// removePart function removes the partial file and its partMeta.
+func removePart(partFile string) {
+	os.Remove(partFile)
+	os.Remove(partFile + ".json")
+}
+
+// parseContentRange function parses the Content-Range header of a 206 response.
+//
+//	bytes 100-199/200   ->   100, 200
+//
+// Input:
+//   - contentRange: Value of the Content-Range header.
+//
+// Output:
+//   - int64: First byte of the response.
+//   - int64: Total size of the file. (-1 if the server returns '*')
+//   - error: Error message if the header is invalid.
+func parseContentRange(contentRange string) (int64, int64, error) {
+	var start, end int64
+	var total string
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%s", &start, &end, &total); err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+	if total == "*" {
+		return start, -1, nil
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil || size <= end {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+	return start, size, nil
+}
+
+// downloadHTTP function downloads the file to partFile. A partial file left by an interrupted
+// download is resumed with "Range" and "If-Range" if its partMeta has the same URL. The server
+// answers 200 with the whole file if the file has changed, so the partial file is never appended
+// to a different version of the file. If the Content-Range of a 206 response doesn't continue the
+// partial file, or the server answers 416, the download restarts from the beginning.
+// The partial file is kept only if the error can be retried. (See isRetryableDownloadError)
+//
+// Input:
+//   - client: HTTP client.
+//   - fullURLFile: URL of the file.
+//   - partFile: Path of the partial file.
+//
+// Output:
+//   - error: An error message if the download fails.
+func downloadHTTP(client *http.Client, fullURLFile string, partFile string) (err error) {
+	defer func() {
+		if err != nil && !isRetryableDownloadError(err) {
+			removePart(partFile)
+		}
+	}()
+
+	metaFile := partFile + ".json"
+	var meta partMeta
+	var offset int64
+	if metaJson, readErr := os.ReadFile(metaFile); readErr == nil && json.Unmarshal(metaJson, &meta) == nil &&
+		meta.URL == fullURLFile && meta.validator() != "" {
+		if partInfo, statErr := os.Stat(partFile); statErr == nil && (meta.Size <= 0 || partInfo.Size() < meta.Size) {
+			offset = partInfo.Size()
+		}
+	}
+	if offset == 0 {
+		removePart(partFile)
+	}
+
+	for {
+		req, err := http.NewRequest("GET", fullURLFile, nil)
+		if err != nil {
+			return err
+		}
+		if offset > 0 {
+			procLog.Info.Printf("[DEPLOY] Resume download %s from %d bytes\n", fullURLFile, offset)
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			req.Header.Set("If-Range", meta.validator())
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+
+		restart := false
+		switch resp.StatusCode {
+		case http.StatusPartialContent:
+			if offset == 0 {
+				// Range was not requested.
+				resp.Body.Close()
+				return &ErrDownloadStatus{StatusCode: resp.StatusCode, Status: resp.Status}
+			}
+			start, total, rangeErr := parseContentRange(resp.Header.Get("Content-Range"))
+			if rangeErr != nil || start != offset || (meta.Size > 0 && total != meta.Size) {
+				procLog.Warn.Printf("[DEPLOY] Content-Range %q doesn't continue the partial file, restart download %s\n",
+					resp.Header.Get("Content-Range"), fullURLFile)
+				restart = true
+			}
+		case http.StatusOK:
+			// The file has changed or the server doesn't support Range.
+			if offset > 0 {
+				procLog.Warn.Printf("[DEPLOY] Partial file can't be resumed, restart download %s\n", fullURLFile)
+			}
+			offset = 0
+			meta = partMeta{
+				URL:          fullURLFile,
+				ETag:         resp.Header.Get("ETag"),
+				LastModified: resp.Header.Get("Last-Modified"),
+				Size:         resp.ContentLength,
+			}
+			if meta.validator() != "" {
+				metaJson, _ := json.Marshal(meta)
+				os.WriteFile(metaFile, metaJson, 0644)
+			} else {
+				os.Remove(metaFile)
+			}
+		case http.StatusRequestedRangeNotSatisfiable:
+			if offset == 0 {
+				resp.Body.Close()
+				return &ErrDownloadStatus{StatusCode: resp.StatusCode, Status: resp.Status}
+			}
+			procLog.Warn.Printf("[DEPLOY] Range of the partial file is not satisfiable, restart download %s\n", fullURLFile)
+			restart = true
+		default:
+			resp.Body.Close()
+			return &ErrDownloadStatus{StatusCode: resp.StatusCode, Status: resp.Status}
+		}
+		if restart {
+			// offset is 0 after a restart, so the loop runs at most twice.
+			resp.Body.Close()
+			removePart(partFile)
+			offset = 0
+			meta = partMeta{}
+			continue
+		}
+
+		err = writePart(partFile, offset, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		partInfo, err := os.Stat(partFile)
+		if err != nil {
+			return err
+		}
+		if meta.Size > 0 && partInfo.Size() < meta.Size {
+			return io.ErrUnexpectedEOF
+		} else if meta.Size > 0 && partInfo.Size() > meta.Size {
+			return fmt.Errorf("downloaded %d bytes, but the file has %d bytes", partInfo.Size(), meta.Size)
+		}
+		return nil
+	}
+}
+
+// writePart function writes the body of the response to the partial file. If offset is 0, the
+// partial file is truncated, otherwise the body is appended to it.
+func writePart(partFile string, offset int64, body io.Reader) error {
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(partFile, flag, 0644)
+	if err != nil {
+		procLog.Error.Println("[DEPLOY] fileDownload file creation error: ", err)
+		return err
+	}
+	_, err = io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// The downloadFromMinio function downloads an application file from the object storage (MinIO, S3).
+// The URI is "minio://<bucket>/<object>" or "s3://<bucket>/<object>", and the endpoint and
+// credentials of the object storage are read from svcInfo.
+//
+// Input:
+//   - fullURLFile: URI of the application file to download.
+//   - appDir: The directory on the device where the file will be saved.
+//   - fileName: Name of the file to save.
+//   - svcInfo: Struct containing the environment information of the control agent.
+//
+// Output:
+//   - int64: Size of the downloaded file.(Byte)
+//   - error: An error message if the file download fails.
+func downloadFromMinio(fullURLFile string, appDir string, fileName string, svcInfo sdtType.ControlService) (int64, error) {
+	fileURL, err := url.Parse(fullURLFile)
+	if err != nil {
+		procLog.Error.Printf("[DEPLOY] Object storage URL parse error: %v\n", err)
+		return 0, err
+	}
+	bucketName := fileURL.Host
+	objectName := strings.TrimPrefix(fileURL.Path, "/")
+	if bucketName == "" || objectName == "" {
+		return 0, errors.New(fmt.Sprintf("Invalid object storage URL: %s", fullURLFile))
+	}
+
+	endpoint := svcInfo.MinioURL
+	useSSL := false
+	if endpoint == "s3" {
+		endpoint = "s3.amazonaws.com"
+		useSSL = true
+	}
+
+	minioClient, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(svcInfo.MinioAccessKey, svcInfo.MinioSecretKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		procLog.Error.Printf("[DEPLOY] Failed access object storage: %v\n", err)
+		return 0, err
+	}
+
+	// Download app file.
+	filePath := fmt.Sprintf("%s/%s", appDir, fileName)
+	procLog.Info.Printf("[DEPLOY] Download %s/%s from object storage.\n", bucketName, objectName)
+	err = minioClient.FGetObject(context.Background(), bucketName, objectName, filePath, minio.GetObjectOptions{})
+	if err != nil {
+		procLog.Error.Printf("[DEPLOY] Failed download from object storage: %v\n", err)
+		return 0, err
+	}
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	procLog.Info.Printf("[DEPLOY] Successfully downloaded %s to %s\n", objectName, filePath)
+	return fileInfo.Size(), nil
+}
+
+// The RunInstallHook function runs a hook script (preInstall, postInstall) of the app.
+// The script path is relative to the app directory and must not leave it, and the script runs
+// in the app directory.
+//
+// Input:
+//   - appDir: Directory of the app.
+//   - script: Relative path of the script.
+//   - svcInfo: Device control information.
+//
+// Output:
+//   - error: Output of the script if the script exits non-zero, or error if the path is invalid.
+func RunInstallHook(appDir string, script string, svcInfo sdtType.ControlService) error {
+	scriptPath := filepath.Join(appDir, script)
+	if !strings.HasPrefix(scriptPath, filepath.Clean(appDir)+"/") {
+		procLog.Error.Printf("[DEPLOY] Hook script is outside of app directory: %s\n", script)
+		return fmt.Errorf("invalid hook script %s: must be inside the app directory", script)
+	}
+	if _, err := os.Stat(scriptPath); err != nil {
+		procLog.Error.Printf("[DEPLOY] Hook script not found: %s\n", scriptPath)
+		return fmt.Errorf("Hook script not found: %s", script)
+	}
+	os.Chmod(scriptPath, 0755)
+
+	procLog.Info.Printf("[DEPLOY] Run hook script: %s\n", scriptPath)
+	cmd_run := exec.Command(svcInfo.BaseCmd[0], svcInfo.BaseCmd[1], scriptPath)
+	cmd_run.Dir = appDir
+	stdout, cmd_err := cmd_run.CombinedOutput()
+	if cmd_err != nil {
+		procLog.Error.Printf("[DEPLOY] Hook script Error: %v\n%s\n", cmd_err, stdout)
+		return errors.New(string(stdout))
+	}
+	procLog.Info.Printf("[DEPLOY] Hook script Result: %s\n", stdout)
+	return nil
+}
+
+// The openDeployLog function opens the deployment log (deploy.log) of the application.
+// Each step of the deployment is recorded with a timestamp in deploy.log and also written
+// to the control agent's log. deploy.log is kept after the deployment for diagnosis.
+//
+// Input:
+//   - appPath: Path where applications are installed.
+//   - appName: Name of the application.
+//   - appId: Application ID.
+//
+// Output:
+//   - *os.File: deploy.log file. (nil if the file can't be opened.)
+//   - *log.Logger: Logger writing to deploy.log and the control agent's log.
+func openDeployLog(appPath string, appName string, appId string) (*os.File, *log.Logger) {
+	appDir := fmt.Sprintf("%s/%s_%s", appPath, appName, appId)
+	err := os.MkdirAll(appDir, os.ModePerm)
+	if err == nil {
+		logFile, err := os.OpenFile(fmt.Sprintf("%s/deploy.log", appDir), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err == nil {
+			return logFile, log.New(io.MultiWriter(logFile, procLog.Info.Writer()), "[DEPLOY-LOG] ", log.Ldate|log.Ltime)
+		}
+	}
+	procLog.Error.Printf("[DEPLOY] Can't open deploy.log: %v\n", err)
+	return nil, log.New(procLog.Info.Writer(), "[DEPLOY-LOG] ", log.Ldate|log.Ltime)
+}
+
+// The GetSharedBase function returns the local path of the base archive shared by the apps of an
+// inference deploy. The base archive is extracted to "<app path>/.base/<key>", and the key is the hash
+// of the URL and the content of the archive (ETag of a HEAD request, or SHA-256 of the archive if the
+// server doesn't return an ETag). So a changed archive is extracted to a new directory, and a
+// directory that deployed apps link to is never replaced. If the directory of the key already exists,
+// it is reused without extracting. In the same deploy, the cached path is returned without downloading.
+//
+// Input:
+//   - baseImage: URL of the base archive. (http, https, minio, s3)
+//   - sharedBaseCache: Base archives already downloaded in this deploy. (baseImage URL -> local path)
+//   - svcInfo: Struct containing the environment information of the control agent.
+//
+// Output:
+//   - string: Directory of the extracted base archive.
+//   - error: An error message if the download or the extraction fails.
+func GetSharedBase(baseImage string, sharedBaseCache map[string]string, svcInfo sdtType.ControlService) (string, error) {
+	if basePath, ok := sharedBaseCache[baseImage]; ok {
+		procLog.Info.Printf("[DEPLOY] Use cached base image: %s\n", basePath)
+		return basePath, nil
+	}
+
+	baseDir := fmt.Sprintf("%s/.base", svcInfo.AppPath)
+	if err := os.MkdirAll(baseDir, os.ModePerm); err != nil {
+		return "", err
+	}
+	fileURL, err := url.Parse(baseImage)
+	if err != nil {
+		return "", err
+	}
+	isObjectStorage := fileURL.Scheme == "minio" || fileURL.Scheme == "s3"
+
+	// If the server returns an ETag, an extracted base can be reused without downloading.
+	if !isObjectStorage {
+		if etag := getETag(baseImage); etag != "" {
+			basePath := fmt.Sprintf("%s/%s", baseDir, sharedBaseKey(baseImage, "etag:"+etag))
+			if _, err := os.Stat(basePath); err == nil {
+				procLog.Info.Printf("[DEPLOY] Reuse base image: %s\n", basePath)
+				sharedBaseCache[baseImage] = basePath
+				return basePath, nil
+			}
+		}
+	}
+
+	// Download to a directory of this deploy, so the name of the archive can't collide.
+	downloadDir, err := os.MkdirTemp(baseDir, ".download-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(downloadDir)
+	segments := strings.Split(fileURL.Path, "/")
+	fileName := segments[len(segments)-1]
+	archiveFile := fmt.Sprintf("%s/%s", downloadDir, fileName)
+
+	procLog.Info.Printf("[DEPLOY] Download base image: %s\n", baseImage)
+	var etag string
+	if isObjectStorage {
+		if _, err := downloadFromMinio(baseImage, downloadDir, fileName, svcInfo); err != nil {
+			return "", err
+		}
+	} else {
+		resp, err := http.Get(baseImage)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("Download base image error: %s", resp.Status)
+		}
+		etag = resp.Header.Get("ETag")
+		file, err := os.Create(archiveFile)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(file, resp.Body)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var basePath string
+	if etag != "" {
+		basePath = fmt.Sprintf("%s/%s", baseDir, sharedBaseKey(baseImage, "etag:"+etag))
+	} else {
+		archiveHash, err := fileSha256(archiveFile)
+		if err != nil {
+			return "", err
+		}
+		basePath = fmt.Sprintf("%s/%s", baseDir, sharedBaseKey(baseImage, "sha256:"+archiveHash))
+	}
+	if _, err := os.Stat(basePath); err == nil {
+		procLog.Info.Printf("[DEPLOY] Reuse base image: %s\n", basePath)
+		sharedBaseCache[baseImage] = basePath
+		return basePath, nil
+	}
+
+	tmpPath := fmt.Sprintf("%s/extract", downloadDir)
+	err = archiver.Unarchive(archiveFile, tmpPath)
+	if err != nil {
+		os.RemoveAll(tmpPath)
+		if err = unarchiveByHeader(archiveFile, tmpPath); err != nil {
+			return "", err
+		}
+	}
+	if err := os.Rename(tmpPath, basePath); err != nil {
+		// Another deploy has extracted the same base.
+		if _, statErr := os.Stat(basePath); statErr != nil {
+			return "", err
+		}
+	}
+	sharedBaseCache[baseImage] = basePath
+	return basePath, nil
+}
+
+// sharedBaseKey function returns the directory name of a base archive in "<app path>/.base".
+//
+// Input:
+//   - baseImage: URL of the base archive.
+//   - version: Content of the archive. ("etag:<ETag>" or "sha256:<SHA-256 of the archive>")
+//
+// Output:
+//   - string: Hash of the URL and the version.
+func sharedBaseKey(baseImage string, version string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(baseImage+"\n"+version)))[:16]
+}
+
+// getETag function reads the ETag of the file with a HEAD request.
+//
+// Input:
+//   - fullURLFile: URL of the file.
+//
+// Output:
+//   - string: ETag of the file. (Empty if the server doesn't return it)
+func getETag(fullURLFile string) string {
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Head(fullURLFile)
+	if err != nil {
+		procLog.Warn.Printf("[DEPLOY] HEAD %s error: %v\n", fullURLFile, err)
+		return ""
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	return resp.Header.Get("ETag")
+}
+
+// fileSha256 function returns the hex SHA-256 of the file.
+func fileSha256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// The LinkSharedBase function creates symlinks to the contents of the base archive in the app directory.
+// Files and directories that already exist in the app directory (the app-specific overlay) are not replaced.
+//
+// Input:
+//   - basePath: Directory of the extracted base archive.
+//   - appDir: Directory of the app.
+//
+// Output:
+//   - error: An error message if a symlink can't be created.
+func LinkSharedBase(basePath string, appDir string) error {
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		linkPath := filepath.Join(appDir, entry.Name())
+		if _, err := os.Lstat(linkPath); err == nil {
+			continue
+		}
+		if err := os.Symlink(filepath.Join(basePath, entry.Name()), linkPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The unarchiveByHeader function extracts the archive file by checking its magic bytes,
+// used when archiver.Unarchive can't tell the format from the file extension.
+// "PK\x03\x04" is extracted as zip, "\x1f\x8b" is extracted as tar.gz.
+//
+// Input:
+//   - source: Path of the archive file.
+//   - destination: Directory to extract into.
+//
+// Output:
+//   - error: An error message if the format is unknown or the extraction fails.
+func unarchiveByHeader(source string, destination string) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, 4)
+	n, _ := io.ReadFull(file, header)
+	file.Close()
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")):
+		err = archiver.NewZip().Unarchive(source, destination)
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		err = archiver.NewTarGz().Unarchive(source, destination)
+	default:
+		err = errors.New("unknown file header")
+	}
+	if err != nil {
+		return fmt.Errorf("archive format mismatch for %s (%v), check the 'app' field in framework.yaml", source, err)
+	}
+	return nil
+}
+
+// The moveDirContents function moves all files of srcDir into dstDir and removes srcDir.
+//
+// Input:
+//   - srcDir: Directory to move from.
+//   - dstDir: Directory to move to.
+//
+// Output:
+//   - error: An error message if moving fails.
+func moveDirContents(srcDir string, dstDir string) error {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		dstPath := filepath.Join(dstDir, entry.Name())
+		os.RemoveAll(dstPath)
+		err = os.Rename(filepath.Join(srcDir, entry.Name()), dstPath)
+		if err != nil {
+			return err
+		}
+	}
+	return os.Remove(srcDir)
+}
+
+// GetWorkingDir function returns the WorkingDirectory of the systemd service. If spec.workingDir
+// is set in framework.yaml, it is used instead of the app directory. (ex. /var/sdt/data/<appName>)
+// A relative path is joined to the app directory.
+//
+// Input:
+//   - appDir: The directory on the device where the app will be installed.
+//   - workingDir: spec.workingDir in framework.yaml.
+//
+// Output:
+//   - string: WorkingDirectory of the service.
+//   - error: Error message if workingDir starts with '..' or does not exist.
+func GetWorkingDir(appDir string, workingDir string) (string, error) {
+	if workingDir == "" {
+		return appDir, nil
+	}
+	if strings.HasPrefix(filepath.Clean(workingDir), "..") {
+		return "", fmt.Errorf("invalid spec.workingDir %s: must not start with '..'", workingDir)
+	}
+	if !filepath.IsAbs(workingDir) {
+		workingDir = filepath.Join(appDir, workingDir)
+	}
+	dirInfo, err := os.Stat(workingDir)
+	if err != nil {
+		return "", fmt.Errorf("spec.workingDir %s not found: %v", workingDir, err)
+	}
+	if !dirInfo.IsDir() {
+		return "", fmt.Errorf("spec.workingDir %s is not a directory", workingDir)
+	}
+	return workingDir, nil
+}
+
 // CreateGoService function creates a Systemd file (.service) for a Golang application.
 //
 // Input:
 //   - appDir: The directory on the device where the app will be installed.
 //   - appName: The name of the application.
 //   - runCmd: The command to execute the application.
-func CreateGoService(appDir string, appName string, runCmd string) {
+//   - env: Environment information of the app in framework.yaml.
+//   - workingDir: spec.workingDir in framework.yaml. (Empty string uses appDir)
+//
+// Output:
+//   - error: Error message if workingDir is invalid or the service file can't be written.
+func CreateGoService(appDir string, appName string, runCmd string, env sdtType.Env, workingDir string) error {
+	workDir, err := GetWorkingDir(appDir, workingDir)
+	if err != nil {
+		return err
+	}
+
 	// Specify the file name and path
 	filePath := fmt.Sprintf("%s/%s.service", appDir, appName)
 
 	// Create a new file or truncate an existing file
 	file, err := os.Create(filePath)
 	if err != nil {
-		fmt.Errorf("error creating service file : %v", err)
-		return
+		return fmt.Errorf("error creating service file : %v", err)
 	}
 	defer file.Close()
 
@@ -1480,14 +2694,14 @@ Restart=always
 RestartSec=10
 StandardOutput=file:/%s/app.log
 StandardError=file:/%s/app-error.log
-
+%s
 [Install]
 WantedBy=multi-user.target
-	`, appName, appDir, appDir, runCmd, appDir, appDir)
+	`, appName, workDir, appDir, runCmd, appDir, appDir, GetServiceEnv(appDir, env))
 	_, err = file.WriteString(content)
 	if err != nil {
 		procLog.Error.Println("Error writing to the file:", err)
-		return
+		return err
 	}
 
 	svcFile := fmt.Sprintf("/etc/systemd/system/%s.service", appName)
@@ -1495,8 +2709,60 @@ WantedBy=multi-user.target
 
 	if err != nil {
 		procLog.Error.Println("Error svc file copy to systemd:", err)
-		return
+		return err
 	}
+	return nil
+}
+
+// GetServiceEnv function creates the environment lines of the systemd file from spec.env.envVars
+// and spec.env.envSecretsFile in framework.yaml. envVars are written as 'Environment=' lines, and
+// the secrets file is loaded by systemd with an 'EnvironmentFile=' line, so the secrets are not
+// copied into the service file. Variables in the secrets file override envVars.
+//
+// Input:
+//   - appDir: The directory on the device where the app will be installed.
+//   - env: Environment information of the app in framework.yaml.
+//
+// Output:
+//   - string: 'Environment=' and 'EnvironmentFile=' lines. (Empty string if there are no variables)
+func GetServiceEnv(appDir string, env sdtType.Env) string {
+	var envLines strings.Builder
+
+	// Sort keys so that the service file is the same on every deploy.
+	keys := make([]string, 0, len(env.EnvVars))
+	for key := range env.EnvVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		envLines.WriteString(fmt.Sprintf("Environment=\"%s=%s\"\n", escapeServiceValue(key), escapeServiceValue(env.EnvVars[key])))
+	}
+
+	if env.EnvSecretsFile != "" {
+		secretsFile := env.EnvSecretsFile
+		if !filepath.IsAbs(secretsFile) {
+			secretsFile = filepath.Join(appDir, secretsFile)
+		}
+		if _, err := os.Stat(secretsFile); err != nil {
+			procLog.Error.Printf("[DEPLOY] Can't read env secrets file(%s): %v\n", secretsFile, err)
+		} else {
+			envLines.WriteString(fmt.Sprintf("EnvironmentFile=%s\n", strings.ReplaceAll(secretsFile, "%", "%%")))
+		}
+	}
+	return envLines.String()
+}
+
+// escapeServiceValue function escapes a value in double quotes of the systemd file.
+// '\' and '"' are escaped with '\', a newline is written as "\n", and '%' is written as "%%"
+// so that it is not expanded as a specifier.
+//
+// Input:
+//   - value: Value of the environment variable.
+//
+// Output:
+//   - string: Escaped value.
+func escapeServiceValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "%", "%%").Replace(value)
 }
 
 // CreatePythonService function creates a systemd file (.service) for a Python application.
@@ -1506,15 +2772,25 @@ WantedBy=multi-user.target
 //   - appName: The name of the application.
 //   - appVenv: The virtual environment name for the application.
 //   - runCmd: The command to execute the application.
-func CreatePythonService(appDir string, appName string, appVenv string, runCmd string, venvPath string) {
+//   - venvPath: The directory of the virtual environments.
+//   - env: Environment information of the app in framework.yaml.
+//   - workingDir: spec.workingDir in framework.yaml. (Empty string uses appDir)
+//
+// Output:
+//   - error: Error message if workingDir is invalid or the service file can't be written.
+func CreatePythonService(appDir string, appName string, appVenv string, runCmd string, venvPath string, env sdtType.Env, workingDir string) error {
+	workDir, err := GetWorkingDir(appDir, workingDir)
+	if err != nil {
+		return err
+	}
+
 	// Specify the file name and path
 	filePath := fmt.Sprintf("%s/%s.service", appDir, appName)
 
 	// Create a new file or truncate an existing file
 	file, err := os.Create(filePath)
 	if err != nil {
-		fmt.Errorf("error creating service file : %v", err)
-		return
+		return fmt.Errorf("error creating service file : %v", err)
 	}
 	defer file.Close()
 
@@ -1539,14 +2815,111 @@ Restart=always
 RestartSec=10
 StandardOutput=file:/%s/app.log
 StandardError=file:/%s/app-error.log
+%s
+[Install]
+WantedBy=multi-user.target
+	`, appName, workDir, execBin, runCmd, appDir, appDir, GetServiceEnv(appDir, env))
+	_, err = file.WriteString(content)
+	if err != nil {
+		procLog.Error.Println("Error writing to the file:", err)
+		return err
+	}
 
+	svcFile := fmt.Sprintf("/etc/systemd/system/%s.service", appName)
+	err = CopyFile(filePath, svcFile)
+
+	if err != nil {
+		procLog.Error.Println("Error svc file copy to systemd:", err)
+		return err
+	}
+	return nil
+}
+
+// NpmInstall function installs the packages of a Node.js application in the app directory.
+// If package.json does not exist, nothing is installed.
+//
+// Input:
+//   - appDir: The directory on the device where the app will be installed.
+//
+// Output:
+//   - string: Output of the npm install command. (stderr is included.)
+//   - error: Error message in case of issues with the npm install command.
+func NpmInstall(appDir string) (string, error) {
+	if _, err := os.Stat(fmt.Sprintf("%s/package.json", appDir)); os.IsNotExist(err) {
+		procLog.Info.Printf("[DEPLOY] package.json not found. Skip npm install.\n")
+		return "", nil
+	}
+
+	procLog.Info.Printf("[DEPLOY] npm install --prefix %s\n", appDir)
+	cmd_run := exec.Command("npm", "install", "--prefix", appDir)
+	stdout, cmd_err := cmd_run.CombinedOutput()
+	if cmd_err != nil {
+		return string(stdout), cmd_err
+	}
+	return string(stdout), nil
+}
+
+// GetNodeBin function finds the Node.js binary of the device. If node is not found in PATH,
+// '/usr/bin/node' is used.
+//
+// Output:
+//   - string: Path of the Node.js binary.
+func GetNodeBin() string {
+	nodeBin, err := exec.LookPath("node")
+	if err != nil {
+		procLog.Warn.Printf("[DEPLOY] node not found in PATH. Use /usr/bin/node.\n")
+		return "/usr/bin/node"
+	}
+	return nodeBin
+}
+
+// CreateNodeService function creates a systemd file (.service) for a Node.js application.
+//
+// Input:
+//   - appDir: The directory on the device where the app will be installed.
+//   - appName: The name of the application.
+//   - runFile: The file to execute the application. (ex. index.js)
+//   - env: Environment information of the app in framework.yaml.
+//   - workingDir: spec.workingDir in framework.yaml. (Empty string uses appDir)
+//
+// Output:
+//   - error: Error message if workingDir is invalid or the service file can't be written.
+func CreateNodeService(appDir string, appName string, runFile string, env sdtType.Env, workingDir string) error {
+	workDir, err := GetWorkingDir(appDir, workingDir)
+	if err != nil {
+		return err
+	}
+
+	// Specify the file name and path
+	filePath := fmt.Sprintf("%s/%s.service", appDir, appName)
+
+	// Create a new file or truncate an existing file
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("error creating service file : %v", err)
+	}
+	defer file.Close()
+
+	// Write content to the file
+	content := fmt.Sprintf(`[Unit]
+Description=%s
+
+[Service]
+WorkingDirectory=%s
+Environment=NODE_ENV=production
+ExecStart=%s %s/%s
+Restart=always
+RestartSec=10
+StandardOutput=file:/%s/app.log
+StandardError=file:/%s/app-error.log
+%s
 [Install]
 WantedBy=multi-user.target
-	`, appName, appDir, execBin, runCmd, appDir, appDir)
+	`, appName, workDir, GetNodeBin(), appDir, runFile, appDir, appDir, GetServiceEnv(appDir, env))
 	_, err = file.WriteString(content)
 	if err != nil {
 		procLog.Error.Println("Error writing to the file:", err)
-		return
+		return err
 	}
 
 	svcFile := fmt.Sprintf("/etc/systemd/system/%s.service", appName)
@@ -1554,8 +2927,9 @@ WantedBy=multi-user.target
 
 	if err != nil {
 		procLog.Error.Println("Error svc file copy to systemd:", err)
-		return
+		return err
 	}
+	return nil
 }
 
 // CopyFile function copies a directory or file.
@@ -1584,6 +2958,54 @@ func CopyFile(srcPath, destPath string) error {
 	return nil
 }
 
+// SaveDeployHistory function appends the deployed app to the deploy history of the device.
+// The deploy history is saved in '<rootPath>/device.config/deploy-history.json' as a JSON array,
+// and only the latest 100 entries are kept. The history is used to find the previous version
+// of an app for rollback and auditing.
+//
+// Input:
+//   - deployResult: Information about the deployed app. (name, pid, size, appRepoPath)
+//   - appId: The ID of the app.
+//   - appVenv: The virtual environment used by the app.
+//   - rootPath: The root path of BWC.
+func SaveDeployHistory(deployResult map[string]interface{}, appId string, appVenv string, rootPath string) {
+	historyFile := fmt.Sprintf("%s/device.config/deploy-history.json", rootPath)
+
+	var historyList []sdtType.DeployHistory
+	jsonFile, err := os.ReadFile(historyFile)
+	if err == nil {
+		if err := json.Unmarshal(jsonFile, &historyList); err != nil {
+			procLog.Warn.Printf("[DEPLOY] Failed load deploy history. History is reset: %v\n", err)
+			historyList = nil
+		}
+	}
+
+	history := sdtType.DeployHistory{
+		AppId:      appId,
+		Venv:       appVenv,
+		DeployedAt: time.Now().UnixMilli(),
+	}
+	history.AppName, _ = deployResult["name"].(string)
+	history.AppRepoPath, _ = deployResult["appRepoPath"].(string)
+	history.FileSize, _ = deployResult["size"].(int64)
+	history.Pid, _ = deployResult["pid"].(int)
+
+	historyList = append(historyList, history)
+	if len(historyList) > maxDeployHistory {
+		historyList = historyList[len(historyList)-maxDeployHistory:]
+	}
+
+	saveJson, err := json.MarshalIndent(historyList, "", "\t")
+	if err != nil {
+		procLog.Error.Printf("[DEPLOY] Failed save deploy history's Marshal: %v\n", err)
+		return
+	}
+	err = os.WriteFile(historyFile, saveJson, 0644)
+	if err != nil {
+		procLog.Error.Printf("[DEPLOY] Failed save deploy history: %v\n", err)
+	}
+}
+
 // SaveAppInfo function saves metadata of the deployed app on the device.
 // BWC manages metadata of deployed apps as a Json file on the device.
 //
@@ -1625,12 +3047,12 @@ func SaveAppInfo(appName string,
 			procLog.Error.Printf("[DEPLAY] Failed save app's Marshal: %v\n", err)
 		}
 
-		err = ioutil.WriteFile(appInfoFile, saveJson, 0644)
+		err = os.WriteFile(appInfoFile, saveJson, 0644)
 		if err != nil {
 			procLog.Error.Printf("[DEPLAY] Failed save app's info: %v\n", err)
 		}
 	} else {
-		jsonFile, err := ioutil.ReadFile(appInfoFile)
+		jsonFile, err := os.ReadFile(appInfoFile)
 		if err != nil {
 			procLog.Error.Printf("[DEPLAY] Failed load app's file: %v\n", err)
 		}
@@ -1655,7 +3077,7 @@ func SaveAppInfo(appName string,
 
 		jsonData.AppInfoList = append(jsonData.AppInfoList, newApp)
 		saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
-		err = ioutil.WriteFile(appInfoFile, saveJson, 0644)
+		err = os.WriteFile(appInfoFile, saveJson, 0644)
 		if err != nil {
 			procLog.Error.Printf("[DEPLOY] failed save app's file: %v\n", err)
 		}
@@ -1680,7 +3102,7 @@ func SaveFramework(appName string, appId string, appPath string) {
 	if os.IsNotExist(err) {
 		procLog.Warn.Printf("[DEPLAY] Find framework.json.\n")
 		frameworkFile = fmt.Sprintf("%s/%s_%s/framework.json", appPath, appName, appId)
-		jsonFile, err := ioutil.ReadFile(frameworkFile)
+		jsonFile, err := os.ReadFile(frameworkFile)
 		if err != nil {
 			procLog.Error.Printf("[DEPLAY] Failed load framework's file: %v\n", err)
 			return
@@ -1694,7 +3116,7 @@ func SaveFramework(appName string, appId string, appPath string) {
 
 		bwcFramework.Spec.AppName = appName
 		saveJson, _ := json.MarshalIndent(&bwcFramework, "", "\t")
-		err = ioutil.WriteFile(frameworkFile, saveJson, 0644)
+		err = os.WriteFile(frameworkFile, saveJson, 0644)
 		if err != nil {
 			procLog.Error.Printf("[DEPLOY] failed save framework's file: %v\n", err)
 		}
@@ -1703,7 +3125,7 @@ func SaveFramework(appName string, appId string, appPath string) {
 
 	// check app's info file
 	procLog.Warn.Printf("[DEPLAY] Find framework.yaml.\n")
-	yamlFile, err := ioutil.ReadFile(frameworkFile)
+	yamlFile, err := os.ReadFile(frameworkFile)
 	if err != nil {
 		procLog.Error.Printf("[DEPLAY] Failed load framework's file: %v\n", err)
 		return
@@ -1717,7 +3139,7 @@ func SaveFramework(appName string, appId string, appPath string) {
 
 	bwcFramework.Spec.AppName = appName
 	saveYaml, _ := yaml.Marshal(&bwcFramework)
-	err = ioutil.WriteFile(frameworkFile, saveYaml, 0644)
+	err = os.WriteFile(frameworkFile, saveYaml, 0644)
 	if err != nil {
 		procLog.Error.Printf("[DEPLOY] failed save framework's file: %v\n", err)
 		return
@@ -1748,7 +3170,7 @@ func GetVenvFromFramework(appName string, appId string, appPath string) sdtType.
 		procLog.Info.Printf("[DEPLAY] Failed read framework.yaml.\n")
 		procLog.Warn.Printf("[DEPLAY] Find framework.json.\n")
 		frameworkFile = fmt.Sprintf("%s/%s_%s/framework.json", appPath, appName, appId)
-		jsonFile, err := ioutil.ReadFile(frameworkFile)
+		jsonFile, err := os.ReadFile(frameworkFile)
 		if err != nil {
 			procLog.Error.Printf("[DEPLAY] Failed load framework's file: %v\n", err)
 			return bwcFramework
@@ -1765,7 +3187,7 @@ func GetVenvFromFramework(appName string, appId string, appPath string) sdtType.
 
 	// check app's info file(yaml)
 	procLog.Warn.Printf("[DEPLAY] Find framework.yaml.\n")
-	yamlFile, err := ioutil.ReadFile(frameworkFile)
+	yamlFile, err := os.ReadFile(frameworkFile)
 	if err != nil {
 		procLog.Error.Printf("[DEPLAY] Failed load framework's file: %v\n", err)
 		return bwcFramework
@@ -1788,7 +3210,7 @@ func DeleteAppInfo(appName string, rootPath string) {
 	var appId string = ""
 	appInfoFile := fmt.Sprintf("%s/device.config/app.json", rootPath)
 
-	jsonFile, err := ioutil.ReadFile(appInfoFile)
+	jsonFile, err := os.ReadFile(appInfoFile)
 	if err != nil {
 		procLog.Error.Printf("[DELETE] Failed load app's info: %v\n", err)
 		return
@@ -1800,7 +3222,7 @@ func DeleteAppInfo(appName string, rootPath string) {
 		return
 	}
 
-	for _, val := range jsonData.AppInfoList {
+	for _, val := range deduplicateAppInfo(jsonData.AppInfoList) {
 		if val.AppName == appName {
 			appId = val.AppId
 			continue
@@ -1809,7 +3231,7 @@ func DeleteAppInfo(appName string, rootPath string) {
 	}
 
 	saveJson, _ := json.MarshalIndent(&saveData, "", "\t")
-	err = ioutil.WriteFile(appInfoFile, saveJson, 0644)
+	err = os.WriteFile(appInfoFile, saveJson, 0644)
 	if err != nil {
 		procLog.Error.Printf("[DELETE] failed delete app's info: %v\n", err)
 		return
@@ -1817,6 +3239,32 @@ func DeleteAppInfo(appName string, rootPath string) {
 	procLog.Warn.Printf("[DELETE] delete app's info: %s\n", appId)
 }
 
+// deduplicateAppInfo function removes the entries of app.json that have the same app name.
+// The most recently deployed entry (the last one in the list) is kept. Duplicates can be
+// left by a failed rollback.
+//
+// Input:
+//   - appInfoList: List of app's info in app.json.
+//
+// Output:
+//   - []sdtType.AppInfo: List of app's info without duplicates.
+func deduplicateAppInfo(appInfoList []sdtType.AppInfo) []sdtType.AppInfo {
+	lastIndex := make(map[string]int)
+	for i, val := range appInfoList {
+		lastIndex[val.AppName] = i
+	}
+
+	var dedupList []sdtType.AppInfo
+	for i, val := range appInfoList {
+		if lastIndex[val.AppName] != i {
+			procLog.Warn.Printf("[DELETE] Duplicate app %s: remove stale appId %s.\n", val.AppName, val.AppId)
+			continue
+		}
+		dedupList = append(dedupList, val)
+	}
+	return dedupList
+}
+
 // GetAppsFromGroup function deletes the app's metadata from the device when the app is being deleted.
 //
 // Input:
@@ -1825,7 +3273,7 @@ func GetAppsFromGroup(appGroupId string, rootPath string) ([]string, []string) {
 	var appNames, appIds []string
 	appInfoFile := fmt.Sprintf("%s/device.config/app.json", rootPath)
 
-	jsonFile, err := ioutil.ReadFile(appInfoFile)
+	jsonFile, err := os.ReadFile(appInfoFile)
 	if err != nil {
 		procLog.Error.Printf("[GET-APPS] Failed load app's info: %v\n", err)
 		return appNames, appIds
@@ -1848,18 +3296,61 @@ func GetAppsFromGroup(appGroupId string, rootPath string) ([]string, []string) {
 	return appNames, appIds
 }
 
+// GetAppsFromVenv function retrieves the names of the apps that use the virtual environment.
+// If app.json exists but can't be read, an error is returned, so the caller doesn't delete
+// a virtual environment that may be in use.
+//
+// Input:
+//   - venvName: Name of the virtual environment.
+//   - rootPath: Root path of the BWC.
+//
+// Output:
+//   - []string: Names of the apps.
+//   - error: Error if app.json can't be read or unmarshaled.
+func GetAppsFromVenv(venvName string, rootPath string) ([]string, error) {
+	var appNames []string
+	appInfoFile := fmt.Sprintf("%s/device.config/app.json", rootPath)
+
+	jsonFile, err := os.ReadFile(appInfoFile)
+	if os.IsNotExist(err) {
+		return appNames, nil
+	} else if err != nil {
+		procLog.Error.Printf("[GET-APPS] Failed load app's info: %v\n", err)
+		return nil, err
+	}
+	var jsonData sdtType.AppConfig
+	err = json.Unmarshal(jsonFile, &jsonData)
+	if err != nil {
+		procLog.Error.Printf("[GET-APPS] Failed get app's Unmarshal: %v\n", err)
+		return nil, err
+	}
+
+	for _, val := range jsonData.AppInfoList {
+		if val.AppVenv == venvName {
+			appNames = append(appNames, val.AppName)
+		}
+	}
+	return appNames, nil
+}
+
 // GetVenvList function retrieves the list of virtual environments installed on the device.
+// Virtual environments in creation (see CreateVenv) are not included.
 //
 // Output:
 //   - []string: List of virtual environments.
 func GetVenvList(venvPath string) []string {
 	var envList []string
-	envDir, _ := ioutil.ReadDir(venvPath)
+	envDir, _ := os.ReadDir(venvPath)
 
 	for _, f := range envDir {
-		if f.IsDir() {
-			envList = append(envList, f.Name())
+		if !f.IsDir() {
+			continue
 		}
+		// Skip venv in creation.
+		if _, err := os.Stat(venvIncompleteFile(filepath.Join(venvPath, f.Name()))); err == nil {
+			continue
+		}
+		envList = append(envList, f.Name())
 	}
 	return envList
 }
@@ -1957,16 +3448,28 @@ func InstallDefaultPkg(venvName string,
 }
 
 // DownloadWeight function download model weight file from storage. Weight file used in inference app.
-// Weight file store objectstorage. So, this function need accesskey and secretkey about objectstorage.
+// If modelUrl is given, the weight file is downloaded with HTTP GET. (ex. Presigned URL of MinIO/S3)
+// Otherwise, weight file is downloaded from objectstorage with accesskey and secretkey in framework.yaml.
 //
 // Input:
 //   - bwcFramework: The name of the virtual environment.
 //   - appDir: The IP address of SDT Cloud.
 //   - minioURL: The port number of the code repository.
+//   - modelUrl: URL of the weight file. (Presigned URL or model URL)
 //
 // Output:
 //   - error: An error message if weight file download fail.
-func DownloadWeight(bwcFramework sdtType.Framework, appDir string, minioURL string) error {
+func DownloadWeight(bwcFramework sdtType.Framework, appDir string, minioURL string, modelUrl string) error {
+	filePath := fmt.Sprintf("%s/weights/%s", appDir, bwcFramework.Inference.WeightFile)
+	if modelUrl != "" {
+		if IsPresignedURL(modelUrl) {
+			procLog.Info.Printf("Download model file with presigned URL.\n")
+		} else {
+			procLog.Info.Printf("Download model file with model URL.\n")
+		}
+		return downloadWeightUrl(modelUrl, filePath)
+	}
+
 	endpoint := minioURL
 	minioKey := bwcFramework.Inference.AccessKey
 	minioSecret := bwcFramework.Inference.SecretKey
@@ -1985,7 +3488,6 @@ func DownloadWeight(bwcFramework sdtType.Framework, appDir string, minioURL stri
 	// Set minio bucket and file.
 	bucketName := bwcFramework.Inference.Bucket
 	objectName := fmt.Sprintf("%s/%s", bwcFramework.Inference.Path, bwcFramework.Inference.WeightFile)
-	filePath := fmt.Sprintf("%s/weights/%s", appDir, bwcFramework.Inference.WeightFile)
 
 	// Download model file.
 	procLog.Info.Printf("Download model file from object storage.\n")
@@ -1999,6 +3501,26 @@ func DownloadWeight(bwcFramework sdtType.Framework, appDir string, minioURL stri
 	return nil
 }
 
+// IsPresignedURL function checks whether the URL is a presigned URL of MinIO or S3.
+// Presigned URL is https URL that has 'X-Amz-Signature' or 'X-Minio-Signature' query parameter.
+//
+// Input:
+//   - modelUrl: URL of the weight file.
+//
+// Output:
+//   - bool: True if the URL is a presigned URL.
+func IsPresignedURL(modelUrl string) bool {
+	if !strings.HasPrefix(modelUrl, "https://") {
+		return false
+	}
+	parsedUrl, err := url.Parse(modelUrl)
+	if err != nil {
+		return false
+	}
+	query := parsedUrl.Query()
+	return query.Has("X-Amz-Signature") || query.Has("X-Minio-Signature")
+}
+
 func DownloadWeight_new(modelUrl string, appName string, appId string, fileName string, appPath string) error {
 	if fileName == "" {
 		procLog.Error.Println("Filename is null: ")
@@ -2006,11 +3528,31 @@ func DownloadWeight_new(modelUrl string, appName string, appId string, fileName
 	}
 	weighFile := fmt.Sprintf("%s/%s_%s/%s", appPath, appName, appId, fileName)
 	procLog.Info.Printf("Weight File: %s.\n", weighFile)
+	err := downloadWeightUrl(modelUrl, weighFile)
+	if err != nil {
+		return err
+	}
+
+	procLog.Info.Printf("Successfully downloaded %s to %s\n", fileName, appName)
+	return nil
+}
+
+// downloadWeightUrl function downloads the weight file from the URL with HTTP GET.
+//
+// Input:
+//   - modelUrl: URL of the weight file.
+//   - weighFile: Path where the weight file is saved.
+//
+// Output:
+//   - error: An error message if weight file download fail.
+func downloadWeightUrl(modelUrl string, weighFile string) error {
 	file, err := os.Create(weighFile)
 	if err != nil {
 		procLog.Error.Println("FileDownload file creation error: ", err)
 		return err
 	}
+	defer file.Close()
+
 	client := http.Client{
 		CheckRedirect: func(r *http.Request, via []*http.Request) error {
 			r.URL.Opaque = r.URL.Path
@@ -2026,11 +3568,17 @@ func DownloadWeight_new(modelUrl string, appName string, appId string, fileName
 	}
 	defer resp.Body.Close()
 
-	_, err = io.Copy(file, resp.Body)
-
-	defer file.Close()
+	// Expired presigned URL returns 403.
+	if resp.StatusCode != http.StatusOK {
+		procLog.Error.Printf("FileDownload URL status error: %s\n", resp.Status)
+		return fmt.Errorf("failed download weight file: %s", resp.Status)
+	}
 
-	procLog.Info.Printf("Successfully downloaded %s to %s\n", fileName, appName)
+	_, err = io.Copy(file, resp.Body)
+	if err != nil {
+		procLog.Error.Println("FileDownload copy error: ", err)
+		return err
+	}
 	return nil
 }
 
@@ -2081,7 +3629,7 @@ func CheckExistApp(targetApp string, rootPath string) bool {
 		return false
 	}
 
-	jsonFile, err := ioutil.ReadFile(appInfoFile)
+	jsonFile, err := os.ReadFile(appInfoFile)
 	if err != nil {
 		procLog.Error.Printf("Failed load app's file: %v\n", err)
 		os.Exit(1)
@@ -2107,7 +3655,7 @@ func CheckExistApp(targetApp string, rootPath string) bool {
 func GetLogsApp(appPath string, appName string, appId string) string {
 	procLog.Info.Printf("Get app logs.\n")
 	var fileName, logContent string
-	// Get appId.
+	// Get appId. (Only app-error.log is read, deploy.log isn't included.)
 	fileName = fmt.Sprintf("%s/%s_%s/app-error.log", appPath, appName, appId)
 
 	// open logfile.
@@ -0,0 +1,99 @@
+package deploy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sdtType "main/src/controlType"
+	sdtLog "pkg/log"
+)
+
+// fakeDiskFree replaces diskFree with a function that returns free and err.
+func fakeDiskFree(t *testing.T, free uint64, err error) {
+	t.Helper()
+	diskFree = func(path string) (uint64, error) { return free, err }
+	t.Cleanup(func() { diskFree = freeDiskSpace })
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	const MB = 1024 * 1024
+
+	tests := []struct {
+		name         string
+		free         uint64
+		statErr      error
+		fileSize     int64
+		wantRequired uint64
+	}{
+		{"enough", 1024 * MB, nil, 300 * MB, 0},
+		{"exactly file and headroom", 500 * MB, nil, 300 * MB, 0},
+		{"no room for headroom", 450 * MB, nil, 300 * MB, 500 * MB},
+		{"unknown size within headroom", 100 * MB, nil, -1, 200 * MB},
+		{"unknown size", 250 * MB, nil, -1, 0},
+		{"statfs error doesn't block", 0, errors.New("statfs failed"), 300 * MB, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeDiskFree(t, tt.free, tt.statErr)
+			err := checkDiskSpace("/usr/local/sdt/app", tt.fileSize)
+			if tt.wantRequired == 0 {
+				if err != nil {
+					t.Errorf("checkDiskSpace() = %v, want nil", err)
+				}
+				return
+			}
+			var diskErr *ErrInsufficientDisk
+			if !errors.As(err, &diskErr) {
+				t.Fatalf("checkDiskSpace() = %v, want ErrInsufficientDisk", err)
+			}
+			if diskErr.Required != tt.wantRequired || diskErr.Free != tt.free || diskErr.Path != "/usr/local/sdt/app" {
+				t.Errorf("ErrInsufficientDisk = %+v", diskErr)
+			}
+			if !strings.Contains(err.Error(), "MB required") {
+				t.Errorf("error message = %q", err)
+			}
+		})
+	}
+}
+
+func TestGetContentLength(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app.zip":
+			w.Header().Set("Content-Length", "4096")
+		case "/chunked.zip":
+			w.Header().Set("Transfer-Encoding", "chunked")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method != http.MethodHead {
+			t.Errorf("method = %s, want HEAD", r.Method)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path string
+		want int64
+	}{
+		{"/app.zip", 4096},
+		{"/chunked.zip", -1},
+		{"/missing.zip", -1},
+	}
+	for _, tt := range tests {
+		if got := getContentLength(srv.URL + tt.path); got != tt.want {
+			t.Errorf("getContentLength(%s) = %d, want %d", tt.path, got, tt.want)
+		}
+	}
+
+	srv.Close()
+	if got := getContentLength(srv.URL + "/app.zip"); got != -1 {
+		t.Errorf("getContentLength() of a closed server = %d, want -1", got)
+	}
+}
@@ -0,0 +1,229 @@
+package deploy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	sdtType "main/src/controlType"
+	sdtLog "pkg/log"
+)
+
+// fileServer serves content with ETag, so http.ServeContent handles Range and If-Range.
+// The Range headers of the requests are recorded.
+type fileServer struct {
+	mu      sync.Mutex
+	content []byte
+	etag    string
+	ranges  []string
+}
+
+func (f *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.ranges = append(f.ranges, r.Header.Get("Range"))
+	content, etag := f.content, f.etag
+	f.mu.Unlock()
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, "app.zip", time.Time{}, bytes.NewReader(content))
+}
+
+// writePartial creates a partial file with the first n bytes of content and its partMeta.
+func writePartial(t *testing.T, partFile string, content []byte, n int, meta partMeta) {
+	t.Helper()
+	if err := os.WriteFile(partFile, content[:n], 0644); err != nil {
+		t.Fatal(err)
+	}
+	metaJson, _ := json.Marshal(meta)
+	if err := os.WriteFile(partFile+".json", metaJson, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkFile(t *testing.T, path string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+}
+
+func TestDownloadHTTPResume(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	oldContent := []byte(strings.Repeat("old-", 100))
+	newContent := []byte(strings.Repeat("new-", 100))
+
+	tests := []struct {
+		name      string
+		partial   bool
+		metaURL   string
+		metaETag  string
+		wantRange string
+	}{
+		{"no partial file", false, "", "", ""},
+		{"resume same version", true, "", `"v1"`, "bytes=150-"},
+		{"file changed on server", true, "", `"v0"`, "bytes=150-"},
+		{"partial of another url", true, "http://other/archive/main.zip", `"v1"`, ""},
+		{"partial without validator", true, "", "", ""},
+		{"weak etag", true, "", `W/"v1"`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &fileServer{content: oldContent, etag: `"v1"`}
+			want := oldContent
+			if tt.name == "file changed on server" {
+				server.content, server.etag = newContent, `"v2"`
+				want = newContent
+			}
+			srv := httptest.NewServer(server)
+			defer srv.Close()
+
+			fileURL := srv.URL + "/archive/main.zip"
+			partFile := filepath.Join(t.TempDir(), "main.zip.part")
+			if tt.partial {
+				metaURL := tt.metaURL
+				if metaURL == "" {
+					metaURL = fileURL
+				}
+				writePartial(t, partFile, oldContent, 150, partMeta{URL: metaURL, ETag: tt.metaETag, Size: int64(len(oldContent))})
+			}
+
+			if err := downloadHTTP(srv.Client(), fileURL, partFile); err != nil {
+				t.Fatalf("downloadHTTP() = %v", err)
+			}
+			checkFile(t, partFile, want)
+			if len(server.ranges) != 1 || server.ranges[0] != tt.wantRange {
+				t.Errorf("Range headers = %q, want [%q]", server.ranges, tt.wantRange)
+			}
+		})
+	}
+}
+
+func TestDownloadHTTPInvalidRangeResponse(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	content := []byte(strings.Repeat("abcd", 50))
+
+	tests := []struct {
+		name   string
+		status int
+		header string
+	}{
+		{"wrong start", http.StatusPartialContent, "bytes 0-199/200"},
+		{"wrong total", http.StatusPartialContent, "bytes 100-299/300"},
+		{"missing content range", http.StatusPartialContent, ""},
+		{"range not satisfiable", http.StatusRequestedRangeNotSatisfiable, "bytes */200"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ranges []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ranges = append(ranges, r.Header.Get("Range"))
+				if r.Header.Get("Range") == "" {
+					w.Header().Set("ETag", `"v1"`)
+					w.Write(content)
+					return
+				}
+				if tt.header != "" {
+					w.Header().Set("Content-Range", tt.header)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte("garbage"))
+			}))
+			defer srv.Close()
+
+			fileURL := srv.URL + "/app.zip"
+			partFile := filepath.Join(t.TempDir(), "app.zip.part")
+			writePartial(t, partFile, content, 100, partMeta{URL: fileURL, ETag: `"v1"`, Size: int64(len(content))})
+
+			if err := downloadHTTP(srv.Client(), fileURL, partFile); err != nil {
+				t.Fatalf("downloadHTTP() = %v", err)
+			}
+			checkFile(t, partFile, content)
+			if len(ranges) != 2 || ranges[0] != "bytes=100-" || ranges[1] != "" {
+				t.Errorf("Range headers = %q, want the resume and a full download", ranges)
+			}
+		})
+	}
+}
+
+func TestDownloadHTTPErrors(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+
+	t.Run("not found removes partial file", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		defer srv.Close()
+		partFile := filepath.Join(t.TempDir(), "app.zip.part")
+		writePartial(t, partFile, []byte("abcdef"), 3, partMeta{URL: srv.URL, ETag: `"v1"`, Size: 6})
+
+		err := downloadHTTP(srv.Client(), srv.URL, partFile)
+		var statusErr *ErrDownloadStatus
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+			t.Fatalf("downloadHTTP() = %v, want 404", err)
+		}
+		if _, err := os.Stat(partFile); !os.IsNotExist(err) {
+			t.Errorf("partial file is kept after 404")
+		}
+	})
+
+	t.Run("interrupted download keeps partial file", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Length", "100")
+			w.Write([]byte(strings.Repeat("x", 40)))
+		}))
+		defer srv.Close()
+		partFile := filepath.Join(t.TempDir(), "app.zip.part")
+
+		err := downloadHTTP(srv.Client(), srv.URL, partFile)
+		if !isRetryableDownloadError(err) {
+			t.Fatalf("downloadHTTP() = %v, want a retryable error", err)
+		}
+		info, statErr := os.Stat(partFile)
+		if statErr != nil || info.Size() != 40 {
+			t.Fatalf("partial file = %v, %v", info, statErr)
+		}
+		var meta partMeta
+		metaJson, _ := os.ReadFile(partFile + ".json")
+		if json.Unmarshal(metaJson, &meta) != nil || meta.URL != srv.URL || meta.Size != 100 {
+			t.Errorf("partMeta = %+v", meta)
+		}
+	})
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		header    string
+		wantStart int64
+		wantTotal int64
+		wantErr   bool
+	}{
+		{"bytes 100-199/200", 100, 200, false},
+		{"bytes 0-0/1", 0, 1, false},
+		{"bytes 100-199/*", 100, -1, false},
+		{"bytes 100-199/150", 0, 0, true},
+		{"bytes 200-100/300", 0, 0, true},
+		{"bytes */200", 0, 0, true},
+		{"items 0-1/2", 0, 0, true},
+		{"", 0, 0, true},
+	}
+	for _, tt := range tests {
+		start, total, err := parseContentRange(tt.header)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseContentRange(%q) error = %v, wantErr %v", tt.header, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (start != tt.wantStart || total != tt.wantTotal) {
+			t.Errorf("parseContentRange(%q) = %d, %d, want %d, %d", tt.header, start, total, tt.wantStart, tt.wantTotal)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdtType "main/src/controlType"
+	sdtLog "pkg/log"
+)
+
+func TestRunInstallHook(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	baseDir := t.TempDir()
+	appDir := filepath.Join(baseDir, "app")
+	if err := os.MkdirAll(filepath.Join(appDir, "hooks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	marker := filepath.Join(baseDir, "ran")
+	script := []byte("touch " + marker + "\n")
+	for _, path := range []string{filepath.Join(appDir, "hooks", "pre.sh"), filepath.Join(baseDir, "evil.sh")} {
+		if err := os.WriteFile(path, script, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svcInfo := sdtType.ControlService{BaseCmd: [2]string{"sh", "-c"}}
+
+	for _, script := range []string{"../evil.sh", filepath.Join(baseDir, "evil.sh"), ".", "hooks/../../evil.sh"} {
+		if err := RunInstallHook(appDir, script, svcInfo); err == nil {
+			t.Errorf("RunInstallHook(%q) expected error", script)
+		}
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatalf("script outside of app directory was run")
+	}
+
+	if err := RunInstallHook(appDir, "hooks/pre.sh", svcInfo); err != nil {
+		t.Fatalf("RunInstallHook() error = %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("hook script was not run: %v", err)
+	}
+}
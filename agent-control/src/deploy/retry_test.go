@@ -0,0 +1,120 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	sdtType "main/src/controlType"
+	sdtLog "pkg/log"
+)
+
+// flakyServer fails the first failures requests with the status, and then serves content.
+type flakyServer struct {
+	mu       sync.Mutex
+	failures int
+	status   int
+	requests int
+	content  []byte
+}
+
+func (f *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests++
+	fail := f.requests <= f.failures
+	f.mu.Unlock()
+	if fail {
+		w.WriteHeader(f.status)
+		return
+	}
+	w.Write(f.content)
+}
+
+// fakeDownloadFile replaces downloadFile with a download of the URL into dir by downloadHTTP.
+func fakeDownloadFile(t *testing.T, dir string) {
+	t.Helper()
+	oldWait := downloadRetryWait
+	downloadRetryWait = 0
+	downloadFile = func(fullURLFile, appId, app, appName, archType string, svcInfo sdtType.ControlService) (string, int64, error, string, string) {
+		partFile := filepath.Join(dir, appName+".zip.part")
+		err := downloadHTTP(http.DefaultClient, fullURLFile, partFile)
+		return dir, 0, err, fullURLFile, partFile
+	}
+	t.Cleanup(func() {
+		downloadFile = fileDownload
+		downloadRetryWait = oldWait
+	})
+}
+
+func TestDownloadWithRetry(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	content := []byte("app archive")
+
+	tests := []struct {
+		name         string
+		failures     int
+		status       int
+		maxRetries   int
+		wantErr      bool
+		wantRequests int
+	}{
+		{"fails twice before success", 2, http.StatusServiceUnavailable, 3, false, 3},
+		{"fails more than retries", 5, http.StatusBadGateway, 3, true, 3},
+		{"not found is not retried", 5, http.StatusNotFound, 3, true, 1},
+		{"single attempt", 1, http.StatusServiceUnavailable, 1, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			fakeDownloadFile(t, dir)
+			server := &flakyServer{failures: tt.failures, status: tt.status, content: content}
+			srv := httptest.NewServer(server)
+			defer srv.Close()
+
+			_, _, err, _, partFile := downloadWithRetry(tt.maxRetries, srv.URL+"/app.zip", "app-1", "app", "web", "amd", sdtType.ControlService{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("downloadWithRetry() = %v, wantErr %v", err, tt.wantErr)
+			}
+			if server.requests != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", server.requests, tt.wantRequests)
+			}
+			if !tt.wantErr {
+				checkFile(t, partFile, content)
+			}
+		})
+	}
+}
+
+func TestIsRetryableDownloadError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network error", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{"unexpected EOF", fmt.Errorf("copy: %w", io.ErrUnexpectedEOF), true},
+		{"server error", &ErrDownloadStatus{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}, true},
+		{"not found", &ErrDownloadStatus{StatusCode: http.StatusNotFound, Status: "404 Not Found"}, false},
+		{"disk full", &ErrInsufficientDisk{Path: "/usr/local/sdt/app"}, false},
+		{"other error", errors.New("unzip error"), false},
+	}
+	for _, tt := range tests {
+		if got := isRetryableDownloadError(tt.err); got != tt.want {
+			t.Errorf("isRetryableDownloadError(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetMaxRetries(t *testing.T) {
+	for maxRetries, want := range map[int]int{0: defaultMaxRetries, -1: defaultMaxRetries, 1: 1, 5: 5} {
+		if got := GetMaxRetries(maxRetries); got != want {
+			t.Errorf("GetMaxRetries(%d) = %d, want %d", maxRetries, got, want)
+		}
+	}
+}
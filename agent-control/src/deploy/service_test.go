@@ -0,0 +1,42 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdtType "main/src/controlType"
+	sdtLog "pkg/log"
+)
+
+func TestGetServiceEnv(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	appDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(appDir, ".env"), []byte("TOKEN=secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		env  sdtType.Env
+		want string
+	}{
+		{"no variables", sdtType.Env{}, ""},
+		{"sorted envVars", sdtType.Env{EnvVars: map[string]string{"B": "2", "A": "1"}},
+			"Environment=\"A=1\"\nEnvironment=\"B=2\"\n"},
+		{"escaped envVars", sdtType.Env{EnvVars: map[string]string{"MSG": "say \"hi\" \\ 100%\nbye"}},
+			"Environment=\"MSG=say \\\"hi\\\" \\\\ 100%%\\nbye\"\n"},
+		{"relative secrets file", sdtType.Env{EnvVars: map[string]string{"A": "1"}, EnvSecretsFile: ".env"},
+			"Environment=\"A=1\"\nEnvironmentFile=" + filepath.Join(appDir, ".env") + "\n"},
+		{"absolute secrets file", sdtType.Env{EnvSecretsFile: filepath.Join(appDir, ".env")},
+			"EnvironmentFile=" + filepath.Join(appDir, ".env") + "\n"},
+		{"missing secrets file", sdtType.Env{EnvSecretsFile: "missing.env"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetServiceEnv(appDir, tt.env); got != tt.want {
+				t.Errorf("GetServiceEnv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package deploy
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdtType "main/src/controlType"
+	sdtLog "pkg/log"
+)
+
+// zipArchive creates a zip archive with one file.
+func zipArchive(t *testing.T, name string, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(content))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// baseServer serves the base archive. If etag is empty, the ETag header is not set.
+type baseServer struct {
+	archive []byte
+	etag    string
+	gets    int
+}
+
+func (b *baseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if b.etag != "" {
+		w.Header().Set("ETag", b.etag)
+	}
+	if r.Method == http.MethodGet {
+		b.gets++
+		w.Write(b.archive)
+	}
+}
+
+func TestGetSharedBase(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	for _, etag := range []string{`"v1"`, ""} {
+		name := "with etag"
+		if etag == "" {
+			name = "without etag"
+		}
+		t.Run(name, func(t *testing.T) {
+			server := &baseServer{archive: zipArchive(t, "lib.txt", "version 1"), etag: etag}
+			srv := httptest.NewServer(server)
+			defer srv.Close()
+			svcInfo := sdtType.ControlService{AppPath: t.TempDir()}
+			baseImage := srv.URL + "/base.zip"
+
+			basePath, err := GetSharedBase(baseImage, map[string]string{}, svcInfo)
+			if err != nil {
+				t.Fatalf("GetSharedBase() = %v", err)
+			}
+			checkFile(t, filepath.Join(basePath, "lib.txt"), []byte("version 1"))
+
+			// Same deploy: cached without a request.
+			cache := map[string]string{baseImage: basePath}
+			if got, _ := GetSharedBase(baseImage, cache, svcInfo); got != basePath || server.gets != 1 {
+				t.Errorf("cached GetSharedBase() = %s (%d downloads)", got, server.gets)
+			}
+
+			// Next deploy of the same archive: the extracted base is reused.
+			reused, err := GetSharedBase(baseImage, map[string]string{}, svcInfo)
+			if err != nil || reused != basePath {
+				t.Fatalf("GetSharedBase() = %s, %v, want %s", reused, err, basePath)
+			}
+			if etag != "" && server.gets != 1 {
+				t.Errorf("base with ETag is downloaded again: %d downloads", server.gets)
+			}
+
+			// Changed archive: a new base is extracted, and the old one is kept for the running apps.
+			server.archive = zipArchive(t, "lib.txt", "version 2")
+			if etag != "" {
+				server.etag = `"v2"`
+			}
+			newPath, err := GetSharedBase(baseImage, map[string]string{}, svcInfo)
+			if err != nil {
+				t.Fatalf("GetSharedBase() = %v", err)
+			}
+			if newPath == basePath {
+				t.Fatalf("changed archive uses the same base %s", newPath)
+			}
+			checkFile(t, filepath.Join(newPath, "lib.txt"), []byte("version 2"))
+			checkFile(t, filepath.Join(basePath, "lib.txt"), []byte("version 1"))
+
+			// Only the bases are left in .base.
+			entries, _ := os.ReadDir(filepath.Join(svcInfo.AppPath, ".base"))
+			if len(entries) != 2 {
+				t.Errorf(".base has %d entries, want 2", len(entries))
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package deploy
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	sdtType "main/src/controlType"
+	sdtLog "pkg/log"
+)
+
+func TestGetVenvList(t *testing.T) {
+	venvPath := t.TempDir()
+	for _, name := range []string{"base", "torch", "creating"} {
+		if err := os.MkdirAll(filepath.Join(venvPath, name, "bin"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(venvIncompleteFile(filepath.Join(venvPath, "creating")), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := GetVenvList(venvPath)
+	if want := []string{"base", "torch"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetVenvList() = %v, want %v", got, want)
+	}
+}
+
+func TestDeleteVenv(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	tests := []struct {
+		name       string
+		appInfo    string
+		wantStatus int
+	}{
+		{"no app.json", "", http.StatusOK},
+		{"not used", `{"AppInfoList": [{"AppName": "web", "AppVenv": "base"}]}`, http.StatusOK},
+		{"used by app", `{"AppInfoList": [{"AppName": "web", "AppVenv": "torch"}]}`, http.StatusConflict},
+		{"invalid app.json", `{"AppInfoList": [`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := t.TempDir()
+			svcInfo := sdtType.ControlService{VenvPath: t.TempDir()}
+			envPath := filepath.Join(svcInfo.VenvPath, "torch")
+			if err := os.MkdirAll(envPath, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.appInfo != "" {
+				appInfoFile := filepath.Join(rootPath, "device.config", "app.json")
+				if err := os.MkdirAll(filepath.Dir(appInfoFile), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(appInfoFile, []byte(tt.appInfo), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err, status := DeleteVenv("torch", rootPath, svcInfo)
+			if status != tt.wantStatus {
+				t.Fatalf("DeleteVenv() = %v, %d, want status %d", err, status, tt.wantStatus)
+			}
+			_, statErr := os.Stat(envPath)
+			if deleted := os.IsNotExist(statErr); deleted != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("venv deleted = %v, want %v", deleted, tt.wantStatus == http.StatusOK)
+			}
+		})
+	}
+}
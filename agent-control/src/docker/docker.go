@@ -21,6 +21,13 @@ import (
 // - procLog: This is the struct that defines the format of the Log.
 var procLog sdtType.Logger
 
+// ContainerClient is the part of the docker client used by the docker package.
+// *client.Client implements it.
+type ContainerClient interface {
+	client.ContainerAPIClient
+	client.ImageAPIClient
+}
+
 // Getlog is a function that loads the log format. Log formats are defined as Info,
 // Warn, Error, and are output using Printf.
 // Here's how you would record the text "Hello World" as an Info log type:
@@ -40,7 +47,7 @@ func Getlog(logConfig sdtType.Logger) {
 // Output:
 //   - error: Error message in case of issues with the stop command.
 //   - int: Status code of the command execution.
-func StopContainer(cli *client.Client, appName string, appId string) (error, int) {
+func StopContainer(cli ContainerClient, appName string, appId string) (error, int) {
 	removeName := fmt.Sprintf("%s-%s", appName, appId)
 	ctx := context.Background()
 	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
@@ -76,7 +83,7 @@ func StopContainer(cli *client.Client, appName string, appId string) (error, int
 // Output:
 //   - error: Error message in case of issues with the start command.
 //   - int: Status code of the command execution.
-func StartContainer(cli *client.Client, appName string, appId string) (error, int) {
+func StartContainer(cli ContainerClient, appName string, appId string) (error, int) {
 	removeName := fmt.Sprintf("%s-%s", appName, appId)
 	ctx := context.Background()
 	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
@@ -112,7 +119,7 @@ func StartContainer(cli *client.Client, appName string, appId string) (error, in
 // Output:
 //   - error: Error message in case of issues with the delete command.
 //   - int: Status code of the command execution.
-func DeleteContainer(cli *client.Client, appName string, appId string, rootPath string) (error, int) {
+func DeleteContainer(cli ContainerClient, appName string, appId string, rootPath string) (error, int) {
 	removeName := fmt.Sprintf("%s-%s", appName, appId)
 	ctx := context.Background()
 	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
@@ -145,6 +152,62 @@ func DeleteContainer(cli *client.Client, appName string, appId string, rootPath
 	return err, http.StatusNotFound
 }
 
+// Resource limits of a container accepted by the control command.
+//   - minMemoryLimit: Minimum memory limit accepted by docker. (6MB)
+//   - maxMemoryLimit: Maximum memory limit. (1TB)
+//   - minCPUShares, maxCPUShares: Range of cpu.shares of the Linux kernel.
+const (
+	minMemoryLimit int64 = 6 * 1024 * 1024
+	maxMemoryLimit int64 = 1024 * 1024 * 1024 * 1024
+	minCPUShares         = 2
+	maxCPUShares         = 262144
+)
+
+// ValidateResources function checks the resource limits and port bindings of the docker command.
+//
+// Input:
+//   - memoryLimit: Memory limit in bytes. (0 is unlimited)
+//   - cpuShares: CPU shares. (0 is the docker default)
+//   - portBindings: Host port of each container port.
+//
+// Output:
+//   - error: Error message if a value is out of range.
+func ValidateResources(memoryLimit int64, cpuShares int64, portBindings map[string]string) error {
+	if memoryLimit != 0 && (memoryLimit < minMemoryLimit || memoryLimit > maxMemoryLimit) {
+		return fmt.Errorf("memoryLimit %d must be 0 or between %d and %d", memoryLimit, minMemoryLimit, maxMemoryLimit)
+	}
+	if cpuShares != 0 && (cpuShares < minCPUShares || cpuShares > maxCPUShares) {
+		return fmt.Errorf("cpuShares %d must be 0 or between %d and %d", cpuShares, minCPUShares, maxCPUShares)
+	}
+	_, _, err := ParsePortBindings(portBindings)
+	return err
+}
+
+// ParsePortBindings function converts the port bindings of the docker command to the docker types.
+//
+// Input:
+//   - portBindings: Host port of each container port. The key is "<containerPort>[/<protocol>]"
+//     and the value is "[<hostIP>:]<hostPort>". (ex. {"80/tcp": "8080"})
+//
+// Output:
+//   - nat.PortSet: Exposed ports of the container.
+//   - nat.PortMap: Port bindings of the container.
+//   - error: Error message if a port is invalid.
+func ParsePortBindings(portBindings map[string]string) (nat.PortSet, nat.PortMap, error) {
+	specs := make([]string, 0, len(portBindings))
+	for containerPort, hostPort := range portBindings {
+		if hostPort == "" {
+			return nil, nil, fmt.Errorf("host port of %s is empty", containerPort)
+		}
+		specs = append(specs, fmt.Sprintf("%s:%s", hostPort, containerPort))
+	}
+	exposedPorts, bindings, err := nat.ParsePortSpecs(specs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return exposedPorts, bindings, nil
+}
+
 // The Deploy function deploys an application onto the device. Deploying as docker container
 //
 // Input:
@@ -152,17 +215,23 @@ func DeleteContainer(cli *client.Client, appName string, appId string, rootPath
 //   - imageName: The name of container image.
 //   - appName: The name of the application.
 //   - appId: The ID of the application.
+//   - portBindings: Host port of each container port. (See ParsePortBindings)
+//   - envData: Environment variables of the container.
+//   - memoryLimit: Memory limit of the container in bytes. (0 is unlimited)
+//   - cpuShares: CPU shares of the container. (0 is the docker default)
 //
 // Output:
 //   - error: Error message in case of issues with the deploy command.
 //   - int: Status code of the command execution.
-func CreateContainer(cli *client.Client,
+func CreateContainer(cli ContainerClient,
 	imageName string,
 	appName string,
 	appId string,
 	rootPath string,
-	portData []interface{},
-	envData map[string]interface{}) (error, int) {
+	portBindings map[string]string,
+	envData map[string]interface{},
+	memoryLimit int64,
+	cpuShares int64) (error, int) {
 
 	containerName := fmt.Sprintf("%s-%s", appName, appId)
 	procLog.Info.Printf("[DEPLOY][DOCKER] Create Container: %s, Image: %s\n", containerName, imageName)
@@ -176,28 +245,21 @@ func CreateContainer(cli *client.Client,
 	defer reader.Close()
 	io.Copy(os.Stdout, reader)
 
-	var newport nat.Port
-	var portConfig []nat.PortBinding
-	var hostConfig *containerType.HostConfig
 	var containerConfig *containerType.Config
 	var envConfig = make([]string, 0)
 
-	// Set container Port
-	if portData != nil {
-		for _, portInterface := range portData {
-			portmap := portInterface.(map[string]interface{})
-			port := nat.PortBinding{
-				HostIP:   "0.0.0.0",
-				HostPort: fmt.Sprintf("%d", int(portmap["hostPort"].(float64))),
-			}
-			newport, _ = nat.NewPort(portmap["protocol"].(string), fmt.Sprintf("%d", int(portmap["containerPort"].(float64))))
-			portConfig = append(portConfig, port)
-		}
-		hostConfig = &containerType.HostConfig{
-			PortBindings: nat.PortMap{
-				newport: portConfig,
-			},
-		}
+	// Set container Port and resources
+	exposedPorts, portMap, err := ParsePortBindings(portBindings)
+	if err != nil {
+		procLog.Error.Printf("[DEPLOY][DOCKER] Invalid port bindings: %v\n", err)
+		return err, http.StatusBadRequest
+	}
+	hostConfig := &containerType.HostConfig{
+		PortBindings: portMap,
+		Resources: containerType.Resources{
+			Memory:    memoryLimit,
+			CPUShares: cpuShares,
+		},
 	}
 
 	// Set container Env Variable
@@ -208,12 +270,14 @@ func CreateContainer(cli *client.Client,
 
 		// Set container config
 		containerConfig = &containerType.Config{
-			Image: imageName,
-			Env:   envConfig,
+			Image:        imageName,
+			Env:          envConfig,
+			ExposedPorts: exposedPorts,
 		}
 	} else {
 		containerConfig = &containerType.Config{
-			Image: imageName,
+			Image:        imageName,
+			ExposedPorts: exposedPorts,
 		}
 	}
 
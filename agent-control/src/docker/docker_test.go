@@ -0,0 +1,126 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	containerType "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	sdtType "main/src/controlType"
+	sdtDeploy "main/src/deploy"
+	sdtLog "pkg/log"
+)
+
+// mockClient records the config passed to ContainerCreate. The other methods of
+// ContainerClient are not used by CreateContainer, so they are left to the nil interface.
+type mockClient struct {
+	ContainerClient
+	config     *containerType.Config
+	hostConfig *containerType.HostConfig
+	name       string
+	startedID  string
+}
+
+func (m *mockClient) ImagePull(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+func (m *mockClient) ContainerCreate(ctx context.Context, config *containerType.Config, hostConfig *containerType.HostConfig,
+	networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (containerType.CreateResponse, error) {
+	m.config = config
+	m.hostConfig = hostConfig
+	m.name = containerName
+	return containerType.CreateResponse{ID: "container-id"}, nil
+}
+
+func (m *mockClient) ContainerStart(ctx context.Context, containerID string, options types.ContainerStartOptions) error {
+	m.startedID = containerID
+	return nil
+}
+
+func TestCreateContainerResources(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	sdtDeploy.Getlog(procLog)
+	rootPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(rootPath, "device.config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := &mockClient{}
+	portBindings := map[string]string{"80/tcp": "8080", "53/udp": "127.0.0.1:5353"}
+	err, status := CreateContainer(cli, "nginx:latest", "web", "app-1", rootPath, portBindings, nil, 64*1024*1024, 512)
+	if err != nil || status != 200 {
+		t.Fatalf("CreateContainer() = %v, %d", err, status)
+	}
+
+	if cli.name != "web-app-1" || cli.startedID != "container-id" {
+		t.Errorf("container name = %q, started = %q", cli.name, cli.startedID)
+	}
+	if cli.config.Image != "nginx:latest" {
+		t.Errorf("image = %q", cli.config.Image)
+	}
+	if cli.hostConfig.Resources.Memory != 64*1024*1024 || cli.hostConfig.Resources.CPUShares != 512 {
+		t.Errorf("resources = %+v", cli.hostConfig.Resources)
+	}
+	for port, want := range map[nat.Port]nat.PortBinding{
+		"80/tcp": {HostIP: "", HostPort: "8080"},
+		"53/udp": {HostIP: "127.0.0.1", HostPort: "5353"},
+	} {
+		if _, ok := cli.config.ExposedPorts[port]; !ok {
+			t.Errorf("%s is not exposed", port)
+		}
+		bindings := cli.hostConfig.PortBindings[port]
+		if len(bindings) != 1 || bindings[0] != want {
+			t.Errorf("bindings of %s = %+v, want %+v", port, bindings, want)
+		}
+	}
+}
+
+func TestCreateContainerInvalidPort(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	sdtDeploy.Getlog(procLog)
+	cli := &mockClient{}
+	err, status := CreateContainer(cli, "nginx:latest", "web", "app-1", t.TempDir(), map[string]string{"80/tcp": ""}, nil, 0, 0)
+	if err == nil || status != 400 {
+		t.Fatalf("CreateContainer() = %v, %d, want error", err, status)
+	}
+	if cli.config != nil {
+		t.Errorf("ContainerCreate is called with invalid port bindings")
+	}
+}
+
+func TestValidateResources(t *testing.T) {
+	tests := []struct {
+		name         string
+		memoryLimit  int64
+		cpuShares    int64
+		portBindings map[string]string
+		wantErr      bool
+	}{
+		{"unlimited", 0, 0, nil, false},
+		{"in range", 512 * 1024 * 1024, 1024, map[string]string{"80": "8080"}, false},
+		{"negative memory", -1, 0, nil, true},
+		{"too small memory", 1024, 0, nil, true},
+		{"too large memory", maxMemoryLimit + 1, 0, nil, true},
+		{"negative cpu shares", 0, -1, nil, true},
+		{"too large cpu shares", 0, maxCPUShares + 1, nil, true},
+		{"invalid container port", 0, 0, map[string]string{"http": "8080"}, true},
+		{"empty host port", 0, 0, map[string]string{"80": ""}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateResources(tt.memoryLimit, tt.cpuShares, tt.portBindings)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateResources() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// The Healthz package provides HTTP endpoints to check the status of Device-Control.
+// These endpoints are used by liveness/readiness probes of Kubernetes or Docker
+// when the agent is containerized.
+//   - /healthz: Status of the agent and the MQTT connection.
+//   - /readyz: Status of the agent, the MQTT connection and the BWC config files.
+package healthz
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	sdtType "main/src/controlType"
+)
+
+// These are the global variables used in the healthz package.
+// - procLog: This is the struct that defines the format of the Log.
+var procLog sdtType.Logger
+
+// Getlog is a function that loads the log format. Log formats are defined as Info,
+// Warn, Error, and are output using Printf.
+// Here's how you would record the text "Hello World" as an Info log type:
+//
+//	procLog.Info.Printf("Hello World\n")   ->   [INFO] Hello World
+func Getlog(logConfig sdtType.Logger) {
+	procLog = logConfig
+}
+
+// The RunServer function starts the HTTP server of the health endpoints.
+//
+// Input:
+//   - port: Port of the HTTP server.
+//   - rootPath: Root path of BWC.
+//   - mqttConnected: Function that returns the MQTT connection status.
+func RunServer(port int, rootPath string, mqttConnected func() bool) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, http.StatusOK, map[string]interface{}{
+			"status":         "ok",
+			"mqtt_connected": mqttConnected(),
+		})
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		connected := mqttConnected()
+		err := checkConfig(rootPath)
+		if !connected || err != nil {
+			reason := "MQTT not connected."
+			if err != nil {
+				reason = err.Error()
+			}
+			writeStatus(w, http.StatusServiceUnavailable, map[string]interface{}{
+				"status":         "not ready",
+				"mqtt_connected": connected,
+				"reason":         reason,
+			})
+			return
+		}
+		writeStatus(w, http.StatusOK, map[string]interface{}{
+			"status":         "ok",
+			"mqtt_connected": connected,
+		})
+	})
+
+	procLog.Info.Printf("[HEALTHZ] Listen health endpoint: :%d\n", port)
+	err := http.ListenAndServe(fmt.Sprintf(":%d", port), mux)
+	if err != nil {
+		procLog.Error.Printf("[HEALTHZ] Health endpoint error: %v\n", err)
+	}
+}
+
+// The checkConfig function checks that config.json is readable and app.json is parseable.
+// If app.json doesn't exist, no app has been deployed on the device.
+//
+// Input:
+//   - rootPath: Root path of BWC.
+//
+// Output:
+//   - error: Error message if the config files aren't valid.
+func checkConfig(rootPath string) error {
+	_, err := os.ReadFile(fmt.Sprintf("%s/device.config/config.json", rootPath))
+	if err != nil {
+		return fmt.Errorf("config.json not readable: %v", err)
+	}
+
+	appInfoFile := fmt.Sprintf("%s/device.config/app.json", rootPath)
+	jsonFile, err := os.ReadFile(appInfoFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("app.json not readable: %v", err)
+	}
+	var jsonData sdtType.AppConfig
+	err = json.Unmarshal(jsonFile, &jsonData)
+	if err != nil {
+		return fmt.Errorf("app.json not parseable: %v", err)
+	}
+	return nil
+}
+
+// The writeStatus function writes the status as a JSON response.
+func writeStatus(w http.ResponseWriter, statusCode int, status map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(status)
+}
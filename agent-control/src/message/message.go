@@ -9,9 +9,9 @@ import (
 	"fmt"
 	mqttCli "github.com/eclipse/paho.mqtt.golang"
 	"github.com/google/uuid"
-	"io/ioutil"
 	sdtType "main/src/controlType"
 	"os"
+	sdtMqtt "pkg/mqttutil"
 	"time"
 )
 
@@ -20,11 +20,13 @@ import (
 //   - procLog: Struct defining the format of logs.
 //   - mqttUser: User ID used for MQTT connection.
 //   - mqttPassword: Password used for MQTT connection.
+//   - onReconnect: Function called after the MQTT client is reconnected. (ex. Subscribe again)
 var (
 	cli          mqttCli.Client
 	procLog      sdtType.Logger
 	mqttUser     = "sdt"
 	mqttPassword = "251327"
+	onReconnect  func()
 )
 
 // Getlog is a function that loads the log format. Log formats are defined as Info,
@@ -36,6 +38,27 @@ func Getlog(logConfig sdtType.Logger) {
 	procLog = logConfig
 }
 
+// connectionLostHandler function is called when the connection to the MQTT broker is lost.
+// Instead of exiting the agent, it reconnects with exponential backoff. (See pkg/mqttutil)
+//
+// Input:
+//   - client: MQTT client that lost the connection.
+//   - err: Reason of the connection loss.
+func connectionLostHandler(client mqttCli.Client, err error) {
+	procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
+	sdtMqtt.OnConnectionLost(func() error {
+		token := client.Connect()
+		if token.Wait() && token.Error() != nil {
+			return token.Error()
+		}
+		// Subscriptions are not kept after the connection is lost.
+		if onReconnect != nil {
+			onReconnect()
+		}
+		return nil
+	}, procLog.Error)
+}
+
 // This function defines options for connecting to the AWS IoT Core MQTT Broker.
 // It specifies options such as TLS, MQTT URI, client name, and handlers.
 //
@@ -49,7 +72,7 @@ func Getlog(logConfig sdtType.Logger) {
 //   - *mqttCli.ClientOptions: Variable of type MQTT ClientOptions.
 func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey string) *mqttCli.ClientOptions {
 	// Load CA certificate
-	caCert, err := ioutil.ReadFile(rootCa)
+	caCert, err := os.ReadFile(rootCa)
 	if err != nil {
 		procLog.Error.Printf("Error reading CA certificate file: %v", err)
 	}
@@ -83,10 +106,63 @@ func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey string) *m
 	opts.AddBroker(mqttURL)
 	opts.SetTLSConfig(tlsConfig)
 	opts.SetClientID(fmt.Sprintf("blokworks-client-live-control-%s", cilentUUID))
-	opts.SetConnectionLostHandler(func(client mqttCli.Client, err error) {
-		procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
-		os.Exit(1)
-	})
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
+
+	return opts
+}
+
+// createExmqClientOptions function defines options for connecting to the EXMQ Broker.
+// The certificates of the EXMQ broker are read from the 'exmq' section of the config file.
+//
+// Input:
+//   - config: Struct storing the Config file saved on the device in JSON format.
+//
+// Output:
+//   - *mqttCli.ClientOptions: Options of the MQTT client.
+func createExmqClientOptions(config sdtType.ConfigInfo) *mqttCli.ClientOptions {
+	tlsConfig := &tls.Config{}
+
+	// Load CA certificate
+	if config.Exmq.RootCa != "" {
+		caCert, err := os.ReadFile(config.Exmq.RootCa)
+		if err != nil {
+			procLog.Error.Printf("Error reading EXMQ CA certificate file: %v", err)
+		}
+		roots := x509.NewCertPool()
+		roots.AppendCertsFromPEM(caCert)
+		tlsConfig.RootCAs = roots
+	}
+
+	// Load client certificate
+	if config.Exmq.Cert != "" && config.Exmq.PrivateKey != "" {
+		clientCert, err := tls.LoadX509KeyPair(config.Exmq.Cert, config.Exmq.PrivateKey)
+		if err != nil {
+			procLog.Error.Printf("Error reading EXMQ client key file: %v", err)
+		} else {
+			tlsConfig.Certificates = []tls.Certificate{clientCert}
+		}
+	}
+
+	mqttURL := config.Exmq.MqttUrl
+	if mqttURL == "" {
+		mqttURL = config.MqttUrl
+	}
+
+	// set uuid
+	newUUID := uuid.New()
+	cilentUUID := newUUID.String()
+
+	opts := mqttCli.NewClientOptions()
+	opts.AddBroker(mqttURL)
+	opts.SetTLSConfig(tlsConfig)
+	if config.Exmq.Username != "" {
+		opts.SetUsername(config.Exmq.Username)
+		opts.SetPassword(config.Exmq.Password)
+	}
+	opts.SetClientID(fmt.Sprintf("blokworks-client-live-control-%s", cilentUUID))
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
 
 	return opts
 }
@@ -96,22 +172,24 @@ func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey string) *m
 //
 // Input:
 //   - config: Struct storing the config file saved on the device in JSON format.
+//   - user: User ID used for MQTT connection.
+//   - password: Password used for MQTT connection.
 //
 // Output:
 //   - mqttCli.Client: Variable of type MQTT Client.
 func connectToMqtt(
 	config sdtType.ConfigInfo, // The variable of config
+	user string,
+	password string,
 ) mqttCli.Client {
 	procLog.Info.Println("[MQTT] In connectToMqtt Function")
 	opts := mqttCli.NewClientOptions()
 	opts.AddBroker(config.MqttUrl)
-	opts.SetPassword(mqttPassword)
-	opts.SetUsername(mqttUser)
+	opts.SetPassword(password)
+	opts.SetUsername(user)
 	opts.SetClientID(fmt.Sprintf("blokworks-client-control-%s", config.AssetCode))
-	opts.SetConnectionLostHandler(func(client mqttCli.Client, err error) {
-		procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
-		os.Exit(1)
-	})
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
 
 	cli = mqttCli.NewClient(opts)
 
@@ -174,6 +252,30 @@ func SendDataEdgeMqttInterface(
 	}
 }
 
+// SetMqttCredential function overrides the MQTT user and password used for connecting to
+// the Mosquitto MQTT Broker. Empty values are ignored, so the default values are used.
+//
+// Input:
+//   - user: User ID used for MQTT connection.
+//   - password: Password used for MQTT connection.
+func SetMqttCredential(user string, password string) {
+	if user != "" {
+		mqttUser = user
+	}
+	if password != "" {
+		mqttPassword = password
+	}
+}
+
+// SetOnReconnect function sets the function called after the MQTT client is reconnected.
+// Subscriptions are not kept after the connection is lost, so the topic must be subscribed again.
+//
+// Input:
+//   - f: Function called after the reconnection.
+func SetOnReconnect(f func()) {
+	onReconnect = f
+}
+
 // SetMqttClient selects an MQTT broker based on the SDTCloud service type of the device and publishes messages accordingly.
 //
 // Input:
@@ -185,11 +287,15 @@ func SendDataEdgeMqttInterface(
 func SetMqttClient(mqttType string, configData sdtType.ConfigInfo, rootCa string, fullCertChain string, private string) mqttCli.Client {
 	if mqttType == "onprem" {
 		// Set mqtt client - EC2
-		cli = connectToMqtt(configData)
+		cli = connectToMqtt(configData, mqttUser, mqttPassword)
 	} else if mqttType == "aws-dev" || mqttType == "eks" || mqttType == "dev" {
 		// Set MQTT - AWS IoT Core
 		opts := createAwsClientOptions(configData.MqttUrl, rootCa, fullCertChain, private)
 		cli = mqttCli.NewClient(opts)
+	} else if mqttType == "exmq" {
+		// Set MQTT - EXMQ
+		opts := createExmqClientOptions(configData)
+		cli = mqttCli.NewClient(opts)
 	} else {
 		err := errors.New("Please input mqtt variable.")
 		procLog.Error.Printf("[MQTT] MQTT connection Error: %v\n", err)
@@ -207,11 +313,9 @@ func SetMqttClient(mqttType string, configData sdtType.ConfigInfo, rootCa string
 func SendAckMessage(
 	payload map[string]interface{}, // The variable of command
 	cli mqttCli.Client,
-	projectCode string,
-	assetCode string,
-	serviceCode string,
+	configData sdtType.ConfigInfo,
 ) {
-	topic := fmt.Sprintf("%s/%s/%s/bwc/control/request/ack", serviceCode, projectCode, assetCode)
+	topic := configData.Topic("bwc/control/request/ack")
 	resultBody, err := json.Marshal(payload)
 	if err != nil {
 		procLog.Error.Printf("[MQTT] Unmarshal error: %v\n", err)
@@ -21,8 +21,6 @@ import (
 	"fmt"
 	winSvc "golang.org/x/sys/windows/svc"
 	"io"
-	"io/ioutil"
-	"log"
 	"net/http"
 	"os"
 	"os/exec"
@@ -33,13 +31,23 @@ import (
 
 	dockerCli "github.com/docker/docker/client"
 	mqttCli "github.com/eclipse/paho.mqtt.golang"
+	sdtAudit "main/src/audit"
 	sdtConfig "main/src/config"
 	sdtControl "main/src/control"
 	sdtType "main/src/controlType"
 	sdtDeploy "main/src/deploy"
 	sdtDocker "main/src/docker"
+	sdtHealthz "main/src/healthz"
 	sdtMessage "main/src/message"
 	sdtModel "main/src/model"
+	sdtLog "pkg/log"
+	sdtWinService "pkg/winservice"
+)
+
+// Name and description of the Windows service of the agent. (-service install|uninstall|start|stop)
+const (
+	serviceName        = "DeviceControlService"
+	serviceDescription = "BWC agent that deploys and controls the apps of the device."
 )
 
 // Global variables used in the Device-Control package.
@@ -94,13 +102,13 @@ func SubMessage(client mqttCli.Client, msg mqttCli.Message) {
 			"requestId": m.RequestId,
 			"message":   "ok",
 		}
-		sdtMessage.SendAckMessage(ackMsg, cli, configData.ProjectCode, configData.AssetCode, configData.ServiceCode)
+		sdtMessage.SendAckMessage(ackMsg, cli, configData)
 		procLog.Info.Printf("[MQTT] Send ACK message. \n")
 
 		result, configResult = sdtControl.Control(svcInfo, configData, m, dockerClient, systemArch, systemHome, cli)
 
 		// MQTT Pub
-		topic = fmt.Sprintf("%s/%s/%s/bwc/control/response", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
+		topic = configData.Topic("bwc/control/response")
 		// DEBUG
 		//topic = fmt.Sprintf("%s/%s/%s/debug", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
 
@@ -125,10 +133,13 @@ func SubMessage(client mqttCli.Client, msg mqttCli.Message) {
 // The way to log the text "Hello World" as an Info log type is shown below.
 // procLog.Info.Printf("Hello World\n")
 // Output: [INFO] Hello World
-func initError(logFile io.Writer) {
-	procLog.Info = log.New(logFile, "[INFO] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Warn = log.New(logFile, "[WARNING] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Error = log.New(logFile, "[ERROR] ", log.Ldate|log.Ltime|log.Lshortfile)
+//
+// Logs below logLevel (loglevel in config.json) are discarded.
+// If logFormat is "json", each log is written as a JSON line. (See pkg/log NewJSONLogger)
+func initError(logFile io.Writer, logLevel string, logFormat string) {
+	procLog.Info = sdtLog.NewLogger(logFile, "info", logLevel, logFormat)
+	procLog.Warn = sdtLog.NewLogger(logFile, "warn", logLevel, logFormat)
+	procLog.Error = sdtLog.NewLogger(logFile, "error", logLevel, logFormat)
 }
 
 // This function is the main operation function of device control. It selects the MQTT broker
@@ -145,9 +156,12 @@ func RunBody() {
 	commonPythonPath := svcInfo.CommonPythonPath
 	//appPath := svcInfo.AppPath
 
+	// MQTT credentials can be overridden by environment variables.
+	sdtMessage.SetMqttCredential(os.Getenv("MQTT_USER"), os.Getenv("MQTT_PASSWORD"))
+
 	// var configData sdtType.ConfigInfo
 	jsonFilePath := fmt.Sprintf("%s/device.config/config.json", rootPath)
-	jsonFile, err := ioutil.ReadFile(jsonFilePath)
+	jsonFile, err := os.ReadFile(jsonFilePath)
 	if err != nil {
 		procLog.Error.Printf("[MAIN] %d Not found file Error: %v\n", err, time.Now())
 		panic(err)
@@ -180,6 +194,8 @@ func RunBody() {
 	} else {
 		procLog.Error.Printf("%s not supported. Please check your service.\n", configData.ServiceType)
 	}
+	svcInfo.MinioAccessKey = configData.MinioAccessKey
+	svcInfo.MinioSecretKey = configData.MinioSecretKey
 
 	// Set username
 	if systemHome == "" {
@@ -234,7 +250,7 @@ func RunBody() {
 			RequestId: configData.RequestId,
 		}
 
-		rebootTopic := fmt.Sprintf("%s/%s/%s/bwc/control/response", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
+		rebootTopic := configData.Topic("bwc/control/response")
 		sdtMessage.SendDataEdgeMqtt(rebootMsg, rebootTopic, cli)
 
 		// 메시지 보낸 후, 상태값 변경
@@ -291,7 +307,7 @@ func RunBody() {
 	}
 
 	procLog.Info.Printf("[MAIN] Send runtime info: %s\n", runtimeList)
-	runtimeTopic := fmt.Sprintf("%s/%s/%s/bwc/control/runtime", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
+	runtimeTopic := configData.Topic("bwc/control/runtime")
 	sdtMessage.SendDataEdgeMqttInterface(runtimeResult, runtimeTopic, cli)
 
 	// Create Base Venv
@@ -299,13 +315,18 @@ func RunBody() {
 	sdtDeploy.InstallDefaultPkg("base", configData.DeviceType, configData.ServiceType, svcInfo)
 
 	// Set subscribe mqtt
-	topic := fmt.Sprintf("%s/%s/%s/bwc/control/request", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
+	topic := configData.Topic("bwc/control/request")
 	token := cli.Subscribe(topic, 0, SubMessage)
 
 	if token.Wait() && token.Error() != nil {
 		procLog.Error.Printf("[MAIN] %v \n", token.Error())
 		os.Exit(1)
 	}
+	sdtMessage.SetOnReconnect(func() {
+		if token := cli.Subscribe(topic, 0, SubMessage); token.Wait() && token.Error() != nil {
+			procLog.Error.Printf("[MAIN] Subscribe again Error: %v \n", token.Error())
+		}
+	})
 
 	select {
 	case <-stopchan:
@@ -324,14 +345,30 @@ func RunBody() {
 //   - - exmq: EXMQ
 //   - arch: Architecture of the device.
 //   - home: Hostname of the device.
+//   - healthz-port: Port of the health endpoints(/healthz, /readyz). (0 is disabled)
 func main() {
 	// Set parameter
-	var mqttType, archType, rootPath, minicondaPath, commonPythonPath, appPath, venvPath, home string
+	var mqttType, archType, rootPath, minicondaPath, commonPythonPath, appPath, venvPath, home, logFormat, serviceAction string
+	var healthzPort int
 	var baseCmd [2]string
 	flag.StringVar(&mqttType, "mqtt", "", "Please input mqtt type(mosq? or aws? or exmq?)")
 	flag.StringVar(&archType, "arch", "", "Please input architecture type(amd? or arm? or win?)")
 	flag.StringVar(&home, "home", "", "Please input home's name.")
+	flag.IntVar(&healthzPort, "healthz-port", 0, "Please input port of health endpoint.(0 is disabled)")
+	flag.StringVar(&logFormat, "log-format", "text", "Please input log format(text or json)")
+	flag.StringVar(&serviceAction, "service", "", "Please input windows service action(install, uninstall, start or stop)")
 	flag.Parse()
+
+	// Manage the Windows service of the agent and exit.
+	if serviceAction != "" {
+		err := sdtWinService.Control(serviceAction, serviceName, serviceDescription, os.Args[1:])
+		if err != nil {
+			fmt.Printf("Failed %s %s: %v\n", serviceAction, serviceName, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Successfully %s %s.\n", serviceAction, serviceName)
+		return
+	}
 	systemArch = archType
 	systemHome = home
 
@@ -366,12 +403,13 @@ func main() {
 
 	// Set logger
 	logFilePath := fmt.Sprintf("%s/device.logs/device-control.log", rootPath)
-	logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY|os.O_SYNC, 0644)
+	logFile, err := sdtLog.NewRotateWriter(logFilePath, sdtLog.GetRotateConfig(fmt.Sprintf("%s/device.config/config.json", rootPath)))
 	if err != nil {
 		panic(err)
 	}
 	defer logFile.Close()
-	initError(logFile)
+	logLevel := sdtLog.GetLevel(fmt.Sprintf("%s/device.config/config.json", rootPath))
+	initError(logFile, logLevel, logFormat)
 
 	sdtDeploy.Getlog(procLog)
 	sdtConfig.Getlog(procLog)
@@ -379,12 +417,24 @@ func main() {
 	sdtMessage.Getlog(procLog)
 	sdtDocker.Getlog(procLog)
 	sdtModel.Getlog(procLog)
+	sdtHealthz.Getlog(procLog)
+	sdtAudit.Getlog(procLog)
+	if err := sdtAudit.Init(rootPath); err != nil {
+		procLog.Error.Printf("[MAIN] Audit log Error: %v\n", err)
+	}
+
+	// Run health endpoint
+	if healthzPort != 0 {
+		go sdtHealthz.RunServer(healthzPort, rootPath, func() bool {
+			return cli != nil && cli.IsConnected()
+		})
+	}
 
 	// Run main
 
 	// Set Service Variable
 	winSvcInfo := winControlService{
-		ServiceName: "DeviceControlService",
+		ServiceName: serviceName,
 	}
 	err = winSvc.Run(winSvcInfo.ServiceName, &winSvcInfo)
 	//RunBody()
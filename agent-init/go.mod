@@ -24,3 +24,7 @@ require (
 	github.com/yusufpapurcu/wmi v1.2.3 // indirect
 	golang.org/x/sys v0.10.0 // indirect
 )
+
+require pkg/configlock v0.0.0
+
+replace pkg/configlock => ../pkg/configlock
@@ -6,37 +6,169 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"runtime"
 	"strconv"
 	"strings"
-	// "runtime"
+	"time"
+
 	"github.com/shirou/gopsutil/v3/host"
 
 	sdtAquaRack "main/src/aquarack"
+	sdtRegister "main/src/register"
+	sdtConfigLock "pkg/configlock"
+)
+
+// buildVersion is the version of agent-init. It is set at build time.
+// (ex. go build -ldflags "-X main.buildVersion=v1.2.3")
+var buildVersion = "dev"
+
+// InitResult is the summary of the registration written to the result file for CI and automation.
+// (ex. cat /etc/sdt/init-result.json | jq .success)
+//   - Success: True if all steps succeeded.
+//   - AssetCode: Serial number of the device.
+//   - OrganizationId: ID of the organization.
+//   - ServiceType: Type of cloud server.
+//   - MqttUrl: MQTT endpoint of the cloud.
+//   - CertDir: Directory of the cert files.
+//   - RegisteredAt: Time the device was registered. (Unix time in ms, 0 if not registered)
+//   - Error: Error message of the failed step. (Empty if succeeded)
+type InitResult struct {
+	Success        bool   `json:"success"`
+	AssetCode      string `json:"assetCode"`
+	OrganizationId string `json:"organizationId"`
+	ServiceType    string `json:"serviceType"`
+	MqttUrl        string `json:"mqttUrl"`
+	CertDir        string `json:"certDir"`
+	RegisteredAt   int64  `json:"registeredAt"`
+	Error          string `json:"error"`
+}
+
+// These are the global variables of the result file. (--result-file)
+var (
+	initResult InitResult
+	resultFile string
 )
 
+// WriteInitResult writes the registration result to the result file with 0640 permissions.
+// The default path is /etc/sdt/init-result.json. (C:/sdt/init-result.json on Windows)
+//
+// Input:
+//   - resultErr: Error of the failed step. nil if all steps succeeded.
+func WriteInitResult(resultErr error) {
+	targetFile := resultFile
+	if targetFile == "" {
+		if runtime.GOOS == "windows" {
+			targetFile = "C:/sdt/init-result.json"
+		} else {
+			targetFile = "/etc/sdt/init-result.json"
+		}
+	}
+
+	initResult.Success = resultErr == nil
+	initResult.Error = ""
+	if resultErr != nil {
+		initResult.Error = resultErr.Error()
+	}
+
+	saveJson, _ := json.MarshalIndent(&initResult, "", "\t")
+	if err := os.WriteFile(targetFile, saveJson, 0640); err != nil {
+		fmt.Printf("[WARN] Cannot write result file %s: %v\n", targetFile, err)
+		return
+	}
+	os.Chmod(targetFile, 0640)
+}
+
+// exitInit writes the result file and exits agent-init with the exit code.
+//
+// Input:
+//   - code: Exit code.
+//   - resultErr: Error of the failed step.
+func exitInit(code int, resultErr error) {
+	WriteInitResult(resultErr)
+	os.Exit(code)
+}
+
 // GetOS function retrieves the operating system (OS) information of the device.
+// If the device is running in a container (Docker, LXC, containerd), containerized is true.
 //
 // Output:
 //   - map[string]interface{}: Structure containing the OS information.
 func GetOS() map[string]interface{} {
 	osInfo, _ := host.Info()
+
+	// Check container
+	containerized := false
+	if osInfo.VirtualizationRole == "guest" {
+		switch osInfo.VirtualizationSystem {
+		case "docker", "lxc", "containerd":
+			containerized = true
+		}
+	}
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		containerized = true
+	}
+
 	osValue := map[string]interface{}{
-		"osType":    osInfo.Platform,
-		"osVersion": osInfo.PlatformVersion,
-		"createdAt": int64(osInfo.BootTime) * 1000,
+		"osType":        osInfo.Platform,
+		"osVersion":     osInfo.PlatformVersion,
+		"createdAt":     int64(osInfo.BootTime) * 1000,
+		"containerized": containerized,
 	}
 
 	return osValue
 }
 
+// vpnPrefixes is the list of interface name prefixes of VPN interfaces.
+// (OpenVPN: tun, tap / WireGuard: wg / Tailscale: tailscale / macOS: utun)
+var vpnPrefixes = []string{"tun", "tap", "wg", "tailscale", "vpn", "utun"}
+
+// IsVpnInterface function checks whether the network interface is an external (VPN) interface.
+// Hamachi and ZeroTier interfaces are matched by name, and the other VPN interfaces are matched
+// by a case-insensitive prefix of the interface name.
+//
+// Input:
+//   - name: Name of the network interface.
+//
+// Output:
+//   - bool: true if the interface is a VPN interface.
+func IsVpnInterface(name string) bool {
+	lowerName := strings.ToLower(name)
+	if strings.Contains(lowerName, "ham") || strings.Contains(lowerName, "ztt") || strings.Contains(lowerName, "zerotier") {
+		return true
+	}
+	for _, prefix := range vpnPrefixes {
+		if strings.HasPrefix(lowerName, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// getIPv4Addr function returns the first IPv4 address of the network interface.
+// VPN interfaces often have only one address, so the address is not selected by index.
+//
+// Input:
+//   - addrs: Addresses of the network interface.
+//
+// Output:
+//   - string: IPv4 address with prefix length. (Empty if not found)
+func getIPv4Addr(addrs []net.Addr) string {
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+			return ipNet.String()
+		}
+	}
+	return ""
+}
+
 // GetNetwork function retrieves the network IP information of the device,
 // distinguishing between internal and external IPs.
 //
@@ -68,16 +200,23 @@ func GetNetwork(systemArch string) (string, string) {
 		if strings.Contains(inter.Name, "docker") {
 			continue
 		}
-		if len(addrs) < 2 {
+		isVpn := IsVpnInterface(inter.Name)
+		if isVpn {
+			net_addrs = getIPv4Addr(addrs)
+			if net_addrs == "" {
+				continue
+			}
+		} else if len(addrs) < 2 {
 			continue
 		} else if len(addrs) == 0 {
 			continue
 		} else if strings.Contains(addrs[ipIndex].String(), ":") {
 			continue
+		} else {
+			net_addrs = addrs[ipIndex].String()
 		}
 
-		net_addrs = addrs[ipIndex].String()
-		if strings.Contains(strings.ToLower(inter.Name), "ham") || strings.Contains(strings.ToLower(inter.Name), "ztt") || strings.Contains(strings.ToLower(inter.Name), "zerotier") {
+		if isVpn {
 			outNet = outNet + fmt.Sprintf("/ %s: %s  ", inter.Name, net_addrs)
 		} else {
 			inNet = inNet + fmt.Sprintf("/ %s: %s  ", inter.Name, net_addrs)
@@ -95,20 +234,104 @@ func GetNetwork(systemArch string) (string, string) {
 
 }
 
-// GetGPU function
+// coralUSBIds are the USB IDs (VID:PID) of Google Coral in lsusb output. The USB Accelerator
+// has 1a6e:089a until its firmware is loaded, and 18d1:9302 after that. Other devices of
+// Google (VID 18d1), such as Android phones, are not TPUs.
+var coralUSBIds = map[string]string{
+	"1a6e:089a": "Coral USB Accelerator",
+	"18d1:9302": "Coral Accelerator",
+}
+
+// coralTPUName function returns the name of Google Coral in a line of lsusb output.
+// (ex. "Bus 002 Device 003: ID 18d1:9302 Google Inc.")
+//
+// Input:
+//   - line: A line of lsusb output.
+//
+// Output:
+//   - string: Name of the Coral accelerator. (Empty string if the device is not Coral)
+func coralTPUName(line string) string {
+	fields := strings.Fields(line)
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "ID" {
+			return coralUSBIds[strings.ToLower(fields[i+1])]
+		}
+	}
+	return ""
+}
+
+// GetTPU function retrieves the TPU/NPU accelerators of the device. Google Coral
+// is found in lsusb output (1a6e:089a, 18d1:9302) and Intel Movidius (NCS2) is found with
+// OpenVINO's benchmark_app if the tool is present.
+//
+// Output:
+//   - []map[string]interface{}: TPU/NPU information.
+func GetTPU() []map[string]interface{} {
+	var tpuInfo []map[string]interface{}
+	tpuIndex := 0
+
+	// Google Coral
+	lsusbOut, err := exec.Command("lsusb").Output()
+	if err != nil {
+		fmt.Printf("[INFO] Not found lsusb cmd.\n%v\n", err)
+	} else {
+		for _, line := range strings.Split(string(lsusbOut), "\n") {
+			tpuName := coralTPUName(line)
+			if tpuName == "" {
+				continue
+			}
+			tpuData := map[string]interface{}{
+				"vendor": "Google",
+				"name":   tpuName,
+				"type":   "tpu",
+				"index":  fmt.Sprintf("%d", tpuIndex),
+			}
+			tpuInfo = append(tpuInfo, tpuData)
+			tpuIndex++
+		}
+	}
+
+	// Intel Movidius (NCS2)
+	if _, err := exec.LookPath("benchmark_app"); err == nil {
+		benchOut, err := exec.Command("benchmark_app", "--list_devices").CombinedOutput()
+		if err != nil {
+			fmt.Printf("[INFO] Failed get device list of benchmark_app.\n%v\n", err)
+		}
+		for _, field := range strings.Fields(string(benchOut)) {
+			if !strings.HasPrefix(field, "MYRIAD") {
+				continue
+			}
+			tpuData := map[string]interface{}{
+				"vendor": "Intel",
+				"name":   fmt.Sprintf("Movidius Neural Compute Stick 2 (%s)", strings.Trim(field, ",[]'")),
+				"type":   "npu",
+				"index":  fmt.Sprintf("%d", tpuIndex),
+			}
+			tpuInfo = append(tpuInfo, tpuData)
+			tpuIndex++
+		}
+	}
+
+	return tpuInfo
+}
+
+// GetGPU function retrieves the NVIDIA GPU and TPU/NPU accelerators of the device.
 //
 // Output:
 //   - []map[string]interface{}: GPU information.
-func GetGPU() []map[string]interface{} {
+//   - []map[string]interface{}: TPU/NPU information.
+func GetGPU() ([]map[string]interface{}, []map[string]interface{}) {
 	var out bytes.Buffer
 	var gpuInfo []map[string]interface{}
 
+	tpuInfo := GetTPU()
+
 	cmd := exec.Command("nvidia-smi", "--query-gpu=index,name,utilization.gpu,memory.total,memory.used,temperature.gpu", "--format=csv,noheader,nounits")
 	cmd.Stdout = &out
 	err := cmd.Run()
 	if err != nil {
 		fmt.Printf("[INFO] This device not have gpu device or not found nvidia-smi cmd.\n%v\n", err)
-		return nil
+		return nil, tpuInfo
 	}
 
 	// Parsing for output
@@ -131,248 +354,91 @@ func GetGPU() []map[string]interface{} {
 		gpuInfo = append(gpuInfo, gpuData)
 	}
 
-	return gpuInfo
+	return gpuInfo, tpuInfo
 }
 
-// RegisterDevice registers the device with the cloud. It calls the cloud's
-// device registration API to perform the registration. This step registers
-// the device with the cloud but does not make it operational or usable by the cloud.
+// GetUSBDevices function retrieves the USB devices connected to the device. On Linux, the
+// devices are found in lsusb output. On Windows, the devices are found in Win32_PnPEntity.
 //
-// Input:
-//   - serialNumber: Serial number of the device.
-//   - OrganizationId: Organization ID to register the device under.
-//   - BwURL: BW API URL of the cloud.
-//   - BwPort: BW API Port of the cloud.
+//	Bus 001 Device 002: ID 046d:c52b Logitech, Inc. Unifying Receiver
+//	-> {"vendorId": "046d", "productId": "c52b", "description": "Logitech, Inc. Unifying Receiver"}
 //
 // Output:
-//   - string: Cloud access key.
-//   - string: Cloud secret key.
-func RegisterDevice(serialNumber string, organizationId string, bwURL string, bwPort int) (string, string) {
-	input := map[string]interface{}{
-		"code": serialNumber,
-	}
-	pbytes, _ := json.Marshal(input)
-	buff := bytes.NewBuffer(pbytes)
-
-	apiUrl := fmt.Sprintf("http://%s:%d/init/assets", bwURL, bwPort)
-	req, err := http.NewRequest("POST", apiUrl, buff)
-	if err != nil {
-		fmt.Printf("[ERROR] Http not connected. : %v\n", err)
-	}
-
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("X-OrganizationId", organizationId)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Printf("[ERROR] Connection to SDTCloud failed.\n")
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
-
-	//request에 대한 응답
-	respBody, err := ioutil.ReadAll(resp.Body)
-	fmt.Println("[INFO]: 1-1. Register equirement: ", resp.Status)
-	statusArr := strings.Split(resp.Status, " ")
-	statusValue, _ := strconv.Atoi(statusArr[0])
-
-	// TODO
-	//  - 에러 코드에 대한 에러메시지 반환 - BlokWorks와 맞춰야 함.
-	if statusValue == 404 {
-		fmt.Printf("[ERROR] %s not found in SDTCloud.\n", serialNumber)
-		fmt.Printf("[ERROR] Please check your serialNumber.\n")
-		os.Exit(1)
-	} else if statusValue == 409 {
-		fmt.Printf("[ERROR] %s already been registered in SDTCloud.\n", serialNumber)
-		fmt.Printf("[ERROR] Please check your serialNumber.\n")
-		os.Exit(1)
-	} else if statusValue < 400 {
-		fmt.Println("[INFO]: Success register.")
-	}
-
-	result := map[string]interface{}{}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		fmt.Printf("[ERROR] This is an incorrect secret key.\b")
-		fmt.Printf("[ERROR] Please contact SDT inc.\n")
-		os.Exit(1)
-	}
-	// fmt.Println(result["model"]["code"])
-	accessKeyId := fmt.Sprintf("%s", result["accessKeyId"])
-	secretAccessKey := fmt.Sprintf("%s", result["secretAccessKey"])
-
-	return accessKeyId, secretAccessKey
-}
-
-// ConnectDevice connects the device to the cloud. This step is part of registering
-// the device with the cloud, not making it operational or usable by the cloud.
-//
-// Input:
-//   - accessKeyId: Cloud access key.
-//   - secretAccessKey: Cloud secret key.
-//   - assetCode: Serial number of the device.
-//   - OrganizationId: Organization ID to register the device under.
-//   - BwURL: BW API URL of the cloud.
-//   - BwPort: BW API Port of the cloud.
-func ConnectDevice(accessKeyId string, secretAccessKey string, assetCode string, organizationId string, bwURL string, bwPort int) {
-	input := map[string]interface{}{
-		"accessKeyId":     accessKeyId,
-		"secretAccessKey": secretAccessKey,
-	}
-	pbytes, _ := json.Marshal(input)
-	buff := bytes.NewBuffer(pbytes)
-
-	apiUrl := fmt.Sprintf("http://%s:%d/init/assets/%s/connection", bwURL, bwPort, assetCode)
-	req, err := http.NewRequest("POST", apiUrl, buff)
-	if err != nil {
-		fmt.Printf("[ERROR] Http not connected. : %v\n", err)
-	}
-
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("X-OrganizationId", organizationId)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Printf("[ERROR] Connection to SDTCloud failed.\n")
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
-
-	//request에 대한 응답
-	// respBody, err := ioutil.ReadAll(resp.Body)
-	fmt.Println("[INFO]: 1-2. Connection equirement: ", resp.Status)
-	statusArr := strings.Split(resp.Status, " ")
-	statusValue, _ := strconv.Atoi(statusArr[0])
-	if statusValue < 400 {
-		fmt.Println("[INFO]: Success connection.")
-	} else {
-		fmt.Printf("[ERROR] The device is not connected.\n")
-		fmt.Printf("[ERROR] Please contact SDT inc.\n")
-		os.Exit(1)
-	}
-}
-
-// fileDownload downloads a file from the given URI to the specified directory with the target filename.
-//
-// Input:
-//   - dir: BWC Root Path.
-//   - targetFile: Name of the file to save.
-//   - fullURLFile: URI value of the file to download.
-func ProvisioningDevice(assetCode string, organizationId string, dir string, bwURL string, bwPort int, serviceType string) {
-	apiUrl := fmt.Sprintf("http://%s:%d/init/assets/%s/provisions", bwURL, bwPort, assetCode)
-	req, err := http.NewRequest("POST", apiUrl, nil)
-	if err != nil {
-		fmt.Printf("[ERROR] Http not connected. : %v\n", err)
-	}
-
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("X-OrganizationId", organizationId)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Printf("[ERROR] Connection to SDTCloud failed.\n")
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
-
-	//request에 대한 응답
-	fmt.Println("[INFO]: 1-3. Provisioning: ", resp.Status)
-	statusArr := strings.Split(resp.Status, " ")
-	statusValue, _ := strconv.Atoi(statusArr[0])
-	if statusValue < 400 {
-		fmt.Println("[INFO]: Success provisioning.")
-	} else {
-		fmt.Printf("[ERROR] The device provisioning failed.\n")
-		fmt.Printf("[ERROR] Please contact SDT inc.\n")
-		os.Exit(1)
-	}
-
-	// response data
-	respBody, err := ioutil.ReadAll(resp.Body)
-	result := map[string]interface{}{}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		panic(err)
-	}
-
-	// Download cert file.
-	var rootcaFile string
-	if serviceType == "onprem" {
-		rootcaFile = "rootCa.pem"
-	} else {
-		rootcaFile = "AmazonRootCA1.pem"
-	}
-	priFile := "no_project-private.pem"
-	certFile := "no_project-certificate.pem"
-
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		os.Mkdir(dir, os.ModePerm)
-	}
-
-	fileDownload(dir, rootcaFile, result["rootCa"].(string))
-	fileDownload(dir, priFile, result["privateKey"].(string))
-	fileDownload(dir, certFile, result["certificate"].(string))
-	//if serviceType != "onprem" {
-	//	fileDownload(dir, rootcaFile, result["rootCa"].(string))
-	//	fileDownload(dir, priFile, result["privateKey"].(string))
-	//	fileDownload(dir, certFile, result["certificate"].(string))
-	//}
-}
-
-// fileDownload downloads a file from the specified URI to the given directory with the target filename.
-//
-// Input:
-//   - dir: The root path of BWC.
-//   - targetFile: The name of the file to save.
-//   - fullURLFile: The URI value of the file to download.
-func fileDownload(dir string, targetFile string, fullURLFile string) {
-
-	fileName := fmt.Sprintf("%s%s", dir, targetFile)
-	file, err := os.Create(fileName)
-	if err != nil {
-		fmt.Println("[ERROR] fileDownload file creation error: ", err)
-		os.Exit(1)
-	}
-
-	// Put content on file
-	client := http.Client{
-		CheckRedirect: func(r *http.Request, via []*http.Request) error {
-			r.URL.Opaque = r.URL.Path
+//   - []map[string]interface{}: USB device information. (nil if the command is not found.)
+func GetUSBDevices() []map[string]interface{} {
+	var usbInfo []map[string]interface{}
+
+	if runtime.GOOS == "windows" {
+		wmicOut, err := exec.Command("wmic", "path", "Win32_PnPEntity", "get", "Name,DeviceID", "/format:csv").Output()
+		if err != nil {
+			fmt.Printf("[INFO] Not found wmic cmd.\n%v\n", err)
 			return nil
-		},
+		}
+		// Node,DeviceID,Name
+		for _, line := range strings.Split(string(wmicOut), "\n") {
+			fields := strings.SplitN(strings.TrimSpace(line), ",", 3)
+			if len(fields) < 3 || !strings.HasPrefix(strings.ToUpper(fields[1]), "USB\\VID_") {
+				continue
+			}
+			// USB\VID_046D&PID_C52B\5&...
+			var vendorId, productId string
+			for _, id := range strings.Split(strings.Split(fields[1], "\\")[1], "&") {
+				if val, ok := strings.CutPrefix(strings.ToUpper(id), "VID_"); ok {
+					vendorId = strings.ToLower(val)
+				} else if val, ok := strings.CutPrefix(strings.ToUpper(id), "PID_"); ok {
+					productId = strings.ToLower(val)
+				}
+			}
+			usbData := map[string]interface{}{
+				"vendorId":    vendorId,
+				"productId":   productId,
+				"description": fields[2],
+			}
+			usbInfo = append(usbInfo, usbData)
+		}
+		return usbInfo
 	}
 
-	resp, err := client.Get(fullURLFile)
+	lsusbOut, err := exec.Command("lsusb").Output()
 	if err != nil {
-		fmt.Println("[ERROR] fileDownload URL get file error: ", err)
-		os.Exit(1)
+		fmt.Printf("[INFO] Not found lsusb cmd.\n%v\n", err)
+		return nil
 	}
-	defer resp.Body.Close()
-
-	if resp.Status[:3] != "200" {
-		fmt.Printf("[ERROR] Download error: %s\n", resp.Status)
-		os.Exit(1)
+	for _, line := range strings.Split(string(lsusbOut), "\n") {
+		_, idStr, found := strings.Cut(line, " ID ")
+		if !found {
+			continue
+		}
+		id, description, _ := strings.Cut(idStr, " ")
+		vendorId, productId, found := strings.Cut(id, ":")
+		if !found {
+			continue
+		}
+		usbData := map[string]interface{}{
+			"vendorId":    vendorId,
+			"productId":   productId,
+			"description": strings.TrimSpace(description),
+		}
+		usbInfo = append(usbInfo, usbData)
 	}
-	io.Copy(file, resp.Body)
-	//fmt.Printf("[INFO]: Downloaded a file %s with size %d\n", fileName, size)
-	fmt.Printf("[INFO] Successfully download cert files.\n")
-
-	defer file.Close()
+	return usbInfo
 }
 
 // SendHwInfo sends hardware information of the device to the cloud. The collected
-// information includes OS and Network details. After this step, the device can be used in the cloud.
+// information includes OS (with container status), Network, GPU and USB device details. After this step, the device can be used in the cloud.
 //
 // Input:
 //   - assetCode: The serial number of the device.
 //   - OrganizationId: The organization ID for registration.
 //   - osInfo: OS information of the device.
+//   - gpuInfo: GPU information of the device.
+//   - tpuInfo: TPU/NPU information of the device.
+//   - usbInfo: USB device information of the device.
 //   - inNet: Internal network IP address.
 //   - OutNet: External network IP address.
 //   - BwURL: BW API URL of the cloud.
 //   - BwPort: BW API Port of the cloud.
-func SendHwInfo(assetCode string, organizationId string, osInfo map[string]interface{}, gpuInfo []map[string]interface{}, inNet string, outNet string, bwURL string, bwPort int) {
+func SendHwInfo(assetCode string, organizationId string, osInfo map[string]interface{}, gpuInfo []map[string]interface{}, tpuInfo []map[string]interface{}, usbInfo []map[string]interface{}, inNet string, outNet string, bwURL string, bwPort int) {
 	input := map[string]interface{}{
 		"os": osInfo,
 		"network": map[string]interface{}{
@@ -380,12 +446,14 @@ func SendHwInfo(assetCode string, organizationId string, osInfo map[string]inter
 			"publicIP":  outNet,
 		},
 		"gpu": gpuInfo,
+		"tpu": tpuInfo,
+		"usb": usbInfo,
 	}
 
 	pbytes, _ := json.Marshal(input)
 	buff := bytes.NewBuffer(pbytes)
 
-	apiUrl := fmt.Sprintf("http://%s:%d/init/assets/%s/hardware", bwURL, bwPort, assetCode)
+	apiUrl := sdtRegister.ApiURL(bwURL, bwPort, fmt.Sprintf("/init/assets/%s/hardware", assetCode))
 	req, err := http.NewRequest("POST", apiUrl, buff)
 	if err != nil {
 		fmt.Printf("[ERROR] Http not connected. : %v\n", err)
@@ -394,16 +462,16 @@ func SendHwInfo(assetCode string, organizationId string, osInfo map[string]inter
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("X-OrganizationId", organizationId)
 
-	client := &http.Client{}
+	client := sdtRegister.ApiClient
 	resp, err := client.Do(req)
 	if err != nil {
 		fmt.Printf("[ERROR] Connection to SDTCloud failed.\n")
-		os.Exit(1)
+		exitInit(1, fmt.Errorf("connection to SDTCloud failed: %v", err))
 	}
 	defer resp.Body.Close()
 
 	//request에 대한 응답
-	_, err = ioutil.ReadAll(resp.Body)
+	_, err = io.ReadAll(resp.Body)
 	fmt.Println("[INFO]: 1-4. Send H/W information: ", resp.Status)
 	statusArr := strings.Split(resp.Status, " ")
 	statusValue, _ := strconv.Atoi(statusArr[0])
@@ -430,7 +498,14 @@ func SetAssetCode(archType string, asset string, organzation string) {
 		targetFile = "/etc/sdt/device.config/config.json"
 	}
 
-	jsonFile, err := ioutil.ReadFile(targetFile)
+	release, err := sdtConfigLock.AcquireConfigLock(targetFile)
+	if err != nil {
+		fmt.Printf("[ERROR](setAssetCode) Can't lock config Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer release()
+
+	jsonFile, err := os.ReadFile(targetFile)
 	if err != nil {
 		fmt.Printf("[ERROR](setAssetCode) Not found file Error: %v\n", err)
 		os.Exit(1)
@@ -448,7 +523,7 @@ func SetAssetCode(archType string, asset string, organzation string) {
 	jsonData["organzation"] = organzation
 
 	saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
-	err = ioutil.WriteFile(targetFile, saveJson, 0644)
+	err = os.WriteFile(targetFile, saveJson, 0644)
 	if err != nil {
 		fmt.Printf("[ERROR](setAssetCode) Not found file Error: %v\n", err)
 		os.Exit(1)
@@ -470,7 +545,14 @@ func SetConfig(archType string, mqttURL string, serviceCode string, serviceType
 		targetFile = "/etc/sdt/device.config/config.json"
 	}
 
-	jsonFile, err := ioutil.ReadFile(targetFile)
+	release, err := sdtConfigLock.AcquireConfigLock(targetFile)
+	if err != nil {
+		fmt.Printf("[ERROR](setConfig) Can't lock config Error: %v\n", err)
+		return "", err
+	}
+	defer release()
+
+	jsonFile, err := os.ReadFile(targetFile)
 
 	if err != nil {
 		fmt.Printf("[ERROR](setConfig) Not found file Error: %v\n", err)
@@ -492,13 +574,203 @@ func SetConfig(archType string, mqttURL string, serviceCode string, serviceType
 	jsonData["serverip"] = serverip
 
 	saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
-	err = ioutil.WriteFile(targetFile, saveJson, 0644)
+	err = os.WriteFile(targetFile, saveJson, 0644)
 	if err != nil {
 		return "", err
 	}
 	return jsonData["devicetype"].(string), err
 }
 
+// SetRegistrationInfo writes the registration time and the version of agent-init to config.json
+// for fleet auditing. The values are written only once, so re-running agent-init doesn't change them.
+//
+// Input:
+//   - archType: Architecture of the device.
+//
+// Output:
+//   - error: Error if config.json can't be read or written.
+func SetRegistrationInfo(archType string) error {
+	var targetFile string
+	if archType == "win" {
+		targetFile = "C:/sdt/device.config/config.json"
+	} else {
+		targetFile = "/etc/sdt/device.config/config.json"
+	}
+
+	release, err := sdtConfigLock.AcquireConfigLock(targetFile)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	jsonFile, err := os.ReadFile(targetFile)
+	if err != nil {
+		return err
+	}
+
+	var jsonData map[string]interface{}
+	jsonRecode := json.NewDecoder(strings.NewReader(string(jsonFile)))
+	jsonRecode.UseNumber()
+	if err = jsonRecode.Decode(&jsonData); err != nil {
+		return err
+	}
+
+	if _, ok := jsonData["registeredAt"]; ok {
+		return nil
+	}
+	jsonData["registeredAt"] = time.Now().UnixMilli()
+	jsonData["agentInitVersion"] = buildVersion
+
+	saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
+	return os.WriteFile(targetFile, saveJson, 0644)
+}
+
+// handleRegistrationError prints the error of the device registration and returns the exit code.
+//
+// Input:
+//   - err: Error returned by RegisterDevice, ConnectDevice or ProvisioningDevice.
+//
+// Output:
+//   - int: Exit code. (2: Serial number not found, 3: Already registered, 4: Unauthorized,
+//     5: Cloud server error, 1: Other errors)
+func handleRegistrationError(err error) int {
+	var regErr *sdtRegister.RegistrationError
+	if !errors.As(err, &regErr) {
+		fmt.Printf("[ERROR] Connection to SDTCloud failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("[ERROR] %s\n", regErr.Message)
+	switch {
+	case errors.Is(err, sdtRegister.ErrNotFound):
+		fmt.Printf("[ERROR] Please check your serialNumber.\n")
+		return 2
+	case errors.Is(err, sdtRegister.ErrAlreadyRegistered):
+		fmt.Printf("[ERROR] Please check your serialNumber.\n")
+		return 3
+	case errors.Is(err, sdtRegister.ErrUnauthorized):
+		fmt.Printf("[ERROR] Please check your organizationId.\n")
+		return 4
+	case errors.Is(err, sdtRegister.ErrServer):
+		fmt.Printf("[ERROR] SDTCloud is not available. Please try again later. (status: %d)\n", regErr.StatusCode)
+		return 5
+	default:
+		fmt.Printf("[ERROR] Please contact SDT inc. (status: %d)\n", regErr.StatusCode)
+		return 1
+	}
+}
+
+// envOrFlag returns the flag value if it was set. If the flag value is the default value,
+// the value of the environment variable is returned instead. This allows agent-init to be
+// configured with environment variables in a container. (ex. Docker, Kubernetes init-container)
+//
+// Input:
+//   - flagVal: Value of the flag.
+//   - envName: Name of the environment variable.
+//   - defaultVal: Default value of the flag.
+//
+// Output:
+//   - string: Flag value, environment variable value or default value.
+func envOrFlag(flagVal string, envName string, defaultVal string) string {
+	if flagVal != defaultVal {
+		return flagVal
+	}
+	if envVal, ok := os.LookupEnv(envName); ok && envVal != "" {
+		return envVal
+	}
+	return defaultVal
+}
+
+// InitConfig is the struct of the config file for the unattended installation. (--config-file)
+//   - OrganizationId: ID of the organization to register.
+//   - AssetCode: Serial number of the device.
+//   - Arch: Architecture of the device.
+//   - ServiceType: Type of cloud server.
+//   - BwIP: Cloud BW IP address. (Only onprem)
+type InitConfig struct {
+	OrganizationId string `json:"organizationId"`
+	AssetCode      string `json:"assetCode"`
+	Arch           string `json:"arch"`
+	ServiceType    string `json:"serviceType"`
+	BwIP           string `json:"bwIP"`
+}
+
+// ReadInitConfig reads the config file for the unattended installation.
+//
+// Input:
+//   - configFile: Path of the config file.
+//
+// Output:
+//   - InitConfig: Parameters in the config file.
+//   - error: Error if the config file can't be read.
+func ReadInitConfig(configFile string) (InitConfig, error) {
+	var initConfig InitConfig
+	jsonFile, err := os.ReadFile(configFile)
+	if err != nil {
+		return initConfig, err
+	}
+	err = json.Unmarshal(jsonFile, &initConfig)
+	return initConfig, err
+}
+
+// fileOrDefault returns the value of the config file if the value is still the default value.
+// So the flag and the environment variable take precedence over the config file.
+//
+// Input:
+//   - val: Value of the flag or the environment variable.
+//   - fileVal: Value of the config file.
+//   - defaultVal: Default value of the flag.
+//
+// Output:
+//   - string: Flag value, environment variable value, config file value or default value.
+func fileOrDefault(val string, fileVal string, defaultVal string) string {
+	if val == defaultVal && fileVal != "" {
+		return fileVal
+	}
+	return val
+}
+
+// ImdsUserData is the JSON userdata blob of cloud-init, read from the IMDS metadata service.
+//   - ServiceType: Type of cloud server.
+//   - BwIP: Cloud BW IP address. (Only onprem)
+type ImdsUserData struct {
+	ServiceType string `json:"sdtServiceType"`
+	BwIP        string `json:"sdtBwIP"`
+}
+
+// ReadImdsUserData reads the cloud-init userdata from the IMDS metadata service.
+// The URL can be changed with the BWC_IMDS_URL environment variable.
+// If the metadata service is not reachable in 500ms or the userdata is not JSON,
+// an error is returned.
+//
+// Output:
+//   - ImdsUserData: sdtServiceType and sdtBwIP in the userdata.
+//   - error: Error if the userdata can't be read.
+func ReadImdsUserData() (ImdsUserData, error) {
+	var userData ImdsUserData
+	imdsURL := "http://169.254.169.254/latest/user-data"
+	if envVal := os.Getenv("BWC_IMDS_URL"); envVal != "" {
+		imdsURL = envVal
+	}
+
+	client := http.Client{Timeout: 500 * time.Millisecond}
+	resp, err := client.Get(imdsURL)
+	if err != nil {
+		return userData, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return userData, fmt.Errorf("IMDS response: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return userData, err
+	}
+	err = json.Unmarshal(body, &userData)
+	return userData, err
+}
+
 // This function takes the server's architecture information as input and configures
 // the environment accordingly, then executes core functions.
 //
@@ -509,14 +781,84 @@ func SetConfig(archType string, mqttURL string, serviceCode string, serviceType
 //   - organization: Organization to register.
 //   - serviceType: Type of cloud server.
 //   - bwIP: Cloud BW IP address.
+//   - tls: Use HTTPS for the BW API. HTTPS is also used if the BW API port is 443.
+//   - cacert: PEM CA certificate to verify the BW API server. (Added to the system CA pool)
+//   - insecure: Skip the verification of the BW API server certificate.
+//
+// Each value can also be set with an environment variable. (BWC_ORG_ID, BWC_ASSET_CODE,
+// BWC_ARCH, BWC_SERVICE_TYPE, BWC_BW_IP) The flag takes precedence over the environment variable.
+// On a cloud instance, serviceType and bwIP are read from the cloud-init userdata of the IMDS
+// metadata service (sdtServiceType, sdtBwIP) if they are not set. Use --no-imds to disable it.
+// For unattended installation, all values can be read from a JSON file with --config-file.
+// The flag and the environment variable take precedence over the userdata and the config file.
+// The result of the registration is written to the JSON file of --result-file, even if a step fails.
 func main() {
 	organizationId := flag.String("oid", "0", "0")
 	assetCode := flag.String("acode", "0", "0")
 	archType := flag.String("arch", "linux", "linux")
 	serviceType := flag.String("type", "", "")
 	bwIP := flag.String("ip", "", "")
+	configFile := flag.String("config-file", "", "Path of the JSON config file for unattended installation.")
+	noImds := flag.Bool("no-imds", false, "Don't read the service type from the IMDS metadata service.")
+	flag.StringVar(&resultFile, "result-file", "", "Path of the JSON result file. (Default: /etc/sdt/init-result.json)")
+	useTLS := flag.Bool("tls", false, "Use HTTPS for the BW API. (Port 80 is changed to 443)")
+	caCert := flag.String("cacert", "", "Path of the PEM CA certificate of the BW API server.")
+	insecure := flag.Bool("insecure", false, "Skip the verification of the BW API server certificate. (For testing only)")
 	flag.Parse()
 
+	// If the flag is not set, use the environment variable.
+	*organizationId = envOrFlag(*organizationId, "BWC_ORG_ID", "0")
+	*assetCode = envOrFlag(*assetCode, "BWC_ASSET_CODE", "0")
+	*archType = envOrFlag(*archType, "BWC_ARCH", "linux")
+	*serviceType = envOrFlag(*serviceType, "BWC_SERVICE_TYPE", "")
+	*bwIP = envOrFlag(*bwIP, "BWC_BW_IP", "")
+
+	// If the service type is not set, use the cloud-init userdata.
+	if !*noImds && (*serviceType == "" || *bwIP == "") {
+		userData, err := ReadImdsUserData()
+		if err != nil {
+			fmt.Printf("[INFO] Skip IMDS userdata: %v\n", err)
+		} else {
+			*serviceType = fileOrDefault(*serviceType, userData.ServiceType, "")
+			*bwIP = fileOrDefault(*bwIP, userData.BwIP, "")
+		}
+	}
+
+	// Unattended installation: use the config file for the values not set.
+	if *configFile != "" {
+		initConfig, err := ReadInitConfig(*configFile)
+		if err != nil {
+			fmt.Printf("[ERROR] Cannot read config file %s: %v\n", *configFile, err)
+			exitInit(1, fmt.Errorf("cannot read config file %s: %v", *configFile, err))
+		}
+		*organizationId = fileOrDefault(*organizationId, initConfig.OrganizationId, "0")
+		*assetCode = fileOrDefault(*assetCode, initConfig.AssetCode, "0")
+		*archType = fileOrDefault(*archType, initConfig.Arch, "linux")
+		*serviceType = fileOrDefault(*serviceType, initConfig.ServiceType, "")
+		*bwIP = fileOrDefault(*bwIP, initConfig.BwIP, "")
+
+		var missingFields []string
+		if *organizationId == "0" || *organizationId == "" {
+			missingFields = append(missingFields, "organizationId")
+		}
+		if *assetCode == "0" || *assetCode == "" {
+			missingFields = append(missingFields, "assetCode")
+		}
+		if *serviceType == "" {
+			missingFields = append(missingFields, "serviceType")
+		}
+		if *serviceType == "onprem" && *bwIP == "" {
+			missingFields = append(missingFields, "bwIP")
+		}
+		if len(missingFields) > 0 {
+			fmt.Printf("[ERROR] Missing fields in config file %s: %s\n", *configFile, strings.Join(missingFields, ", "))
+			exitInit(1, fmt.Errorf("missing fields in config file %s: %s", *configFile, strings.Join(missingFields, ", ")))
+		}
+	}
+	initResult.AssetCode = *assetCode
+	initResult.OrganizationId = *organizationId
+	initResult.ServiceType = *serviceType
+
 	// Set Service Type
 	//var bwURL, mqttURL, serviceCode, codeRepoIp, codeRepoPort, fileUrl string
 	//var bwPort int
@@ -556,11 +898,29 @@ func main() {
 
 	}
 
+	// HTTPS of the BW API. The server certificate is always verified unless --insecure is set.
+	if *useTLS && bwPort == 80 {
+		bwPort = 443
+	}
+	if *useTLS || bwPort == 443 {
+		sdtRegister.ApiScheme = "https"
+	}
+	client, err := sdtRegister.NewApiClient(*caCert, *insecure)
+	if err != nil {
+		fmt.Printf("[ERROR] Cannot load CA certificate %s: %v\n", *caCert, err)
+		exitInit(1, fmt.Errorf("cannot load CA certificate %s: %v", *caCert, err))
+	}
+	sdtRegister.ApiClient = client
+	if *insecure {
+		fmt.Printf("[WARNING] --insecure is set. The BW API server certificate is not verified.\n")
+	}
+
+	initResult.MqttUrl = mqttURL
 	deviceType, _ := SetConfig(*archType, mqttURL, serviceCode, *serviceType, *bwIP)
 
 	if *organizationId == "0" || *assetCode == "0" {
 		fmt.Printf("Please fill in parameter!!\b")
-		os.Exit(0)
+		exitInit(0, fmt.Errorf("organizationId and assetCode are required"))
 	}
 
 	var dir string
@@ -571,18 +931,33 @@ func main() {
 		dir = "/etc/sdt/cert/"
 	}
 
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		os.Mkdir(dir, os.ModePerm)
+	initResult.CertDir = dir
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		fmt.Printf("[ERROR] Cannot create cert directory %s: %v. Try running as root.\n", dir, err)
+		exitInit(1, fmt.Errorf("cannot create cert directory %s: %v", dir, err))
 	}
 
-	accessKeyId, secretAccessKey := RegisterDevice(*assetCode, *organizationId, bwURL, bwPort)
-	ConnectDevice(accessKeyId, secretAccessKey, *assetCode, *organizationId, bwURL, bwPort)
-	ProvisioningDevice(*assetCode, *organizationId, dir, bwURL, bwPort, *serviceType)
+	accessKeyId, secretAccessKey, err := sdtRegister.RegisterDevice(*assetCode, *organizationId, bwURL, bwPort)
+	if err == nil {
+		err = sdtRegister.ConnectDevice(accessKeyId, secretAccessKey, *assetCode, *organizationId, bwURL, bwPort)
+	}
+	if err == nil {
+		err = sdtRegister.ProvisioningDevice(*assetCode, *organizationId, dir, bwURL, bwPort, *serviceType)
+	}
+	if err != nil {
+		exitInit(handleRegistrationError(err), err)
+	}
+	initResult.RegisteredAt = time.Now().UnixMilli()
 	osInfo := GetOS()
 	inNet, outNet := GetNetwork(*archType)
-	gpuInfo := GetGPU()
+	gpuInfo, tpuInfo := GetGPU()
 
-	SendHwInfo(*assetCode, *organizationId, osInfo, gpuInfo, inNet, outNet, bwURL, bwPort)
+	usbInfo := GetUSBDevices()
+	SendHwInfo(*assetCode, *organizationId, osInfo, gpuInfo, tpuInfo, usbInfo, inNet, outNet, bwURL, bwPort)
+
+	if err := SetRegistrationInfo(*archType); err != nil {
+		fmt.Printf("[WARN] Cannot write registration info to config.json: %v\n", err)
+	}
 
 	// 버전 선택
 	fmt.Println(mqttURL, serviceCode, bwPort)
@@ -595,4 +970,15 @@ func main() {
 		fmt.Println("Install aquarack agent...")
 		sdtAquaRack.Deploy(fileUrl, codeRepoIp, codeRepoPort, deviceType)
 	}
+
+	// The health collector sends the first-run message to the cloud and removes this file.
+	firstRunFlag := "/etc/sdt/first-run.flag"
+	if *archType == "win" {
+		firstRunFlag = "C:/sdt/first-run.flag"
+	}
+	if err := os.WriteFile(firstRunFlag, []byte(fmt.Sprintf("%d\n", time.Now().Unix())), 0644); err != nil {
+		fmt.Printf("[WARN] Cannot create %s: %v\n", firstRunFlag, err)
+	}
+
+	WriteInitResult(nil)
 }
@@ -1,6 +1,7 @@
 #/bin/bash
 
-GOOS=linux GOARCH=amd64 go build -o main main.go
+VERSION=$(git describe --tags --always 2>/dev/null || echo dev)
+GOOS=linux GOARCH=amd64 go build -ldflags "-X main.buildVersion=${VERSION}" -o main main.go
 mv main bwc-init
 mv bwc-init ../sdt-cloud-deploy/v4/bwc-installer/bin/amd/
 #cp device-control ../deploy-bwc/device-control/
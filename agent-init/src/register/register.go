@@ -0,0 +1,367 @@
+// The register package registers the device with SDT Cloud through the BW API.
+// The device is registered, connected and provisioned in order, and the cert files
+// of the device are downloaded. A failure status of the API is returned as *RegistrationError,
+// so the caller can decide whether to retry or exit.
+package register
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// These are the global variables of the BW API client.
+//   - ApiClient: HTTP client of the BW API. (Set by NewApiClient with --cacert and --insecure)
+//   - ApiScheme: Scheme of the BW API URL. ("https" with --tls or port 443)
+var (
+	ApiClient = &http.Client{}
+	ApiScheme = "http"
+)
+
+// NewApiClient creates the HTTP client of the BW API. The server certificate is verified
+// with the system CA pool and the CA certificate of caCertPath.
+//
+// Input:
+//   - caCertPath: Path of the PEM CA certificate of the server. (Empty to use only the system CA pool)
+//   - insecure: Skip the verification of the server certificate. (For testing only)
+//
+// Output:
+//   - *http.Client: HTTP client of the BW API.
+//   - error: Error if the CA certificate can't be read or parsed.
+func NewApiClient(caCertPath string, insecure bool) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caCertPath != "" {
+		caCert, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, err
+		}
+		certPool, err := x509.SystemCertPool()
+		if err != nil || certPool == nil {
+			certPool = x509.NewCertPool()
+		}
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no PEM certificate in %s", caCertPath)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+	if insecure {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}, nil
+}
+
+// ApiURL returns the URL of the BW API with the scheme of the client. (ex. https://<bwURL>:443/init/assets)
+func ApiURL(bwURL string, bwPort int, path string) string {
+	return fmt.Sprintf("%s://%s:%d%s", ApiScheme, bwURL, bwPort, path)
+}
+
+// RegistrationError is the error returned when the cloud API responds with a failure status
+// while registering the device.
+//   - StatusCode: HTTP status code of the response. (ex. 404: Serial number not found, 409: Already registered)
+//   - Message: Error message.
+type RegistrationError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error function returns the error message with the HTTP status code.
+func (e *RegistrationError) Error() string {
+	return fmt.Sprintf("[%d] %s", e.StatusCode, e.Message)
+}
+
+// These are the kinds of RegistrationError by the HTTP status code. They can be checked with errors.Is.
+// (ex. errors.Is(err, ErrAlreadyRegistered))
+var (
+	ErrNotFound          = errors.New("serial number not found")
+	ErrAlreadyRegistered = errors.New("device already registered")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrBadRequest        = errors.New("bad request")
+	ErrServer            = errors.New("cloud server error")
+)
+
+// Unwrap function returns the kind of the error by the HTTP status code.
+func (e *RegistrationError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusConflict:
+		return ErrAlreadyRegistered
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode >= 500:
+		return ErrServer
+	default:
+		return ErrBadRequest
+	}
+}
+
+// RegisterDevice registers the device with the cloud. It calls the cloud's
+// device registration API to perform the registration. This step registers
+// the device with the cloud but does not make it operational or usable by the cloud.
+//
+// Input:
+//   - serialNumber: Serial number of the device.
+//   - OrganizationId: Organization ID to register the device under.
+//   - BwURL: BW API URL of the cloud.
+//   - BwPort: BW API Port of the cloud.
+//
+// Output:
+//   - string: Cloud access key.
+//   - string: Cloud secret key.
+//   - error: *RegistrationError if the cloud responds with a failure status, otherwise the error of the request.
+func RegisterDevice(serialNumber string, organizationId string, bwURL string, bwPort int) (string, string, error) {
+	input := map[string]interface{}{
+		"code": serialNumber,
+	}
+	pbytes, _ := json.Marshal(input)
+	buff := bytes.NewBuffer(pbytes)
+
+	apiUrl := ApiURL(bwURL, bwPort, "/init/assets")
+	req, err := http.NewRequest("POST", apiUrl, buff)
+	if err != nil {
+		return "", "", err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-OrganizationId", organizationId)
+
+	client := ApiClient
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	//request에 대한 응답
+	respBody, err := io.ReadAll(resp.Body)
+	fmt.Println("[INFO]: 1-1. Register equirement: ", resp.Status)
+	statusArr := strings.Split(resp.Status, " ")
+	statusValue, _ := strconv.Atoi(statusArr[0])
+
+	// TODO
+	//  - 에러 코드에 대한 에러메시지 반환 - BlokWorks와 맞춰야 함.
+	if statusValue == 404 {
+		return "", "", &RegistrationError{StatusCode: statusValue, Message: fmt.Sprintf("%s not found in SDTCloud.", serialNumber)}
+	} else if statusValue == 409 {
+		return "", "", &RegistrationError{StatusCode: statusValue, Message: fmt.Sprintf("%s already been registered in SDTCloud.", serialNumber)}
+	} else if statusValue < 200 || statusValue >= 300 {
+		return "", "", &RegistrationError{StatusCode: statusValue, Message: fmt.Sprintf("Register failed: %s", respBody)}
+	}
+	fmt.Println("[INFO]: Success register.")
+
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", "", fmt.Errorf("This is an incorrect secret key: %v", err)
+	}
+	// fmt.Println(result["model"]["code"])
+	accessKeyId := fmt.Sprintf("%s", result["accessKeyId"])
+	secretAccessKey := fmt.Sprintf("%s", result["secretAccessKey"])
+
+	return accessKeyId, secretAccessKey, nil
+}
+
+// ConnectDevice connects the device to the cloud. This step is part of registering
+// the device with the cloud, not making it operational or usable by the cloud.
+//
+// Input:
+//   - accessKeyId: Cloud access key.
+//   - secretAccessKey: Cloud secret key.
+//   - assetCode: Serial number of the device.
+//   - OrganizationId: Organization ID to register the device under.
+//   - BwURL: BW API URL of the cloud.
+//   - BwPort: BW API Port of the cloud.
+//
+// Output:
+//   - error: *RegistrationError if the cloud responds with a failure status, otherwise the error of the request.
+func ConnectDevice(accessKeyId string, secretAccessKey string, assetCode string, organizationId string, bwURL string, bwPort int) error {
+	input := map[string]interface{}{
+		"accessKeyId":     accessKeyId,
+		"secretAccessKey": secretAccessKey,
+	}
+	pbytes, _ := json.Marshal(input)
+	buff := bytes.NewBuffer(pbytes)
+
+	apiUrl := ApiURL(bwURL, bwPort, fmt.Sprintf("/init/assets/%s/connection", assetCode))
+	req, err := http.NewRequest("POST", apiUrl, buff)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-OrganizationId", organizationId)
+
+	client := ApiClient
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	//request에 대한 응답
+	// respBody, err := io.ReadAll(resp.Body)
+	fmt.Println("[INFO]: 1-2. Connection equirement: ", resp.Status)
+	statusArr := strings.Split(resp.Status, " ")
+	statusValue, _ := strconv.Atoi(statusArr[0])
+	if statusValue < 200 || statusValue >= 300 {
+		return &RegistrationError{StatusCode: statusValue, Message: "The device is not connected."}
+	}
+	fmt.Println("[INFO]: Success connection.")
+	return nil
+}
+
+// ProvisioningDevice provisions the device in the cloud and downloads the cert files of the device.
+//
+// Input:
+//   - assetCode: Serial number of the device.
+//   - organizationId: Organization ID to register the device under.
+//   - dir: Directory to save the cert files.
+//   - bwURL: BW API URL of the cloud.
+//   - bwPort: BW API Port of the cloud.
+//   - serviceType: Type of cloud server.
+//
+// Output:
+//   - error: *RegistrationError if the cloud responds with a failure status, otherwise the error of the request.
+func ProvisioningDevice(assetCode string, organizationId string, dir string, bwURL string, bwPort int, serviceType string) error {
+	apiUrl := ApiURL(bwURL, bwPort, fmt.Sprintf("/init/assets/%s/provisions", assetCode))
+	req, err := http.NewRequest("POST", apiUrl, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-OrganizationId", organizationId)
+
+	client := ApiClient
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	//request에 대한 응답
+	fmt.Println("[INFO]: 1-3. Provisioning: ", resp.Status)
+	statusArr := strings.Split(resp.Status, " ")
+	statusValue, _ := strconv.Atoi(statusArr[0])
+	if statusValue < 200 || statusValue >= 300 {
+		return &RegistrationError{StatusCode: statusValue, Message: "The device provisioning failed."}
+	}
+	fmt.Println("[INFO]: Success provisioning.")
+
+	// response data
+	respBody, err := io.ReadAll(resp.Body)
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return err
+	}
+
+	// Download cert file.
+	var rootcaFile string
+	if serviceType == "onprem" {
+		rootcaFile = "rootCa.pem"
+	} else {
+		rootcaFile = "AmazonRootCA1.pem"
+	}
+	priFile := "no_project-private.pem"
+	certFile := "no_project-certificate.pem"
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("Cannot create cert directory %s: %v. Try running as root.", dir, err)
+	}
+
+	certFiles := []struct {
+		name string
+		key  string
+	}{
+		{rootcaFile, "rootCa"},
+		{priFile, "privateKey"},
+		{certFile, "certificate"},
+	}
+	for _, cert := range certFiles {
+		certURL, ok := result[cert.key].(string)
+		if !ok {
+			return fmt.Errorf("%s is missing in the provisioning response", cert.key)
+		}
+		if err := fileDownload(dir, cert.name, certURL, true); err != nil {
+			return err
+		}
+	}
+	//if serviceType != "onprem" {
+	//	fileDownload(dir, rootcaFile, result["rootCa"].(string))
+	//	fileDownload(dir, priFile, result["privateKey"].(string))
+	//	fileDownload(dir, certFile, result["certificate"].(string))
+	//}
+	return nil
+}
+
+// fileDownload downloads a file from the specified URI to the given directory with the target filename.
+//
+// Input:
+//   - dir: The root path of BWC.
+//   - targetFile: The name of the file to save.
+//   - fullURLFile: The URI value of the file to download.
+//   - validatePEM: Check that the downloaded file is PEM data. If not, the file is removed.
+//     (ex. The server or CDN returns an HTML error page with 200 OK.)
+//
+// Output:
+//   - error: *RegistrationError if the server responds with a failure status, otherwise the error of the download.
+func fileDownload(dir string, targetFile string, fullURLFile string, validatePEM bool) error {
+
+	fileName := fmt.Sprintf("%s%s", dir, targetFile)
+	file, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("fileDownload file creation error: %v", err)
+	}
+	defer file.Close()
+
+	// Put content on file
+	client := http.Client{
+		Transport: ApiClient.Transport,
+		CheckRedirect: func(r *http.Request, via []*http.Request) error {
+			r.URL.Opaque = r.URL.Path
+			return nil
+		},
+	}
+
+	resp, err := client.Get(fullURLFile)
+	if err != nil {
+		return fmt.Errorf("fileDownload URL get file error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &RegistrationError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("Download %s error: %s", targetFile, resp.Status)}
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		return fmt.Errorf("fileDownload copy error: %v", err)
+	}
+	file.Close()
+
+	if validatePEM {
+		fileContents, err := os.ReadFile(fileName)
+		if err != nil {
+			return fmt.Errorf("cannot read cert file %s: %v", fileName, err)
+		}
+		if block, _ := pem.Decode(fileContents); block == nil {
+			os.Remove(fileName)
+			return fmt.Errorf("downloaded %s is not valid PEM data. Please check the cert URL: %s", targetFile, fullURLFile)
+		}
+	}
+	//fmt.Printf("[INFO]: Downloaded a file %s with size %d\n", fileName, size)
+	fmt.Printf("[INFO] Successfully download cert files.\n")
+	return nil
+}
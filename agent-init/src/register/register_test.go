@@ -0,0 +1,153 @@
+package register
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// newAPIServer starts a mock BW API server and returns its host and port.
+func newAPIServer(t *testing.T, handler http.HandlerFunc) (string, int) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	host, portStr, err := net.SplitHostPort(srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	return host, port
+}
+
+func statusHandler(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"message": "error"}`))
+	}
+}
+
+func TestRegisterDeviceErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr error
+	}{
+		{"not found", http.StatusNotFound, ErrNotFound},
+		{"already registered", http.StatusConflict, ErrAlreadyRegistered},
+		{"unauthorized", http.StatusUnauthorized, ErrUnauthorized},
+		{"bad request", http.StatusBadRequest, ErrBadRequest},
+		{"server error", http.StatusInternalServerError, ErrServer},
+		{"unavailable", http.StatusServiceUnavailable, ErrServer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port := newAPIServer(t, statusHandler(tt.status))
+
+			_, _, err := RegisterDevice("SN-1", "org-1", host, port)
+			var regErr *RegistrationError
+			if !errors.As(err, &regErr) || regErr.StatusCode != tt.status {
+				t.Fatalf("RegisterDevice() = %v, want RegistrationError %d", err, tt.status)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RegisterDevice() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegisterDevice(t *testing.T) {
+	host, port := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/init/assets" || r.Header.Get("X-OrganizationId") != "org-1" {
+			t.Errorf("request = %s %v", r.URL.Path, r.Header)
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["code"] != "SN-1" {
+			t.Errorf("body = %v", body)
+		}
+		w.Write([]byte(`{"accessKeyId": "access", "secretAccessKey": "secret"}`))
+	})
+
+	accessKey, secretKey, err := RegisterDevice("SN-1", "org-1", host, port)
+	if err != nil || accessKey != "access" || secretKey != "secret" {
+		t.Errorf("RegisterDevice() = %q, %q, %v", accessKey, secretKey, err)
+	}
+}
+
+func TestConnectDeviceErrors(t *testing.T) {
+	for _, tt := range []struct {
+		status  int
+		wantErr error
+	}{
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusConflict, ErrAlreadyRegistered},
+		{http.StatusBadGateway, ErrServer},
+	} {
+		host, port := newAPIServer(t, statusHandler(tt.status))
+		err := ConnectDevice("access", "secret", "SN-1", "org-1", host, port)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("ConnectDevice() with %d = %v, want %v", tt.status, err, tt.wantErr)
+		}
+	}
+
+	host, port := newAPIServer(t, statusHandler(http.StatusOK))
+	if err := ConnectDevice("access", "secret", "SN-1", "org-1", host, port); err != nil {
+		t.Errorf("ConnectDevice() = %v", err)
+	}
+}
+
+func TestProvisioningDevice(t *testing.T) {
+	const pemData = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+
+	for _, tt := range []struct {
+		status  int
+		wantErr error
+	}{
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusInternalServerError, ErrServer},
+	} {
+		host, port := newAPIServer(t, statusHandler(tt.status))
+		err := ProvisioningDevice("SN-1", "org-1", t.TempDir()+"/", host, port, "aws")
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("ProvisioningDevice() with %d = %v, want %v", tt.status, err, tt.wantErr)
+		}
+	}
+
+	var baseURL string
+	host, port := newAPIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/init/assets/SN-1/provisions":
+			json.NewEncoder(w).Encode(map[string]string{
+				"rootCa":      baseURL + "/rootCa.pem",
+				"privateKey":  baseURL + "/private.pem",
+				"certificate": baseURL + "/certificate.pem",
+			})
+		case "/certificate.pem":
+			// The CDN returns an error page with 200 OK.
+			w.Write([]byte("<html>error</html>"))
+		default:
+			w.Write([]byte(pemData))
+		}
+	})
+	baseURL = "http://" + net.JoinHostPort(host, strconv.Itoa(port))
+
+	dir := t.TempDir() + "/"
+	if err := ProvisioningDevice("SN-1", "org-1", dir, host, port, "onprem"); err == nil {
+		t.Fatal("ProvisioningDevice() = nil, want invalid PEM error")
+	}
+	for name, want := range map[string]bool{"rootCa.pem": true, "no_project-private.pem": true, "no_project-certificate.pem": false} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if want && string(data) != pemData {
+			t.Errorf("%s = %q, %v", name, data, err)
+		}
+		if !want && !os.IsNotExist(err) {
+			t.Errorf("invalid %s is kept", name)
+		}
+	}
+}
@@ -19,9 +19,10 @@ var (
 )
 
 type managementService struct {
-	MqttType string
-	ArchType string
-	RootPath string
+	MqttType    string
+	ArchType    string
+	RootPath    string
+	ConfigWatch bool
 }
 
 func initError(logFile io.Writer) {
@@ -37,7 +38,7 @@ func (srv *managementService) Execute(args []string, req <-chan winSvc.ChangeReq
 	// 실제 서비스 내용
 	procLog.Info.Printf("[SVC] Service Content!!!\n")
 	stopChan := make(chan bool, 1)
-	go sdtManagement.RunBody(srv.MqttType, srv.ArchType, srv.RootPath)
+	go sdtManagement.RunBody(srv.MqttType, srv.ArchType, srv.RootPath, srv.ConfigWatch)
 
 	stat <- winSvc.Status{State: winSvc.Running, Accepts: winSvc.AcceptStop | winSvc.AcceptShutdown}
 
@@ -68,8 +69,10 @@ LOOP:
 func main() {
 	// Set parameter
 	var mqttType, archType, rootPath string
+	var configWatch bool
 	flag.StringVar(&mqttType, "mqtt", "", "Please input mqtt type(mosq? or aws? or exmq?)")
 	flag.StringVar(&archType, "arch", "", "Please input architecture type(amd? or arm?)")
+	flag.BoolVar(&configWatch, "config-watch", false, "Restart agents when config.json is changed manually")
 	flag.Parse()
 
 	// Set Config PATH
@@ -81,9 +84,10 @@ func main() {
 
 	// Set Service Variable
 	svcInfo := managementService{
-		MqttType: mqttType,
-		ArchType: archType,
-		RootPath: rootPath,
+		MqttType:    mqttType,
+		ArchType:    archType,
+		RootPath:    rootPath,
+		ConfigWatch: configWatch,
 	}
 
 	// Set logger
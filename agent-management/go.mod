@@ -4,12 +4,29 @@ go 1.19
 
 require (
 	github.com/eclipse/paho.mqtt.golang v1.4.3
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/google/uuid v1.6.0
 	golang.org/x/sys v0.15.0
 )
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
 	github.com/gorilla/websocket v1.5.0 // indirect
 	golang.org/x/net v0.8.0 // indirect
 	golang.org/x/sync v0.1.0 // indirect
 )
+
+require pkg/log v0.0.0
+
+replace pkg/log => ../pkg/log
+
+require pkg/mqttutil v0.0.0
+
+replace pkg/mqttutil => ../pkg/mqttutil
+
+require pkg/configlock v0.0.0
+
+replace pkg/configlock => ../pkg/configlock
+
+require pkg/winservice v0.0.0
+
+replace pkg/winservice => ../pkg/winservice
@@ -11,19 +11,12 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"log"
-	"os"
 
 	sdtManagement "main/src/management"
 	sdtType "main/src/managementType"
+	sdtLog "pkg/log"
 )
 
-type winManagementService struct {
-	MqttType string
-	ArchType string
-	RootPath string
-}
-
 // - procLog: This is the Struct that defines the format of the Log.
 var (
 	procLog sdtType.Logger
@@ -35,10 +28,13 @@ var (
 // The way to log the text "Hello World" as an Info log type is shown below.
 // procLog.Info.Printf("Hello World\n")
 // Output: [INFO] Hello World
-func initError(logFile io.Writer) {
-	procLog.Info = log.New(logFile, "[INFO] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Warn = log.New(logFile, "[WARNING] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Error = log.New(logFile, "[ERROR] ", log.Ldate|log.Ltime|log.Lshortfile)
+//
+// Logs below logLevel (loglevel in config.json) are discarded.
+// If logFormat is "json", each log is written as a JSON line. (See pkg/log NewJSONLogger)
+func initError(logFile io.Writer, logLevel string, logFormat string) {
+	procLog.Info = sdtLog.NewLogger(logFile, "info", logLevel, logFormat)
+	procLog.Warn = sdtLog.NewLogger(logFile, "warn", logLevel, logFormat)
+	procLog.Error = sdtLog.NewLogger(logFile, "error", logLevel, logFormat)
 }
 
 // This function receives server architecture information, configures the environment
@@ -50,11 +46,15 @@ func initError(logFile io.Writer) {
 //     -- mosq: Mosquitto
 //   - - exmq: EXMQ
 //   - arch: Architecture of the device.
+//   - config-watch: Restart the BWC Agents when config.json is changed manually.
 func main() {
 	// Set parameter
-	var mqttType, archType, rootPath string
+	var mqttType, archType, rootPath, logFormat string
+	var configWatch bool
 	flag.StringVar(&mqttType, "mqtt", "", "Please input mqtt type(mosq? or aws? or exmq?)")
 	flag.StringVar(&archType, "arch", "", "Please input architecture type(amd? or arm?)")
+	flag.BoolVar(&configWatch, "config-watch", false, "Restart agents when config.json is changed manually")
+	flag.StringVar(&logFormat, "log-format", "text", "Please input log format(text or json)")
 	flag.Parse()
 
 	// Set Config PATH
@@ -66,20 +66,22 @@ func main() {
 
 	// Set Service Variable
 	svcInfo := sdtType.ManagementService{
-		MqttType: mqttType,
-		ArchType: archType,
-		RootPath: rootPath,
+		MqttType:    mqttType,
+		ArchType:    archType,
+		RootPath:    rootPath,
+		ConfigWatch: configWatch,
 	}
 
 	// Set logger
 	logFilePath := fmt.Sprintf("%s/device.logs/bwc-management.log", rootPath)
-	logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY|os.O_SYNC, 0644)
+	logFile, err := sdtLog.NewRotateWriter(logFilePath, sdtLog.GetRotateConfig(fmt.Sprintf("%s/device.config/config.json", rootPath)))
 	if err != nil {
 		panic(err)
 	}
 	defer logFile.Close()
-	initError(logFile)
+	logLevel := sdtLog.GetLevel(fmt.Sprintf("%s/device.config/config.json", rootPath))
+	initError(logFile, logLevel, logFormat)
 
 	sdtManagement.Getlog(procLog)
-	sdtManagement.RunBody(svcInfo.MqttType, svcInfo.ArchType, svcInfo.RootPath)
+	sdtManagement.RunBody(svcInfo.MqttType, svcInfo.ArchType, svcInfo.RootPath, svcInfo.ConfigWatch)
 }
@@ -8,22 +8,29 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	mqttCli "github.com/eclipse/paho.mqtt.golang"
+	"github.com/fsnotify/fsnotify"
 	"github.com/google/uuid"
 
 	sdtType "main/src/managementType"
+	sdtConfigLock "pkg/configlock"
+	sdtMqtt "pkg/mqttutil"
 )
 
 // Global variables used in the BWC Management package:
@@ -36,12 +43,22 @@ import (
 // - systemArch: Architecture of the device.
 // - rootPath: Root path of BWC.
 // - mqType: Type of MQTT service used by BWC.
+// - mqttUrl: MQTT URL currently used by BWC Management.
+// - serverIp: Server IP of SDT Cloud (onprem).
+// - reloadDelay: Debounce time (seconds) before reloading a changed config file.
+// - topicPrefix: Namespace prepended to the MQTT topics.
+// - configWatch: If true, the BWC Agents are restarted when config.json is changed manually. (--config-watch)
+// - configSnapshot: Config values used to find the changed fields of config.json. (Guarded by configMu)
+// - projectChanging: True while the project change command is writing config.json and restarting the agents.
 var (
 	pjCode       string
 	assetCode    string
 	serviceCode  string
 	serviceType  string
 	deviceType   string
+	mqttUrl      string
+	serverIp     string
+	reloadDelay  time.Duration = 2
 	cli          mqttCli.Client
 	mqttUser     = "sdt"
 	mqttPassword = "251327"
@@ -49,6 +66,12 @@ var (
 	systemArch   string
 	rootPath     string
 	mqType       string
+	topicPrefix  string
+
+	configWatch     bool
+	configSnapshot  sdtType.ConfigInfo
+	configMu        sync.Mutex
+	projectChanging atomic.Bool
 )
 
 // Getlog is a function that loads the log format. Log formats are defined as Info,
@@ -60,18 +83,74 @@ func Getlog(logConfig sdtType.Logger) {
 	procLog = logConfig
 }
 
-// 사용안함
-func rollback(err error) {
+// rollback function restores the BWC Config file from the backup taken before the project change
+// and restarts the BWC Agents so that they run with the previous project code again.
+//
+// Input:
+//   - err: Error that caused the rollback.
+//   - backup: Contents of the Config file before the project change.
+//   - dir: The BWC Root Path.
+//
+// Output:
+//   - error: An error message string.
+func rollback(err error, backup []byte, dir string) error {
 	procLog.Warn.Printf("[Rollback] Errors: %v\n", err)
-	// projectChange("")
-	// processRestart()
 
-	// if token := cli.Unsubscribe(newTopic); token.Wait() && token.Error() != nil {
-	// 	procLog.Error.Println("[Project] Error unsubscribing from the current topic:", token.Error())
-	// }
-	// changeSubscription(m.ProjectCode, assetCode)
+	targetFile := fmt.Sprintf("%s/device.config/config.json", dir)
+	release, lerr := sdtConfigLock.AcquireConfigLock(targetFile)
+	if lerr != nil {
+		procLog.Error.Printf("[Rollback] Can't lock config Error: %v\n", lerr)
+		return lerr
+	}
+	werr := os.WriteFile(targetFile, backup, 0644)
+	release()
+	if werr != nil {
+		procLog.Error.Printf("[Rollback] Can't restore config Error: %v\n", werr)
+		return werr
+	}
+	if rerr := ProcessRestart(nil, false); rerr != nil {
+		procLog.Error.Printf("[Rollback] Can't restart agents Error: %v\n", rerr)
+		return rerr
+	}
 
 	procLog.Warn.Printf("[Rollback] Config was rollback.\n")
+	return nil
+}
+
+// getTopic function returns the MQTT topic of the device with the current project code.
+//
+// Input:
+//   - subTopic: Topic after the asset code. (ex. bwc/register-project/request)
+//
+// Output:
+//   - string: MQTT topic.
+func getTopic(subTopic string) string {
+	configData := sdtType.ConfigInfo{
+		ServiceCode: serviceCode,
+		ProjectCode: pjCode,
+		AssetCode:   assetCode,
+		TopicPrefix: topicPrefix,
+	}
+	return configData.Topic(subTopic)
+}
+
+// connectionLostHandler function is called when the connection to the MQTT broker is lost.
+// Instead of exiting the agent, it reconnects with exponential backoff. (See pkg/mqttutil)
+//
+// Input:
+//   - client: MQTT client that lost the connection.
+//   - err: Reason of the connection loss.
+func connectionLostHandler(client mqttCli.Client, err error) {
+	procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
+	sdtMqtt.OnConnectionLost(func() error {
+		token := client.Connect()
+		if token.Wait() && token.Error() != nil {
+			return token.Error()
+		}
+		// Subscriptions are not kept after the connection is lost.
+		ChangeSubscription()
+		return nil
+	}, procLog.Error)
 }
 
 // This function defines options for connecting to the AWS IoT Core MQTT Broker.
@@ -87,7 +166,7 @@ func rollback(err error) {
 //   - *mqttCli.ClientOptions: Variable of type MQTT ClientOptions.
 func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey string) *mqttCli.ClientOptions {
 	// Load CA certificate
-	caCert, err := ioutil.ReadFile(rootCa)
+	caCert, err := os.ReadFile(rootCa)
 	if err != nil {
 		procLog.Error.Printf("Error reading CA certificate file: %v", err)
 	}
@@ -121,10 +200,63 @@ func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey string) *m
 	opts.AddBroker(mqttURL)
 	opts.SetTLSConfig(tlsConfig)
 	opts.SetClientID(fmt.Sprintf("blokworks-client-bwcmanagement-%s", cilentUUID))
-	opts.SetConnectionLostHandler(func(client mqttCli.Client, err error) {
-		procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
-		os.Exit(1)
-	})
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
+
+	return opts
+}
+
+// createExmqClientOptions function defines options for connecting to the EXMQ Broker.
+// The certificates of the EXMQ broker are read from the 'exmq' section of the config file.
+//
+// Input:
+//   - config: Struct storing the Config file saved on the device in JSON format.
+//
+// Output:
+//   - *mqttCli.ClientOptions: Options of the MQTT client.
+func createExmqClientOptions(config sdtType.ConfigInfo) *mqttCli.ClientOptions {
+	tlsConfig := &tls.Config{}
+
+	// Load CA certificate
+	if config.Exmq.RootCa != "" {
+		caCert, err := os.ReadFile(config.Exmq.RootCa)
+		if err != nil {
+			procLog.Error.Printf("Error reading EXMQ CA certificate file: %v", err)
+		}
+		roots := x509.NewCertPool()
+		roots.AppendCertsFromPEM(caCert)
+		tlsConfig.RootCAs = roots
+	}
+
+	// Load client certificate
+	if config.Exmq.Cert != "" && config.Exmq.PrivateKey != "" {
+		clientCert, err := tls.LoadX509KeyPair(config.Exmq.Cert, config.Exmq.PrivateKey)
+		if err != nil {
+			procLog.Error.Printf("Error reading EXMQ client key file: %v", err)
+		} else {
+			tlsConfig.Certificates = []tls.Certificate{clientCert}
+		}
+	}
+
+	mqttURL := config.Exmq.MqttUrl
+	if mqttURL == "" {
+		mqttURL = config.MqttUrl
+	}
+
+	// set uuid
+	newUUID := uuid.New()
+	cilentUUID := newUUID.String()
+
+	opts := mqttCli.NewClientOptions()
+	opts.AddBroker(mqttURL)
+	opts.SetTLSConfig(tlsConfig)
+	if config.Exmq.Username != "" {
+		opts.SetUsername(config.Exmq.Username)
+		opts.SetPassword(config.Exmq.Password)
+	}
+	opts.SetClientID(fmt.Sprintf("blokworks-client-bwcmanagement-%s", cilentUUID))
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
 
 	return opts
 }
@@ -134,22 +266,24 @@ func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey string) *m
 //
 // Input:
 //   - config: Struct storing the Config file saved on the device in JSON format.
+//   - user: User ID used for MQTT connection.
+//   - password: Password used for MQTT connection.
 //
 // Output:
 //   - mqttCli.Client: Variable of type MQTT Client.
 func connectToMqtt(
 	config sdtType.ConfigInfo, // Information of config
+	user string,
+	password string,
 ) mqttCli.Client {
 	procLog.Info.Printf("[MQTT] In connectToMqtt Function")
 	opts := mqttCli.NewClientOptions()
 	opts.AddBroker(config.MqttUrl)
-	opts.SetPassword(mqttPassword)
-	opts.SetUsername(mqttUser)
+	opts.SetPassword(password)
+	opts.SetUsername(user)
 	opts.SetClientID(fmt.Sprintf("blokworks-client-bwc-management-%s", config.AssetCode))
-	opts.SetConnectionLostHandler(func(client mqttCli.Client, err error) {
-		procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
-		os.Exit(1)
-	})
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
 
 	cli = mqttCli.NewClient(opts)
 
@@ -173,7 +307,8 @@ func sendDataEdgeMqtt(
 	pjCode string, // Information of config
 	assetCode string,
 ) {
-	topic := fmt.Sprintf("%s/%s/%s/bwc/register-project/response", serviceCode, pjCode, assetCode)
+	configData := sdtType.ConfigInfo{ServiceCode: serviceCode, ProjectCode: pjCode, AssetCode: assetCode, TopicPrefix: topicPrefix}
+	topic := configData.Topic("bwc/register-project/response")
 
 	resultBody, err := json.Marshal(payload)
 	if err != nil {
@@ -192,13 +327,21 @@ func sendDataEdgeMqtt(
 // Input:
 //   - projectCode: The new project code to set.
 //   - dir: The BWC Root Path.
+//   - dryRun: If true, the change is only logged and the Config file is not written.
 //
 // Output:
 //   - error: An error message string.
-func ProjectChange(projectCode string, dir string) error {
+func ProjectChange(projectCode string, dir string, dryRun bool) error {
 	// yaml read
 	targetFile := fmt.Sprintf("%s/device.config/config.json", dir)
-	jsonFile, err := ioutil.ReadFile(targetFile)
+	release, err := sdtConfigLock.AcquireConfigLock(targetFile)
+	if err != nil {
+		procLog.Error.Printf("[CONFIG] Can't lock config Error: %v\n", err)
+		return err
+	}
+	defer release()
+
+	jsonFile, err := os.ReadFile(targetFile)
 	if err != nil {
 		procLog.Error.Printf("[CONFIG] Not found file Error: %v\n", err)
 		return err
@@ -211,10 +354,14 @@ func ProjectChange(projectCode string, dir string) error {
 		return err
 	}
 
+	if dryRun {
+		procLog.Info.Printf("[DryRun] %s: projectcode %v -> %s\n", targetFile, jsonData["projectcode"], projectCode)
+		return nil
+	}
 	jsonData["projectcode"] = projectCode
 
 	saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
-	err = ioutil.WriteFile(targetFile, saveJson, 0644)
+	err = os.WriteFile(targetFile, saveJson, 0644)
 	if err != nil {
 		procLog.Error.Printf("[CONFIG] Save yaml file Error: %v\n", err)
 		return err
@@ -225,20 +372,64 @@ func ProjectChange(projectCode string, dir string) error {
 
 // ProcessRestart function restarts the BWC Agents. When the project value changes,
 // the BWC Agents need to be restarted to operate with the updated project value.
+// If services is given, only those agents are restarted. If services is empty or has an
+// unknown agent name, all agents are restarted.
+//
+// Input:
+//   - services: Names of the BWC Agents to restart. (ex. device-control)
+//   - dryRun: If true, the agents to restart are only logged.
 //
 // Output:
 //   - error: An error message string.
-func ProcessRestart() error {
-	var svcList []string
+func ProcessRestart(services []string, dryRun bool) error {
+	var agentList []string
 	var cmd_err error
-	if systemArch == "win" {
-		svcList = []string{"SDTCloud DeviceControl", "SDTCloud DeviceHealth", "SDTCloud DeviceHeartbeat", "SDTCloud ProcessChecker"}
-	} else if deviceType == "aquarack" {
-		svcList = []string{"device-control", "device-health", "device-heartbeat", "process-checker", "aquarack-data-collector"}
+	if deviceType == "aquarack" {
+		agentList = []string{"device-control", "device-health", "device-heartbeat", "process-checker", "aquarack-data-collector"}
 	} else {
-		svcList = []string{"device-control", "device-health", "device-heartbeat", "process-checker"}
+		agentList = []string{"device-control", "device-health", "device-heartbeat", "process-checker"}
 	}
 	// aquarack-data-collector
+	winSvcName := map[string]string{
+		"device-control":   "SDTCloud DeviceControl",
+		"device-health":    "SDTCloud DeviceHealth",
+		"device-heartbeat": "SDTCloud DeviceHeartbeat",
+		"process-checker":  "SDTCloud ProcessChecker",
+	}
+
+	if len(services) > 0 {
+		knownList := make(map[string]bool)
+		for _, agent := range agentList {
+			knownList[agent] = true
+		}
+		valid := true
+		for _, svc := range services {
+			if !knownList[svc] || (systemArch == "win" && winSvcName[svc] == "") {
+				procLog.Warn.Printf("[DEPLOY] Unknown service name: %s. All services are restarted.\n", svc)
+				valid = false
+				break
+			}
+		}
+		if valid {
+			agentList = services
+		}
+	}
+
+	var svcList []string
+	if systemArch == "win" {
+		for _, agent := range agentList {
+			if svc, ok := winSvcName[agent]; ok {
+				svcList = append(svcList, svc)
+			}
+		}
+	} else {
+		svcList = agentList
+	}
+
+	if dryRun {
+		procLog.Info.Printf("[DryRun] Restart services: %s\n", strings.Join(svcList, ", "))
+		return nil
+	}
 
 	for _, svc := range svcList {
 		if systemArch == "win" {
@@ -262,29 +453,88 @@ func ProcessRestart() error {
 }
 
 // ProjectCert function downloads a new Cert file when changing the project.
+// When the project is changed to no_project, the Cert file of the previous project is deleted
+// and nothing is downloaded. If the previous project is also no_project, nothing is done.
 //
 // Input:
 //   - projectInfo: Struct containing project code and Cert download information.
 //   - cntProject: Previous project code value.
 //   - dir: BWC Root Path.
+//   - dryRun: If true, the Cert files are downloaded to a temporary directory and only checked that they can be loaded.
 //
 // Output:
 //   - error: String of type Error.
-func ProjectCert(projectInfo sdtType.ProjectControl, cntProject string, dir string) error {
+func ProjectCert(projectInfo sdtType.ProjectControl, cntProject string, dir string, dryRun bool) error {
 	// Onprem pass.
 	//if serviceType == "onprem" {
 	//	return nil
 	//}
+	if dryRun {
+		return checkCert(projectInfo, cntProject)
+	}
 	if projectInfo.ProjectCode == "no_project" {
+		// The device was never assigned to a project, so there is no Cert file to delete.
+		if cntProject == "no_project" {
+			procLog.Info.Printf("[Project] Skip cert: device is not assigned to a project.\n")
+			return nil
+		}
 		DeleteCert(cntProject, dir)
 	} else {
 		priFile := fmt.Sprintf("%s-private.pem", projectInfo.ProjectCode)
 		certFile := fmt.Sprintf("%s-certificate.pem", projectInfo.ProjectCode)
 		procLog.Info.Printf("[Project] Download: %s\n", priFile)
-		fileDownload(dir, priFile, projectInfo.PrivateKey)
+		if err := fileDownload(dir, priFile, projectInfo.PrivateKey); err != nil {
+			return err
+		}
 		procLog.Info.Printf("[Project] Download: %s\n", certFile)
-		fileDownload(dir, certFile, projectInfo.Cert)
+		if err := fileDownload(dir, certFile, projectInfo.Cert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkCert function downloads the Cert files of the project to a temporary directory and checks
+// that they can be loaded as a key pair. The temporary directory is removed after the check.
+//
+// Input:
+//   - projectInfo: Struct containing project code and Cert download information.
+//   - cntProject: Previous project code value.
+//
+// Output:
+//   - error: String of type Error.
+func checkCert(projectInfo sdtType.ProjectControl, cntProject string) error {
+	if projectInfo.ProjectCode == "no_project" {
+		if cntProject == "no_project" {
+			procLog.Info.Printf("[DryRun] Skip cert: device is not assigned to a project.\n")
+			return nil
+		}
+		procLog.Info.Printf("[DryRun] Delete cert: %s-private.pem, %s-certificate.pem\n", cntProject, cntProject)
+		return nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "bwc-dryrun-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := os.MkdirAll(filepath.Join(tmpDir, "cert"), 0755); err != nil {
+		return err
 	}
+
+	priFile := fmt.Sprintf("%s-private.pem", projectInfo.ProjectCode)
+	certFile := fmt.Sprintf("%s-certificate.pem", projectInfo.ProjectCode)
+	if err := fileDownload(tmpDir, priFile, projectInfo.PrivateKey); err != nil {
+		return err
+	}
+	if err := fileDownload(tmpDir, certFile, projectInfo.Cert); err != nil {
+		return err
+	}
+	_, err = tls.LoadX509KeyPair(filepath.Join(tmpDir, "cert", certFile), filepath.Join(tmpDir, "cert", priFile))
+	if err != nil {
+		return fmt.Errorf("invalid cert of %s: %v", projectInfo.ProjectCode, err)
+	}
+	procLog.Info.Printf("[DryRun] Cert of %s is valid.\n", projectInfo.ProjectCode)
 	return nil
 }
 
@@ -318,7 +568,7 @@ func fileDownload(dir string, targetFile string, fullURLFile string) error {
 	fileName := fmt.Sprintf("%s/cert/%s", dir, targetFile)
 	file, err := os.Create(fileName)
 	if err != nil {
-		procLog.Info.Printf("[DEPLOY] fileDownload file creation error: ", err)
+		procLog.Info.Printf("[DEPLOY] fileDownload file creation error: %v\n", err)
 		return err
 	}
 
@@ -330,17 +580,17 @@ func fileDownload(dir string, targetFile string, fullURLFile string) error {
 		},
 	}
 
-	procLog.Info.Printf("[DEBUG] ", fullURLFile)
+	procLog.Info.Printf("[DEBUG] %s\n", fullURLFile)
 	resp, err := client.Get(fullURLFile)
 	if err != nil {
-		procLog.Info.Printf("[DEPLOY] fileDownload URL get file error: ", err)
+		procLog.Info.Printf("[DEPLOY] fileDownload URL get file error: %v\n", err)
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.Status[:3] != "200" {
 		procLog.Info.Printf("Download error: %s\n", resp.Status)
-		return err
+		return fmt.Errorf("download %s failed: %s", targetFile, resp.Status)
 	}
 	size, err := io.Copy(file, resp.Body)
 	procLog.Info.Printf("[INFO]: Downloaded a file %s with size %d\n", fileName, size)
@@ -349,9 +599,52 @@ func fileDownload(dir string, targetFile string, fullURLFile string) error {
 	return err
 }
 
+// currentProjectCode function reads the project code in the BWC Config file.
+//
+// Input:
+//   - dir: BWC Root Path.
+//
+// Output:
+//   - string: Current project code. Empty if the Config file can't be read.
+func currentProjectCode(dir string) string {
+	var configData sdtType.ConfigInfo
+	jsonFile, err := os.ReadFile(fmt.Sprintf("%s/device.config/config.json", dir))
+	if err != nil {
+		procLog.Error.Printf("[Project] Not found file Error: %v\n", err)
+		return ""
+	}
+	if err := json.Unmarshal(jsonFile, &configData); err != nil {
+		procLog.Error.Printf("[Project] Unmarshal Error: %v\n", err)
+		return ""
+	}
+	return configData.ProjectCode
+}
+
+// certExists function checks that the Cert files of the project exist and are not empty.
+//
+// Input:
+//   - projectCode: Project code of the Cert files.
+//   - dir: BWC Root Path.
+//
+// Output:
+//   - bool: True if both the private key and the certificate exist.
+func certExists(projectCode string, dir string) bool {
+	for _, certFile := range []string{
+		fmt.Sprintf("%s/cert/%s-private.pem", dir, projectCode),
+		fmt.Sprintf("%s/cert/%s-certificate.pem", dir, projectCode),
+	} {
+		fileInfo, err := os.Stat(certFile)
+		if err != nil || fileInfo.Size() == 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // SubMessage function is executed when MQTT subscribes to a message. This function handles
 // the control to modify the BWC Config project code and restart the BWC Agent upon receiving
-// a project change message.
+// a project change message. If the project code is already the current one, nothing is changed
+// and the result is sent with the status "already_current".
 //
 // Input:
 //   - client: MQTT Client variable.
@@ -364,7 +657,7 @@ func SubMessage(client mqttCli.Client, msg mqttCli.Message) {
 		dir = "/etc/sdt"
 	}
 
-	cntTopic := fmt.Sprintf("%s/%s/%s/bwc/register-project/request", serviceCode, pjCode, assetCode)
+	cntTopic := getTopic("bwc/register-project/request")
 	procLog.Info.Printf("[Topic] Before Changing, subscription topic to: %s\n", cntTopic)
 
 	var m sdtType.ProjectControl
@@ -372,32 +665,71 @@ func SubMessage(client mqttCli.Client, msg mqttCli.Message) {
 	err := json.Unmarshal(msg.Payload(), &m)
 	if err != nil {
 		procLog.Error.Printf("[MQTT] Unmarshal Error: %v\n", err)
+	} else if m.DryRun {
+		// Validate project change without changing anything.
+		procLog.Info.Printf("[DryRun] Project change: %s -> %s\n", pjCode, m.ProjectCode)
+		pjerr := ProjectChange(m.ProjectCode, dir, true)
+		if pjerr == nil {
+			pjerr = ProjectCert(m, pjCode, dir, true)
+		}
+		if pjerr == nil {
+			pjerr = ProcessRestart(m.RestartServices, true)
+		}
+		if pjerr != nil {
+			procLog.Error.Printf("[DryRun] Project change would fail Error: %v\n", pjerr)
+		}
+
+		resultMsg := checkResult(assetCode, pjerr, pjCode, m.ProjectCode, false)
+		resultMsg["dryRunPassed"] = pjerr == nil
+		sendDataEdgeMqtt(resultMsg, pjCode, assetCode)
+	} else if curProject := currentProjectCode(dir); curProject != "" && curProject == m.ProjectCode {
+		// Same project code (ex. the cloud retries the message). Skip the change and the restart.
+		procLog.Info.Printf("[Project] %s is already the current project.\n", m.ProjectCode)
+		var pjerr error
+		if m.ProjectCode != "no_project" && !certExists(m.ProjectCode, dir) {
+			pjerr = ProjectCert(m, curProject, dir, false)
+		}
+		resultMsg := checkResult(assetCode, pjerr, pjCode, m.ProjectCode, false)
+		resultMsg["status"] = "already_current"
+		resultMsg["projectCode"] = m.ProjectCode
+		sendDataEdgeMqtt(resultMsg, pjCode, assetCode)
 	} else {
+		// config.json is changed by this command, so the config watcher does not restart the agents.
+		projectChanging.Store(true)
+		defer projectChanging.Store(false)
+
+		// Backup config before project change
+		configFile := fmt.Sprintf("%s/device.config/config.json", dir)
+		backup, bkerr := os.ReadFile(configFile)
+		if bkerr != nil {
+			procLog.Error.Printf("[Project] Can't backup config Error: %v\n", bkerr)
+		}
+
 		// checker project 변경
-		pjerr := ProjectChange(m.ProjectCode, dir)
-		if pjerr != nil {
-			procLog.Error.Printf("[Project] Can't change projectcode Error: %v\n", pjerr)
-			// rollback(pjerr, assetCode)
+		pjerr := ProjectChange(m.ProjectCode, dir, false)
+		if pjerr == nil {
+			pjerr = ProjectCert(m, pjCode, dir, false)
 		}
-		pjerr = ProjectCert(m, pjCode, dir)
-		if pjerr != nil {
-			procLog.Error.Printf("[Project] Can't change projectcode Error: %v\n", pjerr)
-			// rollback(pjerr, assetCode)
+		if pjerr == nil {
+			pjerr = ProcessRestart(m.RestartServices, false)
 		}
-		pjerr = ProcessRestart()
+
+		rolledBack := false
 		if pjerr != nil {
 			procLog.Error.Printf("[Project] Can't change projectcode Error: %v\n", pjerr)
-			// rollback(pjerr, assetCode)
+			if bkerr == nil && rollback(pjerr, backup, dir) == nil {
+				rolledBack = true
+			}
 		}
 		// return result
-		resultMsg := checkResult(assetCode, pjerr, pjCode, m.ProjectCode)
+		resultMsg := checkResult(assetCode, pjerr, pjCode, m.ProjectCode, rolledBack)
 		sendDataEdgeMqtt(resultMsg, pjCode, assetCode)
 
 		// change topic
 		// Get project Code
 		var configData sdtType.ConfigInfo
 		jsonFilePath := fmt.Sprintf("%s/device.config/config.json", rootPath)
-		jsonFile, err := ioutil.ReadFile(jsonFilePath)
+		jsonFile, err := os.ReadFile(jsonFilePath)
 		if err != nil {
 			procLog.Error.Printf("[MAIN] Not found file Error: %v\n", err)
 			panic(err)
@@ -410,6 +742,9 @@ func SubMessage(client mqttCli.Client, msg mqttCli.Message) {
 		}
 		//pjCode = m.ProjectCode
 		pjCode = configData.ProjectCode
+		configMu.Lock()
+		configSnapshot.ProjectCode = pjCode
+		configMu.Unlock()
 
 		// disconnect.
 		cli.Disconnect(0)
@@ -442,7 +777,7 @@ func SubMessage(client mqttCli.Client, msg mqttCli.Message) {
 func ChangeSubscription() {
 	// TODO
 	//  - 변경된 Cert 파일로 mqtt client 갱신하도록 수정
-	newTopic := fmt.Sprintf("%s/%s/%s/bwc/register-project/request", serviceCode, pjCode, assetCode)
+	newTopic := getTopic("bwc/register-project/request")
 	procLog.Info.Printf("[Topic] Changing subscription new topic to: %s\n", newTopic)
 
 	token := cli.Subscribe(newTopic, 0, SubMessage)
@@ -459,11 +794,14 @@ func ChangeSubscription() {
 //
 // msg = {"assetCode": "SerialNumber", "status": {"succeed": 0 or 1, "errMsg": string}, "result": {"message": string, "releasedAt": ~~, "updatedAt": ~~}}
 //
+// When the change failed and the config was restored, "rollback", "reason" and "rolledBackTo" are added to the message.
+//
 // Input:
 //   - assetCode: Serial number of the device.
 //   - errData: Error message for control failure.
 //   - priProject: Previous project code before the change.
 //   - curProject: Current project code after the change.
+//   - rolledBack: Whether the config was restored to the previous project code.
 //
 // Output:
 //   - map[string]interface{}: Message to be sent to the cloud.
@@ -474,7 +812,7 @@ func checkResult(
 	// statusCode int, // Status Code
 	priProject string, // Prior Project Code
 	curProject string, // Current Project Code
-
+	rolledBack bool, // Config was restored to Prior Project Code
 ) map[string]interface{} {
 	var result map[string]interface{}
 	var cmdMsg map[string]interface{}
@@ -513,6 +851,12 @@ func checkResult(
 		// "requestId":  requestId,
 	}
 
+	if rolledBack {
+		cmdMsg["rollback"] = true
+		cmdMsg["reason"] = errMessage
+		cmdMsg["rolledBackTo"] = priProject
+	}
+
 	return cmdMsg
 }
 
@@ -523,13 +867,21 @@ func checkResult(
 //   - mqttType: Type of SDTCloud service used by the device.
 //   - archType: Architecture of the device.
 //   - rootPath: Root path for SDTCloud stored on the device.
-func RunBody(mqttType string, archType string, sdtPath string) {
+func RunBody(mqttType string, archType string, sdtPath string, watch bool) {
+	// MQTT credentials can be overridden by environment variables.
+	if user := os.Getenv("MQTT_USER"); user != "" {
+		mqttUser = user
+	}
+	if password := os.Getenv("MQTT_PASSWORD"); password != "" {
+		mqttPassword = password
+	}
+
 	// Set golbal parameter
 	rootPath = sdtPath
 	systemArch = archType
 	var configData sdtType.ConfigInfo
 	jsonFilePath := fmt.Sprintf("%s/device.config/config.json", rootPath)
-	jsonFile, err := ioutil.ReadFile(jsonFilePath)
+	jsonFile, err := os.ReadFile(jsonFilePath)
 	if err != nil {
 		procLog.Error.Printf("[MAIN] Not found file Error: %v\n", err)
 		panic(err)
@@ -544,6 +896,15 @@ func RunBody(mqttType string, archType string, sdtPath string) {
 	// Set deviceType
 	deviceType = configData.DeviceType
 
+	// Set config watch
+	configWatch = watch
+	configMu.Lock()
+	configSnapshot = configData
+	configMu.Unlock()
+	if configWatch {
+		reloadDelay = 3
+	}
+
 	// mqtt Setting key
 	var rootCa string
 	if configData.ServerIp == "onprem" {
@@ -573,8 +934,17 @@ func RunBody(mqttType string, archType string, sdtPath string) {
 	pjCode = configData.ProjectCode
 	assetCode = configData.AssetCode
 	serviceCode = configData.ServiceCode
+	topicPrefix = configData.TopicPrefix
+	mqttUrl = configData.MqttUrl
+	serverIp = configData.ServerIp
 	ChangeSubscription()
 
+	// Watch config file
+	go WatchConfig(jsonFilePath)
+
+	// Check certificate expiry
+	go WatchCertExpiry(configData.CertWarnDays)
+
 	select {
 	case <-stopchan:
 		procLog.Error.Println("[MAIN] Interrupt, exit.")
@@ -582,6 +952,271 @@ func RunBody(mqttType string, archType string, sdtPath string) {
 	}
 }
 
+// Certificate expiry check.
+//   - defaultCertWarnDays: Days before the expiry to log a warning. (certwarndays in config.json)
+//   - certAlertDays: Days before the expiry to publish an alert to bwc/cert/expiry.
+//   - certCheckInterval: Interval of the check.
+const (
+	defaultCertWarnDays = 30
+	certAlertDays       = 7
+	certCheckInterval   = 24 * time.Hour
+)
+
+// CheckCertExpiry function reads the PEM certificate and logs a warning if it expires within warnDays.
+//
+// Input:
+//   - certPath: Path of the PEM certificate.
+//   - warnDays: Days before the expiry to log a warning. (If 0 or less, 30 is used)
+//
+// Output:
+//   - time.Time: Expiry (NotAfter) of the certificate.
+//   - error: Error if the certificate cannot be read or parsed.
+func CheckCertExpiry(certPath string, warnDays int) (time.Time, error) {
+	if warnDays <= 0 {
+		warnDays = defaultCertWarnDays
+	}
+
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return time.Time{}, fmt.Errorf("%s is not a PEM certificate", certPath)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	left := time.Until(cert.NotAfter)
+	if left <= 0 {
+		procLog.Error.Printf("[CERT] %s expired at %s.\n", certPath, cert.NotAfter.Format(time.RFC3339))
+	} else if left < time.Duration(warnDays)*24*time.Hour {
+		procLog.Warn.Printf("[CERT] %s expires in %d days. (%s)\n", certPath, int(left.Hours()/24), cert.NotAfter.Format(time.RFC3339))
+	}
+	return cert.NotAfter, nil
+}
+
+// WatchCertExpiry function checks the certificate of the current project on startup and daily
+// thereafter. If it expires within certAlertDays, an alert is published to the bwc/cert/expiry topic:
+//
+//	Payload = {"timestamp": 1858182312, "data": {"assetCode": ~, "projectCode": ~, "notAfter": ~, "daysLeft": ~}}
+//
+// Input:
+//   - warnDays: Days before the expiry to log a warning.
+func WatchCertExpiry(warnDays int) {
+	ticker := time.NewTicker(certCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		certPath := fmt.Sprintf("%s/cert/%s-certificate.pem", rootPath, pjCode)
+		notAfter, err := CheckCertExpiry(certPath, warnDays)
+		if err != nil {
+			procLog.Error.Printf("[CERT] Check expiry of %s Error: %v\n", certPath, err)
+		} else if left := time.Until(notAfter); left < certAlertDays*24*time.Hour {
+			alert := map[string]interface{}{
+				"timestamp": int64(time.Now().UTC().Unix() * 1000),
+				"data": map[string]interface{}{
+					"assetCode":   assetCode,
+					"projectCode": pjCode,
+					"notAfter":    notAfter.UTC().Format(time.RFC3339),
+					"daysLeft":    int(left.Hours() / 24),
+				},
+			}
+			alertBody, _ := json.Marshal(alert)
+			pubToken := cli.Publish(getTopic("bwc/cert/expiry"), 0, false, alertBody)
+			if pubToken.Wait() && pubToken.Error() != nil {
+				procLog.Error.Printf("[CERT] Publish expiry alert Error: %v\n", pubToken.Error())
+			} else {
+				procLog.Warn.Printf("[CERT] Published expiry alert of %s.\n", certPath)
+			}
+		}
+
+		<-ticker.C
+	}
+}
+
+// WatchConfig function watches the BWC config file and reloads it when it is modified.
+// Events are debounced so that a file still being written is not read.
+//
+// Input:
+//   - jsonFilePath: Path of the BWC config file.
+func WatchConfig(jsonFilePath string) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		procLog.Error.Printf("[CONFIG] Can't create config watcher: %v\n", err)
+		return
+	}
+	defer watcher.Close()
+
+	// Editors often replace the file, so watch the directory and filter by name.
+	err = watcher.Add(filepath.Dir(jsonFilePath))
+	if err != nil {
+		procLog.Error.Printf("[CONFIG] Can't watch config file: %v\n", err)
+		return
+	}
+	procLog.Info.Printf("[CONFIG] Watching config file: %s\n", jsonFilePath)
+
+	var reloadTimer *time.Timer
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(jsonFilePath) {
+				continue
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			if reloadTimer != nil {
+				reloadTimer.Stop()
+			}
+			reloadTimer = time.AfterFunc(reloadDelay*time.Second, func() {
+				configData, err := readConfigFile(jsonFilePath)
+				if err != nil {
+					procLog.Error.Printf("[CONFIG] Reload config Error: %v\n", err)
+					return
+				}
+				ReloadConfig(configData)
+				// Agents are restarted only in --config-watch mode.
+				if configWatch {
+					RestartChangedServices(configData)
+				}
+			})
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			procLog.Error.Printf("[CONFIG] Config watcher Error: %v\n", err)
+		}
+	}
+}
+
+// readConfigFile function reads the BWC config file.
+//
+// Input:
+//   - jsonFilePath: Path of the BWC config file.
+//
+// Output:
+//   - ConfigInfo: BWC config.
+//   - error: Error of reading or unmarshaling the file.
+func readConfigFile(jsonFilePath string) (sdtType.ConfigInfo, error) {
+	var configData sdtType.ConfigInfo
+	jsonFile, err := os.ReadFile(jsonFilePath)
+	if err != nil {
+		return configData, err
+	}
+	err = json.Unmarshal(jsonFile, &configData)
+	return configData, err
+}
+
+// ReloadConfig function applies the changed values of the BWC config file to BWC Management.
+// Values not related to MQTT are updated in memory. The MQTT client is reconnected
+// only when the mqtturl is changed, and the subscription is changed when the
+// servicecode or topicprefix is changed. The other agents are never restarted here.
+// (See RestartChangedServices)
+//
+// Input:
+//   - configData: Changed BWC config.
+func ReloadConfig(configData sdtType.ConfigInfo) {
+	// Update non-MQTT values.
+	if configData.ServerIp != serverIp {
+		procLog.Info.Printf("[CONFIG] serverip changed: %s -> %s\n", serverIp, configData.ServerIp)
+		serverIp = configData.ServerIp
+	}
+	if configData.DeviceType != deviceType {
+		procLog.Info.Printf("[CONFIG] devicetype changed: %s -> %s\n", deviceType, configData.DeviceType)
+		deviceType = configData.DeviceType
+	}
+
+	urlChanged := configData.MqttUrl != mqttUrl
+	serviceChanged := configData.ServiceCode != serviceCode
+	prefixChanged := configData.TopicPrefix != topicPrefix
+	if !urlChanged && !serviceChanged && !prefixChanged {
+		procLog.Info.Printf("[CONFIG] Config reloaded without MQTT changes.\n")
+		return
+	}
+
+	if urlChanged {
+		procLog.Info.Printf("[CONFIG] mqtturl changed: %s -> %s\n", mqttUrl, configData.MqttUrl)
+		cli.Disconnect(0)
+
+		// pem setting
+		var rootCa string
+		if mqType == "onprem" {
+			rootCa = fmt.Sprintf("%s/cert/rootCa.pem", rootPath)
+		} else {
+			rootCa = fmt.Sprintf("%s/cert/AmazonRootCA1.pem", rootPath)
+		}
+		private := fmt.Sprintf("%s/cert/%s-private.pem", rootPath, pjCode)
+		fullCertChain := fmt.Sprintf("%s/cert/%s-certificate.pem", rootPath, pjCode)
+
+		SetMqttClient(mqType, configData, rootCa, fullCertChain, private)
+		mqttUrl = configData.MqttUrl
+		procLog.Info.Printf("[MQTT] Reconnecting mqtt\n")
+	} else {
+		oldTopic := getTopic("bwc/register-project/request")
+		if token := cli.Unsubscribe(oldTopic); token.Wait() && token.Error() != nil {
+			procLog.Error.Printf("[CONFIG] Error unsubscribing from the current topic: %v\n", token.Error())
+		}
+	}
+
+	if serviceChanged {
+		procLog.Info.Printf("[CONFIG] servicecode changed: %s -> %s\n", serviceCode, configData.ServiceCode)
+		serviceCode = configData.ServiceCode
+	}
+	if prefixChanged {
+		procLog.Info.Printf("[CONFIG] topicprefix changed: %s -> %s\n", topicPrefix, configData.TopicPrefix)
+		topicPrefix = configData.TopicPrefix
+	}
+	ChangeSubscription()
+}
+
+// RestartChangedServices function compares the changed config with the previous config and
+// restarts only the BWC Agents that use the changed fields. It is called only in --config-watch
+// mode, after ReloadConfig has applied the config to BWC Management itself.
+//   - mqtturl, projectcode: All agents except BWC Management are restarted.
+//   - serverip: device-health and device-heartbeat are restarted.
+//   - assetcode, organzation: Only a warning is logged. (The device must be registered again.)
+//
+// Input:
+//   - configData: Changed BWC config.
+func RestartChangedServices(configData sdtType.ConfigInfo) {
+	if projectChanging.Load() {
+		procLog.Info.Printf("[CONFIG] Config changed by project change command. Skip restart.\n")
+		return
+	}
+	configMu.Lock()
+	prevConfig := configSnapshot
+	configSnapshot = configData
+	configMu.Unlock()
+
+	if prevConfig.AssetCode != configData.AssetCode {
+		procLog.Warn.Printf("[CONFIG] assetcode changed manually: %s -> %s. Agents are not restarted.\n", prevConfig.AssetCode, configData.AssetCode)
+	}
+	if prevConfig.Organzation != configData.Organzation {
+		procLog.Warn.Printf("[CONFIG] organzation changed manually: %s -> %s. Agents are not restarted.\n", prevConfig.Organzation, configData.Organzation)
+	}
+
+	var services []string
+	if prevConfig.MqttUrl != configData.MqttUrl || prevConfig.ProjectCode != configData.ProjectCode {
+		// nil restarts all agents.
+		services = nil
+	} else if prevConfig.ServerIp != configData.ServerIp {
+		services = []string{"device-health", "device-heartbeat"}
+	} else {
+		return
+	}
+
+	procLog.Info.Printf("[CONFIG] Restart agents for changed config: %v\n", services)
+	if err := ProcessRestart(services, false); err != nil {
+		procLog.Error.Printf("[CONFIG] Restart agents Error: %v\n", err)
+	}
+}
+
 // SetMqttClient selects an MQTT broker based on the SDTCloud service type of the device and publishes messages accordingly.
 //
 // Input:
@@ -593,11 +1228,15 @@ func RunBody(mqttType string, archType string, sdtPath string) {
 func SetMqttClient(mqttType string, configData sdtType.ConfigInfo, rootCa string, fullCertChain string, private string) {
 	if mqttType == "onprem" {
 		// Set mqtt client - EC2
-		cli = connectToMqtt(configData)
+		cli = connectToMqtt(configData, mqttUser, mqttPassword)
 	} else if mqttType == "aws-dev" || mqttType == "eks" || mqttType == "dev" {
 		// Set MQTT - AWS IoT Core
 		opts := createAwsClientOptions(configData.MqttUrl, rootCa, fullCertChain, private)
 		cli = mqttCli.NewClient(opts)
+	} else if mqttType == "exmq" {
+		// Set MQTT - EXMQ
+		opts := createExmqClientOptions(configData)
+		cli = mqttCli.NewClient(opts)
 	} else {
 		err := errors.New("Please input mqtt variable.")
 		procLog.Error.Printf("[MQTT] MQTT connection Error: %v\n", err)
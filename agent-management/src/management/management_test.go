@@ -0,0 +1,87 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	sdtType "main/src/managementType"
+	sdtLog "pkg/log"
+)
+
+// listFiles returns the files under dir.
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestProjectCertNoProject(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("pem"))
+	}))
+	defer srv.Close()
+	projectInfo := sdtType.ProjectControl{
+		ProjectCode: "no_project",
+		PrivateKey:  srv.URL + "/private.pem",
+		Cert:        srv.URL + "/certificate.pem",
+	}
+
+	for _, dryRun := range []bool{false, true} {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, "cert"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		before := listFiles(t, dir)
+
+		if err := ProjectCert(projectInfo, "no_project", dir, dryRun); err != nil {
+			t.Fatalf("ProjectCert(dryRun=%v) = %v", dryRun, err)
+		}
+		after := listFiles(t, dir)
+		if len(after) != len(before) {
+			t.Errorf("dryRun=%v: files changed from %v to %v", dryRun, before, after)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("cert is downloaded %d times for no_project", requests)
+	}
+}
+
+func TestProjectCertToNoProject(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	dir := t.TempDir()
+	certDir := filepath.Join(dir, "cert")
+	if err := os.MkdirAll(certDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"p1-private.pem", "p1-certificate.pem", "root-CA.pem"} {
+		if err := os.WriteFile(filepath.Join(certDir, name), []byte("pem"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ProjectCert(sdtType.ProjectControl{ProjectCode: "no_project"}, "p1", dir, false); err != nil {
+		t.Fatalf("ProjectCert() = %v", err)
+	}
+	entries, _ := os.ReadDir(certDir)
+	if len(entries) != 1 || entries[0].Name() != "root-CA.pem" {
+		t.Errorf("cert dir = %v, want only root-CA.pem", entries)
+	}
+}
@@ -2,6 +2,7 @@
 package managementType
 
 import (
+	"fmt"
 	"log"
 )
 
@@ -9,14 +10,56 @@ import (
 //   - AssetCode: Device serial number.
 //   - MqttUrl: MQTT URL of SDT Cloud.
 //   - ProjectCode: ID of the project to which the device belongs.
+//   - Organzation: ID of the organization to which the device belongs.
 //   - ServiceCode: Service code of SDT Cloud.
+//   - TopicPrefix: Namespace prepended to the MQTT topics. (Empty by default)
+//   - Exmq: EXMQ broker information. (Only used if the mqtt type is exmq)
+//   - CertWarnDays: Days before the certificate expiry to log a warning. (30 if not set)
 type ConfigInfo struct {
-	AssetCode   string `json:"assetcode"`
-	MqttUrl     string `json:"mqtturl"`
-	ProjectCode string `json:"projectcode"`
-	ServiceCode string `json:"servicecode"`
-	ServerIp    string `json:"serverip"`
-	DeviceType  string `json:"devicetype"`
+	AssetCode    string   `json:"assetcode"`
+	MqttUrl      string   `json:"mqtturl"`
+	ProjectCode  string   `json:"projectcode"`
+	Organzation  string   `json:"organzation"`
+	ServiceCode  string   `json:"servicecode"`
+	ServerIp     string   `json:"serverip"`
+	DeviceType   string   `json:"devicetype"`
+	TopicPrefix  string   `json:"topicprefix,omitempty"`
+	Exmq         ExmqInfo `json:"exmq"`
+	CertWarnDays int      `json:"certwarndays,omitempty"`
+}
+
+// ExmqInfo struct defines the EXMQ broker information under the 'exmq' section of the config file.
+//   - MqttUrl: MQTT URL of the EXMQ broker. If empty, mqtturl of the config is used.
+//   - RootCa: Path to the Root CA file of the EXMQ broker.
+//   - Cert: Path to the client certificate file.
+//   - PrivateKey: Path to the private key file of the client certificate.
+//   - Username: Username of the EXMQ broker. (Optional)
+//   - Password: Password of the EXMQ broker. (Optional)
+type ExmqInfo struct {
+	MqttUrl    string `json:"mqtturl,omitempty"`
+	RootCa     string `json:"rootca"`
+	Cert       string `json:"cert"`
+	PrivateKey string `json:"privatekey"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+}
+
+// Topic function returns the MQTT topic of the device. If TopicPrefix is set, it is prepended
+// to the topic so that multiple BWC deployments can share one MQTT broker.
+//
+//	<topicPrefix>/<serviceCode>/<projectCode>/<assetCode>/<subTopic>
+//
+// Input:
+//   - subTopic: Topic after the asset code. (ex. bwc/health)
+//
+// Output:
+//   - string: MQTT topic.
+func (c ConfigInfo) Topic(subTopic string) string {
+	topic := fmt.Sprintf("%s/%s/%s/%s", c.ServiceCode, c.ProjectCode, c.AssetCode, subTopic)
+	if c.TopicPrefix != "" {
+		topic = fmt.Sprintf("%s/%s", c.TopicPrefix, topic)
+	}
+	return topic
 }
 
 // Struct defining the format of logs.
@@ -34,19 +77,25 @@ type Logger struct {
 //   - RootCA: File path of the Root CA for the project.
 //   - PrivateKey: File path of the Private Key for the project.
 //   - Cert: File path of the Certificate for the project.
+//   - RestartServices: BWC Agents to restart. (ex. device-control) If empty, all agents are restarted.
+//   - DryRun: If true, the project change is only validated. No files are modified and no agents are restarted.
 type ProjectControl struct {
-	ProjectCode string `json: "projectCode"`
-	RootCA      string `json: "rootCA"`
-	PrivateKey  string `json: "privateKey"`
-	Cert        string `json: "cert"`
+	ProjectCode     string   `json: "projectCode"`
+	RootCA          string   `json: "rootCA"`
+	PrivateKey      string   `json: "privateKey"`
+	Cert            string   `json: "cert"`
+	RestartServices []string `json:"restartServices,omitempty"`
+	DryRun          bool     `json:"dryRun,omitempty"`
 }
 
 // Struct defining the environment information of the BWC-Management agent.
 //   - MqttType: MQTT service type used by the agent.
 //   - ArchType: Architecture type of the device.
 //   - RootPath: Root path of the BWC.
+//   - ConfigWatch: Option to restart the BWC Agents when config.json is changed manually.
 type ManagementService struct {
-	MqttType string
-	ArchType string
-	RootPath string
+	MqttType    string
+	ArchType    string
+	RootPath    string
+	ConfigWatch bool
 }
@@ -11,7 +11,6 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"time"
 
@@ -19,12 +18,23 @@ import (
 
 	sdtManagement "main/src/management"
 	sdtType "main/src/managementType"
+	sdtLog "pkg/log"
+	sdtWinService "pkg/winservice"
 )
 
+// Name and description of the Windows service of the agent. (-service install|uninstall|start|stop)
+const (
+	serviceName        = "BWCManagementService"
+	serviceDescription = "BWC agent that manages the BWC agents of the device."
+)
+
+// winManagementService is the svc.Handler of BWCManagementService. It holds the flags of the
+// agent, which are the start parameters registered by -service install.
 type winManagementService struct {
-	MqttType string
-	ArchType string
-	RootPath string
+	MqttType    string
+	ArchType    string
+	RootPath    string
+	ConfigWatch bool
 }
 
 // - procLog: This is the Struct that defines the format of the Log.
@@ -38,10 +48,13 @@ var (
 // The way to log the text "Hello World" as an Info log type is shown below.
 // procLog.Info.Printf("Hello World\n")
 // Output: [INFO] Hello World
-func initError(logFile io.Writer) {
-	procLog.Info = log.New(logFile, "[INFO] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Warn = log.New(logFile, "[WARNING] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Error = log.New(logFile, "[ERROR] ", log.Ldate|log.Ltime|log.Lshortfile)
+//
+// Logs below logLevel (loglevel in config.json) are discarded.
+// If logFormat is "json", each log is written as a JSON line. (See pkg/log NewJSONLogger)
+func initError(logFile io.Writer, logLevel string, logFormat string) {
+	procLog.Info = sdtLog.NewLogger(logFile, "info", logLevel, logFormat)
+	procLog.Warn = sdtLog.NewLogger(logFile, "warn", logLevel, logFormat)
+	procLog.Error = sdtLog.NewLogger(logFile, "error", logLevel, logFormat)
 }
 
 // This function receives server architecture information, configures the environment
@@ -53,13 +66,29 @@ func initError(logFile io.Writer) {
 //     -- mosq: Mosquitto
 //   - - exmq: EXMQ
 //   - arch: Architecture of the device.
+//   - config-watch: Restart the BWC Agents when config.json is changed manually.
 func main() {
 	// Set parameter
-	var mqttType, archType, rootPath string
+	var mqttType, archType, rootPath, logFormat, serviceAction string
+	var configWatch bool
 	flag.StringVar(&mqttType, "mqtt", "", "Please input mqtt type(mosq? or aws? or exmq?)")
 	flag.StringVar(&archType, "arch", "", "Please input architecture type(amd? or arm?)")
+	flag.BoolVar(&configWatch, "config-watch", false, "Restart agents when config.json is changed manually")
+	flag.StringVar(&logFormat, "log-format", "text", "Please input log format(text or json)")
+	flag.StringVar(&serviceAction, "service", "", "Please input windows service action(install, uninstall, start or stop)")
 	flag.Parse()
 
+	// Manage the Windows service of the agent and exit.
+	if serviceAction != "" {
+		err := sdtWinService.Control(serviceAction, serviceName, serviceDescription, os.Args[1:])
+		if err != nil {
+			fmt.Printf("Failed %s %s: %v\n", serviceAction, serviceName, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Successfully %s %s.\n", serviceAction, serviceName)
+		return
+	}
+
 	// Set Config PATH
 	if archType == "win" {
 		rootPath = "C:/sdt"
@@ -76,22 +105,24 @@ func main() {
 
 	// Set logger
 	logFilePath := fmt.Sprintf("%s/device.logs/bwc-management.log", rootPath)
-	logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY|os.O_SYNC, 0644)
+	logFile, err := sdtLog.NewRotateWriter(logFilePath, sdtLog.GetRotateConfig(fmt.Sprintf("%s/device.config/config.json", rootPath)))
 	if err != nil {
 		panic(err)
 	}
 	defer logFile.Close()
-	initError(logFile)
+	logLevel := sdtLog.GetLevel(fmt.Sprintf("%s/device.config/config.json", rootPath))
+	initError(logFile, logLevel, logFormat)
 
 	sdtManagement.Getlog(procLog)
 
 	// Set Service Variable
 	winSvcInfo := winManagementService{
-		MqttType: mqttType,
-		ArchType: archType,
-		RootPath: rootPath,
+		MqttType:    mqttType,
+		ArchType:    archType,
+		RootPath:    rootPath,
+		ConfigWatch: configWatch,
 	}
-	err = winSvc.Run("BWCManagementService", &winSvcInfo)
+	err = winSvc.Run(serviceName, &winSvcInfo)
 	if err != nil {
 		procLog.Error.Printf("cannot start service: %v\n", err)
 	}
@@ -105,7 +136,7 @@ func (srv *winManagementService) Execute(args []string, req <-chan winSvc.Change
 	// 실제 서비스 내용
 	procLog.Info.Printf("[SVC] Service Content!!!\n")
 	stopChan := make(chan bool, 1)
-	go sdtManagement.RunBody(srv.MqttType, srv.ArchType, srv.RootPath)
+	go sdtManagement.RunBody(srv.MqttType, srv.ArchType, srv.RootPath, srv.ConfigWatch)
 
 	stat <- winSvc.Status{State: winSvc.Running, Accepts: winSvc.AcceptStop | winSvc.AcceptShutdown}
 
@@ -11,14 +11,11 @@ require (
 require (
 	github.com/Microsoft/go-winio v0.4.14 // indirect
 	github.com/docker/distribution v2.8.2+incompatible // indirect
-	github.com/docker/docker v24.0.5+incompatible // indirect
 	github.com/docker/go-connections v0.5.0 // indirect
 	github.com/docker/go-units v0.5.0 // indirect
 	github.com/go-ole/go-ole v1.2.6 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
-	github.com/google/uuid v1.6.0 // indirect
 	github.com/gorilla/websocket v1.5.0 // indirect
-	github.com/leizongmin/fuser v0.0.0-20230202135413-76cb1ec1b521 // indirect
 	github.com/lufia/plan9stats v0.0.0-20211012122336-39d0f177ccd0 // indirect
 	github.com/moby/docker-image-spec v1.3.1 // indirect
 	github.com/opencontainers/go-digest v1.0.0 // indirect
@@ -31,5 +28,22 @@ require (
 	github.com/yusufpapurcu/wmi v1.2.3 // indirect
 	golang.org/x/net v0.8.0 // indirect
 	golang.org/x/sync v0.1.0 // indirect
-	golang.org/x/sys v0.27.0 // indirect
 )
+
+require pkg/log v0.0.0
+
+replace pkg/log => ../pkg/log
+
+require pkg/mqttutil v0.0.0
+
+replace pkg/mqttutil => ../pkg/mqttutil
+
+require (
+	github.com/docker/docker v24.0.5+incompatible
+	github.com/google/uuid v1.6.0
+	github.com/leizongmin/fuser v0.0.0-20230202135413-76cb1ec1b521
+	golang.org/x/sys v0.27.0
+	pkg/winservice v0.0.0
+)
+
+replace pkg/winservice => ../pkg/winservice
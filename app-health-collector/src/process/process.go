@@ -10,12 +10,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
+	"math"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -28,6 +29,7 @@ import (
 	"github.com/shirou/gopsutil/v3/process"
 
 	sdtType "main/src/processType"
+	sdtMqtt "pkg/mqttutil"
 )
 
 // Global variables used in the process package.
@@ -36,6 +38,7 @@ import (
 // - mqttUser: User ID used for MQTT connection.
 // - mqttPassword: Password used for MQTT connection.
 // - procLog: Struct defining the format of logs.
+// - prevNetStats: Previous network statistics of containers. (containerID -> networkStats)
 var (
 	cli          mqttCli.Client
 	mqttUser     = "sdt"
@@ -43,8 +46,19 @@ var (
 	//configPath                 = "/etc/sdt/device.config/config.json"
 	procLog      sdtType.Logger
 	dockerClient *dockerCli.Client
+	prevNetStats sync.Map
 )
 
+// networkStats defines the network statistics of a container at a point in time.
+//   - rxBytes: Total received bytes of all networks of the container.
+//   - txBytes: Total transmitted bytes of all networks of the container.
+//   - readAt: Time the statistics were read.
+type networkStats struct {
+	rxBytes uint64
+	txBytes uint64
+	readAt  time.Time
+}
+
 // Getlog is a function that loads the log format. Log formats are defined as Info,
 // Warn, Error, and are output using Printf.
 // Here's how you would record the text "Hello World" as an Info log type:
@@ -74,6 +88,21 @@ func CalculateCPUPercent(stats *types.StatsJSON) float64 {
 	return 0.0
 }
 
+// connectionLostHandler function is called when the connection to the MQTT broker is lost.
+// Instead of exiting the agent, it reconnects with exponential backoff. (See pkg/mqttutil)
+//
+// Input:
+//   - client: MQTT client that lost the connection.
+//   - err: Reason of the connection loss.
+func connectionLostHandler(client mqttCli.Client, err error) {
+	procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
+	sdtMqtt.OnConnectionLost(func() error {
+		token := client.Connect()
+		token.Wait()
+		return token.Error()
+	}, procLog.Error)
+}
+
 // This function defines options for connecting to the AWS IoT Core MQTT Broker.
 // It specifies options such as TLS, MQTT URI, client name, and handlers.
 //
@@ -87,7 +116,7 @@ func CalculateCPUPercent(stats *types.StatsJSON) float64 {
 //   - *mqttCli.ClientOptions: Variable of type MQTT ClientOptions.
 func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey string) *mqttCli.ClientOptions {
 	// Load CA certificate
-	caCert, err := ioutil.ReadFile(rootCa)
+	caCert, err := os.ReadFile(rootCa)
 	if err != nil {
 		log.Fatalf("Error reading CA certificate file: %v", err)
 	}
@@ -121,10 +150,63 @@ func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey string) *m
 	opts.AddBroker(mqttURL)
 	opts.SetTLSConfig(tlsConfig)
 	opts.SetClientID(fmt.Sprintf("blokworks-client-processchecker-%s", cilentUUID))
-	opts.SetConnectionLostHandler(func(client mqttCli.Client, err error) {
-		procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
-		os.Exit(1)
-	})
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
+
+	return opts
+}
+
+// createExmqClientOptions function defines options for connecting to the EXMQ Broker.
+// The certificates of the EXMQ broker are read from the 'exmq' section of the config file.
+//
+// Input:
+//   - config: Struct storing the Config file saved on the device in JSON format.
+//
+// Output:
+//   - *mqttCli.ClientOptions: Options of the MQTT client.
+func createExmqClientOptions(config sdtType.ConfigInfo) *mqttCli.ClientOptions {
+	tlsConfig := &tls.Config{}
+
+	// Load CA certificate
+	if config.Exmq.RootCa != "" {
+		caCert, err := os.ReadFile(config.Exmq.RootCa)
+		if err != nil {
+			procLog.Error.Printf("Error reading EXMQ CA certificate file: %v", err)
+		}
+		roots := x509.NewCertPool()
+		roots.AppendCertsFromPEM(caCert)
+		tlsConfig.RootCAs = roots
+	}
+
+	// Load client certificate
+	if config.Exmq.Cert != "" && config.Exmq.PrivateKey != "" {
+		clientCert, err := tls.LoadX509KeyPair(config.Exmq.Cert, config.Exmq.PrivateKey)
+		if err != nil {
+			procLog.Error.Printf("Error reading EXMQ client key file: %v", err)
+		} else {
+			tlsConfig.Certificates = []tls.Certificate{clientCert}
+		}
+	}
+
+	mqttURL := config.Exmq.MqttUrl
+	if mqttURL == "" {
+		mqttURL = config.MqttUrl
+	}
+
+	// set uuid
+	newUUID := uuid.New()
+	cilentUUID := newUUID.String()
+
+	opts := mqttCli.NewClientOptions()
+	opts.AddBroker(mqttURL)
+	opts.SetTLSConfig(tlsConfig)
+	if config.Exmq.Username != "" {
+		opts.SetUsername(config.Exmq.Username)
+		opts.SetPassword(config.Exmq.Password)
+	}
+	opts.SetClientID(fmt.Sprintf("blokworks-client-processchecker-%s", cilentUUID))
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
 
 	return opts
 }
@@ -140,7 +222,29 @@ func sendDataEdgeMqtt(
 ) {
 	// topic := fmt.Sprintf("/test", configData.AssetCode)
 	// topic := fmt.Sprintf("$aws/things/sdt-cloud-development/shadow/name/device-control/%s/app-health", configData.AssetCode)
-	topic := fmt.Sprintf("%s/%s/%s/bwc/apps/health", configData.ServiceCode, configData.ProjectCode, configData.AssetCode)
+	topic := configData.Topic("bwc/apps/health")
+
+	resultBody, err := json.Marshal(payload)
+	if err != nil {
+		procLog.Error.Printf("[MQTT] Unmarshal error: %v\n", err)
+	}
+	pub_token := cli.Publish(topic, 0, false, resultBody)
+
+	if pub_token.Wait() && pub_token.Error() != nil {
+		procLog.Error.Printf("[MQTT] Error: %v\n", pub_token.Error())
+	}
+}
+
+// sendEventMqtt function publishes an app event message (e.g. oom_kill) to the MQTT Broker.
+//
+// Input:
+//   - payload: Event message to publish.
+//   - config: Struct storing the Config file saved on the device in JSON format.
+func sendEventMqtt(
+	payload map[string]interface{}, // Event of app
+	configData sdtType.ConfigInfo,
+) {
+	topic := configData.Topic("bwc/apps/events")
 
 	resultBody, err := json.Marshal(payload)
 	if err != nil {
@@ -158,22 +262,24 @@ func sendDataEdgeMqtt(
 //
 // Input:
 //   - config: Struct storing the Config file saved on the device in JSON format.
+//   - user: User ID used for MQTT connection.
+//   - password: Password used for MQTT connection.
 //
 // Output:
 //   - mqttCli.Client: Variable of type MQTT Client.
 func connectToMqtt(
 	config sdtType.ConfigInfo, // Information of config
+	user string,
+	password string,
 ) mqttCli.Client {
 	procLog.Info.Printf("[MQTT] In connectToMqtt Function")
 	opts := mqttCli.NewClientOptions()
 	opts.AddBroker(config.MqttUrl)
-	opts.SetPassword(mqttPassword)
-	opts.SetUsername(mqttUser)
+	opts.SetPassword(password)
+	opts.SetUsername(user)
 	opts.SetClientID(fmt.Sprintf("blokworks-client-process-checker-%s", config.AssetCode))
-	opts.SetConnectionLostHandler(func(client mqttCli.Client, err error) {
-		procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
-		os.Exit(1)
-	})
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
 
 	cli = mqttCli.NewClient(opts)
 
@@ -315,6 +421,122 @@ func GetPid(appName string) []int {
 	return pidArr
 }
 
+// GetUptime function collects the uptime of an app deployed on a linux device. The uptime
+// is calculated from the start time of the app's service. (ExecMainStartTimestamp of systemd)
+//
+// Input:
+//   - appName: Name of the application whose uptime is to be collected.
+//
+// Output:
+//   - int64: Uptime of the app in seconds. (-1 if not found)
+func GetUptime(appName string) int64 {
+	getStart := fmt.Sprintf("systemctl show --property ExecMainStartTimestamp %s.service", appName)
+	cmd_run := exec.Command("sh", "-c", getStart)
+	stdout, err := cmd_run.Output()
+	if err != nil {
+		procLog.Error.Printf("[PROCESS-CHECKER] Get start time error: %v\n", err)
+		return -1
+	}
+	startStr := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(stdout)), "ExecMainStartTimestamp="))
+	if startStr == "" {
+		return -1
+	}
+
+	// ex) Mon 2024-01-15 10:23:45 KST
+	startTime, err := time.ParseInLocation("Mon 2006-01-02 15:04:05 MST", startStr, time.Local)
+	if err != nil {
+		startTime, err = time.Parse(time.RFC1123Z, startStr)
+		if err != nil {
+			procLog.Error.Printf("[PROCESS-CHECKER] Parse start time error: %v\n", err)
+			return -1
+		}
+	}
+	return int64(time.Since(startTime).Seconds())
+}
+
+// WinGetUptime function collects the uptime of an app deployed on a windows device. The uptime
+// is calculated from the creation time of the app's process.
+//
+// Input:
+//   - pid: PID of the app.
+//
+// Output:
+//   - int64: Uptime of the app in seconds. (-1 if not found)
+func WinGetUptime(pid int) int64 {
+	if pid <= 0 {
+		return -1
+	}
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		procLog.Error.Printf("[PROCESS-CHECKER] Not found process(%d): %v\n", pid, err)
+		return -1
+	}
+	createTime, err := p.CreateTime()
+	if err != nil {
+		procLog.Error.Printf("[PROCESS-CHECKER] Get create time error: %v\n", err)
+		return -1
+	}
+	return int64(time.Since(time.UnixMilli(createTime)).Seconds())
+}
+
+// GetOomKill function checks whether an app deployed on a linux device was killed by the OOM killer.
+// The last 100 OOM entries of the kernel log (dmesg, or /var/log/kern.log if dmesg fails) are checked,
+// and an entry matches if it contains the app's service name or its last PID.
+//
+// Input:
+//   - appName: Name of the application.
+//   - lastPid: Last PID of the application. (-1 if unknown)
+//
+// Output:
+//   - bool: true if the app was killed by the OOM killer.
+//   - int64: Time the app was killed. (Unix time in ms)
+func GetOomKill(appName string, lastPid int) (bool, int64) {
+	var timeLayout string
+	cmd_run := exec.Command("sh", "-c", "dmesg --time-format iso | grep -i -E 'oom|killed process' | tail -n 100")
+	stdout, err := cmd_run.Output()
+	if err == nil && len(stdout) > 0 {
+		// ex) 2024-01-15T10:23:45,123456+09:00 Out of memory: Killed process 1234 (python3) ...
+		timeLayout = "2006-01-02T15:04:05.999999-07:00"
+	} else {
+		cmd_run = exec.Command("sh", "-c", "grep -i -E 'oom|killed process' /var/log/kern.log | tail -n 100")
+		stdout, err = cmd_run.Output()
+		if err != nil {
+			return false, 0
+		}
+		// ex) Jan 15 10:23:45 hostname kernel: [12345.678] Out of memory: Killed process 1234 (python3) ...
+		timeLayout = "Jan _2 15:04:05"
+	}
+
+	service := fmt.Sprintf("%s.service", appName)
+	lines := strings.Split(strings.TrimSpace(string(stdout)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		matched := strings.Contains(line, service)
+		if !matched && lastPid > 0 {
+			matched = strings.Contains(line, fmt.Sprintf("pid=%d,", lastPid)) ||
+				strings.Contains(line, fmt.Sprintf("Killed process %d ", lastPid))
+		}
+		if !matched {
+			continue
+		}
+
+		var killTime time.Time
+		if strings.HasPrefix(timeLayout, "2006") {
+			fields := strings.Fields(line)
+			killTime, err = time.Parse(timeLayout, strings.Replace(fields[0], ",", ".", 1))
+		} else if len(line) >= len(timeLayout) {
+			killTime, err = time.ParseInLocation(timeLayout, line[:len(timeLayout)], time.Local)
+			killTime = killTime.AddDate(time.Now().Year(), 0, 0)
+		}
+		if err != nil || killTime.IsZero() {
+			procLog.Warn.Printf("[PROCESS-CHECKER] Parse OOM time error: %s\n", line)
+			return true, 0
+		}
+		return true, killTime.UnixMilli()
+	}
+	return false, 0
+}
+
 // GetPort function collects the port information used by an app deployed on Linux ARM32 devices.
 // The collected information includes:
 //   - Port number
@@ -358,13 +580,16 @@ func GetPort(pid int) string {
 // Output:
 //   - int: The usage of CPU(%).
 //   - int: The usage of Memory(%).
-func GetProcDockerd(appName string, appId string) (int, int) {
+//   - float64: Received network traffic(Kbps). -1 if the container has no network.
+//   - float64: Transmitted network traffic(Kbps). -1 if the container has no network.
+//   - map[string]interface{}: Exit information if the container has exited. (exitCode, exitedAt) Otherwise nil.
+func GetProcDockerd(appName string, appId string) (int, int, float64, float64, map[string]interface{}) {
 	targetContainer := fmt.Sprintf("%s-%s", appName, appId)
 	ctx := context.Background()
 	containers, err := dockerClient.ContainerList(ctx, types.ContainerListOptions{All: true})
 	if err != nil {
 		procLog.Error.Printf("Dockerclient error: %v\n", err)
-		return -1, -1
+		return -1, -1, -1, -1, nil
 	}
 
 	for _, container := range containers {
@@ -374,13 +599,14 @@ func GetProcDockerd(appName string, appId string) (int, int) {
 
 			// Check container state(Running? or Exited?)
 			if container.State == "exited" {
-				return -1, -1
+				prevNetStats.Delete(container.ID)
+				return -1, -1, -1, -1, GetDockerdExit(ctx, container.ID)
 			}
 
 			stats, err := dockerClient.ContainerStats(ctx, container.ID, false)
 			if err != nil {
 				procLog.Error.Printf("Error getting container stats: %v\n", err)
-				return -1, -1
+				return -1, -1, -1, -1, nil
 			}
 			defer stats.Body.Close()
 
@@ -388,35 +614,136 @@ func GetProcDockerd(appName string, appId string) (int, int) {
 			err = json.NewDecoder(stats.Body).Decode(&containerStats)
 			if err != nil {
 				procLog.Error.Printf("Error decoding stats: %v\n", err)
-				return -1, -1
+				return -1, -1, -1, -1, nil
 			}
 
 			cpuUsage := CalculateCPUPercent(&containerStats)
 			memoryUsage := float64(containerStats.MemoryStats.Usage) / (1024 * 1024) // MB
 			memoryLimit := float64(containerStats.MemoryStats.Limit) / (1024 * 1024) // MB
 			memoryPercent := (memoryUsage / memoryLimit) * 100.0
+			rxKbps, txKbps := CalculateNetworkKbps(container.ID, &containerStats)
 
-			return int(cpuUsage), int(memoryPercent)
+			return int(cpuUsage), int(memoryPercent), rxKbps, txKbps, nil
 		}
 	}
 
 	procLog.Error.Printf("Not found container[%s]\n", targetContainer)
-	return -1, -1
+	return -1, -1, -1, -1, nil
+}
+
+// CalculateNetworkKbps function calculates the network traffic of container.
+// The calculation is based on the difference in received and transmitted bytes between
+// the current and previous points in time. The first call for a container returns 0.
+//
+// Input:
+//   - containerId: ID of the container.
+//   - stats: Statistics of the container.
+//
+// Output:
+//   - float64: Received network traffic(Kbps). -1 if the container has no network.
+//   - float64: Transmitted network traffic(Kbps). -1 if the container has no network.
+func CalculateNetworkKbps(containerId string, stats *types.StatsJSON) (float64, float64) {
+	if stats.Networks == nil {
+		return -1, -1
+	}
+
+	curStats := networkStats{readAt: stats.Read}
+	for _, network := range stats.Networks {
+		curStats.rxBytes += network.RxBytes
+		curStats.txBytes += network.TxBytes
+	}
+
+	value, ok := prevNetStats.Load(containerId)
+	prevNetStats.Store(containerId, curStats)
+	if !ok {
+		return 0, 0
+	}
+	prev := value.(networkStats)
+	elapsed := curStats.readAt.Sub(prev.readAt).Seconds()
+	// Counters are reset when the container restarts.
+	if elapsed <= 0 || curStats.rxBytes < prev.rxBytes || curStats.txBytes < prev.txBytes {
+		return 0, 0
+	}
+
+	rxKbps := float64(curStats.rxBytes-prev.rxBytes) * 8 / 1000 / elapsed
+	txKbps := float64(curStats.txBytes-prev.txBytes) * 8 / 1000 / elapsed
+	return math.Round(rxKbps*100) / 100, math.Round(txKbps*100) / 100
+}
+
+// deduplicateAppInfo function removes the entries of app.json that have the same app name.
+// The most recently deployed entry (the last one in the list) is kept. Duplicates can be
+// left by a failed rollback.
+//
+// Input:
+//   - appInfoList: List of app's info in app.json.
+//
+// Output:
+//   - []sdtType.AppInfo: List of app's info without duplicates.
+func deduplicateAppInfo(appInfoList []sdtType.AppInfo) []sdtType.AppInfo {
+	lastIndex := make(map[string]int)
+	for i, val := range appInfoList {
+		lastIndex[val.AppName] = i
+	}
+
+	var dedupList []sdtType.AppInfo
+	for i, val := range appInfoList {
+		if lastIndex[val.AppName] != i {
+			procLog.Warn.Printf("[PROCESS-CHECKER] Duplicate app %s: skip stale appId %s.\n", val.AppName, val.AppId)
+			continue
+		}
+		dedupList = append(dedupList, val)
+	}
+	return dedupList
+}
+
+// GetDockerdExit function inspects an exited container and returns its exit code and exit time.
+// The exit code tells why the container stopped. (ex. 0: clean shutdown, 1: app crash, 137: OOM kill)
+//
+// Input:
+//   - ctx: Context of the docker client.
+//   - containerId: ID of the exited container.
+//
+// Output:
+//   - map[string]interface{}: {"exitCode": exit code, "exitedAt": exit time (Unix time in ms)}
+func GetDockerdExit(ctx context.Context, containerId string) map[string]interface{} {
+	inspect, err := dockerClient.ContainerInspect(ctx, containerId)
+	if err != nil || inspect.ContainerJSONBase == nil || inspect.State == nil {
+		procLog.Error.Printf("Error inspecting container: %v\n", err)
+		return nil
+	}
+
+	var exitedAt int64
+	finishedAt, err := time.Parse(time.RFC3339Nano, inspect.State.FinishedAt)
+	if err == nil {
+		exitedAt = finishedAt.UnixMilli()
+	}
+	return map[string]interface{}{
+		"exitCode": inspect.State.ExitCode,
+		"exitedAt": exitedAt,
+	}
 }
 
 // Main function of the process package. Selects MQTT broker based on the device's SDTCloud
 // service type and publishes messages. The payload is defined as follows:
 //
-// Payload = {"assetCode": SerialNumber, "data": {"appName": ~~, "appId": ~~, "pid": ~, "cpu": ~~, "memory": ~~}, "venvName": ~~}
+// Payload = {"assetCode": SerialNumber, "data": {"appName": ~~, "appId": ~~, "pid": ~, "cpu": ~~, "memory": ~~, "uptimeSec": ~~}, "venvName": ~~}
 //
 // Input:
 //   - mqttType: SDTCloud service type used by the device.
 //   - archType: Architecture of the device.
 //   - rootPath: Root path of SDTCloud stored on the device.
 func RunBody(mqttType string, archType string, rootPath string, appPath string) {
+	// MQTT credentials can be overridden by environment variables.
+	if user := os.Getenv("MQTT_USER"); user != "" {
+		mqttUser = user
+	}
+	if password := os.Getenv("MQTT_PASSWORD"); password != "" {
+		mqttPassword = password
+	}
+
 	jsonFilePath := fmt.Sprintf("%s/device.config/config.json", rootPath)
-	jsonFile, err := ioutil.ReadFile(jsonFilePath)
-	// yamlFile, err := ioutil.ReadFile("./config.yaml")
+	jsonFile, err := os.ReadFile(jsonFilePath)
+	// yamlFile, err := os.ReadFile("./config.yaml")
 	if err != nil {
 		procLog.Error.Printf("[PROCESS-CHECKER] Not found file Error: %v\n", err)
 	}
@@ -440,11 +767,15 @@ func RunBody(mqttType string, archType string, rootPath string, appPath string)
 	// Set Mqtt
 	if mqttType == "onprem" {
 		// Set mqtt client - EC2
-		cli = connectToMqtt(configData)
+		cli = connectToMqtt(configData, mqttUser, mqttPassword)
 	} else if mqttType == "aws-dev" || mqttType == "eks" || mqttType == "dev" {
 		// Set MQTT - AWS IoT Core
 		opts := createAwsClientOptions(configData.MqttUrl, rootCa, fullCertChain, private)
 		cli = mqttCli.NewClient(opts)
+	} else if mqttType == "exmq" {
+		// Set MQTT - EXMQ
+		opts := createExmqClientOptions(configData)
+		cli = mqttCli.NewClient(opts)
 	} else {
 		err = errors.New("Please input mqtt variable.")
 		procLog.Error.Printf("[MAIN] Docker connection Error: %v\n", err)
@@ -468,9 +799,14 @@ func RunBody(mqttType string, archType string, rootPath string, appPath string)
 	// Send app health
 	var pid []int
 	var mainPid, cpu, mem int
+	var uptimeSec int64
 	var envList []string
 	var result map[string]interface{}
 	var appHealth []map[string]interface{}
+	lastPids := map[string]int{}
+	oomEvents := map[string]int64{}
+	exitEvents := map[string]int64{}
+	missingDirs := map[string]bool{}
 
 	// Setting 5 sec [Delay time]
 	for {
@@ -490,9 +826,9 @@ func RunBody(mqttType string, archType string, rootPath string, appPath string)
 		procLog.Info.Printf("%v: Get data.\n", curTime)
 
 		// get app info - change dir -> json
-		//dirs, err := ioutil.ReadDir(appPath)
+		//dirs, err := os.ReadDir(appPath)
 		appInfoFile := fmt.Sprintf("%s/device.config/app.json", rootPath)
-		jsonFile, err = ioutil.ReadFile(appInfoFile)
+		jsonFile, err = os.ReadFile(appInfoFile)
 		if err != nil {
 			procLog.Warn.Printf("Failed load app's info: %v\n", err)
 			procLog.Warn.Printf("The app has never been deployed in device.\n")
@@ -508,15 +844,40 @@ func RunBody(mqttType string, archType string, rootPath string, appPath string)
 		// Init variable.
 		appHealth = make([]map[string]interface{}, 0)
 		envList = make([]string, 0)
+		skipped := 0
+
+		for _, appInfo := range deduplicateAppInfo(jsonData.AppInfoList) {
+			// Skip the app whose directory was removed without cleaning up app.json.
+			// Container apps don't have an app directory.
+			appDir := fmt.Sprintf("%s/%s_%s", appPath, appInfo.AppName, appInfo.AppId)
+			if appInfo.Managed != "dockerd" {
+				if _, err := os.Stat(appDir); os.IsNotExist(err) {
+					if !missingDirs[appDir] {
+						missingDirs[appDir] = true
+						procLog.Warn.Printf("[PROCESS-CHECKER] App directory not found, skip %s: %s\n", appInfo.AppName, appDir)
+					}
+					appHealth = append(appHealth, map[string]interface{}{
+						"appName": appInfo.AppName,
+						"appId":   appInfo.AppId,
+						"pid":     -2,
+						"status":  "directory_missing",
+					})
+					skipped++
+					continue
+				}
+				delete(missingDirs, appDir)
+			}
 
-		for _, appInfo := range jsonData.AppInfoList {
 			// check -> systemd and dockerd
 			cpu = -1
 			mem = -1
 			mainPid = 0
+			uptimeSec = -1
 
+			var exitInfo map[string]interface{}
+			var netRxKbps, netTxKbps float64 = -1, -1
 			if appInfo.Managed == "dockerd" {
-				cpu, mem = GetProcDockerd(appInfo.AppName, appInfo.AppId)
+				cpu, mem, netRxKbps, netTxKbps, exitInfo = GetProcDockerd(appInfo.AppName, appInfo.AppId)
 			} else { // systemd
 				if archType == "win" {
 					pid = WinGetPid(appInfo.AppName)
@@ -529,6 +890,12 @@ func RunBody(mqttType string, archType string, rootPath string, appPath string)
 				} else {
 					mainPid = pid[0]
 				}
+
+				if archType == "win" {
+					uptimeSec = WinGetUptime(mainPid)
+				} else if mainPid != -1 {
+					uptimeSec = GetUptime(appInfo.AppName)
+				}
 			}
 
 			if mainPid == 0 {
@@ -538,18 +905,61 @@ func RunBody(mqttType string, archType string, rootPath string, appPath string)
 			// for nodeq!!!
 			// portName := getPort(mainPid)
 			healthData := map[string]interface{}{
-				"appName": appInfo.AppName,
-				"appId":   appInfo.AppId,
-				"pid":     mainPid,
-				"cpu":     cpu,
-				"memory":  mem,
+				"appName":   appInfo.AppName,
+				"appId":     appInfo.AppId,
+				"pid":       mainPid,
+				"cpu":       cpu,
+				"memory":    mem,
+				"uptimeSec": uptimeSec,
+				"netRxKbps": netRxKbps,
+				"netTxKbps": netTxKbps,
 				// "portName": portName,
 			}
+
+			// Check OOM kill if the app is gone.
+			appKey := fmt.Sprintf("%s_%s", appInfo.AppName, appInfo.AppId)
+			if appInfo.Managed != "dockerd" && archType != "win" {
+				if mainPid != -1 {
+					lastPids[appKey] = mainPid
+				} else if oomKilled, oomTimestamp := GetOomKill(appInfo.AppName, lastPids[appKey]); oomKilled {
+					healthData["oomKilled"] = true
+					healthData["oomTimestamp"] = oomTimestamp
+					if oomEvents[appKey] != oomTimestamp {
+						oomEvents[appKey] = oomTimestamp
+						procLog.Warn.Printf("[PROCESS-CHECKER] %s was killed by OOM killer.\n", appInfo.AppName)
+						sendEventMqtt(map[string]interface{}{
+							"event":     "oom_kill",
+							"appName":   appInfo.AppName,
+							"timestamp": oomTimestamp,
+						}, configData)
+					}
+				}
+			}
+
+			// Check exit code if the container has exited.
+			if exitInfo != nil {
+				healthData["exitCode"] = exitInfo["exitCode"]
+				healthData["exitedAt"] = exitInfo["exitedAt"]
+				if exitInfo["exitCode"] != 0 && exitEvents[appKey] != exitInfo["exitedAt"] {
+					exitEvents[appKey] = exitInfo["exitedAt"].(int64)
+					procLog.Warn.Printf("[PROCESS-CHECKER] %s container exited with code %v.\n", appInfo.AppName, exitInfo["exitCode"])
+					sendEventMqtt(map[string]interface{}{
+						"event":     "container_exit",
+						"appName":   appInfo.AppName,
+						"appId":     appInfo.AppId,
+						"exitCode":  exitInfo["exitCode"],
+						"timestamp": exitInfo["exitedAt"],
+					}, configData)
+				}
+			}
 			appHealth = append(appHealth, healthData)
 		}
+		if skipped > 0 {
+			procLog.Info.Printf("[PROCESS-CHECKER] Skipped %d app(s) without app directory.\n", skipped)
+		}
 
 		// Get env list
-		envDir, _ := ioutil.ReadDir(fmt.Sprintf("%s/venv", rootPath))
+		envDir, _ := os.ReadDir(fmt.Sprintf("%s/venv", rootPath))
 
 		for _, f := range envDir {
 			if f.IsDir() {
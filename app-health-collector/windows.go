@@ -10,7 +10,6 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"time"
 
@@ -18,6 +17,14 @@ import (
 
 	sdtProcess "main/src/process"
 	sdtType "main/src/processType"
+	sdtLog "pkg/log"
+	sdtWinService "pkg/winservice"
+)
+
+// Name and description of the Windows service of the agent. (-service install|uninstall|start|stop)
+const (
+	serviceName        = "ProcessCheckerService"
+	serviceDescription = "BWC agent that checks the processes of the deployed apps."
 )
 
 // - procLog: This is the Struct that defines the format of the Log.
@@ -50,10 +57,13 @@ type processService struct {
 // The way to log the text "Hello World" as an Info log type is shown below.
 // procLog.Info.Printf("Hello World\n")
 // Output: [INFO] Hello World
-func initError(logFile io.Writer) {
-	procLog.Info = log.New(logFile, "[INFO] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Warn = log.New(logFile, "[WARNING] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Error = log.New(logFile, "[ERROR] ", log.Ldate|log.Ltime|log.Lshortfile)
+//
+// Logs below logLevel (loglevel in config.json) are discarded.
+// If logFormat is "json", each log is written as a JSON line. (See pkg/log NewJSONLogger)
+func initError(logFile io.Writer, logLevel string, logFormat string) {
+	procLog.Info = sdtLog.NewLogger(logFile, "info", logLevel, logFormat)
+	procLog.Warn = sdtLog.NewLogger(logFile, "warn", logLevel, logFormat)
+	procLog.Error = sdtLog.NewLogger(logFile, "error", logLevel, logFormat)
 }
 
 // This function configures the environment based on the server's architecture information
@@ -67,11 +77,24 @@ func initError(logFile io.Writer) {
 //   - arch: Architecture of the device.
 func main() {
 	// Set parameter
-	var mqttType, archType, rootPath, appPath string
+	var mqttType, archType, rootPath, appPath, logFormat, serviceAction string
 	flag.StringVar(&mqttType, "mqtt", "", "Please input mqtt type(mosq? or aws? or exmq?)")
 	flag.StringVar(&archType, "arch", "", "Please input architecture type(amd? or arm?)")
+	flag.StringVar(&logFormat, "log-format", "text", "Please input log format(text or json)")
+	flag.StringVar(&serviceAction, "service", "", "Please input windows service action(install, uninstall, start or stop)")
 	flag.Parse()
 
+	// Manage the Windows service of the agent and exit.
+	if serviceAction != "" {
+		err := sdtWinService.Control(serviceAction, serviceName, serviceDescription, os.Args[1:])
+		if err != nil {
+			fmt.Printf("Failed %s %s: %v\n", serviceAction, serviceName, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Successfully %s %s.\n", serviceAction, serviceName)
+		return
+	}
+
 	// Set Config PATH
 	if archType == "win" {
 		rootPath = "C:/sdt"
@@ -91,12 +114,13 @@ func main() {
 
 	// Set Log
 	logFilePath := fmt.Sprintf("%s/device.logs/process-checker.log", rootPath)
-	logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY|os.O_SYNC, 0644)
+	logFile, err := sdtLog.NewRotateWriter(logFilePath, sdtLog.GetRotateConfig(fmt.Sprintf("%s/device.config/config.json", rootPath)))
 	if err != nil {
 		panic(err)
 	}
 	defer logFile.Close()
-	initError(logFile)
+	logLevel := sdtLog.GetLevel(fmt.Sprintf("%s/device.config/config.json", rootPath))
+	initError(logFile, logLevel, logFormat)
 
 	sdtProcess.Getlog(procLog)
 
@@ -107,7 +131,7 @@ func main() {
 		RootPath: rootPath,
 		AppPath:  appPath,
 	}
-	err = winSvc.Run("ProcessCheckerService", &winSvcInfo)
+	err = winSvc.Run(serviceName, &winSvcInfo)
 	if err != nil {
 		procLog.Error.Printf("cannot start service: %v\n", err)
 	}
@@ -23,6 +23,11 @@ type healthService struct {
 	MqttType	string
 	ArchType	string
 	RootPath	string
+	NoMqtt		bool
+	HealthAddr	string
+	HealthPort	int
+	HealthTimeout	time.Duration
+	MetricsPort	int
 }
 
 func initError(logFile io.Writer) {
@@ -38,7 +43,7 @@ func (srv *healthService) Execute(args []string, req <-chan winSvc.ChangeRequest
     // 실제 서비스 내용
 	procLog.Info.Printf("[SVC] Service Content!!!\n")
     stopChan := make(chan bool, 1)
-    go sdtHealth.RunBody(srv.MqttType, srv.ArchType, srv.RootPath)
+    go sdtHealth.RunBody(srv.MqttType, srv.ArchType, srv.RootPath, srv.NoMqtt, srv.HealthAddr, srv.HealthPort, srv.HealthTimeout, srv.MetricsPort)
  
     stat <- winSvc.Status{State: winSvc.Running, Accepts: winSvc.AcceptStop | winSvc.AcceptShutdown}
  
@@ -72,9 +77,16 @@ LOOP:
 // network bit 단위로 계산하고 싶다. 
 func main() {
 	// Set parameter
-	var mqttType, archType, rootPath string
+	var mqttType, archType, rootPath, healthAddr string
+	var noMqtt bool
+	var healthPort, healthTimeout, metricsPort int
 	flag.StringVar(&mqttType, "mqtt", "", "Please input mqtt type(mosq? or aws? or exmq?)")
 	flag.StringVar(&archType, "arch", "", "Please input architecture type(amd? or arm?)")
+	flag.BoolVar(&noMqtt, "no-mqtt", false, "Write health data to stdout without MQTT(for debugging)")
+	flag.StringVar(&healthAddr, "healthaddr", "127.0.0.1", "Please input listen address of health endpoint.(0.0.0.0 is all interfaces)")
+	flag.IntVar(&healthPort, "healthport", 9090, "Please input port of health endpoint.(0 is disabled)")
+	flag.IntVar(&healthTimeout, "healthtimeout", 30, "Please input seconds since the last publish until health endpoint returns 503.")
+	flag.IntVar(&metricsPort, "metrics-port", 0, "Please input port of Prometheus metrics endpoint.(0 is disabled)")
 	flag.Parse()
 
 	// Set Config PATH
@@ -89,6 +101,11 @@ func main() {
 		MqttType:	mqttType,
 		ArchType:	archType,
 		RootPath:	rootPath,
+		NoMqtt:		noMqtt,
+		HealthAddr:	healthAddr,
+		HealthPort:	healthPort,
+		HealthTimeout:	time.Duration(healthTimeout) * time.Second,
+		MetricsPort:	metricsPort,
 	}
 
 	// Set logger
@@ -11,10 +11,12 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"log"
 	sdtHealth "main/src/health"
 	sdtType "main/src/healthType"
-	"os"
+	sdtHealthz "main/src/healthz"
+	sdtMetrics "main/src/metrics"
+	sdtLog "pkg/log"
+	"time"
 )
 
 // - procLog: This is the Struct that defines the format of the Log.
@@ -28,10 +30,13 @@ var (
 // The way to log the text "Hello World" as an Info log type is shown below.
 // procLog.Info.Printf("Hello World\n")
 // Output: [INFO] Hello World
-func initError(logFile io.Writer) {
-	procLog.Info = log.New(logFile, "[INFO] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Warn = log.New(logFile, "[WARNING] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Error = log.New(logFile, "[ERROR] ", log.Ldate|log.Ltime|log.Lshortfile)
+//
+// Logs below logLevel (loglevel in config.json) are discarded.
+// If logFormat is "json", each log is written as a JSON line. (See pkg/log NewJSONLogger)
+func initError(logFile io.Writer, logLevel string, logFormat string) {
+	procLog.Info = sdtLog.NewLogger(logFile, "info", logLevel, logFormat)
+	procLog.Warn = sdtLog.NewLogger(logFile, "warn", logLevel, logFormat)
+	procLog.Error = sdtLog.NewLogger(logFile, "error", logLevel, logFormat)
 }
 
 // This function receives server architecture information, configures the environment
@@ -44,11 +49,23 @@ func initError(logFile io.Writer) {
 //   - - exmq: EXMQ
 //     -- inspector: If an Inspector sensor exists on the device, here are the options it utilizes.
 //   - arch: Architecture of the device.
+//   - no-mqtt: Write health data to stdout as JSON without connecting MQTT.
+//   - healthaddr: Listen address of the health endpoint. (127.0.0.1 by default)
+//   - healthport: Port of the health endpoint(/healthz). (0 is disabled)
+//   - healthtimeout: Seconds since the last publish after which /healthz returns 503. (At least twice the health interval)
 func main() {
 	// Set parameter
-	var mqttType, archType, rootPath string
+	var mqttType, archType, rootPath, logFormat, healthAddr string
+	var noMqtt bool
+	var healthPort, healthTimeout, metricsPort int
 	flag.StringVar(&mqttType, "mqtt", "", "Please input mqtt type(mosq? or aws? or exmq?)")
 	flag.StringVar(&archType, "arch", "", "Please input architecture type(amd? or arm?)")
+	flag.BoolVar(&noMqtt, "no-mqtt", false, "Write health data to stdout without MQTT(for debugging)")
+	flag.StringVar(&healthAddr, "healthaddr", "127.0.0.1", "Please input listen address of health endpoint.(0.0.0.0 is all interfaces)")
+	flag.IntVar(&healthPort, "healthport", 9090, "Please input port of health endpoint.(0 is disabled)")
+	flag.IntVar(&healthTimeout, "healthtimeout", 30, "Please input seconds since the last publish until health endpoint returns 503.")
+	flag.IntVar(&metricsPort, "metrics-port", 0, "Please input port of Prometheus metrics endpoint.(0 is disabled)")
+	flag.StringVar(&logFormat, "log-format", "text", "Please input log format(text or json)")
 	flag.Parse()
 
 	// Set Config PATH
@@ -60,25 +77,33 @@ func main() {
 
 	// Set Service Variable
 	svcInfo := sdtType.HealthService{
-		MqttType: mqttType,
-		ArchType: archType,
-		RootPath: rootPath,
+		MqttType:      mqttType,
+		ArchType:      archType,
+		RootPath:      rootPath,
+		NoMqtt:        noMqtt,
+		HealthAddr:    healthAddr,
+		HealthPort:    healthPort,
+		HealthTimeout: time.Duration(healthTimeout) * time.Second,
+		MetricsPort:   metricsPort,
 	}
 
 	// Set logger
 	logFilePath := fmt.Sprintf("%s/device.logs/device-health.log", rootPath)
-	logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY|os.O_SYNC, 0644)
+	logFile, err := sdtLog.NewRotateWriter(logFilePath, sdtLog.GetRotateConfig(fmt.Sprintf("%s/device.config/config.json", rootPath)))
 	if err != nil {
 		panic(err)
 	}
 	defer logFile.Close()
-	initError(logFile)
+	logLevel := sdtLog.GetLevel(fmt.Sprintf("%s/device.config/config.json", rootPath))
+	initError(logFile, logLevel, logFormat)
 
 	sdtHealth.Getlog(procLog)
+	sdtHealthz.Getlog(procLog)
+	sdtMetrics.Getlog(procLog)
 
 	if mqttType == "inspector" {
 		sdtHealth.RunBodyForInspector(svcInfo.ArchType)
 	} else {
-		sdtHealth.RunBody(svcInfo.MqttType, svcInfo.ArchType, svcInfo.RootPath)
+		sdtHealth.RunBody(svcInfo.MqttType, svcInfo.ArchType, svcInfo.RootPath, svcInfo.NoMqtt, svcInfo.HealthAddr, svcInfo.HealthPort, svcInfo.HealthTimeout, svcInfo.MetricsPort)
 	}
 }
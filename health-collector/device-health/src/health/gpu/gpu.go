@@ -0,0 +1,231 @@
+// The gpu package collects the GPU information of the device from the tools of each
+// GPU vendor. NVIDIA GPUs are read with nvidia-smi, AMD GPUs with rocm-smi, and Intel
+// GPUs from /sys/class/drm. The backends are probed in the order NVIDIA -> AMD -> Intel,
+// and the first backend that finds a GPU is used.
+package gpu
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// notAvailable is the value of the fields that the backend can't read. (Same as nvidia-smi)
+const notAvailable = "[N/A]"
+
+// These are variables so that they can be replaced in tests.
+//   - execCommand: Function that creates the command of the GPU tools.
+//   - drmDir: Directory of the DRM cards read by the Intel backend.
+var (
+	execCommand = exec.Command
+	drmDir      = "/sys/class/drm"
+)
+
+// GPU is the information of a GPU.
+//   - Vendor: Vendor of the GPU. (nvidia, amd, intel)
+//   - Index: Index of the GPU.
+//   - Name: Product name of the GPU.
+//   - Util: Utilization of the GPU. (%)
+//   - TotalMem: Total memory of the GPU. (MiB)
+//   - UsedMem: Used memory of the GPU. (MiB)
+//   - Temp: Temperature of the GPU. (C)
+//   - FanSpeed: Fan speed of the GPU. (%)
+type GPU struct {
+	Vendor   string
+	Index    string
+	Name     string
+	Util     string
+	TotalMem string
+	UsedMem  string
+	Temp     string
+	FanSpeed string
+}
+
+// GPUBackend is the interface of the GPU tools of each vendor.
+type GPUBackend interface {
+	// Vendor returns the vendor name of the backend.
+	Vendor() string
+	// Probe returns the GPUs of the device. An error is returned if the tool is not found.
+	Probe() ([]GPU, error)
+}
+
+// Backends is the list of GPU backends in the detection order.
+var Backends = []GPUBackend{NvidiaBackend{}, AmdBackend{}, IntelBackend{}}
+
+// Detect function probes the backends in order and returns the GPUs of the first backend
+// that finds a GPU.
+//
+// Output:
+//   - []GPU: GPUs of the device.
+//   - error: Errors of all backends if no GPU is found.
+func Detect() ([]GPU, error) {
+	var errList []string
+	for _, backend := range Backends {
+		gpus, err := backend.Probe()
+		if err != nil {
+			errList = append(errList, fmt.Sprintf("%s: %v", backend.Vendor(), err))
+			continue
+		}
+		if len(gpus) > 0 {
+			return gpus, nil
+		}
+	}
+	return nil, errors.New(strings.Join(errList, "; "))
+}
+
+// NvidiaBackend reads NVIDIA GPUs with nvidia-smi.
+type NvidiaBackend struct{}
+
+// Vendor function returns "nvidia".
+func (NvidiaBackend) Vendor() string { return "nvidia" }
+
+// Probe function gets data of all NVIDIA GPUs in one nvidia-smi call.
+func (b NvidiaBackend) Probe() ([]GPU, error) {
+	var out bytes.Buffer
+	cmd := execCommand("nvidia-smi", "--query-gpu=index,name,utilization.gpu,memory.total,memory.used,temperature.gpu,fan.speed", "--format=csv,noheader,nounits")
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	// Parsing for output (one line per gpu)
+	var gpus []GPU
+	for _, line := range strings.Split(out.String(), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ", ")
+		if len(fields) < 7 {
+			continue
+		}
+		gpus = append(gpus, GPU{
+			Vendor:   b.Vendor(),
+			Index:    fields[0],
+			Name:     fields[1],
+			Util:     fields[2],
+			TotalMem: fields[3],
+			UsedMem:  fields[4],
+			Temp:     fields[5],
+			FanSpeed: fields[6],
+		})
+	}
+	return gpus, nil
+}
+
+// AmdBackend reads AMD GPUs with rocm-smi.
+type AmdBackend struct{}
+
+// Vendor function returns "amd".
+func (AmdBackend) Vendor() string { return "amd" }
+
+// Probe function gets data of all AMD GPUs from the JSON output of rocm-smi.
+// The output is keyed by card. (ex. {"card0": {"GPU use (%)": "3", ...}})
+func (b AmdBackend) Probe() ([]GPU, error) {
+	stdout, err := execCommand("rocm-smi", "--showproductname", "--showuse", "--showmeminfo", "vram", "--showtemp", "--showfan", "--json").Output()
+	if err != nil {
+		return nil, err
+	}
+	var cards map[string]map[string]interface{}
+	if err := json.Unmarshal(stdout, &cards); err != nil {
+		return nil, err
+	}
+
+	cardNames := make([]string, 0, len(cards))
+	for name := range cards {
+		if strings.HasPrefix(name, "card") {
+			cardNames = append(cardNames, name)
+		}
+	}
+	sort.Strings(cardNames)
+
+	var gpus []GPU
+	for _, name := range cardNames {
+		card := cards[name]
+		gpuName := findField(card, "Card series")
+		if gpuName == notAvailable {
+			gpuName = findField(card, "Card model")
+		}
+		gpus = append(gpus, GPU{
+			Vendor:   b.Vendor(),
+			Index:    strings.TrimPrefix(name, "card"),
+			Name:     gpuName,
+			Util:     findField(card, "GPU use"),
+			TotalMem: bytesToMiB(findField(card, "VRAM Total Memory")),
+			UsedMem:  bytesToMiB(findField(card, "VRAM Total Used Memory")),
+			Temp:     findField(card, "Temperature (Sensor edge)"),
+			FanSpeed: findField(card, "Fan speed (%)"),
+		})
+	}
+	return gpus, nil
+}
+
+// IntelBackend reads Intel GPUs from /sys/class/drm. Utilization, memory and temperature
+// are not available in sysfs, so only the GPUs are listed.
+type IntelBackend struct{}
+
+// Vendor function returns "intel".
+func (IntelBackend) Vendor() string { return "intel" }
+
+// cardPattern matches the GPU directories in /sys/class/drm. (Not the connectors like card0-HDMI-A-1)
+var cardPattern = regexp.MustCompile(`^card[0-9]+$`)
+
+// Probe function finds the DRM cards with the Intel PCI vendor ID (0x8086).
+func (b IntelBackend) Probe() ([]GPU, error) {
+	cardDirs, err := filepath.Glob(filepath.Join(drmDir, "card*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(cardDirs)
+
+	var gpus []GPU
+	for _, cardDir := range cardDirs {
+		cardName := filepath.Base(cardDir)
+		if !cardPattern.MatchString(cardName) {
+			continue
+		}
+		vendorId, err := os.ReadFile(filepath.Join(cardDir, "device", "vendor"))
+		if err != nil || strings.TrimSpace(string(vendorId)) != "0x8086" {
+			continue
+		}
+		deviceId, _ := os.ReadFile(filepath.Join(cardDir, "device", "device"))
+		gpus = append(gpus, GPU{
+			Vendor:   b.Vendor(),
+			Index:    strings.TrimPrefix(cardName, "card"),
+			Name:     fmt.Sprintf("Intel GPU %s", strings.TrimSpace(string(deviceId))),
+			Util:     notAvailable,
+			TotalMem: notAvailable,
+			UsedMem:  notAvailable,
+			Temp:     notAvailable,
+			FanSpeed: notAvailable,
+		})
+	}
+	if len(gpus) == 0 {
+		return nil, fmt.Errorf("no Intel GPU in %s", drmDir)
+	}
+	return gpus, nil
+}
+
+// findField function returns the value of the first key that starts with the prefix.
+// The keys of rocm-smi include units that differ by version. (ex. "GPU use (%)")
+func findField(card map[string]interface{}, prefix string) string {
+	for key, val := range card {
+		if strings.HasPrefix(key, prefix) {
+			return fmt.Sprintf("%v", val)
+		}
+	}
+	return notAvailable
+}
+
+// bytesToMiB function converts bytes to MiB, the unit of nvidia-smi.
+func bytesToMiB(val string) string {
+	size, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return val
+	}
+	return strconv.FormatInt(int64(size/1024/1024), 10)
+}
@@ -0,0 +1,184 @@
+package gpu
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeCommand replaces execCommand so that the GPU tools are run as TestHelperProcess.
+// The output of each tool is set in outputs. A tool without output exits with 1. (ex. not installed)
+func fakeCommand(t *testing.T, outputs map[string]string) {
+	t.Helper()
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+		cmd.Env = append(os.Environ(), "GO_HELPER_PROCESS=1")
+		if out, ok := outputs[name]; ok {
+			cmd.Env = append(cmd.Env, "GO_HELPER_OUTPUT="+out)
+		}
+		return cmd
+	}
+	t.Cleanup(func() { execCommand = exec.Command })
+}
+
+// TestHelperProcess is not a real test. It prints the output of the fake GPU tool.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_HELPER_PROCESS") != "1" {
+		return
+	}
+	out, ok := os.LookupEnv("GO_HELPER_OUTPUT")
+	if !ok {
+		os.Exit(1)
+	}
+	fmt.Print(out)
+	os.Exit(0)
+}
+
+// fakeDrm creates the DRM cards in a temporary directory. vendors is keyed by card name.
+func fakeDrm(t *testing.T, vendors map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for card, vendor := range vendors {
+		deviceDir := filepath.Join(dir, card, "device")
+		if err := os.MkdirAll(deviceDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		os.WriteFile(filepath.Join(deviceDir, "vendor"), []byte(vendor+"\n"), 0644)
+		os.WriteFile(filepath.Join(deviceDir, "device"), []byte("0x46a6\n"), 0644)
+	}
+	oldDir := drmDir
+	drmDir = dir
+	t.Cleanup(func() { drmDir = oldDir })
+}
+
+const nvidiaOutput = "0, NVIDIA GeForce RTX 3080, 12, 10240, 512, 45, 30\n1, Tesla T4, 0, 15360, 0, 38, [N/A]\n"
+
+const rocmOutput = `{
+	"card1": {"Card series": "Radeon RX 7900", "GPU use (%)": "7", "VRAM Total Memory (B)": "25753026560",
+		"VRAM Total Used Memory (B)": "1073741824", "Temperature (Sensor edge) (C)": "41.0", "Fan speed (%)": "20"},
+	"card0": {"Card model": "0x744c", "GPU use (%)": "0"},
+	"system": {"Driver version": "6.3.6"}
+}`
+
+func TestNvidiaBackend(t *testing.T) {
+	fakeCommand(t, map[string]string{"nvidia-smi": nvidiaOutput})
+	gpus, err := NvidiaBackend{}.Probe()
+	if err != nil {
+		t.Fatalf("Probe() = %v", err)
+	}
+	want := []GPU{
+		{"nvidia", "0", "NVIDIA GeForce RTX 3080", "12", "10240", "512", "45", "30"},
+		{"nvidia", "1", "Tesla T4", "0", "15360", "0", "38", "[N/A]"},
+	}
+	if !reflect.DeepEqual(gpus, want) {
+		t.Errorf("Probe() = %+v, want %+v", gpus, want)
+	}
+}
+
+func TestAmdBackend(t *testing.T) {
+	fakeCommand(t, map[string]string{"rocm-smi": rocmOutput})
+	gpus, err := AmdBackend{}.Probe()
+	if err != nil {
+		t.Fatalf("Probe() = %v", err)
+	}
+	want := []GPU{
+		{"amd", "0", "0x744c", "0", notAvailable, notAvailable, notAvailable, notAvailable},
+		{"amd", "1", "Radeon RX 7900", "7", "24560", "1024", "41.0", "20"},
+	}
+	if !reflect.DeepEqual(gpus, want) {
+		t.Errorf("Probe() = %+v, want %+v", gpus, want)
+	}
+}
+
+func TestIntelBackend(t *testing.T) {
+	fakeDrm(t, map[string]string{"card0": "0x8086", "card1": "0x10de", "card0-HDMI-A-1": "0x8086"})
+	gpus, err := IntelBackend{}.Probe()
+	if err != nil {
+		t.Fatalf("Probe() = %v", err)
+	}
+	want := []GPU{{"intel", "0", "Intel GPU 0x46a6", notAvailable, notAvailable, notAvailable, notAvailable, notAvailable}}
+	if !reflect.DeepEqual(gpus, want) {
+		t.Errorf("Probe() = %+v, want %+v", gpus, want)
+	}
+
+	fakeDrm(t, map[string]string{"card0": "0x1002"})
+	if _, err := (IntelBackend{}).Probe(); err == nil {
+		t.Errorf("Probe() without Intel GPU = nil, want error")
+	}
+}
+
+func TestDetectOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		outputs    map[string]string
+		intel      bool
+		wantVendor string
+	}{
+		{"nvidia first", map[string]string{"nvidia-smi": nvidiaOutput, "rocm-smi": rocmOutput}, true, "nvidia"},
+		{"amd without nvidia-smi", map[string]string{"rocm-smi": rocmOutput}, true, "amd"},
+		{"nvidia-smi without gpu", map[string]string{"nvidia-smi": "", "rocm-smi": rocmOutput}, false, "amd"},
+		{"intel only", map[string]string{}, true, "intel"},
+		{"no gpu", map[string]string{}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeCommand(t, tt.outputs)
+			if tt.intel {
+				fakeDrm(t, map[string]string{"card0": "0x8086"})
+			} else {
+				fakeDrm(t, map[string]string{})
+			}
+
+			gpus, err := Detect()
+			if tt.wantVendor == "" {
+				if err == nil {
+					t.Errorf("Detect() = %+v, want error", gpus)
+				}
+				return
+			}
+			if err != nil || len(gpus) == 0 || gpus[0].Vendor != tt.wantVendor {
+				t.Errorf("Detect() = %+v, %v, want %s", gpus, err, tt.wantVendor)
+			}
+		})
+	}
+}
+
+// stubBackend counts the probes.
+type stubBackend struct {
+	vendor string
+	gpus   []GPU
+	probes *int
+}
+
+func (s stubBackend) Vendor() string { return s.vendor }
+
+func (s stubBackend) Probe() ([]GPU, error) {
+	*s.probes++
+	if s.gpus == nil {
+		return nil, errors.New("not found")
+	}
+	return s.gpus, nil
+}
+
+func TestDetectStopsAtFirstBackend(t *testing.T) {
+	var nvidia, amd, intel int
+	oldBackends := Backends
+	Backends = []GPUBackend{
+		stubBackend{"nvidia", nil, &nvidia},
+		stubBackend{"amd", []GPU{{Vendor: "amd"}}, &amd},
+		stubBackend{"intel", []GPU{{Vendor: "intel"}}, &intel},
+	}
+	defer func() { Backends = oldBackends }()
+
+	gpus, err := Detect()
+	if err != nil || len(gpus) != 1 || gpus[0].Vendor != "amd" {
+		t.Fatalf("Detect() = %+v, %v", gpus, err)
+	}
+	if nvidia != 1 || amd != 1 || intel != 0 {
+		t.Errorf("probes = nvidia %d, amd %d, intel %d", nvidia, amd, intel)
+	}
+}
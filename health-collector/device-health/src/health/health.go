@@ -1,25 +1,33 @@
 // The health package collects health information of the device and
 // sends it to the SDT Cloud via MQTT messages. DeviceHealth connects to MQTT
-// and publishes the device's health information every second.
+// and publishes the device's health information every healthIntervalSec seconds. (5 by default)
 package health
 
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
+	"math"
 	insp_net "net"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	// ******
@@ -30,11 +38,16 @@ import (
 	"github.com/leizongmin/fuser"
 	"github.com/shirou/gopsutil/disk"
 	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
 	"github.com/shirou/gopsutil/v3/mem"
 	"github.com/shirou/gopsutil/v3/net"
 	"github.com/shirou/gopsutil/v3/process"
 
+	sdtGpu "main/src/health/gpu"
 	sdtType "main/src/healthType"
+	sdtHealthz "main/src/healthz"
+	sdtMetrics "main/src/metrics"
+	sdtMqtt "pkg/mqttutil"
 )
 
 // Global variables used in the health package.
@@ -67,6 +80,12 @@ var (
 	procLog      sdtType.Logger
 )
 
+// lastPublishAt is the time of the last published health message. (unix ms, read by /healthz)
+var lastPublishAt atomic.Int64
+
+// healthInterval is the current health publishing interval. (nanoseconds, read by /healthz)
+var healthInterval atomic.Int64
+
 // Getlog is a function that loads the log format. Log formats are defined as Info,
 // Warn, Error, and are output using Printf.
 // Here's how you would record the text "Hello World" as an Info log type:
@@ -76,6 +95,21 @@ func Getlog(logConfig sdtType.Logger) {
 	procLog = logConfig
 }
 
+// connectionLostHandler function is called when the connection to the MQTT broker is lost.
+// Instead of exiting the agent, it reconnects with exponential backoff. (See pkg/mqttutil)
+//
+// Input:
+//   - client: MQTT client that lost the connection.
+//   - err: Reason of the connection loss.
+func connectionLostHandler(client mqttCli.Client, err error) {
+	procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
+	sdtMqtt.OnConnectionLost(func() error {
+		token := client.Connect()
+		token.Wait()
+		return token.Error()
+	}, procLog.Error)
+}
+
 // This function defines options for connecting to the AWS IoT Core MQTT Broker.
 // It specifies options such as TLS, MQTT URI, client name, and handlers.
 //
@@ -89,7 +123,7 @@ func Getlog(logConfig sdtType.Logger) {
 //   - *mqttCli.ClientOptions: Variable of type MQTT ClientOptions.
 func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey, assetCode string) *mqttCli.ClientOptions {
 	// Load CA certificate
-	caCert, err := ioutil.ReadFile(rootCa)
+	caCert, err := os.ReadFile(rootCa)
 	if err != nil {
 		log.Fatalf("Error reading CA certificate file: %v", err)
 	}
@@ -123,10 +157,63 @@ func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey, assetCode
 	opts.AddBroker(mqttURL)
 	opts.SetTLSConfig(tlsConfig)
 	opts.SetClientID(fmt.Sprintf("blokworks-client-health-%s", cilentUUID))
-	opts.SetConnectionLostHandler(func(client mqttCli.Client, err error) {
-		procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
-		os.Exit(1)
-	})
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
+
+	return opts
+}
+
+// createExmqClientOptions function defines options for connecting to the EXMQ Broker.
+// The certificates of the EXMQ broker are read from the 'exmq' section of the config file.
+//
+// Input:
+//   - config: Struct storing the Config file saved on the device in JSON format.
+//
+// Output:
+//   - *mqttCli.ClientOptions: Options of the MQTT client.
+func createExmqClientOptions(config sdtType.ConfigInfo) *mqttCli.ClientOptions {
+	tlsConfig := &tls.Config{}
+
+	// Load CA certificate
+	if config.Exmq.RootCa != "" {
+		caCert, err := os.ReadFile(config.Exmq.RootCa)
+		if err != nil {
+			procLog.Error.Printf("Error reading EXMQ CA certificate file: %v", err)
+		}
+		roots := x509.NewCertPool()
+		roots.AppendCertsFromPEM(caCert)
+		tlsConfig.RootCAs = roots
+	}
+
+	// Load client certificate
+	if config.Exmq.Cert != "" && config.Exmq.PrivateKey != "" {
+		clientCert, err := tls.LoadX509KeyPair(config.Exmq.Cert, config.Exmq.PrivateKey)
+		if err != nil {
+			procLog.Error.Printf("Error reading EXMQ client key file: %v", err)
+		} else {
+			tlsConfig.Certificates = []tls.Certificate{clientCert}
+		}
+	}
+
+	mqttURL := config.Exmq.MqttUrl
+	if mqttURL == "" {
+		mqttURL = config.MqttUrl
+	}
+
+	// set uuid
+	newUUID := uuid.New()
+	cilentUUID := newUUID.String()
+
+	opts := mqttCli.NewClientOptions()
+	opts.AddBroker(mqttURL)
+	opts.SetTLSConfig(tlsConfig)
+	if config.Exmq.Username != "" {
+		opts.SetUsername(config.Exmq.Username)
+		opts.SetPassword(config.Exmq.Password)
+	}
+	opts.SetClientID(fmt.Sprintf("blokworks-client-health-%s", cilentUUID))
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
 
 	return opts
 }
@@ -136,22 +223,24 @@ func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey, assetCode
 //
 // Input:
 //   - config: Struct storing the config file saved on the device in JSON format.
+//   - user: User ID used for MQTT connection.
+//   - password: Password used for MQTT connection.
 //
 // Output:
 //   - mqttCli.Client: Variable of type MQTT client.
 func connectToMqtt(
 	config sdtType.ConfigInfo, // Information of config
+	user string,
+	password string,
 ) mqttCli.Client {
 	procLog.Info.Printf("[MQTT] In connectToMqtt Function")
 	opts := mqttCli.NewClientOptions()
 	opts.AddBroker(config.MqttUrl)
-	opts.SetPassword(mqttPassword)
-	opts.SetUsername(mqttUser)
+	opts.SetPassword(password)
+	opts.SetUsername(user)
 	opts.SetClientID(fmt.Sprintf("blokworks-client-health-%s", config.AssetCode))
-	opts.SetConnectionLostHandler(func(client mqttCli.Client, err error) {
-		procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
-		os.Exit(1)
-	})
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
 
 	cli = mqttCli.NewClient(opts)
 
@@ -164,17 +253,31 @@ func connectToMqtt(
 	return cli
 }
 
-// This function publishes a message to the MQTT Broker.
+// This function publishes a message to the MQTT Broker. If dryRun is true, the message
+// is written to stdout instead of the MQTT Broker.
 //
 // Input:
 //   - payload: Message content to publish, of type interface{} which is a map variable.
 //   - config: Struct storing the config file saved on the device in JSON format.
+//   - dryRun: Option to write the message to stdout without MQTT.
 func sendDataEdgeMqtt(
 	payload map[string]interface{}, // Result of command
 	config sdtType.ConfigInfo, // Information of config
+	dryRun bool, // Write stdout without MQTT
 ) {
+	if dryRun {
+		resultBody, err := json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			procLog.Error.Printf("[MQTT] Marshal error: %v\n", err)
+			return
+		}
+		os.Stdout.Write(append(resultBody, '\n'))
+		lastPublishAt.Store(time.Now().UnixMilli())
+		return
+	}
+
 	// topic := fmt.Sprintf("$aws/things/sdt-cloud-development/shadow/name/device-health/%s", config.AssetCode)
-	topic := fmt.Sprintf("%s/%s/%s/bwc/health", config.ServiceCode, config.ProjectCode, config.AssetCode)
+	topic := config.Topic("bwc/health")
 
 	resultBody, err := json.Marshal(payload)
 	if err != nil {
@@ -184,14 +287,210 @@ func sendDataEdgeMqtt(
 
 	if pub_token.Wait() && pub_token.Error() != nil {
 		procLog.Error.Printf("[MQTT] Error: %v\n", pub_token.Error())
+		return
+	}
+	lastPublishAt.Store(time.Now().UnixMilli())
+}
+
+// LastPublishAt function returns the time of the last published health message.
+//
+// Output:
+//   - int64: Unix time in milliseconds. (0 if no message has been published)
+func LastPublishAt() int64 {
+	return lastPublishAt.Load()
+}
+
+// HealthTimeout function returns the timeout of /healthz. Health messages are published
+// every interval, so the timeout is at least twice the current interval. Otherwise, an
+// interval longer than -healthtimeout would make /healthz return 503 permanently.
+//
+// Input:
+//   - minTimeout: -healthtimeout of Device-Health.
+//
+// Output:
+//   - func() time.Duration: Function that returns max(minTimeout, 2 * current interval).
+func HealthTimeout(minTimeout time.Duration) func() time.Duration {
+	return func() time.Duration {
+		if timeout := 2 * time.Duration(healthInterval.Load()); timeout > minTimeout {
+			return timeout
+		}
+		return minTimeout
+	}
+}
+
+// This function publishes an event message to the sub topic of the device, apart from the health
+// message. (ex. bwc/first-run when the device is newly registered, bwc/alerts when the disk is almost full)
+//
+//	<serviceCode>/<projectCode>/<assetCode>/<subTopic>
+//
+// Input:
+//   - subTopic: Topic after the asset code.
+//   - payload: Message content to publish.
+//   - config: Struct storing the config file saved on the device in JSON format.
+//   - dryRun: Option to write the message to stdout without MQTT.
+//
+// Output:
+//   - error: An error message if the message can't be published.
+func sendEventMqtt(subTopic string, payload map[string]interface{}, config sdtType.ConfigInfo, dryRun bool) error {
+	resultBody, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		os.Stdout.Write(append(resultBody, '\n'))
+		return nil
+	}
+
+	topic := config.Topic(subTopic)
+	pub_token := cli.Publish(topic, 1, false, resultBody)
+	if pub_token.Wait() && pub_token.Error() != nil {
+		return pub_token.Error()
+	}
+	return nil
+}
+
+// Disk usage thresholds (%) of the disk alert, and the alert level of each threshold.
+var diskAlertLevels = []struct {
+	threshold float64
+	level     string
+}{
+	{99, "critical"},
+	{95, "critical"},
+	{90, "warning"},
+}
+
+// MonitorDiskUsage function checks the usage of the root disk every second, apart from the 5 second
+// health tick. When the usage crosses 90%, 95% or 99%, a disk alert is published immediately to
+// bwc/alerts. The alert of each threshold is not repeated within 60 seconds.
+//
+// Input:
+//   - archType: Architecture of the device.
+//   - config: Struct storing the config file saved on the device in JSON format.
+//   - dryRun: Option to write the message to stdout without MQTT.
+func MonitorDiskUsage(archType string, config sdtType.ConfigInfo, dryRun bool) {
+	rootDisk := "/"
+	if archType == "win" {
+		rootDisk = "C:"
+	}
+	lastAlert := map[float64]time.Time{}
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		usage, err := disk.Usage(rootDisk)
+		if err != nil {
+			procLog.Error.Printf("[HEALTH] Disk alert Error: %v\n", err)
+			continue
+		}
+
+		// Only the highest threshold crossed is alerted.
+		for _, alert := range diskAlertLevels {
+			if usage.UsedPercent < alert.threshold {
+				continue
+			}
+			if time.Since(lastAlert[alert.threshold]) >= 60*time.Second {
+				alertMsg := map[string]interface{}{
+					"type":            "disk_alert",
+					"timestamp":       int64(time.Now().UTC().Unix() * 1000),
+					"level":           alert.level,
+					"threshold":       alert.threshold,
+					"diskUsedPercent": math.Round(usage.UsedPercent*10) / 10,
+				}
+				if err := sendEventMqtt("bwc/alerts", alertMsg, config, dryRun); err != nil {
+					procLog.Error.Printf("[HEALTH] Disk alert Error: %v\n", err)
+				} else {
+					procLog.Warn.Printf("[HEALTH] Disk usage %.1f%% crossed %.0f%%.\n", usage.UsedPercent, alert.threshold)
+					lastAlert[alert.threshold] = time.Now()
+				}
+			}
+			break
+		}
+	}
+}
+
+// GetOSInfo function collects OS information of the device for the first-run message.
+//
+// Output:
+//   - map[string]interface{}: OS name, version, kernel and architecture.
+func GetOSInfo() map[string]interface{} {
+	hostInfo, err := host.Info()
+	if err != nil {
+		procLog.Error.Printf("[HEALTH] OS Error: %v\n", err)
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"os":       hostInfo.OS,
+		"platform": hostInfo.Platform,
+		"version":  hostInfo.PlatformVersion,
+		"kernel":   hostInfo.KernelVersion,
+		"arch":     hostInfo.KernelArch,
+		"hostname": hostInfo.Hostname,
 	}
 }
 
+// CPU temperature warning. A warning is logged once when the temperature rises above
+// cpuTempWarn, and again after it drops below. cpuTempWarn is set by cputempwarn in config.json.
+var (
+	cpuTempWarn = 85.0
+	cpuTempHot  = false
+)
+
+// cpuSensorKeys are the sensor names of the CPU reported by host.SensorsTemperatures.
+// (ex. coretemp_package_id_0, k10temp_tctl, cpu_thermal_input, soc_thermal_input)
+var cpuSensorKeys = []string{"coretemp", "k10temp", "zenpower", "cpu", "soc", "x86_pkg", "package", "tctl"}
+
+// sensorsTemperatures reads the temperature sensors of the device. It is a variable so that it can be replaced in tests.
+var sensorsTemperatures = host.SensorsTemperatures
+
+// GetCpuTemp function returns the highest temperature of the CPU sensors.
+//
+// Output:
+//   - float64: CPU temperature in Celsius.
+//   - bool: false if the device has no CPU temperature sensor.
+func GetCpuTemp() (float64, bool) {
+	// Some sensors can't be read without root, so the result is used even with an error.
+	temps, _ := sensorsTemperatures()
+	maxTemp, found := 0.0, false
+	for _, temp := range temps {
+		key := strings.ToLower(temp.SensorKey)
+		isCpu := false
+		for _, cpuKey := range cpuSensorKeys {
+			if strings.Contains(key, cpuKey) {
+				isCpu = true
+				break
+			}
+		}
+		if !isCpu || temp.Temperature <= 0 {
+			continue
+		}
+		if !found || temp.Temperature > maxTemp {
+			maxTemp, found = temp.Temperature, true
+		}
+	}
+	return maxTemp, found
+}
+
+// checkCpuTemp function logs a warning when the CPU temperature rises above cpuTempWarn,
+// and logs again when it drops below.
+//
+// Input:
+//   - tempC: CPU temperature in Celsius.
+func checkCpuTemp(tempC float64) {
+	if tempC >= cpuTempWarn && !cpuTempHot {
+		procLog.Warn.Printf("[HEALTH] CPU temperature %.1f°C is over %.1f°C. The CPU can be throttled.\n", tempC, cpuTempWarn)
+	} else if tempC < cpuTempWarn && cpuTempHot {
+		procLog.Info.Printf("[HEALTH] CPU temperature %.1f°C is back under %.1f°C.\n", tempC, cpuTempWarn)
+	}
+	cpuTempHot = tempC >= cpuTempWarn
+}
+
 // GetCpu function collects CPU information from the device. The collected information includes:
 //   - CPU usage rate
 //   - Number of CPU cores
+//   - CPU temperature (Omitted if the device has no CPU temperature sensor.)
 //
 // Output:
+//   - map[string]interface{} = {"usage": usage rate, "total": number of cores * 100, "tempCelsius": CPU temperature}
 //   - NodeCpu = {"Cpu": usage rate, "Total": number of cores, "Time": collection time}
 func GetCpu() (map[string]interface{}, []sdtType.NodeCpu) {
 	cpuInfo, err := cpu.Percent(time.Second, false) // get data after 1sec.
@@ -203,6 +502,10 @@ func GetCpu() (map[string]interface{}, []sdtType.NodeCpu) {
 		"usage": fmt.Sprintf("%0.4f", cpuInfo[0]),
 		"total": fmt.Sprintf("%d", cpuTotal*100),
 	}
+	if tempC, ok := GetCpuTemp(); ok {
+		newCpu["tempCelsius"] = math.Round(tempC*10) / 10
+		checkCpuTemp(tempC)
+	}
 
 	nodeCpu_arr := make([]sdtType.NodeCpu, 0)
 	insp_newCpu := sdtType.NodeCpu{
@@ -216,6 +519,83 @@ func GetCpu() (map[string]interface{}, []sdtType.NodeCpu) {
 	return newCpu, nodeCpu_arr
 }
 
+// GetThermalThrottling function checks whether the CPU of the device is throttled by temperature.
+// The temperature is read from the thermal zone of the CPU (/sys/class/thermal/thermal_zone*).
+// The throttle flags are read from the firmware on Raspberry Pi. On other devices (ex. Jetson),
+// the CPU is regarded as throttled when the temperature reaches the passive trip point of the zone.
+//
+// Input:
+//   - archType: Architecture of the device.
+//
+// Output:
+//   - bool: true if the CPU is throttled.
+//   - float64: CPU temperature in Celsius. (-1 if not available)
+func GetThermalThrottling(archType string) (bool, float64) {
+	if archType == "win" {
+		return false, -1
+	}
+
+	// Find the thermal zone of the CPU. If not found, the first zone is used.
+	zones, _ := filepath.Glob("/sys/class/thermal/thermal_zone*")
+	cpuZone := ""
+	for _, zone := range zones {
+		zoneType, err := os.ReadFile(fmt.Sprintf("%s/type", zone))
+		if err != nil {
+			continue
+		}
+		if cpuZone == "" {
+			cpuZone = zone
+		}
+		name := strings.ToLower(string(zoneType))
+		if strings.Contains(name, "cpu") || strings.Contains(name, "soc") || strings.Contains(name, "x86_pkg") {
+			cpuZone = zone
+			break
+		}
+	}
+	if cpuZone == "" {
+		return false, -1
+	}
+
+	tempC := -1.0
+	tempData, err := os.ReadFile(fmt.Sprintf("%s/temp", cpuZone))
+	if err == nil {
+		milliTemp, err := strconv.ParseFloat(strings.TrimSpace(string(tempData)), 64)
+		if err == nil {
+			tempC = milliTemp / 1000
+		}
+	}
+
+	// Raspberry Pi: Bit 1(arm frequency capped), 2(currently throttled), 3(soft temperature limit)
+	throttledData, err := os.ReadFile("/sys/devices/platform/soc/soc:firmware/get_throttled")
+	if err == nil {
+		flags, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(string(throttledData)), "0x"), 16, 64)
+		if err == nil {
+			return flags&0xE != 0, tempC
+		}
+	}
+
+	// Others: Compare the temperature with the passive trip point.
+	if tempC < 0 {
+		return false, tempC
+	}
+	tripTypes, _ := filepath.Glob(fmt.Sprintf("%s/trip_point_*_type", cpuZone))
+	for _, tripType := range tripTypes {
+		typeData, err := os.ReadFile(tripType)
+		if err != nil || strings.TrimSpace(string(typeData)) != "passive" {
+			continue
+		}
+		tripData, err := os.ReadFile(strings.TrimSuffix(tripType, "_type") + "_temp")
+		if err != nil {
+			continue
+		}
+		tripTemp, err := strconv.ParseFloat(strings.TrimSpace(string(tripData)), 64)
+		if err == nil && tripTemp > 0 && tempC*1000 >= tripTemp {
+			return true, tempC
+		}
+	}
+	return false, tempC
+}
+
 // GetMem function collects memory information from the device. The collected information includes:
 //   - Memory usage rate
 //   - Total memory size
@@ -284,10 +664,13 @@ func GetProc(totalCpu int) []sdtType.ProcInfo {
 //   - Disk used size
 //   - Disk usage rate
 //   - Disk mount point
+//   - Disk read/write bytes per second (since the last call)
 //
 // Output:
-//   - map[string]interface{} = {"total": total size of '/' path, "usage": usage of '/' path}
-//   - DiskInfo = {"Name": name, "Totalsize": total size, "Used": used size, "UsedPercent": usage rate, "Mountpoint": mount point, "Time": collection time}
+//   - map[string]interface{} = {"total": total size of '/' path, "usage": usage of '/' path,
+//     "io": [{"name": name, "mountpoint": mount point, "readBytesPerSec": read rate, "writeBytesPerSec": write rate}]}
+//   - DiskInfo = {"Name": name, "Totalsize": total size, "Used": used size, "UsedPercent": usage rate, "Mountpoint": mount point,
+//     "ReadBytesPerSec": read rate, "WriteBytesPerSec": write rate, "Time": collection time}
 //   - float64 = overall disk usage rate
 func GetDisk() (map[string]interface{}, []sdtType.DiskInfo, float64) {
 	disks, _ := disk.Usage("/")
@@ -296,7 +679,12 @@ func GetDisk() (map[string]interface{}, []sdtType.DiskInfo, float64) {
 		"usage": fmt.Sprintf("%d", int64(float64(disks.Used)/KB)),
 	}
 
+	// I/O counters are keyed by the device name without /dev/. (ex. sda1, nvme0n1p1, C:)
+	ioCounters, _ := disk.IOCounters()
+	ioTime := time.Now()
+
 	diskList := make([]sdtType.DiskInfo, 0)
+	diskIO := make([]map[string]interface{}, 0)
 	parts, _ := disk.Partitions(true)
 	totalDisk := 0.0
 	usedDisk := 0.0
@@ -307,22 +695,46 @@ func GetDisk() (map[string]interface{}, []sdtType.DiskInfo, float64) {
 			continue
 		} else if strings.Contains(p.Mountpoint, "var/lib") {
 			continue
+		} else if p.Fstype == "overlay" || strings.Contains(strings.ToLower(p.Mountpoint), "docker") {
+			// Skip overlay mounts of docker. (ex. /var/lib/docker/overlay2/<hash>/merged)
+			continue
 		}
 		totalDisk = totalDisk + float64(s.Total)/GiB
 		usedDisk = usedDisk + float64(s.Used)/GiB + 0.000001
 
+		var readRate, writeRate float64
+		if counter, ok := ioCounters[strings.TrimPrefix(p.Device, "/dev/")]; ok {
+			cur := diskIOSample{readBytes: counter.ReadBytes, writeBytes: counter.WriteBytes, time: ioTime}
+			readRate, writeRate = calcIORate(diskIOPrev[counter.Name], cur)
+			diskIO = append(diskIO, map[string]interface{}{
+				"name":             p.Device,
+				"mountpoint":       p.Mountpoint,
+				"readBytesPerSec":  int64(readRate),
+				"writeBytesPerSec": int64(writeRate),
+			})
+		}
+
 		disk_percent := fmt.Sprintf("%0.2f", s.UsedPercent)
 		insp_newDisk := sdtType.DiskInfo{
-			Name:        p.Device,
-			Totalsize:   human.Bytes(s.Total),
-			Used:        float64(s.Used)/GiB + 0.000001,
-			UsedPercent: disk_percent,
-			Mountpoint:  p.Mountpoint,
-			Time:        time.Now(),
+			Name:             p.Device,
+			Totalsize:        human.Bytes(s.Total),
+			Used:             float64(s.Used)/GiB + 0.000001,
+			UsedPercent:      disk_percent,
+			Mountpoint:       p.Mountpoint,
+			Fstype:           p.Fstype,
+			ReadBytesPerSec:  readRate,
+			WriteBytesPerSec: writeRate,
+			Time:             time.Now(),
 		}
 
 		diskList = append(diskList, insp_newDisk)
 	}
+	newDisk["io"] = diskIO
+
+	// Save counters for the next call.
+	for name, counter := range ioCounters {
+		diskIOPrev[name] = diskIOSample{readBytes: counter.ReadBytes, writeBytes: counter.WriteBytes, time: ioTime}
+	}
 
 	sort.SliceStable(diskList, func(i, j int) bool {
 		return diskList[i].Used > diskList[j].Used
@@ -331,54 +743,314 @@ func GetDisk() (map[string]interface{}, []sdtType.DiskInfo, float64) {
 	return newDisk, diskList, usedDisk / totalDisk * 100
 }
 
+// diskIOSample is the I/O counter of a disk at the time of collection.
+type diskIOSample struct {
+	readBytes  uint64
+	writeBytes uint64
+	time       time.Time
+}
+
+// diskIOPrev is the I/O counters of the last GetDisk call, keyed by the device name.
+var diskIOPrev = map[string]diskIOSample{}
+
+// calcIORate function computes the read/write bytes per second between two samples of
+// a disk, like the uplink/downlink of the network. If there is no previous sample or
+// the counter was reset (ex. the device was re-attached), 0 is returned.
+//
+// Input:
+//   - prev: Previous sample of the disk.
+//   - cur: Current sample of the disk.
+//
+// Output:
+//   - float64: Read bytes per second.
+//   - float64: Write bytes per second.
+func calcIORate(prev diskIOSample, cur diskIOSample) (float64, float64) {
+	elapsed := cur.time.Sub(prev.time).Seconds()
+	if prev.time.IsZero() || elapsed <= 0 {
+		return 0, 0
+	}
+	if cur.readBytes < prev.readBytes || cur.writeBytes < prev.writeBytes {
+		return 0, 0
+	}
+	readRate := float64(cur.readBytes-prev.readBytes) / elapsed
+	writeRate := float64(cur.writeBytes-prev.writeBytes) / elapsed
+	return readRate, writeRate
+}
+
+// Paths of the serial driver information. /proc/tty/driver/serial has the UARTs of x86/x86-64
+// devices, and /proc/tty/driver/usbserial has the USB serial devices of ARM32 devices. (ex. Raspberry Pi)
+var (
+	serialDriverFile    = "/proc/tty/driver/serial"
+	usbSerialDriverFile = "/proc/tty/driver/usbserial"
+)
+
+// parseSerialLine function splits a line of the serial driver file into the index and the
+// "key:value" fields. Quoted values (ex. name:"USB Serial") can have spaces.
+//
+//	0: uart:16550A port:000003F8 irq:4 tx:0 rx:0   ->   "0", {"uart": "16550A", "port": "000003F8", ...}
+func parseSerialLine(line string) (string, map[string]string) {
+	index, rest, found := strings.Cut(line, ":")
+	if !found {
+		return "", nil
+	}
+	fields := map[string]string{}
+	var token strings.Builder
+	inQuote := false
+	flush := func() {
+		if key, val, ok := strings.Cut(token.String(), ":"); ok {
+			fields[key] = strings.Trim(val, "\"")
+		}
+		token.Reset()
+	}
+	for _, r := range rest {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			token.WriteRune(r)
+		case r == ' ' && !inQuote:
+			flush()
+		default:
+			token.WriteRune(r)
+		}
+	}
+	flush()
+	return strings.TrimSpace(index), fields
+}
+
+// atoiOrDefault function converts the field to int. If the field doesn't exist or is not a number, -1 is returned.
+func atoiOrDefault(fields map[string]string, key string) int {
+	val, err := strconv.Atoi(fields[key])
+	if err != nil {
+		return -1
+	}
+	return val
+}
+
+// parseSerial function parses /proc/tty/driver/serial. The first line is the header.
+//
+//	serinfo:1.0 driver revision:
+//	0: uart:16550A port:000003F8 irq:4 tx:0 rx:0
+//	1: uart:unknown port:000002F8 irq:3
+//
+// Input:
+//   - data: Content of the file.
+//
+// Output:
+//   - []SerialInfo: Serial information of the UARTs. (Tx, Rx are -1 if not reported)
+func parseSerial(data io.Reader) []sdtType.SerialInfo {
+	serialInfo := make([]sdtType.SerialInfo, 0)
+	scan := bufio.NewScanner(data)
+	scan.Scan()
+	for scan.Scan() {
+		index, fields := parseSerialLine(scan.Text())
+		if fields == nil {
+			continue
+		}
+		serialInfo = append(serialInfo, sdtType.SerialInfo{
+			Index: index,
+			Uart:  fields["uart"],
+			Port:  fields["port"],
+			Irq:   atoiOrDefault(fields, "irq"),
+			Tx:    atoiOrDefault(fields, "tx"),
+			Rx:    atoiOrDefault(fields, "rx"),
+			Time:  time.Now(),
+		})
+	}
+	return serialInfo
+}
+
+// parseUsbSerial function parses /proc/tty/driver/usbserial. The first line is the header.
+// USB serial devices have no UART, IRQ and Tx/Rx counters, so the driver name is used as
+// Uart, the USB path as Port, and Irq, Tx, Rx are -1.
+//
+//	usbserinfo:1.0 driver:2.0
+//	0: module:pl2303 name:"pl2303" vendor:067b product:2303 num_ports:1 port:0 path:usb-3f980000.usb-1.3
+//
+// Input:
+//   - data: Content of the file.
+//
+// Output:
+//   - []SerialInfo: Serial information of the USB serial devices.
+func parseUsbSerial(data io.Reader) []sdtType.SerialInfo {
+	serialInfo := make([]sdtType.SerialInfo, 0)
+	scan := bufio.NewScanner(data)
+	scan.Scan()
+	for scan.Scan() {
+		index, fields := parseSerialLine(scan.Text())
+		if fields == nil {
+			continue
+		}
+		uart := fields["name"]
+		if uart == "" {
+			uart = fields["module"]
+		}
+		serialInfo = append(serialInfo, sdtType.SerialInfo{
+			Index: index,
+			Uart:  uart,
+			Port:  fields["path"],
+			Irq:   atoiOrDefault(fields, "irq"),
+			Tx:    atoiOrDefault(fields, "tx"),
+			Rx:    atoiOrDefault(fields, "rx"),
+			Time:  time.Now(),
+		})
+	}
+	return serialInfo
+}
+
 // GetSerial function collects the serial information from the device. The collected information includes:
 //   - Port information
 //   - Tx value
 //   - Rx value
 //
+// The driver file is selected by runtime.GOARCH. ARM32 devices read /proc/tty/driver/usbserial,
+// and the other devices read /proc/tty/driver/serial. If the file doesn't exist, the other file is
+// read, and if neither exists, an empty list is returned.
+//
 // Output:
 //   - SerialInfo = {"Index": index, "Uart": Uart value, "Port": port, "Irq": IRQ usage, "Tx": Tx usage, "Rx": Rx usage, "Time": collection time}
 func GetSerial(archType string) []sdtType.SerialInfo {
 	if archType == "win" {
 		return nil
 	}
-	data, err := os.Open("/proc/tty/driver/serial")
-	if err != nil {
-		fmt.Println(err)
+	type driverFile struct {
+		path  string
+		parse func(io.Reader) []sdtType.SerialInfo
+	}
+	driverFiles := []driverFile{{serialDriverFile, parseSerial}, {usbSerialDriverFile, parseUsbSerial}}
+	if runtime.GOARCH == "arm" {
+		driverFiles[0], driverFiles[1] = driverFiles[1], driverFiles[0]
 	}
-	defer data.Close()
 
-	scan := bufio.NewScanner(data)
+	for _, driver := range driverFiles {
+		data, err := os.Open(driver.path)
+		if err != nil {
+			continue
+		}
+		defer data.Close()
+		return driver.parse(data)
+	}
+	procLog.Warn.Printf("Serial driver information not found: %s, %s\n", serialDriverFile, usbSerialDriverFile)
+	return make([]sdtType.SerialInfo, 0)
+}
 
-	serial_info := make([]sdtType.SerialInfo, 0)
-	var tx int
-	var rx int
+// vpnPrefixes is the list of interface name prefixes of VPN interfaces.
+// (OpenVPN: tun, tap / WireGuard: wg / Tailscale: tailscale / macOS: utun)
+var vpnPrefixes = []string{"tun", "tap", "wg", "tailscale", "vpn", "utun"}
 
-	scan.Scan()
-	for scan.Scan() {
-		data_slice := strings.Split(strings.Replace(scan.Text(), ":", " ", -1), " ")
-		if len(data_slice) < 9 {
-			tx = -1
-			rx = -1
-		} else {
-			tx, _ = strconv.Atoi(data_slice[9])
-			rx, _ = strconv.Atoi(data_slice[11])
-		}
-		// index, _ := strconv.Atoi(data_slice[0])
-		irq, _ := strconv.Atoi(data_slice[7])
-		newSerial := sdtType.SerialInfo{
-			Index: data_slice[0],
-			Uart:  data_slice[3],
-			Port:  data_slice[5],
-			Irq:   irq,
-			Tx:    tx,
-			Rx:    rx,
-			Time:  time.Now(),
+// IsVpnInterface function checks whether the network interface is an external (VPN) interface.
+// Hamachi and ZeroTier interfaces are matched by name, and the other VPN interfaces are matched
+// by a case-insensitive prefix of the interface name.
+//
+// Input:
+//   - name: Name of the network interface.
+//
+// Output:
+//   - bool: true if the interface is a VPN interface.
+func IsVpnInterface(name string) bool {
+	lowerName := strings.ToLower(name)
+	if strings.Contains(lowerName, "ham") || strings.Contains(lowerName, "ztt") || strings.Contains(lowerName, "zerotier") {
+		return true
+	}
+	for _, prefix := range vpnPrefixes {
+		if strings.HasPrefix(lowerName, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUsableIP function checks whether the IP address can be reported as the address of the device.
+// Loopback (127.0.0.0/8), link-local (169.254.0.0/16), multicast and IPv6 addresses are not used.
+//
+// Input:
+//   - ip: IP address of the network interface.
+//
+// Output:
+//   - bool: true if the address is a usable IPv4 address.
+func isUsableIP(ip insp_net.IP) bool {
+	if ip.To4() == nil {
+		return false
+	}
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// getIPv4Addr function returns the first usable IPv4 address of the network interface.
+// The address is not selected by index, since the order of the addresses differs by OS and
+// VPN interfaces often have only one address.
+//
+// Input:
+//   - addrs: Addresses of the network interface.
+//
+// Output:
+//   - string: IPv4 address with prefix length. (Empty if not found)
+func getIPv4Addr(addrs []insp_net.Addr) string {
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*insp_net.IPNet); ok && isUsableIP(ipNet.IP) {
+			return ipNet.String()
 		}
-		serial_info = append(serial_info, newSerial)
 	}
+	return ""
+}
+
+// netInterface is a network interface of the device and its addresses.
+//   - Iface: Network interface.
+//   - Addrs: Addresses of the network interface.
+type netInterface struct {
+	Iface insp_net.Interface
+	Addrs []insp_net.Addr
+}
 
-	return serial_info
+// netAddress is a network interface selected by filterInterfaces.
+//   - Iface: Network interface.
+//   - Address: IPv4 address of the interface with prefix length.
+//   - IsVpn: true if the interface is a VPN interface.
+type netAddress struct {
+	Iface   insp_net.Interface
+	Address string
+	IsVpn   bool
+}
+
+// filterInterfaces function selects the network interfaces reported as the private and VPN
+// addresses of the device. The following interfaces are skipped:
+//   - Docker interfaces.
+//   - Interfaces without a usable IPv4 address. (See isUsableIP)
+//   - Interfaces without a hardware address (virtual/tunnel interfaces), except VPN interfaces.
+//     They are kept if there is no other interface.
+//
+// Input:
+//   - ifaces: Network interfaces of the device.
+//
+// Output:
+//   - []netAddress: Selected interfaces in the order of ifaces.
+func filterInterfaces(ifaces []netInterface) []netAddress {
+	var candidates []netAddress
+	hasPhysical := false
+	for _, inter := range ifaces {
+		if strings.Contains(inter.Iface.Name, "docker") {
+			continue
+		}
+		address := getIPv4Addr(inter.Addrs)
+		if address == "" {
+			continue
+		}
+		isVpn := IsVpnInterface(inter.Iface.Name)
+		if isVpn || len(inter.Iface.HardwareAddr) > 0 {
+			hasPhysical = true
+		}
+		candidates = append(candidates, netAddress{Iface: inter.Iface, Address: address, IsVpn: isVpn})
+	}
+	if !hasPhysical {
+		return candidates
+	}
+
+	var selected []netAddress
+	for _, candidate := range candidates {
+		if !candidate.IsVpn && len(candidate.Iface.HardwareAddr) == 0 {
+			continue
+		}
+		selected = append(selected, candidate)
+	}
+	return selected
 }
 
 // GetNetwork function collects the network information from the device. The collected information includes:
@@ -387,6 +1059,9 @@ func GetSerial(archType string) []sdtType.SerialInfo {
 //   - Network MTU
 //   - Network hardware address
 //
+// Only the interfaces selected by filterInterfaces are reported, so loopback and link-local
+// addresses are not included in the private IP.
+//
 // Output:
 //   - NetInfo = {"Index": index, "Name": name, "Address": IP, "Mtu": MTU, "HardwareAddr": hardware address, "Time": collection time}
 func GetNetwork(archType string) (map[string]interface{}, []sdtType.NetInfo, string, string) {
@@ -416,41 +1091,23 @@ func GetNetwork(archType string) (map[string]interface{}, []sdtType.NetInfo, str
 		os.Exit(1)
 	}
 
-	net_info := make([]sdtType.NetInfo, 0)
-	var net_addrs string
-	inNet := ""
-	outNet := ""
-
-	var ipIndex int
-	if archType == "win" {
-		ipIndex = 1
-	} else {
-		ipIndex = 0
-	}
-
+	ifaces := make([]netInterface, 0, len(insp_netw))
 	for _, inter := range insp_netw {
 		addrs, _ := inter.Addrs()
-		// fmt.Printf("[TEST] %s: %s\n", inter.Name, addrs)
-		if strings.Contains(inter.Name, "docker") {
-			continue
-		}
-		if len(addrs) < 2 {
-			continue
-		} else if len(addrs) == 0 {
-			// net_addrs = "notFound"
-			continue
-		} else if strings.Contains(addrs[ipIndex].String(), ":") {
-			continue
-			// net_addrs = "notFound"
-		}
+		ifaces = append(ifaces, netInterface{Iface: inter, Addrs: addrs})
+	}
 
+	net_info := make([]sdtType.NetInfo, 0)
+	inNet := ""
+	outNet := ""
+	for _, selected := range filterInterfaces(ifaces) {
+		inter := selected.Iface
 		// -------------------------device network interface!!
-		net_addrs = addrs[ipIndex].String()
-		// fmt.Printf("[TEST] %s: %s\n", inter.Name, net_addrs)
-		if strings.Contains(inter.Name, "ham") || strings.Contains(inter.Name, "ztt") || strings.Contains(strings.ToLower(inter.Name), "zerotier") {
-			outNet = outNet + fmt.Sprintf("/ %s: %s  ", inter.Name, net_addrs)
+		// fmt.Printf("[TEST] %s: %s\n", inter.Name, selected.Address)
+		if selected.IsVpn {
+			outNet = outNet + fmt.Sprintf("/ %s: %s  ", inter.Name, selected.Address)
 		} else {
-			inNet = inNet + fmt.Sprintf("/ %s: %s  ", inter.Name, net_addrs)
+			inNet = inNet + fmt.Sprintf("/ %s: %s  ", inter.Name, selected.Address)
 		}
 
 		if len(outNet) > 250 || len(inNet) > 250 {
@@ -463,7 +1120,7 @@ func GetNetwork(archType string) (map[string]interface{}, []sdtType.NetInfo, str
 			Name:         inter.Name,
 			HardwareAddr: inter.HardwareAddr,
 			Mtu:          inter.MTU,
-			Address:      net_addrs,
+			Address:      selected.Address,
 			Time:         time.Now(),
 		}
 		net_info = append(net_info, insp_newNet)
@@ -515,73 +1172,130 @@ func GetPort() []map[string]interface{} {
 	return portInfo
 }
 
-// GetGPU function
+// coralUSBIds are the USB IDs (VID:PID) of Google Coral in lsusb output. The USB Accelerator
+// has 1a6e:089a until its firmware is loaded, and 18d1:9302 after that. Other devices of
+// Google (VID 18d1), such as Android phones, are not TPUs.
+var coralUSBIds = map[string]string{
+	"1a6e:089a": "Coral USB Accelerator",
+	"18d1:9302": "Coral Accelerator",
+}
+
+// coralTPUName function returns the name of Google Coral in a line of lsusb output.
+// (ex. "Bus 002 Device 003: ID 18d1:9302 Google Inc.")
+//
+// Input:
+//   - line: A line of lsusb output.
 //
 // Output:
-//   - []map[string]interface{}: GPU information.
-func GetGPU() ([]map[string]interface{}, []map[string]interface{}) {
-	var out, gpuOut bytes.Buffer
-	var gpuInfo, gpuMeta []map[string]interface{}
-
-	// Get list of gpus
-	//cmd := exec.Command("nvidia-smi", "--query-gpu=index,name,utilization.gpu,memory.total,memory.used,temperature.gpu", "--format=csv,noheader,nounits")
-	cmd := exec.Command("nvidia-smi", "-L")
-	cmd.Stdout = &out
-	err := cmd.Run()
-	if err != nil {
-		procLog.Warn.Printf("[INFO] This device not have gpu device or not found nvidia-smi cmd.\n%v\n", err)
-		return nil, nil
+//   - string: Name of the Coral accelerator. (Empty string if the device is not Coral)
+func coralTPUName(line string) string {
+	fields := strings.Fields(line)
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "ID" {
+			return coralUSBIds[strings.ToLower(fields[i+1])]
+		}
 	}
+	return ""
+}
 
-	// Parsing for output
-	output := out.String()
-	lines := strings.Split(output, "\n")
-	lines = lines[:len(lines)-1] // 마지막 값은 필요 없는 요소이므로 삭제(\n 이후로 빈값이 있음)
+// GetTPU function retrieves the TPU/NPU accelerators of the device. Google Coral
+// is found in lsusb output (1a6e:089a, 18d1:9302) and Intel Movidius (NCS2) is found with
+// OpenVINO's benchmark_app if the tool is present.
+//
+// Output:
+//   - []map[string]interface{}: TPU/NPU information.
+func GetTPU() []map[string]interface{} {
+	var tpuInfo []map[string]interface{}
+	tpuIndex := 0
 
-	for n, _ := range lines {
-		gpuOut.Reset() // 값 초기화
-		gpuCmd := exec.Command("nvidia-smi", "-i", fmt.Sprintf("%d", n), "--query-gpu=index,name,utilization.gpu,memory.total,memory.used,temperature.gpu,fan.speed", "--format=csv,noheader,nounits")
-		//procLog.Warn.Printf("[DEBUG] CMD: %s\n", gpuCmd)
-		gpuCmd.Stdout = &gpuOut
-		err = gpuCmd.Run()
+	// Google Coral
+	lsusbOut, err := exec.Command("lsusb").Output()
+	if err != nil {
+		procLog.Info.Printf("[HEALTH] Not found lsusb cmd.\n%v\n", err)
+	} else {
+		for _, line := range strings.Split(string(lsusbOut), "\n") {
+			tpuName := coralTPUName(line)
+			if tpuName == "" {
+				continue
+			}
+			tpuData := map[string]interface{}{
+				"vendor": "Google",
+				"name":   tpuName,
+				"type":   "tpu",
+				"index":  fmt.Sprintf("%d", tpuIndex),
+			}
+			tpuInfo = append(tpuInfo, tpuData)
+			tpuIndex++
+		}
+	}
 
+	// Intel Movidius (NCS2)
+	if _, err := exec.LookPath("benchmark_app"); err == nil {
+		benchOut, err := exec.Command("benchmark_app", "--list_devices").CombinedOutput()
 		if err != nil {
-			procLog.Warn.Printf("[WARN] Not found %d's GPU[%d].\n%v\n", n+1, n, err)
-			continue
+			procLog.Warn.Printf("[WARN] Failed get device list of benchmark_app.\n%v\n", err)
 		}
-		// Convert to string
-		gpuOutput := gpuOut.String()
-
-		// 공백 제거
-		if strings.TrimSpace(gpuOutput) == "" {
-			continue
+		for _, field := range strings.Fields(string(benchOut)) {
+			if !strings.HasPrefix(field, "MYRIAD") {
+				continue
+			}
+			tpuData := map[string]interface{}{
+				"vendor": "Intel",
+				"name":   fmt.Sprintf("Movidius Neural Compute Stick 2 (%s)", strings.Trim(field, ",[]'")),
+				"type":   "npu",
+				"index":  fmt.Sprintf("%d", tpuIndex),
+			}
+			tpuInfo = append(tpuInfo, tpuData)
+			tpuIndex++
 		}
-		// 뒤에 \n 제거
-		gpuOutput = strings.Split(gpuOutput, "\n")[0]
-		//procLog.Warn.Printf("[DEBUG] %d's DATA: %s\n", n, gpuOutput)
+	}
 
-		fields := strings.Split(gpuOutput, ", ")
+	return tpuInfo
+}
+
+// GetGPU function retrieves the GPU and TPU/NPU accelerators of the device. The GPU
+// backends are probed in the order NVIDIA (nvidia-smi) -> AMD (rocm-smi) -> Intel
+// (/sys/class/drm), and the first backend that finds a GPU is used.
+//
+// Output:
+//   - []map[string]interface{}: GPU information.
+//   - []map[string]interface{}: GPU metadata.
+//   - []map[string]interface{}: TPU/NPU information.
+func GetGPU() ([]map[string]interface{}, []map[string]interface{}, []map[string]interface{}) {
+	var gpuInfo, gpuMeta []map[string]interface{}
+
+	tpuInfo := GetTPU()
+
+	gpus, err := sdtGpu.Detect()
+	if err != nil {
+		procLog.Warn.Printf("[INFO] This device not have gpu device or not found gpu tools (nvidia-smi, rocm-smi, /sys/class/drm).\n%v\n", err)
+		return nil, nil, tpuInfo
+	}
+
+	for _, gpu := range gpus {
 		// Get GPU Data
 		gpuData := map[string]interface{}{
-			"index":    fields[0],
-			"name":     fields[1],
-			"util":     fields[2],
-			"totalMem": fields[3],
-			"usedMem":  fields[4],
-			"temp":     fields[5],
-			"fanSpeed": fields[6],
+			"vendor":   gpu.Vendor,
+			"index":    gpu.Index,
+			"name":     gpu.Name,
+			"util":     gpu.Util,
+			"totalMem": gpu.TotalMem,
+			"usedMem":  gpu.UsedMem,
+			"temp":     gpu.Temp,
+			"fanSpeed": gpu.FanSpeed,
 		}
 		gpuInfo = append(gpuInfo, gpuData)
 
 		// Get GPU Metadata
 		gpuData = map[string]interface{}{
-			"index": fields[0],
-			"name":  fields[1],
+			"vendor": gpu.Vendor,
+			"index":  gpu.Index,
+			"name":   gpu.Name,
 		}
 		gpuMeta = append(gpuMeta, gpuData)
 	}
 
-	return gpuInfo, gpuMeta
+	return gpuInfo, gpuMeta, tpuInfo
 }
 
 // CheckNetwork function checks the network information of the device. If the network
@@ -631,7 +1345,7 @@ func SendNetInfo(assetCode string, input map[string]interface{}, bwUrl string) {
 	defer resp.Body.Close()
 
 	//request에 대한 응답
-	_, err = ioutil.ReadAll(resp.Body)
+	_, err = io.ReadAll(resp.Body)
 	// fmt.Println("[INFO]: 1-4. Send H/W information: ", resp.Status)
 	statusArr := strings.Split(resp.Status, " ")
 	statusValue, _ := strconv.Atoi(statusArr[0])
@@ -642,17 +1356,157 @@ func SendNetInfo(assetCode string, input map[string]interface{}, bwUrl string) {
 
 }
 
+// RunCustomMetrics function runs the executable scripts in the script directory and collects
+// their JSON output. Scripts are run in parallel and each script is killed after the timeout.
+// The output of each script is stored under the script's file name. A script that fails
+// or does not print a JSON object is stored as {"error": "<msg>"}.
+//
+//	/etc/sdt/health-scripts/sensor.sh   ->   {"sensor.sh": {"humidity": 41.2}}
+//
+// Input:
+//   - scriptDir: Directory of the custom metric scripts.
+//   - timeout: Timeout of each script.
+//
+// Output:
+//   - map[string]interface{}: Output of the scripts. (nil if there is no script.)
+func RunCustomMetrics(scriptDir string, timeout time.Duration) map[string]interface{} {
+	files, err := os.ReadDir(scriptDir)
+	if err != nil {
+		// The directory is optional.
+		return nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	customData := make(map[string]interface{})
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			var result map[string]interface{}
+			stdout, err := exec.CommandContext(ctx, filepath.Join(scriptDir, name)).Output()
+			if ctx.Err() == context.DeadlineExceeded {
+				err = fmt.Errorf("timeout after %v", timeout)
+			} else if err == nil {
+				err = json.Unmarshal(stdout, &result)
+			}
+			if err != nil {
+				procLog.Warn.Printf("[CUSTOM] Failed custom metric script %s: %v\n", name, err)
+				result = map[string]interface{}{"error": err.Error()}
+			}
+
+			mu.Lock()
+			customData[name] = result
+			mu.Unlock()
+		}(file.Name())
+	}
+	wg.Wait()
+
+	if len(customData) == 0 {
+		return nil
+	}
+	return customData
+}
+
+// Range of healthIntervalSec in config.json. (seconds)
+const (
+	defaultHealthInterval = 5
+	minHealthInterval     = 1
+	maxHealthInterval     = 300
+)
+
+// GetHealthInterval function returns the health publishing interval from healthIntervalSec of config.json.
+// If the value is not set, 5 seconds is used. If it is out of range [1, 300], a warning is logged and 5 seconds is used.
+//
+// Input:
+//   - sec: healthIntervalSec of config.json.
+//
+// Output:
+//   - time.Duration: Health publishing interval.
+func GetHealthInterval(sec int) time.Duration {
+	if sec == 0 {
+		return defaultHealthInterval * time.Second
+	}
+	if sec < minHealthInterval || sec > maxHealthInterval {
+		procLog.Warn.Printf("[HEALTH] healthIntervalSec %d is out of range [%d, %d]. Use %d sec.\n", sec, minHealthInterval, maxHealthInterval, defaultHealthInterval)
+		return defaultHealthInterval * time.Second
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// reloadConfig function reads config.json again when SIGHUP is received and applies
+// the health interval and CPU temperature threshold. If the file cannot be read, the current interval is kept.
+//
+// Input:
+//   - jsonFilePath: Path to config.json.
+//   - interval: Current health publishing interval.
+//
+// Output:
+//   - time.Duration: Health publishing interval to use.
+func reloadConfig(jsonFilePath string, interval time.Duration) time.Duration {
+	var configData sdtType.ConfigInfo
+
+	jsonFile, err := os.ReadFile(jsonFilePath)
+	if err != nil {
+		procLog.Error.Printf("[HEALTH] Reload config Error: %v\n", err)
+		return interval
+	}
+	if err = json.Unmarshal(jsonFile, &configData); err != nil {
+		procLog.Error.Printf("[HEALTH] Reload config Unmarshal Error: %v\n", err)
+		return interval
+	}
+
+	if configData.CpuTempWarn > 0 {
+		cpuTempWarn = configData.CpuTempWarn
+	}
+	newInterval := GetHealthInterval(configData.HealthIntervalSec)
+	if newInterval != interval {
+		procLog.Info.Printf("[HEALTH] Health interval is changed: %v -> %v\n", interval, newInterval)
+	}
+	return newInterval
+}
+
 // Main function of the health package. Depending on the SDT Cloud service type of the device,
 // this function selects an MQTT broker and publishes messages.
 // The message is defined as follows:
 //
 //	Payload = {"timestamp": 1858182312, "data": {"cpu": ~~, "memory": ~~, "disk": ~, "network": ~~, "port": ~~}}
 //
+// The output of the executable scripts in '<rootPath>/health-scripts' is added to the data as "custom".
+//
 // Input:
 //   - mqttType: SDTCloud service type of the device.
 //   - archType: Architecture of the device.
 //   - rootPath: Root path of SDTCloud stored on the device.
-func RunBody(mqttType string, archType string, rootPath string) {
+//   - noMqtt: Option to write messages to stdout without connecting MQTT. (For debugging)
+//   - healthAddr: Listen address of the health endpoint.
+//   - healthPort: Port of the health endpoint(/healthz). (0 is disabled)
+//   - healthTimeout: Time since the last publish after which /healthz returns 503. (At least twice the interval)
+//   - metricsPort: Port of the Prometheus metrics endpoint(/metrics). (0 is disabled)
+func RunBody(mqttType string, archType string, rootPath string, noMqtt bool, healthAddr string, healthPort int, healthTimeout time.Duration, metricsPort int) {
+	// MQTT credentials can be overridden by environment variables.
+	if user := os.Getenv("MQTT_USER"); user != "" {
+		mqttUser = user
+	}
+	if password := os.Getenv("MQTT_PASSWORD"); password != "" {
+		mqttPassword = password
+	}
+
+	if healthPort != 0 {
+		go sdtHealthz.RunServer(healthAddr, healthPort, HealthTimeout(healthTimeout), LastPublishAt)
+	}
+	if metricsPort != 0 {
+		go sdtMetrics.RunServer(metricsPort)
+	}
+
 	var configData sdtType.ConfigInfo
 	curNetInter := map[string]interface{}{
 		"privateIP": "",
@@ -661,7 +1515,7 @@ func RunBody(mqttType string, archType string, rootPath string) {
 
 	jsonFilePath := fmt.Sprintf("%s/device.config/config.json", rootPath)
 	procLog.Info.Printf("[HEALTH] read config file: %s \n", jsonFilePath)
-	jsonFile, err := ioutil.ReadFile(jsonFilePath)
+	jsonFile, err := os.ReadFile(jsonFilePath)
 	if err != nil {
 		procLog.Error.Printf("[HEALTH] Not found file Error: %v\n", err)
 	}
@@ -671,6 +1525,12 @@ func RunBody(mqttType string, archType string, rootPath string) {
 		procLog.Error.Printf("[HEALTH] Unmarshal Error: %v\n", err)
 	}
 
+	if configData.CpuTempWarn > 0 {
+		cpuTempWarn = configData.CpuTempWarn
+	}
+	interval := GetHealthInterval(configData.HealthIntervalSec)
+	healthInterval.Store(int64(interval))
+
 	// Set apiurl
 	var bwUrl string
 	if configData.ServiceType == "eks" {
@@ -697,40 +1557,74 @@ func RunBody(mqttType string, archType string, rootPath string) {
 	fullCertChain := fmt.Sprintf("%s/cert/%s-certificate.pem", rootPath, configData.ProjectCode)
 
 	// Set Mqtt
-	if mqttType == "onprem" {
-		// Set mqtt client - EC2
-		cli = connectToMqtt(configData)
-	} else if mqttType == "aws-dev" || mqttType == "eks" || mqttType == "dev" {
-		// Set MQTT - AWS IoT Core
-		opts := createAwsClientOptions(configData.MqttUrl, rootCa, fullCertChain, private, configData.AssetCode)
-		cli = mqttCli.NewClient(opts)
+	if noMqtt {
+		procLog.Info.Printf("[MQTT] --no-mqtt is set. Health data is written to stdout.\n")
 	} else {
-		err = errors.New("Please input mqtt variable.")
-		procLog.Error.Printf("[MAIN] mqtt command Error: %v\n", err)
-		panic(err)
-	}
+		if mqttType == "onprem" {
+			// Set mqtt client - EC2
+			cli = connectToMqtt(configData, mqttUser, mqttPassword)
+		} else if mqttType == "aws-dev" || mqttType == "eks" || mqttType == "dev" {
+			// Set MQTT - AWS IoT Core
+			opts := createAwsClientOptions(configData.MqttUrl, rootCa, fullCertChain, private, configData.AssetCode)
+			cli = mqttCli.NewClient(opts)
+		} else if mqttType == "exmq" {
+			// Set MQTT - EXMQ
+			opts := createExmqClientOptions(configData)
+			cli = mqttCli.NewClient(opts)
+		} else {
+			err = errors.New("Please input mqtt variable.")
+			procLog.Error.Printf("[MAIN] mqtt command Error: %v\n", err)
+			panic(err)
+		}
 
-	if token := cli.Connect(); token.Wait() && token.Error() != nil {
-		log.Fatalf("Failed to connect to MQTT broker: %v", token.Error())
-	}
+		if token := cli.Connect(); token.Wait() && token.Error() != nil {
+			log.Fatalf("Failed to connect to MQTT broker: %v", token.Error())
+		}
 
-	defer cli.Disconnect(250)
+		defer cli.Disconnect(250)
+	}
 
-	// Setting 5 sec
+	// Align the first tick to the interval. (Up to 1 minute)
+	alignSec := int(interval / time.Second)
+	if alignSec > 60 {
+		alignSec = 60
+	}
 	for {
 		curSec := time.Now().Second()
-		if curSec%5 == 0 {
+		if curSec%alignSec == 0 {
 			//time.Sleep(1 * time.Second)
 			break
 		}
 	}
 
-	// when execute 5sec...
-	delayTime := time.NewTicker(5 * time.Second)
+	// Disk alert runs faster than the health tick.
+	go MonitorDiskUsage(archType, configData, noMqtt)
+
+	// first-run.flag is created by agent-init after the device is registered.
+	firstRunFlag := fmt.Sprintf("%s/first-run.flag", rootPath)
+	_, err = os.Stat(firstRunFlag)
+	firstRun := err == nil
+
+	// when execute every interval...
+	delayTime := time.NewTicker(interval)
 	defer delayTime.Stop()
 
+	// SIGHUP reloads config.json and restarts the ticker with the new interval.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
 	for true {
-		curTime := <-delayTime.C
+		var curTime time.Time
+		select {
+		case curTime = <-delayTime.C:
+		case <-hup:
+			procLog.Info.Printf("[HEALTH] SIGHUP received. Reload %s\n", jsonFilePath)
+			interval = reloadConfig(jsonFilePath, interval)
+			healthInterval.Store(int64(interval))
+			delayTime.Reset(interval)
+			continue
+		}
 
 		//node CPU
 		nodecpu_info, inspectorCpu := GetCpu()
@@ -747,7 +1641,11 @@ func RunBody(mqttType string, archType string, rootPath string) {
 		//port info
 		port_info := GetPort()
 		//gpu info
-		gpuInfo, gpuMeta := GetGPU()
+		gpuInfo, gpuMeta, tpuInfo := GetGPU()
+		//thermal info
+		throttled, tempC := GetThermalThrottling(archType)
+		inspectorCpu[0].Throttled = throttled
+		inspectorCpu[0].TempC = tempC
 
 		healthData := map[string]interface{}{
 			"cpu":     nodecpu_info,
@@ -756,6 +1654,15 @@ func RunBody(mqttType string, archType string, rootPath string) {
 			"network": net_info,
 			"port":    port_info,
 			"gpu":     gpuInfo,
+			"tpu":     tpuInfo,
+			"thermal": map[string]interface{}{
+				"throttled": throttled,
+				"tempC":     tempC,
+			},
+		}
+		//custom metrics
+		if customData := RunCustomMetrics(fmt.Sprintf("%s/health-scripts", rootPath), 2*time.Second); customData != nil {
+			healthData["custom"] = customData
 		}
 
 		netInter := map[string]interface{}{
@@ -768,6 +1675,7 @@ func RunBody(mqttType string, archType string, rootPath string) {
 			hwMsg := map[string]interface{}{
 				"network": netInter,
 				"gpu":     gpuMeta,
+				"tpu":     tpuInfo,
 			}
 
 			SendNetInfo(configData.AssetCode, hwMsg, bwUrl)
@@ -781,9 +1689,38 @@ func RunBody(mqttType string, archType string, rootPath string) {
 			"timestamp": int64(curTime.UTC().Unix() * 1000),
 			"data":      healthData,
 		}
-		fmt.Printf("Time: %v / %d\n", curTime, int64(curTime.UTC().Unix()*1000))
+		if !noMqtt {
+			fmt.Printf("Time: %v / %d\n", curTime, int64(curTime.UTC().Unix()*1000))
+		}
 		// fmt.Println(msg)
-		sendDataEdgeMqtt(msg, configData)
+		if metricsPort != 0 {
+			sdtMetrics.Update(healthData)
+		}
+		sendDataEdgeMqtt(msg, configData, noMqtt)
+
+		if firstRun {
+			firstRunMsg := map[string]interface{}{
+				"timestamp": int64(curTime.UTC().Unix() * 1000),
+				"data": map[string]interface{}{
+					"os":      GetOSInfo(),
+					"cpu":     nodecpu_info,
+					"memory":  nodemem_info,
+					"disk":    disk_info,
+					"gpu":     gpuMeta,
+					"tpu":     tpuInfo,
+					"network": netInter,
+				},
+			}
+			if err := sendEventMqtt("bwc/first-run", firstRunMsg, configData, noMqtt); err != nil {
+				procLog.Error.Printf("[HEALTH] First-run message error: %v\n", err)
+			} else {
+				procLog.Info.Printf("[HEALTH] Sent first-run message.\n")
+				if err := os.Remove(firstRunFlag); err != nil {
+					procLog.Warn.Printf("[HEALTH] Cannot remove %s: %v\n", firstRunFlag, err)
+				}
+				firstRun = false
+			}
+		}
 
 		// Save Inspector File
 		all_data := map[string]interface{}{
@@ -809,12 +1746,13 @@ func RunBody(mqttType string, archType string, rootPath string) {
 		} else {
 			dir = fmt.Sprintf("/etc/sdt/inspector")
 		}
-		if _, err := os.Stat(dir); os.IsNotExist(err) {
-			os.Mkdir(dir, os.ModePerm)
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			procLog.Error.Printf("[HEALTH] Cannot create inspector directory %s: %v\n", dir, err)
+			panic(err)
 		}
 
 		inspectorFile := fmt.Sprintf("%s/data.json", dir)
-		err = ioutil.WriteFile(inspectorFile, data_string, os.FileMode(0644))
+		err = os.WriteFile(inspectorFile, data_string, os.FileMode(0644))
 		if err != nil {
 			procLog.Error.Printf("[HEALTH] Wriet Error: %v\n", err)
 			panic(err)
@@ -844,6 +1782,8 @@ func RunBodyForInspector(archType string) {
 		inspectorSerial := GetSerial(archType)
 		//network info
 		_, inspectorNet, _, _ := GetNetwork(archType)
+		//thermal info
+		inspectorCpu[0].Throttled, inspectorCpu[0].TempC = GetThermalThrottling(archType)
 
 		// Save Inspector File
 		all_data := map[string]interface{}{
@@ -869,12 +1809,13 @@ func RunBodyForInspector(archType string) {
 		} else {
 			dir = fmt.Sprintf("/etc/sdt/inspector")
 		}
-		if _, err := os.Stat(dir); os.IsNotExist(err) {
-			os.Mkdir(dir, os.ModePerm)
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			procLog.Error.Printf("[HEALTH] Cannot create inspector directory %s: %v\n", dir, err)
+			panic(err)
 		}
 
 		inspectorFile := fmt.Sprintf("%s/data.json", dir)
-		err = ioutil.WriteFile(inspectorFile, data_string, os.FileMode(0644))
+		err = os.WriteFile(inspectorFile, data_string, os.FileMode(0644))
 		if err != nil {
 			procLog.Error.Printf("[HEALTH] Wriet Error: %v\n", err)
 			panic(err)
@@ -0,0 +1,486 @@
+package health
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"math"
+	insp_net "net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/host"
+
+	sdtType "main/src/healthType"
+	sdtLog "pkg/log"
+)
+
+func TestCalcIORate(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	first := diskIOSample{readBytes: 1000, writeBytes: 5000, time: start}
+
+	tests := []struct {
+		name      string
+		prev      diskIOSample
+		cur       diskIOSample
+		wantRead  float64
+		wantWrite float64
+	}{
+		{"first sample", diskIOSample{}, first, 0, 0},
+		{"two seconds", first, diskIOSample{readBytes: 3000, writeBytes: 5000 + 4096, time: start.Add(2 * time.Second)}, 1000, 2048},
+		{"half second", first, diskIOSample{readBytes: 1500, writeBytes: 5000, time: start.Add(500 * time.Millisecond)}, 1000, 0},
+		{"counter reset", first, diskIOSample{readBytes: 10, writeBytes: 10, time: start.Add(time.Second)}, 0, 0},
+		{"same time", first, first, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			read, write := calcIORate(tt.prev, tt.cur)
+			if math.Abs(read-tt.wantRead) > 1e-9 || math.Abs(write-tt.wantWrite) > 1e-9 {
+				t.Errorf("calcIORate() = %v, %v, want %v, %v", read, write, tt.wantRead, tt.wantWrite)
+			}
+		})
+	}
+}
+
+func TestCalcIORateSuccessiveSamples(t *testing.T) {
+	// Samples of two successive GetDisk calls, saved in diskIOPrev like GetDisk does.
+	start := time.Now()
+	samples := []diskIOSample{
+		{readBytes: 0, writeBytes: 0, time: start},
+		{readBytes: 10 * 1024 * 1024, writeBytes: 1024 * 1024, time: start.Add(5 * time.Second)},
+	}
+	prevSamples := map[string]diskIOSample{}
+	var read, write float64
+	for _, sample := range samples {
+		read, write = calcIORate(prevSamples["sda1"], sample)
+		prevSamples["sda1"] = sample
+	}
+	if read != 2*1024*1024 || write != 1024*1024/5.0 {
+		t.Errorf("rate = %v, %v, want 2 MiB/s and 200 KiB/s", read, write)
+	}
+}
+
+// fakeSensors replaces sensorsTemperatures with the given sensors.
+func fakeSensors(t *testing.T, temps []host.TemperatureStat, err error) {
+	t.Helper()
+	sensorsTemperatures = func() ([]host.TemperatureStat, error) { return temps, err }
+	t.Cleanup(func() { sensorsTemperatures = host.SensorsTemperatures })
+}
+
+func TestGetCpuTemp(t *testing.T) {
+	tests := []struct {
+		name      string
+		temps     []host.TemperatureStat
+		err       error
+		wantTemp  float64
+		wantFound bool
+	}{
+		{"no sensor", nil, nil, 0, false},
+		{"intel cores", []host.TemperatureStat{
+			{SensorKey: "coretemp_core_0", Temperature: 52},
+			{SensorKey: "coretemp_package_id_0", Temperature: 61.5},
+			{SensorKey: "nvme_composite", Temperature: 70},
+		}, nil, 61.5, true},
+		{"raspberry pi", []host.TemperatureStat{{SensorKey: "cpu_thermal_input", Temperature: 48.3}}, nil, 48.3, true},
+		{"only other sensors", []host.TemperatureStat{
+			{SensorKey: "acpitz_input", Temperature: 40},
+			{SensorKey: "nvme_composite", Temperature: 45},
+		}, nil, 0, false},
+		{"zero reading", []host.TemperatureStat{{SensorKey: "k10temp_tctl", Temperature: 0}}, nil, 0, false},
+		{"partial read error", []host.TemperatureStat{{SensorKey: "k10temp_tctl", Temperature: 75}}, errors.New("permission denied"), 75, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeSensors(t, tt.temps, tt.err)
+			temp, found := GetCpuTemp()
+			if temp != tt.wantTemp || found != tt.wantFound {
+				t.Errorf("GetCpuTemp() = %v, %v, want %v, %v", temp, found, tt.wantTemp, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestCheckCpuTemp(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(&buf, "", 0)
+	procLog = sdtType.Logger{Info: logger, Warn: logger, Error: logger}
+	defer func() { procLog = sdtType.Logger(sdtLog.NewDiscardLogger()) }()
+	cpuTempWarn, cpuTempHot = 85, false
+	defer func() { cpuTempWarn, cpuTempHot = 85, false }()
+
+	// The warning is logged once while the CPU is hot, and again after it cools down.
+	for _, temp := range []float64{70, 86, 90, 84, 88} {
+		checkCpuTemp(temp)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 ||
+		!strings.Contains(lines[0], "86.0°C is over 85.0°C") ||
+		!strings.Contains(lines[1], "84.0°C is back under 85.0°C") ||
+		!strings.Contains(lines[2], "88.0°C is over 85.0°C") {
+		t.Errorf("logs = %q", lines)
+	}
+}
+
+func TestGetHealthInterval(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	tests := []struct {
+		sec  int
+		want time.Duration
+	}{
+		{0, 5 * time.Second},
+		{1, time.Second},
+		{300, 300 * time.Second},
+		{-5, 5 * time.Second},
+		{301, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := GetHealthInterval(tt.sec); got != tt.want {
+			t.Errorf("GetHealthInterval(%d) = %v, want %v", tt.sec, got, tt.want)
+		}
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	defer func() { cpuTempWarn = 85 }()
+	configFile := filepath.Join(t.TempDir(), "config.json")
+
+	if got := reloadConfig(configFile, 5*time.Second); got != 5*time.Second {
+		t.Errorf("reloadConfig() without config = %v, want the current interval", got)
+	}
+	os.WriteFile(configFile, []byte(`{"healthIntervalSec": 20, "cputempwarn": 75}`), 0644)
+	if got := reloadConfig(configFile, 5*time.Second); got != 20*time.Second || cpuTempWarn != 75 {
+		t.Errorf("reloadConfig() = %v, cpuTempWarn = %v, want 20s and 75", got, cpuTempWarn)
+	}
+}
+
+func TestHealthTimeout(t *testing.T) {
+	defer healthInterval.Store(0)
+	timeout := HealthTimeout(30 * time.Second)
+
+	healthInterval.Store(int64(5 * time.Second))
+	if got := timeout(); got != 30*time.Second {
+		t.Errorf("timeout with 5s interval = %v, want 30s", got)
+	}
+	// The interval is changed by SIGHUP.
+	healthInterval.Store(int64(time.Minute))
+	if got := timeout(); got != 2*time.Minute {
+		t.Errorf("timeout with 1m interval = %v, want 2m", got)
+	}
+}
+
+func mustIPNet(t *testing.T, cidr string) *insp_net.IPNet {
+	t.Helper()
+	ip, ipNet, err := insp_net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatalf("ParseCIDR(%q): %v", cidr, err)
+	}
+	ipNet.IP = ip
+	return ipNet
+}
+
+func mockInterface(t *testing.T, name string, hasHardwareAddr bool, cidrs ...string) netInterface {
+	t.Helper()
+	iface := insp_net.Interface{Name: name, MTU: 1500}
+	if hasHardwareAddr {
+		iface.HardwareAddr = insp_net.HardwareAddr{0x02, 0x42, 0xac, 0x11, 0x00, 0x02}
+	}
+	addrs := make([]insp_net.Addr, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		addrs = append(addrs, mustIPNet(t, cidr))
+	}
+	return netInterface{Iface: iface, Addrs: addrs}
+}
+
+func TestIsUsableIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"192.168.0.10", true},
+		{"10.0.0.1", true},
+		{"127.0.0.1", false},
+		{"127.1.2.3", false},
+		{"169.254.10.20", false},
+		{"224.0.0.251", false},
+		{"0.0.0.0", false},
+		{"fe80::1", false},
+		{"2001:db8::1", false},
+	}
+	for _, tt := range tests {
+		if got := isUsableIP(insp_net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isUsableIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestFilterInterfaces(t *testing.T) {
+	type result struct {
+		name    string
+		address string
+		isVpn   bool
+	}
+	tests := []struct {
+		name   string
+		ifaces []netInterface
+		want   []result
+	}{
+		{
+			name: "loopback, link-local and multicast are skipped",
+			ifaces: []netInterface{
+				mockInterface(t, "lo", false, "127.0.0.1/8", "::1/128"),
+				mockInterface(t, "eth0", true, "fe80::42:acff:fe11:2/64", "169.254.3.4/16", "192.168.0.10/24"),
+				mockInterface(t, "eth1", true, "224.0.0.251/32"),
+			},
+			want: []result{{"eth0", "192.168.0.10/24", false}},
+		},
+		{
+			name: "docker interfaces are skipped",
+			ifaces: []netInterface{
+				mockInterface(t, "docker0", true, "172.17.0.1/16"),
+				mockInterface(t, "wlan0", true, "10.0.0.5/24"),
+			},
+			want: []result{{"wlan0", "10.0.0.5/24", false}},
+		},
+		{
+			name: "virtual interfaces without hardware address are skipped",
+			ifaces: []netInterface{
+				mockInterface(t, "eth0", true, "192.168.0.10/24"),
+				mockInterface(t, "veth0", false, "10.8.0.2/24"),
+				mockInterface(t, "ham0", false, "25.1.2.3/8"),
+				mockInterface(t, "zttabcdef", false, "10.147.17.5/24"),
+			},
+			want: []result{
+				{"eth0", "192.168.0.10/24", false},
+				{"ham0", "25.1.2.3/8", true},
+				{"zttabcdef", "10.147.17.5/24", true},
+			},
+		},
+		{
+			name: "vpn interface counts as an interface",
+			ifaces: []netInterface{
+				mockInterface(t, "zerotier0", false, "10.147.17.5/24"),
+				mockInterface(t, "veth1", false, "10.1.0.2/24"),
+			},
+			want: []result{{"zerotier0", "10.147.17.5/24", true}},
+		},
+		{
+			name: "virtual interface is kept if it is the only interface",
+			ifaces: []netInterface{
+				mockInterface(t, "lo", false, "127.0.0.1/8"),
+				mockInterface(t, "venet0", false, "203.0.113.7/32"),
+			},
+			want: []result{{"venet0", "203.0.113.7/32", false}},
+		},
+		{
+			name: "no usable address",
+			ifaces: []netInterface{
+				mockInterface(t, "lo", false, "127.0.0.1/8"),
+				mockInterface(t, "eth0", true, "169.254.3.4/16"),
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterInterfaces(tt.ifaces)
+			if len(got) != len(tt.want) {
+				t.Fatalf("filterInterfaces() = %+v, want %+v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if got[i].Iface.Name != want.name || got[i].Address != want.address || got[i].IsVpn != want.isVpn {
+					t.Errorf("filterInterfaces()[%d] = {%s %s %v}, want %+v", i, got[i].Iface.Name, got[i].Address, got[i].IsVpn, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSerialLine(t *testing.T) {
+	tests := []struct {
+		line       string
+		wantIndex  string
+		wantFields map[string]string
+	}{
+		{
+			line:       "0: uart:16550A port:000003F8 irq:4 tx:0 rx:0",
+			wantIndex:  "0",
+			wantFields: map[string]string{"uart": "16550A", "port": "000003F8", "irq": "4", "tx": "0", "rx": "0"},
+		},
+		{
+			line:       "1: uart:16550A port:000002F8 irq:3 tx:12 rx:34 RTS|DTR",
+			wantIndex:  "1",
+			wantFields: map[string]string{"uart": "16550A", "port": "000002F8", "irq": "3", "tx": "12", "rx": "34"},
+		},
+		{
+			line:       `0: module:ftdi_sio name:"FTDI USB Serial Device" vendor:0403 product:6001 num_ports:1 port:0 path:usb-0000:00:14.0-2`,
+			wantIndex:  "0",
+			wantFields: map[string]string{"module": "ftdi_sio", "name": "FTDI USB Serial Device", "vendor": "0403", "product": "6001", "num_ports": "1", "port": "0", "path": "usb-0000:00:14.0-2"},
+		},
+		{line: "12:", wantIndex: "12", wantFields: map[string]string{}},
+		{line: "no index", wantIndex: "", wantFields: nil},
+	}
+	for _, tt := range tests {
+		index, fields := parseSerialLine(tt.line)
+		if index != tt.wantIndex || !reflect.DeepEqual(fields, tt.wantFields) {
+			t.Errorf("parseSerialLine(%q) = %q, %v, want %q, %v", tt.line, index, fields, tt.wantIndex, tt.wantFields)
+		}
+	}
+}
+
+// clearSerialTime clears the collection time so that the serial information can be compared.
+func clearSerialTime(serialInfo []sdtType.SerialInfo) []sdtType.SerialInfo {
+	for i := range serialInfo {
+		serialInfo[i].Time = time.Time{}
+	}
+	return serialInfo
+}
+
+func TestParseSerial(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []sdtType.SerialInfo
+	}{
+		{
+			name: "x86 uarts",
+			data: "serinfo:1.0 driver revision:\n" +
+				"0: uart:16550A port:000003F8 irq:4 tx:120 rx:45 RTS|DTR\n" +
+				"1: uart:unknown port:000002F8 irq:3\n",
+			want: []sdtType.SerialInfo{
+				{Index: "0", Uart: "16550A", Port: "000003F8", Irq: 4, Tx: 120, Rx: 45},
+				{Index: "1", Uart: "unknown", Port: "000002F8", Irq: 3, Tx: -1, Rx: -1},
+			},
+		},
+		{
+			name: "header only",
+			data: "serinfo:1.0 driver revision:\n",
+			want: []sdtType.SerialInfo{},
+		},
+		{
+			name: "empty file",
+			data: "",
+			want: []sdtType.SerialInfo{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := clearSerialTime(parseSerial(strings.NewReader(tt.data)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSerial() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUsbSerial(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []sdtType.SerialInfo
+	}{
+		{
+			name: "raspberry pi usb serial",
+			data: "usbserinfo:1.0 driver:2.0\n" +
+				"0: module:pl2303 name:\"pl2303\" vendor:067b product:2303 num_ports:1 port:0 path:usb-3f980000.usb-1.3\n" +
+				"1: module:ftdi_sio name:\"FTDI USB Serial Device\" vendor:0403 product:6001 num_ports:1 port:0 path:usb-3f980000.usb-1.4\n",
+			want: []sdtType.SerialInfo{
+				{Index: "0", Uart: "pl2303", Port: "usb-3f980000.usb-1.3", Irq: -1, Tx: -1, Rx: -1},
+				{Index: "1", Uart: "FTDI USB Serial Device", Port: "usb-3f980000.usb-1.4", Irq: -1, Tx: -1, Rx: -1},
+			},
+		},
+		{
+			name: "module without name",
+			data: "usbserinfo:1.0 driver:2.0\n" +
+				"0: module:cp210x vendor:10c4 product:ea60 num_ports:1 port:0 path:usb-3f980000.usb-1.2\n",
+			want: []sdtType.SerialInfo{
+				{Index: "0", Uart: "cp210x", Port: "usb-3f980000.usb-1.2", Irq: -1, Tx: -1, Rx: -1},
+			},
+		},
+		{
+			name: "header only",
+			data: "usbserinfo:1.0 driver:2.0\n",
+			want: []sdtType.SerialInfo{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := clearSerialTime(parseUsbSerial(strings.NewReader(tt.data)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseUsbSerial() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSerial(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	origSerial, origUsbSerial := serialDriverFile, usbSerialDriverFile
+	defer func() { serialDriverFile, usbSerialDriverFile = origSerial, origUsbSerial }()
+
+	const serialData = "serinfo:1.0 driver revision:\n0: uart:16550A port:000003F8 irq:4 tx:0 rx:0\n"
+	const usbSerialData = "usbserinfo:1.0 driver:2.0\n0: module:pl2303 name:\"pl2303\" vendor:067b product:2303 num_ports:1 port:0 path:usb-1.3\n"
+	tests := []struct {
+		name     string
+		files    map[string]string
+		archType string
+		wantUart []string
+	}{
+		{name: "neither file exists", files: nil, wantUart: []string{}},
+		{name: "serial only", files: map[string]string{"serial": serialData}, wantUart: []string{"16550A"}},
+		{name: "usbserial only", files: map[string]string{"usbserial": usbSerialData}, wantUart: []string{"pl2303"}},
+		{name: "windows", files: map[string]string{"serial": serialData}, archType: "win", wantUart: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			serialDriverFile = filepath.Join(dir, "serial")
+			usbSerialDriverFile = filepath.Join(dir, "usbserial")
+			for name, data := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := GetSerial(tt.archType)
+			if tt.wantUart == nil {
+				if got != nil {
+					t.Errorf("GetSerial(%q) = %+v, want nil", tt.archType, got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("GetSerial(%q) = nil, want an empty slice", tt.archType)
+			}
+			uarts := make([]string, 0, len(got))
+			for _, serial := range got {
+				uarts = append(uarts, serial.Uart)
+			}
+			if !reflect.DeepEqual(uarts, tt.wantUart) {
+				t.Errorf("GetSerial(%q) uarts = %v, want %v", tt.archType, uarts, tt.wantUart)
+			}
+		})
+	}
+}
+
+func TestCoralTPUName(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"Bus 002 Device 003: ID 18d1:9302 Google Inc.", "Coral Accelerator"},
+		{"Bus 001 Device 004: ID 1a6e:089a Global Unichip Corp.", "Coral USB Accelerator"},
+		{"Bus 001 Device 005: ID 18d1:4ee7 Google Inc. Nexus/Pixel Device (charging + debug)", ""},
+		{"Bus 001 Device 001: ID 1d6b:0002 Linux Foundation 2.0 root hub", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := coralTPUName(tt.line); got != tt.want {
+			t.Errorf("coralTPUName(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@
 package healthType
 
 import (
+	"fmt"
 	"log"
 	insp_net "net"
 	"time"
@@ -29,33 +30,89 @@ type Logger struct {
 //   - ProjectCode: ID of the project to which the device belongs.
 //   - ServiceCode: Service code of SDT Cloud.
 //   - ServiceType: Service type of SDT Cloud.
+//   - TopicPrefix: Namespace prepended to the MQTT topics. (Empty by default)
+//   - Exmq: EXMQ broker information. (Only used if the mqtt type is exmq)
+//   - CpuTempWarn: CPU temperature (Celsius) to log a warning. (85 if not set)
+//   - HealthIntervalSec: Health publishing interval in seconds. (5 if not set, range [1, 300])
 type ConfigInfo struct {
-	AssetCode   string `json:"assetcode"`
-	MqttUrl     string `json:"mqtturl"`
-	ProjectCode string `json:"projectcode"`
-	ServiceCode string `json:"servicecode"`
-	ServiceType string `json:"servicetype"`
-	ServerIp    string `json:"serverip"`
+	AssetCode         string   `json:"assetcode"`
+	MqttUrl           string   `json:"mqtturl"`
+	ProjectCode       string   `json:"projectcode"`
+	ServiceCode       string   `json:"servicecode"`
+	ServiceType       string   `json:"servicetype"`
+	ServerIp          string   `json:"serverip"`
+	TopicPrefix       string   `json:"topicprefix,omitempty"`
+	Exmq              ExmqInfo `json:"exmq"`
+	CpuTempWarn       float64  `json:"cputempwarn,omitempty"`
+	HealthIntervalSec int      `json:"healthIntervalSec,omitempty"`
+}
+
+// ExmqInfo struct defines the EXMQ broker information under the 'exmq' section of the config file.
+//   - MqttUrl: MQTT URL of the EXMQ broker. If empty, mqtturl of the config is used.
+//   - RootCa: Path to the Root CA file of the EXMQ broker.
+//   - Cert: Path to the client certificate file.
+//   - PrivateKey: Path to the private key file of the client certificate.
+//   - Username: Username of the EXMQ broker. (Optional)
+//   - Password: Password of the EXMQ broker. (Optional)
+type ExmqInfo struct {
+	MqttUrl    string `json:"mqtturl,omitempty"`
+	RootCa     string `json:"rootca"`
+	Cert       string `json:"cert"`
+	PrivateKey string `json:"privatekey"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+}
+
+// Topic function returns the MQTT topic of the device. If TopicPrefix is set, it is prepended
+// to the topic so that multiple BWC deployments can share one MQTT broker.
+//
+//	<topicPrefix>/<serviceCode>/<projectCode>/<assetCode>/<subTopic>
+//
+// Input:
+//   - subTopic: Topic after the asset code. (ex. bwc/health)
+//
+// Output:
+//   - string: MQTT topic.
+func (c ConfigInfo) Topic(subTopic string) string {
+	topic := fmt.Sprintf("%s/%s/%s/%s", c.ServiceCode, c.ProjectCode, c.AssetCode, subTopic)
+	if c.TopicPrefix != "" {
+		topic = fmt.Sprintf("%s/%s", c.TopicPrefix, topic)
+	}
+	return topic
 }
 
 // Struct defining the environment information of the Device-Health agent.
 //   - MqttType: MQTT service type used by the agent.
 //   - ArchType: Architecture type of the device.
 //   - RootPath: Root path of the BWC.
+//   - NoMqtt: Option to write health data to stdout without MQTT.
+//   - HealthAddr: Listen address of the health endpoint. (127.0.0.1 by default)
+//   - HealthPort: Port of the health endpoint(/healthz). (0 is disabled)
+//   - HealthTimeout: Time since the last publish after which /healthz returns 503.
+//   - MetricsPort: Port of the Prometheus metrics endpoint(/metrics). (0 is disabled)
 type HealthService struct {
-	MqttType string
-	ArchType string
-	RootPath string
+	MqttType      string
+	ArchType      string
+	RootPath      string
+	NoMqtt        bool
+	HealthAddr    string
+	HealthPort    int
+	HealthTimeout time.Duration
+	MetricsPort   int
 }
 
 // Struct definition for CPU information.
 //   - Cpu: CPU usage percentage.
 //   - Total: Total number of CPU cores.
+//   - Throttled: Whether the CPU is throttled by temperature. (ARM devices)
+//   - TempC: CPU temperature in Celsius. (-1 if not available)
 //   - Time: Time of collection.
 type NodeCpu struct {
-	Cpu   float64
-	Total int
-	Time  time.Time
+	Cpu       float64
+	Total     int
+	Throttled bool
+	TempC     float64
+	Time      time.Time
 }
 
 // Struct definition for memory information.
@@ -88,14 +145,20 @@ type ProcInfo struct {
 //   - Used: Disk usage.
 //   - UsedPercent: Disk usage percentage.
 //   - Mountpoint: Disk mount point.
+//   - Fstype: Filesystem type of the disk. (ex. ext4, overlay)
+//   - ReadBytesPerSec: Bytes read per second since the last collection.
+//   - WriteBytesPerSec: Bytes written per second since the last collection.
 //   - Time: Time of collection.
 type DiskInfo struct {
-	Name        string
-	Totalsize   string
-	Used        float64
-	UsedPercent string
-	Mountpoint  string
-	Time        time.Time
+	Name             string
+	Totalsize        string
+	Used             float64
+	UsedPercent      string
+	Mountpoint       string
+	Fstype           string
+	ReadBytesPerSec  float64
+	WriteBytesPerSec float64
+	Time             time.Time
 }
 
 // This is a Struct defining network information.
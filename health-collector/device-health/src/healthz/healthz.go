@@ -0,0 +1,96 @@
+// The Healthz package provides the HTTP endpoint to check the liveness of Device-Health.
+// The endpoint is used by systemd watchdogs or liveness probes of container orchestrators.
+//   - /healthz: Time of the last health message. If no message was published within the
+//     timeout, 503 is returned. The timeout follows the health interval, which can be
+//     changed by SIGHUP.
+package healthz
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	sdtType "main/src/healthType"
+)
+
+// These are the global variables used in the healthz package.
+// - procLog: This is the struct that defines the format of the Log.
+var procLog sdtType.Logger
+
+// Getlog is a function that loads the log format. Log formats are defined as Info,
+// Warn, Error, and are output using Printf.
+// Here's how you would record the text "Hello World" as an Info log type:
+//
+//	procLog.Info.Printf("Hello World\n")   ->   [INFO] Hello World
+func Getlog(logConfig sdtType.Logger) {
+	procLog = logConfig
+}
+
+// The NewHandler function creates the handler of the health endpoint. Until the first
+// message is published, the start time of the handler is used as the last publish time.
+//
+// Input:
+//   - timeout: Function that returns the maximum time since the last publish.
+//   - lastPublishAt: Function that returns the time of the last publish. (unix ms, 0 if none)
+//
+// Output:
+//   - http.Handler: Handler of /healthz.
+func NewHandler(timeout func() time.Duration, lastPublishAt func() int64) http.Handler {
+	startedAt := time.Now().UnixMilli()
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		lastPublish := lastPublishAt()
+		since := lastPublish
+		if since == 0 {
+			since = startedAt
+		}
+		maxAge := timeout()
+		if time.Since(time.UnixMilli(since)) > maxAge {
+			writeStatus(w, http.StatusServiceUnavailable, map[string]interface{}{
+				"status":        "degraded",
+				"lastPublishAt": lastPublish,
+				"reason":        fmt.Sprintf("No health message was published in %v.", maxAge),
+			})
+			return
+		}
+		writeStatus(w, http.StatusOK, map[string]interface{}{
+			"status":        "ok",
+			"lastPublishAt": lastPublish,
+		})
+	})
+
+	return mux
+}
+
+// The RunServer function starts the HTTP server of the health endpoint.
+// The endpoint is not authenticated, so it listens on the loopback address by default.
+//
+// Input:
+//   - addr: Listen address of the HTTP server. (e.g. 127.0.0.1, 0.0.0.0)
+//   - port: Port of the HTTP server.
+//   - timeout: Function that returns the maximum time since the last publish.
+//   - lastPublishAt: Function that returns the time of the last publish. (unix ms, 0 if none)
+func RunServer(addr string, port int, timeout func() time.Duration, lastPublishAt func() int64) {
+	listenAddr := net.JoinHostPort(addr, strconv.Itoa(port))
+	procLog.Info.Printf("[HEALTHZ] Listen health endpoint: %s\n", listenAddr)
+	err := http.ListenAndServe(listenAddr, NewHandler(timeout, lastPublishAt))
+	if err != nil {
+		procLog.Error.Printf("[HEALTHZ] Health endpoint error: %v\n", err)
+	}
+}
+
+// The writeStatus function writes the status as a JSON response.
+func writeStatus(w http.ResponseWriter, statusCode int, status map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,71 @@
+package healthz
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	timeout := func() time.Duration { return 10 * time.Second }
+	now := time.Now().UnixMilli()
+
+	tests := []struct {
+		name          string
+		method        string
+		lastPublishAt int64
+		wantStatus    int
+		wantState     string
+	}{
+		{"recent publish", http.MethodGet, now, http.StatusOK, "ok"},
+		{"no publish after start", http.MethodGet, 0, http.StatusOK, "ok"},
+		{"stale publish", http.MethodGet, now - 11*1000, http.StatusServiceUnavailable, "degraded"},
+		{"post", http.MethodPost, now, http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(timeout, func() int64 { return tt.lastPublishAt })
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, "/healthz", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantState == "" {
+				return
+			}
+			var body struct {
+				Status        string `json:"status"`
+				LastPublishAt int64  `json:"lastPublishAt"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Status != tt.wantState || body.LastPublishAt != tt.lastPublishAt {
+				t.Errorf("body = %+v, want status %q", body, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestHandlerTimeoutChange(t *testing.T) {
+	timeout := 10 * time.Second
+	lastPublishAt := time.Now().Add(-20 * time.Second).UnixMilli()
+	handler := NewHandler(func() time.Duration { return timeout }, func() int64 { return lastPublishAt })
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+
+	// A longer interval after SIGHUP extends the timeout.
+	timeout = 30 * time.Second
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+}
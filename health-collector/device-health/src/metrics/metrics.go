@@ -0,0 +1,196 @@
+// The Metrics package exports the health data of Device-Health in the Prometheus text format,
+// so that on-premises Prometheus servers can scrape the device without MQTT.
+//   - /metrics: The latest CPU, memory, disk, network and GPU metrics of the device.
+//
+// The metrics are updated with the same health data that is published to MQTT, so both
+// outputs have the same values.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	sdtType "main/src/healthType"
+)
+
+// These are the global variables used in the metrics package.
+// - procLog: This is the struct that defines the format of the Log.
+// - registry: Registry of the metrics. (The default registry also has the metrics of the Go runtime)
+var (
+	procLog  sdtType.Logger
+	registry = prometheus.NewRegistry()
+)
+
+// Gauges of the health data. The units are the same as the MQTT payload.
+var (
+	cpuUsage = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "bwc_cpu_usage_percent",
+		Help: "CPU usage of the device. (%)",
+	})
+	cpuTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "bwc_cpu_total_percent",
+		Help: "Total CPU of the device. (Number of cores * 100 %)",
+	})
+	cpuTemp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "bwc_cpu_temperature_celsius",
+		Help: "CPU temperature of the device.",
+	})
+	memUsed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "bwc_memory_used_kib",
+		Help: "Used memory of the device. (KiB)",
+	})
+	memTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "bwc_memory_total_kib",
+		Help: "Total memory of the device. (KiB)",
+	})
+	diskUsed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "bwc_disk_used_kb",
+		Help: "Used space of the root file system. (KB)",
+	})
+	diskTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "bwc_disk_total_kb",
+		Help: "Total space of the root file system. (KB)",
+	})
+	netUplink = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "bwc_network_uplink_kbps",
+		Help: "Sent traffic of the device. (Kbit/s)",
+	})
+	netDownlink = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "bwc_network_downlink_kbps",
+		Help: "Received traffic of the device. (Kbit/s)",
+	})
+	gpuLabels = []string{"index", "vendor", "name"}
+	gpuUtil   = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bwc_gpu_utilization_percent",
+		Help: "Utilization of the GPU. (%)",
+	}, gpuLabels)
+	gpuMemUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bwc_gpu_memory_used_mib",
+		Help: "Used memory of the GPU. (MiB)",
+	}, gpuLabels)
+	gpuMemTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bwc_gpu_memory_total_mib",
+		Help: "Total memory of the GPU. (MiB)",
+	}, gpuLabels)
+	gpuTemp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bwc_gpu_temperature_celsius",
+		Help: "Temperature of the GPU.",
+	}, gpuLabels)
+)
+
+func init() {
+	registry.MustRegister(cpuUsage, cpuTotal, cpuTemp, memUsed, memTotal, diskUsed, diskTotal,
+		netUplink, netDownlink, gpuUtil, gpuMemUsed, gpuMemTotal, gpuTemp)
+}
+
+// Getlog is a function that loads the log format. Log formats are defined as Info,
+// Warn, Error, and are output using Printf.
+// Here's how you would record the text "Hello World" as an Info log type:
+//
+//	procLog.Info.Printf("Hello World\n")   ->   [INFO] Hello World
+func Getlog(logConfig sdtType.Logger) {
+	procLog = logConfig
+}
+
+// The setGauge function sets the gauge to the value of the key. The health data has the
+// values as strings (ex. "12.5000") or numbers. Values that are not numbers (ex. "[N/A]")
+// are skipped.
+func setGauge(gauge prometheus.Gauge, data map[string]interface{}, key string) {
+	if val, ok := toFloat(data[key]); ok {
+		gauge.Set(val)
+	}
+}
+
+// The setGaugeVec function is setGauge for the gauge of the labels. The gauge is not created
+// if the value is not a number.
+func setGaugeVec(gaugeVec *prometheus.GaugeVec, labels prometheus.Labels, data map[string]interface{}, key string) {
+	if val, ok := toFloat(data[key]); ok {
+		gaugeVec.With(labels).Set(val)
+	}
+}
+
+// The toFloat function converts a value of the health data to float64.
+func toFloat(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// The Update function sets the metrics to the health data collected by RunBody.
+//
+//	healthData = {"cpu": {"usage": ~, "total": ~}, "memory": {"usage": ~, "total": ~}, "disk": ~, "network": ~, "gpu": [~]}
+//
+// Input:
+//   - healthData: Health data of the device. (The "data" of the MQTT payload)
+func Update(healthData map[string]interface{}) {
+	if cpuData, ok := healthData["cpu"].(map[string]interface{}); ok {
+		setGauge(cpuUsage, cpuData, "usage")
+		setGauge(cpuTotal, cpuData, "total")
+		setGauge(cpuTemp, cpuData, "tempCelsius")
+	}
+	if memData, ok := healthData["memory"].(map[string]interface{}); ok {
+		setGauge(memUsed, memData, "usage")
+		setGauge(memTotal, memData, "total")
+	}
+	if diskData, ok := healthData["disk"].(map[string]interface{}); ok {
+		setGauge(diskUsed, diskData, "usage")
+		setGauge(diskTotal, diskData, "total")
+	}
+	if netData, ok := healthData["network"].(map[string]interface{}); ok {
+		setGauge(netUplink, netData, "uplink")
+		setGauge(netDownlink, netData, "downlink")
+	}
+
+	// Removed GPUs must not be scraped with their last values.
+	gpuUtil.Reset()
+	gpuMemUsed.Reset()
+	gpuMemTotal.Reset()
+	gpuTemp.Reset()
+	gpuList, _ := healthData["gpu"].([]map[string]interface{})
+	for _, gpu := range gpuList {
+		labels := prometheus.Labels{
+			"index":  fmt.Sprintf("%v", gpu["index"]),
+			"vendor": fmt.Sprintf("%v", gpu["vendor"]),
+			"name":   fmt.Sprintf("%v", gpu["name"]),
+		}
+		setGaugeVec(gpuUtil, labels, gpu, "util")
+		setGaugeVec(gpuMemUsed, labels, gpu, "usedMem")
+		setGaugeVec(gpuMemTotal, labels, gpu, "totalMem")
+		setGaugeVec(gpuTemp, labels, gpu, "temp")
+	}
+}
+
+// The NewHandler function creates the handler of the metrics endpoint.
+//
+// Output:
+//   - http.Handler: Handler of /metrics.
+func NewHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// The RunServer function starts the HTTP server of the metrics endpoint.
+//
+// Input:
+//   - port: Port of the HTTP server.
+func RunServer(port int) {
+	procLog.Info.Printf("[METRICS] Listen metrics endpoint: :%d/metrics\n", port)
+	err := http.ListenAndServe(fmt.Sprintf(":%d", port), NewHandler())
+	if err != nil {
+		procLog.Error.Printf("[METRICS] Metrics endpoint error: %v\n", err)
+	}
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// scrape returns the metric lines of /metrics without the HELP and TYPE comments.
+func scrape(t *testing.T) map[string]bool {
+	t.Helper()
+	srv := httptest.NewServer(NewHandler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %s", resp.Status)
+	}
+	body, _ := io.ReadAll(resp.Body)
+
+	lines := map[string]bool{}
+	for _, line := range strings.Split(string(body), "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines[line] = true
+		}
+	}
+	return lines
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	Update(map[string]interface{}{
+		"cpu":     map[string]interface{}{"usage": "12.5000", "total": "400", "tempCelsius": 48.3},
+		"memory":  map[string]interface{}{"usage": "1048576", "total": "8388608"},
+		"disk":    map[string]interface{}{"usage": "2048", "total": "4096"},
+		"network": map[string]interface{}{"uplink": 10.5, "downlink": int64(20)},
+		"gpu": []map[string]interface{}{
+			{"index": "0", "vendor": "nvidia", "name": "Tesla T4", "util": "35", "usedMem": "512", "totalMem": "15360", "temp": "[N/A]"},
+		},
+	})
+
+	lines := scrape(t)
+	for _, want := range []string{
+		"bwc_cpu_usage_percent 12.5",
+		"bwc_cpu_total_percent 400",
+		"bwc_cpu_temperature_celsius 48.3",
+		"bwc_memory_used_kib 1.048576e+06",
+		"bwc_memory_total_kib 8.388608e+06",
+		"bwc_disk_used_kb 2048",
+		"bwc_disk_total_kb 4096",
+		"bwc_network_uplink_kbps 10.5",
+		"bwc_network_downlink_kbps 20",
+		`bwc_gpu_utilization_percent{index="0",name="Tesla T4",vendor="nvidia"} 35`,
+		`bwc_gpu_memory_used_mib{index="0",name="Tesla T4",vendor="nvidia"} 512`,
+		`bwc_gpu_memory_total_mib{index="0",name="Tesla T4",vendor="nvidia"} 15360`,
+	} {
+		if !lines[want] {
+			t.Errorf("metric %q not found in %v", want, lines)
+		}
+	}
+	for line := range lines {
+		if strings.HasPrefix(line, "bwc_gpu_temperature_celsius") {
+			t.Errorf("[N/A] temperature is exported: %s", line)
+		}
+		if strings.HasPrefix(line, "go_") {
+			t.Errorf("Go runtime metric is exported: %s", line)
+		}
+	}
+
+	// The GPU is removed in the next health data.
+	Update(map[string]interface{}{"cpu": map[string]interface{}{"usage": "50"}})
+	lines = scrape(t)
+	if !lines["bwc_cpu_usage_percent 50"] {
+		t.Errorf("cpu usage is not updated: %v", lines)
+	}
+	for line := range lines {
+		if strings.HasPrefix(line, "bwc_gpu_") {
+			t.Errorf("removed GPU is exported: %s", line)
+		}
+	}
+}
+
+func TestToFloat(t *testing.T) {
+	tests := []struct {
+		val    interface{}
+		want   float64
+		wantOk bool
+	}{
+		{"12.5000", 12.5, true},
+		{12.5, 12.5, true},
+		{3, 3, true},
+		{int64(4), 4, true},
+		{"[N/A]", 0, false},
+		{nil, 0, false},
+		{true, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := toFloat(tt.val)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("toFloat(%v) = %v, %v, want %v, %v", tt.val, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
@@ -12,12 +12,21 @@ import (
 	"fmt"
 	winSvc "golang.org/x/sys/windows/svc"
 	"io"
-	"log"
 	"os"
 	"time"
 
 	sdtHealth "main/src/health"
 	sdtType "main/src/healthType"
+	sdtHealthz "main/src/healthz"
+	sdtMetrics "main/src/metrics"
+	sdtLog "pkg/log"
+	sdtWinService "pkg/winservice"
+)
+
+// Name and description of the Windows service of the agent. (-service install|uninstall|start|stop)
+const (
+	serviceName        = "DeviceHealthService"
+	serviceDescription = "BWC agent that sends the health data of the device."
 )
 
 // - procLog: This is the Struct that defines the format of the Log.
@@ -26,9 +35,14 @@ var (
 )
 
 type winHealthService struct {
-	MqttType string
-	ArchType string
-	RootPath string
+	MqttType      string
+	ArchType      string
+	RootPath      string
+	NoMqtt        bool
+	HealthAddr    string
+	HealthPort    int
+	HealthTimeout time.Duration
+	MetricsPort   int
 }
 
 // initError defines and initializes the log format. The log formats are defined as Info,
@@ -37,10 +51,13 @@ type winHealthService struct {
 // The way to log the text "Hello World" as an Info log type is shown below.
 // procLog.Info.Printf("Hello World\n")
 // Output: [INFO] Hello World
-func initError(logFile io.Writer) {
-	procLog.Info = log.New(logFile, "[INFO] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Warn = log.New(logFile, "[WARNING] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Error = log.New(logFile, "[ERROR] ", log.Ldate|log.Ltime|log.Lshortfile)
+//
+// Logs below logLevel (loglevel in config.json) are discarded.
+// If logFormat is "json", each log is written as a JSON line. (See pkg/log NewJSONLogger)
+func initError(logFile io.Writer, logLevel string, logFormat string) {
+	procLog.Info = sdtLog.NewLogger(logFile, "info", logLevel, logFormat)
+	procLog.Warn = sdtLog.NewLogger(logFile, "warn", logLevel, logFormat)
+	procLog.Error = sdtLog.NewLogger(logFile, "error", logLevel, logFormat)
 }
 
 // This function receives server architecture information, configures the environment
@@ -53,13 +70,37 @@ func initError(logFile io.Writer) {
 //   - - exmq: EXMQ
 //     -- inspector: If an Inspector sensor exists on the device, here are the options it utilizes.
 //   - arch: Architecture of the device.
+//   - no-mqtt: Write health data to stdout as JSON without connecting MQTT.
+//   - healthaddr: Listen address of the health endpoint. (127.0.0.1 by default)
+//   - healthport: Port of the health endpoint(/healthz). (0 is disabled)
+//   - healthtimeout: Seconds since the last publish after which /healthz returns 503. (At least twice the health interval)
 func main() {
 	// Set parameter
-	var mqttType, archType, rootPath string
+	var mqttType, archType, rootPath, logFormat, serviceAction, healthAddr string
+	var noMqtt bool
+	var healthPort, healthTimeout, metricsPort int
 	flag.StringVar(&mqttType, "mqtt", "", "Please input mqtt type(mosq? or aws? or exmq?)")
 	flag.StringVar(&archType, "arch", "", "Please input architecture type(amd? or arm?)")
+	flag.BoolVar(&noMqtt, "no-mqtt", false, "Write health data to stdout without MQTT(for debugging)")
+	flag.StringVar(&healthAddr, "healthaddr", "127.0.0.1", "Please input listen address of health endpoint.(0.0.0.0 is all interfaces)")
+	flag.IntVar(&healthPort, "healthport", 9090, "Please input port of health endpoint.(0 is disabled)")
+	flag.IntVar(&healthTimeout, "healthtimeout", 30, "Please input seconds since the last publish until health endpoint returns 503.")
+	flag.IntVar(&metricsPort, "metrics-port", 0, "Please input port of Prometheus metrics endpoint.(0 is disabled)")
+	flag.StringVar(&logFormat, "log-format", "text", "Please input log format(text or json)")
+	flag.StringVar(&serviceAction, "service", "", "Please input windows service action(install, uninstall, start or stop)")
 	flag.Parse()
 
+	// Manage the Windows service of the agent and exit.
+	if serviceAction != "" {
+		err := sdtWinService.Control(serviceAction, serviceName, serviceDescription, os.Args[1:])
+		if err != nil {
+			fmt.Printf("Failed %s %s: %v\n", serviceAction, serviceName, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Successfully %s %s.\n", serviceAction, serviceName)
+		return
+	}
+
 	// Set Config PATH
 	if archType == "win" {
 		rootPath = "C:/sdt"
@@ -69,21 +110,29 @@ func main() {
 
 	// Set Service Variable
 	svcInfo := sdtType.HealthService{
-		MqttType: mqttType,
-		ArchType: archType,
-		RootPath: rootPath,
+		MqttType:      mqttType,
+		ArchType:      archType,
+		RootPath:      rootPath,
+		NoMqtt:        noMqtt,
+		HealthAddr:    healthAddr,
+		HealthPort:    healthPort,
+		HealthTimeout: time.Duration(healthTimeout) * time.Second,
+		MetricsPort:   metricsPort,
 	}
 
 	// Set logger
 	logFilePath := fmt.Sprintf("%s/device.logs/device-health.log", rootPath)
-	logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY|os.O_SYNC, 0644)
+	logFile, err := sdtLog.NewRotateWriter(logFilePath, sdtLog.GetRotateConfig(fmt.Sprintf("%s/device.config/config.json", rootPath)))
 	if err != nil {
 		panic(err)
 	}
 	defer logFile.Close()
-	initError(logFile)
+	logLevel := sdtLog.GetLevel(fmt.Sprintf("%s/device.config/config.json", rootPath))
+	initError(logFile, logLevel, logFormat)
 
 	sdtHealth.Getlog(procLog)
+	sdtHealthz.Getlog(procLog)
+	sdtMetrics.Getlog(procLog)
 
 	// err = svc.Run("DeviceHealthService", &HealthService{})
 	// if err != nil {
@@ -94,11 +143,16 @@ func main() {
 		sdtHealth.RunBodyForInspector(svcInfo.ArchType)
 	} else {
 		winSvcInfo := winHealthService{
-			MqttType: mqttType,
-			ArchType: archType,
-			RootPath: rootPath,
+			MqttType:      mqttType,
+			ArchType:      archType,
+			RootPath:      rootPath,
+			NoMqtt:        noMqtt,
+			HealthAddr:    healthAddr,
+			HealthPort:    healthPort,
+			HealthTimeout: time.Duration(healthTimeout) * time.Second,
+			MetricsPort:   metricsPort,
 		}
-		err = winSvc.Run("DeviceHealthService", &winSvcInfo)
+		err = winSvc.Run(serviceName, &winSvcInfo)
 		if err != nil {
 			procLog.Error.Printf("cannot start service: %v\n", err)
 		}
@@ -112,7 +166,7 @@ func (srv *winHealthService) Execute(args []string, req <-chan winSvc.ChangeRequ
 	// 실제 서비스 내용
 	procLog.Info.Printf("[SVC] Service Content!!!\n")
 	stopChan := make(chan bool, 1)
-	go sdtHealth.RunBody(srv.MqttType, srv.ArchType, srv.RootPath)
+	go sdtHealth.RunBody(srv.MqttType, srv.ArchType, srv.RootPath, srv.NoMqtt, srv.HealthAddr, srv.HealthPort, srv.HealthTimeout, srv.MetricsPort)
 
 	stat <- winSvc.Status{State: winSvc.Running, Accepts: winSvc.AcceptStop | winSvc.AcceptShutdown}
 
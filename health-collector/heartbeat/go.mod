@@ -8,8 +8,22 @@ require (
 )
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
 	github.com/gorilla/websocket v1.5.0 // indirect
 	golang.org/x/net v0.8.0 // indirect
 	golang.org/x/sync v0.1.0 // indirect
 )
+
+require pkg/log v0.0.0
+
+replace pkg/log => ../../pkg/log
+
+require pkg/mqttutil v0.0.0
+
+replace pkg/mqttutil => ../../pkg/mqttutil
+
+require (
+	github.com/google/uuid v1.6.0
+	pkg/winservice v0.0.0
+)
+
+replace pkg/winservice => ../../pkg/winservice
@@ -10,11 +10,10 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"log"
-	"os"
 
 	sdtHeartbeat "main/src/heartbeat"
 	sdtType "main/src/heartbeatType"
+	sdtLog "pkg/log"
 )
 
 // - procLog: This is the Struct that defines the format of the Log.
@@ -28,10 +27,13 @@ var (
 // The way to log the text "Hello World" as an Info log type is shown below.
 // procLog.Info.Printf("Hello World\n")
 // Output: [INFO] Hello World
-func initError(logFile io.Writer) {
-	procLog.Info = log.New(logFile, "[INFO] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Warn = log.New(logFile, "[WARNING] ", log.Ldate|log.Ltime|log.Lshortfile)
-	procLog.Error = log.New(logFile, "[ERROR] ", log.Ldate|log.Ltime|log.Lshortfile)
+//
+// Logs below logLevel (loglevel in config.json) are discarded.
+// If logFormat is "json", each log is written as a JSON line. (See pkg/log NewJSONLogger)
+func initError(logFile io.Writer, logLevel string, logFormat string) {
+	procLog.Info = sdtLog.NewLogger(logFile, "info", logLevel, logFormat)
+	procLog.Warn = sdtLog.NewLogger(logFile, "warn", logLevel, logFormat)
+	procLog.Error = sdtLog.NewLogger(logFile, "error", logLevel, logFormat)
 }
 
 // This function receives server architecture information, configures the environment
@@ -45,9 +47,10 @@ func initError(logFile io.Writer) {
 //   - arch: Architecture of the device.
 func main() {
 	// Set parameter
-	var mqttType, archType, rootPath string
+	var mqttType, archType, rootPath, logFormat string
 	flag.StringVar(&mqttType, "mqtt", "", "Please input mqtt type(mosq? or aws? or exmq?)")
 	flag.StringVar(&archType, "arch", "", "Please input architecture type(amd? or arm?)")
+	flag.StringVar(&logFormat, "log-format", "text", "Please input log format(text or json)")
 	flag.Parse()
 
 	// Set Config PATH
@@ -66,12 +69,13 @@ func main() {
 
 	// Set logger
 	logFilePath := fmt.Sprintf("%s/device.logs/device-heartbeat.log", rootPath)
-	logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY|os.O_SYNC, 0644)
+	logFile, err := sdtLog.NewRotateWriter(logFilePath, sdtLog.GetRotateConfig(fmt.Sprintf("%s/device.config/config.json", rootPath)))
 	if err != nil {
 		panic(err)
 	}
 	defer logFile.Close()
-	initError(logFile)
+	logLevel := sdtLog.GetLevel(fmt.Sprintf("%s/device.config/config.json", rootPath))
+	initError(logFile, logLevel, logFormat)
 
 	sdtHeartbeat.Getlog(procLog)
 	sdtHeartbeat.RunBody(svcInfo.MqttType, svcInfo.ArchType, svcInfo.RootPath)
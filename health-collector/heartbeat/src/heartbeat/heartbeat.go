@@ -1,5 +1,5 @@
 // The heartbeat package updates the device's heartbeat status to the cloud via MQTT messages.
-// Heartbeat establishes an MQTT connection and publishes device status messages at a 10-second interval.
+// Heartbeat establishes an MQTT connection and publishes device status messages every healthIntervalSec seconds. (5 by default)
 package heartbeat
 
 import (
@@ -8,26 +8,28 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	mqttCli "github.com/eclipse/paho.mqtt.golang"
 	"github.com/google/uuid"
 
 	sdtType "main/src/heartbeatType"
+	sdtMqtt "pkg/mqttutil"
 )
 
 // Global variables used in the Heartbeat package.
 // - cli: MQTT Client type variable representing the connected MQTT server's client.
-// - delay: MQTT message publishing interval in seconds.
+// - delay: Default MQTT message publishing interval in seconds. (Used if healthIntervalSec is not set)
 // - mqttUser: User ID used for MQTT connection.
 // - mqttPassword: Password used for MQTT connection.
 // - procLog: Struct defining the format of logs.
 var (
 	cli          mqttCli.Client
-	delay        time.Duration = 10
+	delay        time.Duration = 5
 	mqttUser                   = "sdt"
 	mqttPassword               = "251327"
 	procLog      sdtType.Logger
@@ -42,6 +44,75 @@ func Getlog(logConfig sdtType.Logger) {
 	procLog = logConfig
 }
 
+// Range of healthIntervalSec in config.json. (seconds)
+const (
+	minInterval = 1
+	maxInterval = 300
+)
+
+// GetInterval function returns the heartbeat publishing interval from healthIntervalSec of config.json.
+// If the value is not set, delay is used. If it is out of range [1, 300], a warning is logged and delay is used.
+//
+// Input:
+//   - sec: healthIntervalSec of config.json.
+//
+// Output:
+//   - time.Duration: Heartbeat publishing interval.
+func GetInterval(sec int) time.Duration {
+	if sec == 0 {
+		return delay * time.Second
+	}
+	if sec < minInterval || sec > maxInterval {
+		procLog.Warn.Printf("[HEARTBEAT] healthIntervalSec %d is out of range [%d, %d]. Use %d sec.\n", sec, minInterval, maxInterval, delay)
+		return delay * time.Second
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// reloadInterval function reads config.json again when SIGHUP is received.
+// If the file cannot be read, the current interval is kept.
+//
+// Input:
+//   - jsonFilePath: Path to config.json.
+//   - interval: Current heartbeat publishing interval.
+//
+// Output:
+//   - time.Duration: Heartbeat publishing interval to use.
+func reloadInterval(jsonFilePath string, interval time.Duration) time.Duration {
+	var configData sdtType.ConfigInfo
+
+	jsonFile, err := os.ReadFile(jsonFilePath)
+	if err != nil {
+		procLog.Error.Printf("[HEARTBEAT] Reload config Error: %v\n", err)
+		return interval
+	}
+	if err = json.Unmarshal(jsonFile, &configData); err != nil {
+		procLog.Error.Printf("[HEARTBEAT] Reload config Unmarshal Error: %v\n", err)
+		return interval
+	}
+
+	newInterval := GetInterval(configData.HealthIntervalSec)
+	if newInterval != interval {
+		procLog.Info.Printf("[HEARTBEAT] Heartbeat interval is changed: %v -> %v\n", interval, newInterval)
+	}
+	return newInterval
+}
+
+// connectionLostHandler function is called when the connection to the MQTT broker is lost.
+// Instead of exiting the agent, it reconnects with exponential backoff. (See pkg/mqttutil)
+//
+// Input:
+//   - client: MQTT client that lost the connection.
+//   - err: Reason of the connection loss.
+func connectionLostHandler(client mqttCli.Client, err error) {
+	procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
+	sdtMqtt.OnConnectionLost(func() error {
+		token := client.Connect()
+		token.Wait()
+		return token.Error()
+	}, procLog.Error)
+}
+
 // This function defines options for connecting to the AWS IoT Core MQTT Broker.
 // It specifies options such as TLS, MQTT URI, client name, and handlers.
 //
@@ -55,7 +126,7 @@ func Getlog(logConfig sdtType.Logger) {
 //   - *mqttCli.ClientOptions: Variable of type MQTT clientOptions.
 func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey string) *mqttCli.ClientOptions {
 	// Load CA certificate
-	caCert, err := ioutil.ReadFile(rootCa)
+	caCert, err := os.ReadFile(rootCa)
 	if err != nil {
 		log.Fatalf("Error reading CA certificate file: %v", err)
 	}
@@ -89,10 +160,63 @@ func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey string) *m
 	opts.AddBroker(mqttURL)
 	opts.SetTLSConfig(tlsConfig)
 	opts.SetClientID(fmt.Sprintf("blokworks-client-heartbeat-%s", cilentUUID))
-	opts.SetConnectionLostHandler(func(client mqttCli.Client, err error) {
-		procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
-		os.Exit(1)
-	})
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
+
+	return opts
+}
+
+// createExmqClientOptions function defines options for connecting to the EXMQ Broker.
+// The certificates of the EXMQ broker are read from the 'exmq' section of the config file.
+//
+// Input:
+//   - config: Struct storing the Config file saved on the device in JSON format.
+//
+// Output:
+//   - *mqttCli.ClientOptions: Options of the MQTT client.
+func createExmqClientOptions(config sdtType.ConfigInfo) *mqttCli.ClientOptions {
+	tlsConfig := &tls.Config{}
+
+	// Load CA certificate
+	if config.Exmq.RootCa != "" {
+		caCert, err := os.ReadFile(config.Exmq.RootCa)
+		if err != nil {
+			procLog.Error.Printf("Error reading EXMQ CA certificate file: %v", err)
+		}
+		roots := x509.NewCertPool()
+		roots.AppendCertsFromPEM(caCert)
+		tlsConfig.RootCAs = roots
+	}
+
+	// Load client certificate
+	if config.Exmq.Cert != "" && config.Exmq.PrivateKey != "" {
+		clientCert, err := tls.LoadX509KeyPair(config.Exmq.Cert, config.Exmq.PrivateKey)
+		if err != nil {
+			procLog.Error.Printf("Error reading EXMQ client key file: %v", err)
+		} else {
+			tlsConfig.Certificates = []tls.Certificate{clientCert}
+		}
+	}
+
+	mqttURL := config.Exmq.MqttUrl
+	if mqttURL == "" {
+		mqttURL = config.MqttUrl
+	}
+
+	// set uuid
+	newUUID := uuid.New()
+	cilentUUID := newUUID.String()
+
+	opts := mqttCli.NewClientOptions()
+	opts.AddBroker(mqttURL)
+	opts.SetTLSConfig(tlsConfig)
+	if config.Exmq.Username != "" {
+		opts.SetUsername(config.Exmq.Username)
+		opts.SetPassword(config.Exmq.Password)
+	}
+	opts.SetClientID(fmt.Sprintf("blokworks-client-heartbeat-%s", cilentUUID))
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
 
 	return opts
 }
@@ -102,22 +226,24 @@ func createAwsClientOptions(mqttURL, rootCa, fullCertChain, clientKey string) *m
 //
 // Input:
 //   - config: Struct storing the config file saved on the device in JSON format.
+//   - user: User ID used for MQTT connection.
+//   - password: Password used for MQTT connection.
 //
 // Output:
 //   - mqttCli.Client: Variable of type MQTT Client.
 func connectToMqtt(
 	config sdtType.ConfigInfo, // Information of config
+	user string,
+	password string,
 ) mqttCli.Client {
 	procLog.Info.Printf("[HEARTBEAT] In connectToMqtt Function \n")
 	opts := mqttCli.NewClientOptions()
 	opts.AddBroker(config.MqttUrl)
-	opts.SetPassword(mqttPassword)
-	opts.SetUsername(mqttUser)
+	opts.SetPassword(password)
+	opts.SetUsername(user)
 	opts.SetClientID(fmt.Sprintf("blokworks-client-heartbeat-%s", config.AssetCode))
-	opts.SetConnectionLostHandler(func(client mqttCli.Client, err error) {
-		procLog.Error.Printf("[MQTT] Connection lost: %v\n", err)
-		os.Exit(1)
-	})
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(connectionLostHandler)
 
 	cli = mqttCli.NewClient(opts)
 
@@ -140,7 +266,7 @@ func sendDataEdgeMqtt(
 	config sdtType.ConfigInfo, // Information of config
 ) {
 	// topic := fmt.Sprintf("$aws/things/sdt-cloud-development/shadow/name/device-heartbeat/%s", config.AssetCode)
-	topic := fmt.Sprintf("%s/%s/%s/bwc/heartbeat", config.ServiceCode, config.ProjectCode, config.AssetCode)
+	topic := config.Topic("bwc/heartbeat")
 
 	resultBody, err := json.Marshal(payload)
 	if err != nil {
@@ -165,10 +291,18 @@ func sendDataEdgeMqtt(
 //   - archType: Architecture of the device.
 //   - rootPath: Root path of SDTCloud stored on the device.
 func RunBody(mqttType string, archType string, rootPath string) {
+	// MQTT credentials can be overridden by environment variables.
+	if user := os.Getenv("MQTT_USER"); user != "" {
+		mqttUser = user
+	}
+	if password := os.Getenv("MQTT_PASSWORD"); password != "" {
+		mqttPassword = password
+	}
+
 	var configData sdtType.ConfigInfo
 
 	jsonFilePath := fmt.Sprintf("%s/device.config/config.json", rootPath)
-	jsonFile, err := ioutil.ReadFile(jsonFilePath)
+	jsonFile, err := os.ReadFile(jsonFilePath)
 	if err != nil {
 		procLog.Error.Printf("[HEARTBEAT] Not found file Error: %v\n", err)
 	}
@@ -191,11 +325,15 @@ func RunBody(mqttType string, archType string, rootPath string) {
 	// Set Mqtt
 	if mqttType == "onprem" {
 		// Set mqtt client - EC2
-		cli = connectToMqtt(configData)
+		cli = connectToMqtt(configData, mqttUser, mqttPassword)
 	} else if mqttType == "aws-dev" || mqttType == "eks" || mqttType == "dev" {
 		// Set MQTT - AWS IoT Core
 		opts := createAwsClientOptions(configData.MqttUrl, rootCa, fullCertChain, private)
 		cli = mqttCli.NewClient(opts)
+	} else if mqttType == "exmq" {
+		// Set MQTT - EXMQ
+		opts := createExmqClientOptions(configData)
+		cli = mqttCli.NewClient(opts)
 	} else {
 		err = errors.New("Please input mqtt variable.")
 		procLog.Error.Printf("[MAIN] Docker connection Error: %v\n", err)
@@ -208,7 +346,12 @@ func RunBody(mqttType string, archType string, rootPath string) {
 
 	defer cli.Disconnect(250)
 
-	for true {
+	// SIGHUP reloads config.json and restarts the ticker with the new interval.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	publishLoop(GetInterval(configData.HealthIntervalSec), jsonFilePath, hup, nil, func() {
 		heartBeat := map[string]interface{}{
 			"heartbeat": "OK",
 		}
@@ -219,7 +362,33 @@ func RunBody(mqttType string, archType string, rootPath string) {
 		}
 
 		sendDataEdgeMqtt(msg, configData)
+	})
+}
+
+// publishLoop function calls publish every interval. When SIGHUP is received, config.json
+// is reloaded and the ticker is restarted with the new interval.
+//
+// Input:
+//   - interval: Heartbeat publishing interval.
+//   - jsonFilePath: Path to config.json.
+//   - hup: Channel of SIGHUP.
+//   - done: The loop returns when done is closed. (nil runs forever)
+//   - publish: Function that publishes the heartbeat.
+func publishLoop(interval time.Duration, jsonFilePath string, hup <-chan os.Signal, done <-chan struct{}, publish func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-		time.Sleep(delay * time.Second)
+	for {
+		publish()
+
+		select {
+		case <-ticker.C:
+		case <-hup:
+			procLog.Info.Printf("[HEARTBEAT] SIGHUP received. Reload %s\n", jsonFilePath)
+			interval = reloadInterval(jsonFilePath, interval)
+			ticker.Reset(interval)
+		case <-done:
+			return
+		}
 	}
 }
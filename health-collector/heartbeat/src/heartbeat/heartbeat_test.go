@@ -0,0 +1,84 @@
+package heartbeat
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	sdtType "main/src/heartbeatType"
+	sdtLog "pkg/log"
+)
+
+func TestGetInterval(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	tests := []struct {
+		sec  int
+		want time.Duration
+	}{
+		{0, 5 * time.Second},
+		{1, time.Second},
+		{30, 30 * time.Second},
+		{300, 300 * time.Second},
+		{-1, 5 * time.Second},
+		{301, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := GetInterval(tt.sec); got != tt.want {
+			t.Errorf("GetInterval(%d) = %v, want %v", tt.sec, got, tt.want)
+		}
+	}
+}
+
+func TestReloadInterval(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	configFile := filepath.Join(t.TempDir(), "config.json")
+
+	if got := reloadInterval(configFile, 7*time.Second); got != 7*time.Second {
+		t.Errorf("reloadInterval() without config = %v, want the current interval", got)
+	}
+	os.WriteFile(configFile, []byte(`{"healthIntervalSec": `), 0644)
+	if got := reloadInterval(configFile, 7*time.Second); got != 7*time.Second {
+		t.Errorf("reloadInterval() with malformed config = %v, want the current interval", got)
+	}
+	os.WriteFile(configFile, []byte(`{"healthIntervalSec": 60}`), 0644)
+	if got := reloadInterval(configFile, 7*time.Second); got != time.Minute {
+		t.Errorf("reloadInterval() = %v, want 1m", got)
+	}
+}
+
+func TestPublishLoopSighup(t *testing.T) {
+	procLog = sdtType.Logger(sdtLog.NewDiscardLogger())
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"healthIntervalSec": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hup := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	published := make(chan time.Time, 10)
+	go publishLoop(5*time.Minute, configFile, hup, done, func() { published <- time.Now() })
+	defer close(done)
+
+	waitPublish := func() time.Time {
+		t.Helper()
+		select {
+		case at := <-published:
+			return at
+		case <-time.After(5 * time.Second):
+			t.Fatal("heartbeat is not published")
+			return time.Time{}
+		}
+	}
+
+	// The first heartbeat is published at start. The next one would be after 5 minutes.
+	waitPublish()
+	hup <- syscall.SIGHUP
+	// The heartbeat is published after the reload, and then every second.
+	reloadedAt := waitPublish()
+	next := waitPublish()
+	if gap := next.Sub(reloadedAt); gap < 900*time.Millisecond || gap > 2*time.Second {
+		t.Errorf("heartbeat interval after SIGHUP = %v, want 1s", gap)
+	}
+}
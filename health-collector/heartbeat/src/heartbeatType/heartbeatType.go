@@ -2,6 +2,7 @@
 package heartbeatType
 
 import (
+	"fmt"
 	"log"
 )
 
@@ -20,12 +21,52 @@ type Logger struct {
 //   - MqttUrl: MQTT URL of SDT Cloud.
 //   - ProjectCode: ID of the project to which the device belongs.
 //   - ServiceCode: Service code of SDT Cloud.
+//   - TopicPrefix: Namespace prepended to the MQTT topics. (Empty by default)
+//   - Exmq: EXMQ broker information. (Only used if the mqtt type is exmq)
+//   - HealthIntervalSec: Heartbeat publishing interval in seconds. (5 if not set, range [1, 300])
 type ConfigInfo struct {
-	AssetCode   string `json:"assetcode"`
-	MqttUrl     string `json:"mqtturl"`
-	ProjectCode string `json:"projectcode"`
-	ServiceCode string `json:"servicecode"`
-	ServerIp    string `json:"serverip"`
+	AssetCode         string   `json:"assetcode"`
+	MqttUrl           string   `json:"mqtturl"`
+	ProjectCode       string   `json:"projectcode"`
+	ServiceCode       string   `json:"servicecode"`
+	ServerIp          string   `json:"serverip"`
+	TopicPrefix       string   `json:"topicprefix,omitempty"`
+	Exmq              ExmqInfo `json:"exmq"`
+	HealthIntervalSec int      `json:"healthIntervalSec,omitempty"`
+}
+
+// ExmqInfo struct defines the EXMQ broker information under the 'exmq' section of the config file.
+//   - MqttUrl: MQTT URL of the EXMQ broker. If empty, mqtturl of the config is used.
+//   - RootCa: Path to the Root CA file of the EXMQ broker.
+//   - Cert: Path to the client certificate file.
+//   - PrivateKey: Path to the private key file of the client certificate.
+//   - Username: Username of the EXMQ broker. (Optional)
+//   - Password: Password of the EXMQ broker. (Optional)
+type ExmqInfo struct {
+	MqttUrl    string `json:"mqtturl,omitempty"`
+	RootCa     string `json:"rootca"`
+	Cert       string `json:"cert"`
+	PrivateKey string `json:"privatekey"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+}
+
+// Topic function returns the MQTT topic of the device. If TopicPrefix is set, it is prepended
+// to the topic so that multiple BWC deployments can share one MQTT broker.
+//
+//	<topicPrefix>/<serviceCode>/<projectCode>/<assetCode>/<subTopic>
+//
+// Input:
+//   - subTopic: Topic after the asset code. (ex. bwc/health)
+//
+// Output:
+//   - string: MQTT topic.
+func (c ConfigInfo) Topic(subTopic string) string {
+	topic := fmt.Sprintf("%s/%s/%s/%s", c.ServiceCode, c.ProjectCode, c.AssetCode, subTopic)
+	if c.TopicPrefix != "" {
+		topic = fmt.Sprintf("%s/%s", c.TopicPrefix, topic)
+	}
+	return topic
 }
 
 // Struct definition for Heartbeat agent's environment information.
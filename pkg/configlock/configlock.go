@@ -0,0 +1,38 @@
+// The configlock package is shared by the BWC agents and the CLI. config.json is changed
+// with a read-modify-write cycle by several processes (agent-init, the management agent,
+// the control agent and bwc), so the cycle is guarded by an advisory lock on a lock file
+// next to config.json. (ex. /etc/sdt/device.config/config.json.lock)
+//
+// The lock is taken with flock on Linux and LockFileEx on Windows, and is released by the
+// OS when the process exits.
+package configlock
+
+import (
+	"fmt"
+	"os"
+)
+
+// AcquireConfigLock function blocks until the lock of the config file is acquired.
+// The returned release function must be called after the config file is written.
+//
+// Input:
+//   - path: Path of the config file. (ex. /etc/sdt/device.config/config.json)
+//
+// Output:
+//   - func(): Function that releases the lock.
+//   - error: Error if the lock file can't be opened or locked.
+func AcquireConfigLock(path string) (release func(), err error) {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file of %s: %w", path, err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,104 @@
+package configlock
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentReadModifyWrite(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configFile, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			release, err := AcquireConfigLock(configFile)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer release()
+
+			jsonFile, err := os.ReadFile(configFile)
+			if err != nil {
+				errs <- err
+				return
+			}
+			var jsonData map[string]interface{}
+			if err := json.Unmarshal(jsonFile, &jsonData); err != nil {
+				errs <- err
+				return
+			}
+			// Give the other writers time to race if the lock doesn't work.
+			time.Sleep(time.Millisecond)
+			jsonData[fmt.Sprintf("key%d", i)] = i
+			saveJson, _ := json.MarshalIndent(&jsonData, "", "\t")
+			if err := os.WriteFile(configFile, saveJson, 0644); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	jsonFile, _ := os.ReadFile(configFile)
+	var jsonData map[string]interface{}
+	if err := json.Unmarshal(jsonFile, &jsonData); err != nil {
+		t.Fatal(err)
+	}
+	if len(jsonData) != writers {
+		t.Errorf("config has %d keys, want %d: %s", len(jsonData), writers, jsonFile)
+	}
+}
+
+func TestAcquireConfigLockBlocks(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	release, err := AcquireConfigLock(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		release2, err := AcquireConfigLock(configFile)
+		if err != nil {
+			t.Error(err)
+			close(acquired)
+			return
+		}
+		close(acquired)
+		release2()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock is acquired while it is held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock is not acquired after release")
+	}
+}
+
+func TestAcquireConfigLockError(t *testing.T) {
+	if _, err := AcquireConfigLock(filepath.Join(t.TempDir(), "missing", "config.json")); err == nil {
+		t.Error("AcquireConfigLock() in a missing directory = nil, want error")
+	}
+}
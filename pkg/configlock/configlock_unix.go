@@ -0,0 +1,23 @@
+//go:build !windows
+
+package configlock
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile function takes an exclusive flock on the file.
+func lockFile(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+// unlockFile function releases the flock of the file.
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
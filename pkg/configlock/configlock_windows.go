@@ -0,0 +1,39 @@
+//go:build windows
+
+package configlock
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// LockFileEx and UnlockFileEx are not in the syscall package, so they are loaded from kernel32.dll.
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+// lockfileExclusiveLock is the LOCKFILE_EXCLUSIVE_LOCK flag of LockFileEx.
+const lockfileExclusiveLock = 0x00000002
+
+// lockFile function takes an exclusive lock on the first byte of the file with LockFileEx.
+func lockFile(f *os.File) error {
+	var ol syscall.Overlapped
+	r1, _, err := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock, 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if r1 == 0 {
+		return err
+	}
+	return nil
+}
+
+// unlockFile function releases the lock of the file with UnlockFileEx.
+func unlockFile(f *os.File) error {
+	var ol syscall.Overlapped
+	r1, _, err := procUnlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if r1 == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,3 @@
+module pkg/configlock
+
+go 1.19
@@ -0,0 +1,3 @@
+module pkg/log
+
+go 1.19
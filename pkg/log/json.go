@@ -0,0 +1,147 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	stdlog "log"
+	"strings"
+	"time"
+)
+
+// Log formats selected by the -log-format flag of the agents.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// jsonLine is a log line written by jsonWriter.
+type jsonLine struct {
+	Level  string `json:"level"`
+	Ts     string `json:"ts"`
+	Caller string `json:"caller"`
+	Msg    string `json:"msg"`
+}
+
+// jsonWriter is an io.Writer that converts the output of log.Logger (Lshortfile) to a JSON line.
+//   - out: Writer of the log file.
+//   - level: Level of the logs written by this writer.
+//   - minLevel: Configured log level.
+type jsonWriter struct {
+	out      io.Writer
+	level    int
+	minLevel int
+}
+
+// levelNames is the "level" field of the JSON log for each log level.
+var levelNames = map[int]string{
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+}
+
+// Write function writes the log as a JSON line if its level is enabled.
+// p is "<file>:<line>: <message>" since the logger uses only Lshortfile.
+func (jw *jsonWriter) Write(p []byte) (int, error) {
+	if jw.level < jw.minLevel {
+		return len(p), nil
+	}
+
+	line := jsonLine{
+		Level: levelNames[jw.level],
+		Ts:    time.Now().Format("2006-01-02T15:04:05.000Z07:00"),
+	}
+	msg := p
+	if idx := bytes.Index(p, []byte(": ")); idx >= 0 {
+		line.Caller = string(p[:idx])
+		msg = p[idx+2:]
+	}
+	line.Msg = strings.TrimRight(string(msg), "\r\n")
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := jw.out.Write(append(data, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Logger has the loggers of each level. It has the same fields as the Logger struct of
+// the agents, so it can be converted to it. (ex. sdtType.Logger(sdtLog.NewJSONLogger(out)))
+type Logger struct {
+	Warn  *stdlog.Logger
+	Info  *stdlog.Logger
+	Error *stdlog.Logger
+}
+
+// NewJSONLogger function creates the loggers that write a JSON object per line as follows:
+//
+//	{"level":"INFO","ts":"2024-05-01T09:00:00.000+09:00","caller":"health.go:123","msg":"Hello World"}
+//
+// The logs of all levels are written. Use NewLogger to filter them by the configured log level.
+//
+// Input:
+//   - out: Writer of the log file.
+//
+// Output:
+//   - Logger: Loggers of the Info, Warn and Error levels.
+func NewJSONLogger(out io.Writer) Logger {
+	return Logger{
+		Warn:  newJSONLogger(out, "warn", "debug"),
+		Info:  newJSONLogger(out, "info", "debug"),
+		Error: newJSONLogger(out, "error", "debug"),
+	}
+}
+
+// NewDiscardLogger function creates the loggers that discard all logs. It is used by the
+// tests of the agents. (ex. procLog = sdtType.Logger(sdtLog.NewDiscardLogger()))
+//
+// Output:
+//   - Logger: Loggers of the Info, Warn and Error levels.
+func NewDiscardLogger() Logger {
+	logger := stdlog.New(io.Discard, "", 0)
+	return Logger{Warn: logger, Info: logger, Error: logger}
+}
+
+// newJSONLogger function creates a JSON logger of the level. Logs below logLevel are discarded.
+func newJSONLogger(out io.Writer, level string, logLevel string) *stdlog.Logger {
+	writer := &jsonWriter{
+		out:      out,
+		level:    ParseLevel(level),
+		minLevel: ParseLevel(logLevel),
+	}
+	return stdlog.New(writer, "", stdlog.Lshortfile)
+}
+
+// NewLogger function creates a logger of the level in the log format.
+// The text format is the same as before: "[INFO] 2024/05/01 09:00:00 health.go:123: Hello World"
+//
+// Input:
+//   - out: Writer of the log file.
+//   - level: Level of the logger. ("debug", "info", "warn", "error")
+//   - logLevel: Configured log level.
+//   - logFormat: Log format. ("text", "json")
+//
+// Output:
+//   - *log.Logger: Logger of the level.
+func NewLogger(out io.Writer, level string, logLevel string, logFormat string) *stdlog.Logger {
+	if strings.ToLower(logFormat) == FormatJSON {
+		return newJSONLogger(out, level, logLevel)
+	}
+
+	var prefix string
+	switch ParseLevel(level) {
+	case LevelDebug:
+		prefix = "[DEBUG] "
+	case LevelWarn:
+		prefix = "[WARNING] "
+	case LevelError:
+		prefix = "[ERROR] "
+	default:
+		prefix = "[INFO] "
+	}
+	return stdlog.New(NewWriter(out, level, logLevel), prefix, stdlog.Ldate|stdlog.Ltime|stdlog.Lshortfile)
+}
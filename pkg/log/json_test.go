@@ -0,0 +1,102 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	stdlog "log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// agentLogger has the same fields as the Logger struct of the agents.
+type agentLogger struct {
+	Warn  *stdlog.Logger
+	Info  *stdlog.Logger
+	Error *stdlog.Logger
+}
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]string {
+	t.Helper()
+	var lines []map[string]string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := map[string]string{}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		lines = append(lines, fields)
+	}
+	return lines
+}
+
+func TestNewJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	procLog := agentLogger(NewJSONLogger(&buf))
+
+	procLog.Info.Printf("[TEST] Hello %s\n", "World")
+	procLog.Warn.Printf("[TEST] Warning\n")
+	procLog.Error.Printf("[TEST] Error: \"quoted\"\n")
+
+	lines := decodeLines(t, &buf)
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %q", len(lines), buf.String())
+	}
+	want := []struct{ level, msg string }{
+		{"INFO", "[TEST] Hello World"},
+		{"WARN", "[TEST] Warning"},
+		{"ERROR", "[TEST] Error: \"quoted\""},
+	}
+	for i, line := range lines {
+		for _, key := range []string{"level", "ts", "caller", "msg"} {
+			if _, ok := line[key]; !ok {
+				t.Errorf("line %d has no %q: %v", i, key, line)
+			}
+		}
+		if line["level"] != want[i].level || line["msg"] != want[i].msg {
+			t.Errorf("line %d = %v, want level %q msg %q", i, line, want[i].level, want[i].msg)
+		}
+		if !strings.HasPrefix(line["caller"], "json_test.go:") {
+			t.Errorf("caller = %q", line["caller"])
+		}
+		if _, err := time.Parse("2006-01-02T15:04:05.000Z07:00", line["ts"]); err != nil {
+			t.Errorf("ts = %q: %v", line["ts"], err)
+		}
+	}
+}
+
+func TestNewLoggerJSONLevel(t *testing.T) {
+	var buf bytes.Buffer
+	info := NewLogger(&buf, "info", "warn", "json")
+	warn := NewLogger(&buf, "warn", "warn", "JSON")
+
+	info.Printf("discarded\n")
+	warn.Printf("written\n")
+
+	lines := decodeLines(t, &buf)
+	if len(lines) != 1 || lines[0]["level"] != "WARN" || lines[0]["msg"] != "written" {
+		t.Errorf("lines = %v, want only the warn log", lines)
+	}
+}
+
+func TestNewLoggerText(t *testing.T) {
+	var buf bytes.Buffer
+	NewLogger(&buf, "error", "info", "text").Printf("Hello World\n")
+
+	line := buf.String()
+	if !strings.HasPrefix(line, "[ERROR] ") || !strings.Contains(line, "json_test.go:") || !strings.HasSuffix(line, ": Hello World\n") {
+		t.Errorf("text log = %q", line)
+	}
+}
+
+func TestNewDiscardLogger(t *testing.T) {
+	procLog := agentLogger(NewDiscardLogger())
+	for name, logger := range map[string]*stdlog.Logger{"Info": procLog.Info, "Warn": procLog.Warn, "Error": procLog.Error} {
+		if logger == nil {
+			t.Fatalf("%s logger is nil", name)
+		}
+		logger.Printf("[TEST] discarded\n")
+	}
+}
@@ -0,0 +1,100 @@
+// The log package is shared by the BWC agents. It filters the log output of each
+// agent by the log level defined in the BWC config file (config.json). The log levels
+// are "debug", "info", "warn" and "error", and the default is "info".
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+)
+
+// Log levels. A log is written only if its level is greater than or equal to the
+// configured level.
+const (
+	LevelDebug = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// levelWriter is an io.Writer that discards logs below the configured level.
+//   - out: Writer of the log file.
+//   - level: Level of the logs written by this writer.
+//   - minLevel: Configured log level.
+type levelWriter struct {
+	out      io.Writer
+	level    int
+	minLevel int
+}
+
+// Write function writes the log if its level is enabled. Otherwise, the log is
+// written to io.Discard.
+func (lw *levelWriter) Write(p []byte) (int, error) {
+	if lw.level < lw.minLevel {
+		return io.Discard.Write(p)
+	}
+	return lw.out.Write(p)
+}
+
+// ParseLevel function converts the log level string to the log level value.
+// If the string is empty or unknown, LevelInfo is returned.
+//
+// Input:
+//   - logLevel: Log level string. ("debug", "info", "warn", "error")
+//
+// Output:
+//   - int: Log level value.
+func ParseLevel(logLevel string) int {
+	switch strings.ToLower(logLevel) {
+	case "debug":
+		return LevelDebug
+	case "warn", "warning":
+		return LevelWarn
+	case "error":
+		return LevelError
+	default:
+		return LevelInfo
+	}
+}
+
+// NewWriter function creates an io.Writer for the logger of the level.
+//
+// Input:
+//   - out: Writer of the log file.
+//   - level: Level of the logger. ("debug", "info", "warn", "error")
+//   - logLevel: Configured log level.
+//
+// Output:
+//   - io.Writer: Writer that discards logs below the configured level.
+func NewWriter(out io.Writer, level string, logLevel string) io.Writer {
+	return &levelWriter{
+		out:      out,
+		level:    ParseLevel(level),
+		minLevel: ParseLevel(logLevel),
+	}
+}
+
+// GetLevel function reads the log level (loglevel) from the BWC config file.
+// If the config file or the field is not found, "info" is returned.
+//
+// Input:
+//   - configFile: Path of the BWC config file.
+//
+// Output:
+//   - string: Log level.
+func GetLevel(configFile string) string {
+	var configData struct {
+		LogLevel string `json:"loglevel"`
+	}
+	jsonFile, err := os.ReadFile(configFile)
+	if err != nil {
+		return "info"
+	}
+	err = json.Unmarshal(jsonFile, &configData)
+	if err != nil || configData.LogLevel == "" {
+		return "info"
+	}
+	return strings.ToLower(configData.LogLevel)
+}
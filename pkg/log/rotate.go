@@ -0,0 +1,145 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Default values of the log rotation.
+const (
+	DefaultMaxSize    = 10 // MB
+	DefaultMaxBackups = 5
+)
+
+// RotateConfig defines the log rotation of the agents. It is read from the BWC config
+// file (logmaxsize, logmaxbackups).
+//   - MaxSize: Maximum size of the log file in MB.
+//   - MaxBackups: Number of rotated log files to keep. (ex. device-health.log.1 ~ .5)
+type RotateConfig struct {
+	MaxSize    int `json:"logmaxsize"`
+	MaxBackups int `json:"logmaxbackups"`
+}
+
+// RotateWriter is an io.Writer that rotates the log file when it reaches the maximum size.
+// The log file is renamed to <path>.1, and the older backups are shifted to <path>.2 ...
+// Rename is atomic, so no log line is split between the rotated file and the new one.
+//   - mu: Lock of the log file. The loggers of each level share the writer.
+//   - path: Path of the log file.
+//   - maxSize: Maximum size of the log file in bytes.
+//   - maxBackups: Number of rotated log files to keep.
+//   - file: Opened log file.
+//   - size: Current size of the log file.
+type RotateWriter struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// GetRotateConfig function reads the log rotation config from the BWC config file.
+// If the config file or the fields are not found, the default values are used.
+//
+// Input:
+//   - configFile: Path of the BWC config file.
+//
+// Output:
+//   - RotateConfig: Log rotation config.
+func GetRotateConfig(configFile string) RotateConfig {
+	var rotateConfig RotateConfig
+	if jsonFile, err := os.ReadFile(configFile); err == nil {
+		json.Unmarshal(jsonFile, &rotateConfig)
+	}
+	if rotateConfig.MaxSize <= 0 {
+		rotateConfig.MaxSize = DefaultMaxSize
+	}
+	if rotateConfig.MaxBackups <= 0 {
+		rotateConfig.MaxBackups = DefaultMaxBackups
+	}
+	return rotateConfig
+}
+
+// NewRotateWriter function opens the log file for appending and returns a RotateWriter.
+//
+// Input:
+//   - path: Path of the log file.
+//   - rotateConfig: Log rotation config.
+//
+// Output:
+//   - *RotateWriter: Writer of the log file.
+//   - error: Error if the log file can't be opened.
+func NewRotateWriter(path string, rotateConfig RotateConfig) (*RotateWriter, error) {
+	rw := &RotateWriter{
+		path:       path,
+		maxSize:    int64(rotateConfig.MaxSize) * 1024 * 1024,
+		maxBackups: rotateConfig.MaxBackups,
+	}
+	if err := rw.open(); err != nil {
+		return nil, err
+	}
+	return rw, nil
+}
+
+// Write function writes the log to the log file. If the log file would exceed the
+// maximum size, the log file is rotated first.
+func (rw *RotateWriter) Write(p []byte) (int, error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if rw.size > 0 && rw.size+int64(len(p)) > rw.maxSize {
+		if err := rw.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "[WARNING] Can't rotate %s: %v\n", rw.path, err)
+		}
+	}
+	n, err := rw.file.Write(p)
+	rw.size += int64(n)
+	return n, err
+}
+
+// Close function closes the log file.
+func (rw *RotateWriter) Close() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.file.Close()
+}
+
+// open function opens the log file and reads its current size.
+func (rw *RotateWriter) open() error {
+	file, err := os.OpenFile(rw.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY|os.O_SYNC, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	rw.file = file
+	rw.size = info.Size()
+	return nil
+}
+
+// rotate function shifts the backups, renames the log file to <path>.1 and opens a new
+// log file. The oldest backup is removed. If the rename fails (ex. the file is opened by
+// another process on Windows), the current log file is opened again so that logs aren't lost.
+func (rw *RotateWriter) rotate() error {
+	rw.file.Close()
+
+	os.Remove(fmt.Sprintf("%s.%d", rw.path, rw.maxBackups))
+	for i := rw.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", rw.path, i), fmt.Sprintf("%s.%d", rw.path, i+1))
+	}
+	renameErr := os.Rename(rw.path, rw.path+".1")
+
+	if err := rw.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		// Try again after the next maxSize bytes instead of on every write.
+		rw.size = 0
+	}
+	return renameErr
+}
@@ -0,0 +1,92 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ""
+		}
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotateWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	rw := &RotateWriter{path: path, maxSize: 10, maxBackups: 2}
+	if err := rw.open(); err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Close()
+
+	for _, line := range []string{"line1\n", "line2\n", "line3\n", "line4\n"} {
+		if _, err := rw.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q) error = %v", line, err)
+		}
+	}
+
+	want := map[string]string{
+		path:        "line4\n",
+		path + ".1": "line3\n",
+		path + ".2": "line2\n",
+		path + ".3": "",
+	}
+	for file, content := range want {
+		if got := readLog(t, file); got != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), got, content)
+		}
+	}
+}
+
+func TestRotateWriterAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rw, err := NewRotateWriter(path, RotateConfig{MaxSize: 1, MaxBackups: 1})
+	if err != nil {
+		t.Fatalf("NewRotateWriter() error = %v", err)
+	}
+	defer rw.Close()
+	if rw.size != 4 {
+		t.Errorf("size = %d, want the size of the existing file (4)", rw.size)
+	}
+	rw.Write([]byte("new\n"))
+	if got := readLog(t, path); got != "old\nnew\n" {
+		t.Errorf("log = %q, want the new line appended", got)
+	}
+}
+
+func TestGetRotateConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   RotateConfig
+	}{
+		{"no config file", "", RotateConfig{DefaultMaxSize, DefaultMaxBackups}},
+		{"fields not set", `{"assetcode": "A001"}`, RotateConfig{DefaultMaxSize, DefaultMaxBackups}},
+		{"invalid values", `{"logmaxsize": -1, "logmaxbackups": 0}`, RotateConfig{DefaultMaxSize, DefaultMaxBackups}},
+		{"configured", `{"logmaxsize": 50, "logmaxbackups": 3}`, RotateConfig{50, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "config.json")
+			if tt.config != "" {
+				if err := os.WriteFile(configFile, []byte(tt.config), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := GetRotateConfig(configFile); got != tt.want {
+				t.Errorf("GetRotateConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
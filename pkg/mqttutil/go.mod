@@ -0,0 +1,3 @@
+module pkg/mqttutil
+
+go 1.19
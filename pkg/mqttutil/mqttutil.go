@@ -0,0 +1,119 @@
+// The mqttutil package is shared by the BWC agents. It reconnects to the MQTT broker with
+// exponential backoff when the connection is lost, instead of exiting the agent on every
+// network blip. The retry interval starts at 2 seconds and doubles up to 5 minutes.
+// The maximum retry count is read from the BWC_MQTT_MAX_RETRIES environment variable.
+package mqttutil
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Default values of the reconnection.
+const (
+	DefaultInitialDelay = 2 * time.Second
+	DefaultMaxDelay     = 5 * time.Minute
+	DefaultMaxRetries   = 30
+)
+
+// reconnecting is true while the reconnection goroutine is running, so that the
+// connection lost handler doesn't start another one.
+var reconnecting atomic.Bool
+
+// Backoff defines the retry interval of the reconnection.
+//   - Initial: Delay before the first retry.
+//   - Max: Maximum delay between retries.
+//   - MaxRetries: Number of retries before giving up. (0 means no limit)
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	MaxRetries int
+}
+
+// NewBackoff function returns the default backoff. The maximum retry count can be
+// changed with the BWC_MQTT_MAX_RETRIES environment variable.
+//
+// Output:
+//   - Backoff: Backoff of the reconnection.
+func NewBackoff() Backoff {
+	backoff := Backoff{
+		Initial:    DefaultInitialDelay,
+		Max:        DefaultMaxDelay,
+		MaxRetries: DefaultMaxRetries,
+	}
+	if envVal := os.Getenv("BWC_MQTT_MAX_RETRIES"); envVal != "" {
+		if maxRetries, err := strconv.Atoi(envVal); err == nil && maxRetries >= 0 {
+			backoff.MaxRetries = maxRetries
+		}
+	}
+	return backoff
+}
+
+// Delay function returns the delay before the retry. The delay doubles on every retry
+// and is limited to Max.
+//
+// Input:
+//   - attempt: Number of the retry. (Starts from 0)
+//
+// Output:
+//   - time.Duration: Delay before the retry.
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := b.Initial
+	for i := 0; i < attempt; i++ {
+		delay *= 2
+		if delay >= b.Max {
+			return b.Max
+		}
+	}
+	return delay
+}
+
+// Reconnect function calls connect until it succeeds. It waits for the backoff delay
+// before each retry.
+//
+// Input:
+//   - connect: Function to connect to the MQTT broker.
+//   - backoff: Retry interval of the reconnection.
+//   - logger: Logger of the agent.
+//
+// Output:
+//   - error: Last error of connect if all retries failed.
+func Reconnect(connect func() error, backoff Backoff, logger *log.Logger) error {
+	var err error
+	for attempt := 0; backoff.MaxRetries == 0 || attempt < backoff.MaxRetries; attempt++ {
+		delay := backoff.Delay(attempt)
+		logger.Printf("[MQTT] Reconnect in %v. (%d / %d)\n", delay, attempt+1, backoff.MaxRetries)
+		time.Sleep(delay)
+
+		if err = connect(); err == nil {
+			logger.Printf("[MQTT] Reconnected.\n")
+			return nil
+		}
+		logger.Printf("[MQTT] Reconnect failed: %v\n", err)
+	}
+	return err
+}
+
+// OnConnectionLost function starts the reconnection in a goroutine. It is called in the
+// connection lost handler of the MQTT client. If all retries fail, the agent exits and is
+// restarted by the service manager.
+//
+// Input:
+//   - connect: Function to connect to the MQTT broker.
+//   - logger: Logger of the agent.
+func OnConnectionLost(connect func() error, logger *log.Logger) {
+	if !reconnecting.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer reconnecting.Store(false)
+		backoff := NewBackoff()
+		if err := Reconnect(connect, backoff, logger); err != nil {
+			logger.Printf("[MQTT] Gave up reconnecting after %d retries: %v\n", backoff.MaxRetries, err)
+			os.Exit(1)
+		}
+	}()
+}
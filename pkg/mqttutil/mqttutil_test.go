@@ -0,0 +1,118 @@
+package mqttutil
+
+import (
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+// mockBroker is unreachable until it has refused downCount connections. The time of
+// each connection is recorded.
+type mockBroker struct {
+	downCount int
+	attempts  []time.Time
+}
+
+func (m *mockBroker) connect() error {
+	m.attempts = append(m.attempts, time.Now())
+	if len(m.attempts) <= m.downCount {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestBackoffDelay(t *testing.T) {
+	backoff := Backoff{Initial: 2 * time.Second, Max: 5 * time.Minute}
+	want := []time.Duration{
+		2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second,
+		64 * time.Second, 128 * time.Second, 256 * time.Second, 5 * time.Minute, 5 * time.Minute,
+	}
+	for attempt, delay := range want {
+		if got := backoff.Delay(attempt); got != delay {
+			t.Errorf("Delay(%d) = %v, want %v", attempt, got, delay)
+		}
+	}
+}
+
+func TestNewBackoff(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int
+	}{
+		{"", DefaultMaxRetries},
+		{"5", 5},
+		{"0", 0},
+		{"-1", DefaultMaxRetries},
+		{"many", DefaultMaxRetries},
+	}
+	for _, tt := range tests {
+		t.Setenv("BWC_MQTT_MAX_RETRIES", tt.env)
+		backoff := NewBackoff()
+		if backoff.MaxRetries != tt.want || backoff.Initial != DefaultInitialDelay || backoff.Max != DefaultMaxDelay {
+			t.Errorf("BWC_MQTT_MAX_RETRIES=%q: NewBackoff() = %+v", tt.env, backoff)
+		}
+	}
+}
+
+func TestReconnectTiming(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	backoff := Backoff{Initial: 20 * time.Millisecond, Max: 80 * time.Millisecond, MaxRetries: 10}
+	broker := &mockBroker{downCount: 4}
+
+	start := time.Now()
+	if err := Reconnect(broker.connect, backoff, logger); err != nil {
+		t.Fatalf("Reconnect() = %v", err)
+	}
+	if len(broker.attempts) != 5 {
+		t.Fatalf("connect is called %d times, want 5", len(broker.attempts))
+	}
+
+	// 20ms, 40ms, 80ms, 80ms (limited to Max), 80ms
+	prev := start
+	for i, at := range broker.attempts {
+		want := backoff.Delay(i)
+		if got := at.Sub(prev); got < want {
+			t.Errorf("retry %d after %v, want at least %v", i+1, got, want)
+		}
+		prev = at
+	}
+}
+
+func TestReconnectGiveUp(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	backoff := Backoff{Initial: time.Millisecond, Max: 2 * time.Millisecond, MaxRetries: 3}
+	broker := &mockBroker{downCount: 100}
+
+	if err := Reconnect(broker.connect, backoff, logger); err == nil {
+		t.Fatal("Reconnect() = nil, want the error of the last retry")
+	}
+	if len(broker.attempts) != 3 {
+		t.Errorf("connect is called %d times, want 3", len(broker.attempts))
+	}
+}
+
+func TestOnConnectionLostSingleReconnect(t *testing.T) {
+	t.Setenv("BWC_MQTT_MAX_RETRIES", "1")
+	logger := log.New(io.Discard, "", 0)
+	calls := make(chan struct{}, 10)
+	connect := func() error {
+		calls <- struct{}{}
+		return nil
+	}
+
+	// The second call is ignored while the first reconnection is waiting for its delay.
+	OnConnectionLost(connect, logger)
+	OnConnectionLost(connect, logger)
+
+	select {
+	case <-calls:
+	case <-time.After(DefaultInitialDelay + 2*time.Second):
+		t.Fatal("connect is not called")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if len(calls) != 0 {
+		t.Errorf("connect is called by the second reconnection")
+	}
+}
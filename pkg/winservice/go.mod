@@ -0,0 +1,5 @@
+module pkg/winservice
+
+go 1.19
+
+require golang.org/x/sys v0.6.0
@@ -0,0 +1,44 @@
+// The winservice package registers the BWC agents as Windows services. Each agent binary
+// handles the -service flag with this package:
+//
+//	device-control.exe -service install -mqtt exmq -arch win
+//	device-control.exe -service start
+//
+// The installed service starts automatically and is restarted by the service manager when it
+// fails. The service manager functions are only built on Windows. (winservice_windows.go)
+package winservice
+
+import "strings"
+
+// Actions of the -service flag.
+const (
+	ActionInstall   = "install"
+	ActionUninstall = "uninstall"
+	ActionStart     = "start"
+	ActionStop      = "stop"
+)
+
+// ServiceArgs function returns the arguments of the agent without the -service flag.
+// The arguments are registered as the start parameters of the service, so the service runs
+// with the same flags as the install command.
+//
+// Input:
+//   - args: Command-line arguments of the agent. (os.Args[1:])
+//
+// Output:
+//   - []string: Arguments without -service <action>.
+func ServiceArgs(args []string) []string {
+	serviceArgs := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-service" || arg == "--service" {
+			i++
+			continue
+		}
+		if strings.HasPrefix(arg, "-service=") || strings.HasPrefix(arg, "--service=") {
+			continue
+		}
+		serviceArgs = append(serviceArgs, arg)
+	}
+	return serviceArgs
+}
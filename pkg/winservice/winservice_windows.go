@@ -0,0 +1,190 @@
+//go:build windows
+// +build windows
+
+package winservice
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// Recovery of the installed service. The service is restarted after restartDelay on each failure,
+// and the failure count is reset after resetPeriod without failure.
+const (
+	restartDelay = 10 * time.Second
+	resetPeriod  = 24 * time.Hour
+	stopTimeout  = 30 * time.Second
+)
+
+// Install function registers the running binary as a Windows service that starts automatically
+// and restarts on failure.
+//
+// Input:
+//   - name: Service name. (ex. DeviceControlService)
+//   - description: Description shown in the service manager.
+//   - args: Start parameters of the service. (See ServiceArgs)
+//
+// Output:
+//   - error: Error of the service manager.
+func Install(name string, description string, args []string) error {
+	exePath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	exePath, err = filepath.Abs(exePath)
+	if err != nil {
+		return err
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(name); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s already exists", name)
+	}
+
+	s, err := m.CreateService(name, exePath, mgr.Config{
+		DisplayName: name,
+		Description: description,
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	recovery := []mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: restartDelay},
+		{Type: mgr.ServiceRestart, Delay: restartDelay},
+		{Type: mgr.ServiceRestart, Delay: restartDelay},
+	}
+	if err := s.SetRecoveryActions(recovery, uint32(resetPeriod/time.Second)); err != nil {
+		s.Delete()
+		return fmt.Errorf("cannot set recovery actions of %s: %v", name, err)
+	}
+	return nil
+}
+
+// Uninstall function removes the Windows service. A running service is stopped first.
+//
+// Input:
+//   - name: Service name.
+//
+// Output:
+//   - error: Error of the service manager.
+func Uninstall(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+	defer s.Close()
+
+	if status, err := s.Query(); err == nil && status.State != svc.Stopped {
+		if err := stopService(s); err != nil {
+			return err
+		}
+	}
+	return s.Delete()
+}
+
+// Start function starts the installed Windows service.
+//
+// Input:
+//   - name: Service name.
+//
+// Output:
+//   - error: Error of the service manager.
+func Start(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+	defer s.Close()
+	return s.Start()
+}
+
+// Stop function stops the Windows service and waits until it is stopped.
+//
+// Input:
+//   - name: Service name.
+//
+// Output:
+//   - error: Error of the service manager.
+func Stop(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+	defer s.Close()
+	return stopService(s)
+}
+
+// stopService function sends the stop request and waits up to stopTimeout until the service is stopped.
+func stopService(s *mgr.Service) error {
+	status, err := s.Control(svc.Stop)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(stopTimeout)
+	for status.State != svc.Stopped {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for service %s to stop", s.Name)
+		}
+		time.Sleep(300 * time.Millisecond)
+		if status, err = s.Query(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Control function runs the action of the -service flag.
+//
+// Input:
+//   - action: install, uninstall, start or stop.
+//   - name: Service name.
+//   - description: Description of the service. (Only for install)
+//   - args: Command-line arguments of the agent. (os.Args[1:], only for install)
+//
+// Output:
+//   - error: Error of the action.
+func Control(action string, name string, description string, args []string) error {
+	switch action {
+	case ActionInstall:
+		return Install(name, description, ServiceArgs(args))
+	case ActionUninstall:
+		return Uninstall(name)
+	case ActionStart:
+		return Start(name)
+	case ActionStop:
+		return Stop(name)
+	}
+	return fmt.Errorf("invalid service action: %s (install, uninstall, start or stop)", action)
+}